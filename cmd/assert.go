@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagAssertCount  string
+	flagAssertFilter []string
+	assertCmd        = &cobra.Command{
+		Use:   "assert",
+		Short: "exit with a non-zero status unless the number of processes matching a filter is as expected",
+		Long: `Count the running processes matching every --filter and compare the count with --count, e.g.:
+
+  pstree assert --filter command=nginx --filter under=nginx --filter user=www-data --count '>=4'
+
+When the count is not as expected, the matching processes and their ancestors are printed and
+pstree exits with status 1, or 2 on errors, so that CI jobs and health checks can verify the process
+hierarchy of the services they start.`,
+		Args: cobra.NoArgs,
+		RunE: pstreeAssertCmd,
+		// The failure already shows the matching processes; the usage would bury them
+		SilenceUsage: true,
+	}
+)
+
+// init registers the assert command and its flags.
+func init() {
+	GetAssertFlags(assertCmd)
+	assertCmd.SetUsageTemplate(`Usage: pstree assert [OPTIONS]
+
+Application Options:
+{{.LocalFlags.FlagUsages}}`)
+	rootCmd.AddCommand(assertCmd)
+}
+
+// pstreeAssertCmd collects the processes and checks the number matching --filter against --count.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: Unused
+//
+// Returns:
+//   - error: Error if the options are invalid or the assertion failed
+func pstreeAssertCmd(cmd *cobra.Command, args []string) error {
+	initLogger()
+	filter, err := pstree.ParseProcessFilter(flagAssertFilter)
+	if err != nil {
+		return fmt.Errorf("invalid --filter: %w", err)
+	}
+	expectation, err := pstree.ParseExpectation(flagAssertCount)
+	if err != nil {
+		return fmt.Errorf("invalid --count: %w", err)
+	}
+
+	processes := []tree.Process{}
+	if _, err := collectProcesses(&processes, pstree.MetricsNone); err != nil {
+		return err
+	}
+	result := pstree.CheckAssertion(processes, filter, expectation)
+	if !result.Passed() {
+		return notFound(fmt.Errorf("assertion failed: %s", result))
+	}
+	fmt.Fprintf(summaryOutput(cmd.OutOrStdout()), "ok: found %d processes matching %s, expected %s\n", len(result.Matched), filter, expectation)
+	return nil
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	batchProcesses []tree.Process // Processes collected once by pstree batch, or nil
+	batchCmd       = &cobra.Command{
+		Use:   "batch <file>",
+		Short: "render several views of a single collection pass, as listed in <file>",
+		Long: `Collect the processes once and render each query in <file> from them. Each line names a destination
+followed by the options of the view written to it, e.g.:
+
+  /var/lib/pstree/tree.json   --output json
+  -                           --user www-data --show-pids
+
+A destination of - writes to stdout. Options given to pstree batch apply to every query.`,
+		Args: cobra.ExactArgs(1),
+		RunE: pstreeBatchCmd,
+		// Failed queries are reported by line; the usage would bury them
+		SilenceUsage: true,
+	}
+	// Options that collect more than once or do not render a view
+	batchExcludedFlags = []string{"from-snapshot", "gantt", "kill", "snapshot", "watch"}
+)
+
+// flagState is the value of a flag saved between batch queries.
+type flagState struct {
+	changed bool
+	value   []string
+}
+
+// init registers the batch command.
+func init() {
+	batchCmd.SetUsageTemplate(`Usage: pstree batch [OPTIONS] <file>
+
+Application Options:
+{{.InheritedFlags.FlagUsages}}`)
+	rootCmd.AddCommand(batchCmd)
+}
+
+// pstreeBatchCmd collects the processes once and renders every query of the batch file.
+//
+// A query that fails does not stop the others; all failures are returned together.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: The batch file
+//
+// Returns:
+//   - error: The errors of the failed queries, or of --strict
+func pstreeBatchCmd(cmd *cobra.Command, args []string) error {
+	initLogger()
+	for _, name := range batchExcludedFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("pstree batch cannot be used with --%s", name)
+		}
+	}
+	queries, err := pstree.LoadBatch(args[0])
+	if err != nil {
+		return err
+	}
+
+	batchProcesses = []tree.Process{}
+	defer func() {
+		batchProcesses = nil
+		treeOutput = os.Stdout
+	}()
+	collectedOn, err := collectProcesses(&batchProcesses, pstree.MetricsAll)
+	if err != nil {
+		return err
+	}
+	anomalies := pstree.DetectAnomalies(batchProcesses, collectedOn)
+
+	saved := saveFlags(cmd.Flags())
+	failures := []error{}
+	for _, query := range queries {
+		if err := runBatchQuery(cmd, query); err != nil {
+			failures = append(failures, fmt.Errorf("%s line %d: %w", args[0], query.Line, err))
+		}
+		restoreFlags(cmd.Flags(), saved)
+	}
+	if err := reportAnomalies(anomalies); err != nil {
+		failures = append(failures, err)
+	}
+	return errors.Join(failures...)
+}
+
+// runBatchQuery applies the options of a query on top of those given to pstree batch
+// and renders the view to its destination.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - query: The query to render
+//
+// Returns:
+//   - error: Error if the options are invalid or the view could not be written
+func runBatchQuery(cmd *cobra.Command, query pstree.BatchQuery) (err error) {
+	if err := cmd.Flags().Parse(query.Args); err != nil {
+		return err
+	}
+	if cmd.Flags().NArg() > 0 {
+		return fmt.Errorf("unexpected argument '%s'", cmd.Flags().Arg(0))
+	}
+	for _, name := range batchExcludedFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s cannot be used in a batch query", name)
+		}
+	}
+
+	// Rendering turns colors off for machine-readable output, which must not leak into the next query
+	defer func(supported bool) { colorSupport = supported }(colorSupport)
+
+	var output io.Writer = os.Stdout
+	if query.Destination != "-" {
+		file, err := os.Create(query.Destination)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		output = file
+	}
+	treeOutput = output
+	return pstreeRunCmd(cmd, nil)
+}
+
+// saveFlags records the value of every flag, so that each batch query starts from the
+// options given to pstree batch.
+//
+// Parameters:
+//   - flags: The flags of the command
+//
+// Returns:
+//   - map[string]flagState: The state of each flag, keyed by name
+func saveFlags(flags *pflag.FlagSet) map[string]flagState {
+	saved := map[string]flagState{}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		state := flagState{changed: flag.Changed, value: []string{flag.Value.String()}}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			state.value = slices.Clone(slice.GetSlice())
+		}
+		saved[flag.Name] = state
+	})
+	return saved
+}
+
+// restoreFlags resets every flag to the state recorded by saveFlags.
+//
+// Parameters:
+//   - flags: The flags of the command
+//   - saved: The state of each flag, keyed by name
+func restoreFlags(flags *pflag.FlagSet, saved map[string]flagState) {
+	flags.VisitAll(func(flag *pflag.Flag) {
+		restoreFlag(flag, saved[flag.Name])
+	})
+}
+
+// restoreFlag resets a flag to the state recorded by saveFlags.
+//
+// Parameters:
+//   - flag: The flag
+//   - state: The recorded state of the flag
+func restoreFlag(flag *pflag.Flag, state flagState) {
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		slice.Replace(state.value)
+	} else {
+		flag.Value.Set(state.value[0])
+	}
+	flag.Changed = state.changed
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchmark           *pstree.Benchmark // Timings of pstree bench, or nil when not benchmarking
+	flagBenchIterations int
+	flagCPUProfile      string
+	flagMemProfile      string
+	benchCmd            = &cobra.Command{
+		Use:   "bench",
+		Short: "time the collection, construction, and rendering of the tree",
+		Long: `Render the tree --iterations times, discarding the output, and print how long each phase took:
+collecting the processes, annotating them, building and pruning the tree, and rendering it, e.g.:
+
+  pstree bench --iterations 10 --show-pids --cpu --memory
+  pstree bench --cpuprofile cpu.pprof --memprofile mem.pprof
+
+The options of pstree select the processes and the output format, as they do when displaying
+the tree, so that a slow invocation can be timed as it is. The profiles can be read with
+go tool pprof, and attached to a performance issue with the table.`,
+		Args: cobra.NoArgs,
+		RunE: pstreeBenchCmd,
+	}
+	// Options that do not render a single tree or that have side effects on every iteration
+	benchExcludedFlags = []string{"gantt", "kill", "pager", "snapshot", "watch"}
+)
+
+// init registers the bench command and its flags.
+func init() {
+	GetBenchFlags(benchCmd)
+	benchCmd.SetUsageTemplate(`Usage: pstree bench [OPTIONS]
+
+Application Options:
+{{.LocalFlags.FlagUsages}}{{.InheritedFlags.FlagUsages}}`)
+	rootCmd.AddCommand(benchCmd)
+}
+
+// pstreeBenchCmd validates the options of pstree bench and starts timing the tree.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: Unused
+//
+// Returns:
+//   - error: Error if the options are invalid, or the tree or the profiles could not be written
+func pstreeBenchCmd(cmd *cobra.Command, args []string) error {
+	for _, name := range benchExcludedFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("pstree bench cannot be used with --%s", name)
+		}
+	}
+	if flagBenchIterations < 1 {
+		return errors.New("--iterations cannot be set to less than 1")
+	}
+	benchmark = pstree.NewBenchmark()
+	defer func() { benchmark = nil }()
+	return pstreeRunCmd(cmd, nil)
+}
+
+// benchTree renders the tree --iterations times to io.Discard, profiling the iterations if
+// requested, and prints the timings of each phase.
+//
+// Parameters:
+//   - cmd: The command whose flags were parsed
+//   - renderer: The renderer for the selected output format
+//
+// Returns:
+//   - error: Error if the tree could not be rendered or a profile could not be written
+func benchTree(cmd *cobra.Command, renderer tree.Renderer) error {
+	treeOutput = io.Discard
+	defer func() { treeOutput = os.Stdout }()
+
+	if flagCPUProfile != "" {
+		file, err := os.Create(flagCPUProfile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if err := pprof.StartCPUProfile(file); err != nil {
+			return err
+		}
+	}
+	for range flagBenchIterations {
+		if err := renderTree(cmd, renderer, nil); err != nil {
+			pprof.StopCPUProfile()
+			return err
+		}
+		benchmark.Processes = len(processes)
+	}
+	pprof.StopCPUProfile()
+
+	if flagMemProfile != "" {
+		if err := writeMemProfile(flagMemProfile); err != nil {
+			return err
+		}
+	}
+
+	environment := fmt.Sprintf("pstree %s, %s/%s, %s, %d CPUs", version, runtime.GOOS, runtime.GOARCH, runtime.Version(), runtime.NumCPU())
+	return pstree.WriteBenchmark(os.Stdout, benchmark, environment)
+}
+
+// writeMemProfile writes the allocations of the iterations as a heap profile.
+//
+// Parameters:
+//   - path: The file to write the profile to
+//
+// Returns:
+//   - error: Error if the profile could not be written
+func writeMemProfile(path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	// Garbage is collected first, so that the profile shows the memory in use after the last iteration
+	runtime.GC()
+	return pprof.WriteHeapProfile(file)
+}
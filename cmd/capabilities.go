@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "report the platform features supported on this host and the options needing them",
+	Long: `Probe this host for the platform features some options depend on, e.g., /proc, the control
+group hierarchy and its version, a running systemd, or tput, and report whether each one is
+available and which options need it, e.g.:
+
+  pstree capabilities
+  pstree capabilities --output json
+
+Options needing a missing feature fail with an error naming it instead of showing partial data.`,
+	Args: cobra.NoArgs,
+	RunE: pstreeCapabilitiesCmd,
+}
+
+// init registers the capabilities command.
+func init() {
+	capabilitiesCmd.SetUsageTemplate(`Usage: pstree capabilities [--output json|yaml]
+`)
+	rootCmd.AddCommand(capabilitiesCmd)
+}
+
+// pstreeCapabilitiesCmd probes the host and writes its capabilities as a table, or as JSON
+// or YAML with --output.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: Unused
+//
+// Returns:
+//   - error: Error if the capabilities could not be written
+func pstreeCapabilitiesCmd(cmd *cobra.Command, args []string) error {
+	initLogger()
+	capabilities := pstree.ProbeCapabilities()
+	switch flagOutput {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(capabilities)
+	case "yaml":
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(capabilities)
+	}
+	return pstree.WriteCapabilities(os.Stdout, capabilities, showHeaders())
+}
+
+// checkCapabilities returns an error if an option that was set needs a platform feature
+// this host lacks. With --remote, only ssh is needed on this host, since the processes
+// are collected on the remote host.
+//
+// Parameters:
+//   - cmd: The command whose flags were parsed
+//
+// Returns:
+//   - error: Error naming the option and the missing feature
+func checkCapabilities(cmd *cobra.Command) error {
+	changed := func(flag string) bool {
+		if flagRemote != "" && flag != "remote" {
+			return false
+		}
+		return cmd.Flags().Changed(flag)
+	}
+	if flag, capability := pstree.MissingCapability(pstree.ProbeCapabilities(), changed); flag != "" {
+		return fmt.Errorf("--%s needs %s, which is not available on this host: %s; see pstree capabilities", flag, capability.Name, capability.Detail)
+	}
+	return nil
+}
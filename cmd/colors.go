@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/color"
+	"github.com/gdanko/pstree/pkg/logger"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	colorsCmd = &cobra.Command{
+		Use:   "colors",
+		Short: "list and preview the color schemes",
+		Long: `List the color schemes available to --color-scheme, or render a sample tree in each of
+them, including the schemes of --color-scheme-file, e.g.:
+
+  pstree colors list
+  pstree colors preview --color-scheme-file ~/.config/pstree/colors.yaml solarized`,
+	}
+	colorsListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list the available color schemes",
+		Args:  cobra.NoArgs,
+		RunE:  pstreeColorsListCmd,
+	}
+	colorsPreviewCmd = &cobra.Command{
+		Use:   "preview [scheme...]",
+		Short: "render a sample tree in each color scheme, or in the given schemes",
+		RunE:  pstreeColorsPreviewCmd,
+	}
+)
+
+// init registers the colors command and its subcommands.
+func init() {
+	colorsCmd.SetUsageTemplate(`Usage: pstree colors list|preview [OPTIONS]
+`)
+	colorsListCmd.SetUsageTemplate(`Usage: pstree colors list [--color-scheme-file <file>]
+`)
+	colorsPreviewCmd.SetUsageTemplate(`Usage: pstree colors preview [--color-scheme-file <file>] [scheme...]
+`)
+	colorsCmd.AddCommand(colorsListCmd, colorsPreviewCmd)
+	rootCmd.AddCommand(colorsCmd)
+}
+
+// loadColorSchemeFile registers the color schemes of --color-scheme-file, if given.
+//
+// Returns:
+//   - error: Error if the file could not be read or is malformed
+func loadColorSchemeFile() error {
+	if flagColorSchemeFile == "" {
+		return nil
+	}
+	schemes, err := color.LoadColorSchemes(flagColorSchemeFile)
+	if err != nil {
+		return fmt.Errorf("invalid --color-scheme-file: %w", err)
+	}
+	color.RegisterColorSchemes(schemes)
+	return nil
+}
+
+// availableColorSchemes returns the names of the built-in color schemes followed by those
+// of --color-scheme-file.
+//
+// Returns:
+//   - The names of the color schemes
+func availableColorSchemes() []string {
+	return append(slices.Clone(validColorSchemes), color.CustomSchemeNames()...)
+}
+
+// pstreeColorsListCmd prints the names of the available color schemes, one per line, with
+// the custom schemes marked.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: Unused
+//
+// Returns:
+//   - error: Error if --color-scheme-file could not be loaded
+func pstreeColorsListCmd(cmd *cobra.Command, args []string) error {
+	if err := loadColorSchemeFile(); err != nil {
+		return err
+	}
+	custom := color.CustomSchemeNames()
+	for _, name := range availableColorSchemes() {
+		if slices.Contains(custom, name) {
+			fmt.Fprintf(os.Stdout, "%s (%s)\n", name, flagColorSchemeFile)
+		} else {
+			fmt.Fprintln(os.Stdout, name)
+		}
+	}
+	return nil
+}
+
+// pstreeColorsPreviewCmd renders a sample tree in each of the given color schemes, or in
+// every available scheme if none is given.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: Names of the color schemes to preview
+//
+// Returns:
+//   - error: Error if --color-scheme-file could not be loaded or a scheme does not exist
+func pstreeColorsPreviewCmd(cmd *cobra.Command, args []string) error {
+	initLogger()
+	if err := loadColorSchemeFile(); err != nil {
+		return err
+	}
+	names := args
+	if len(names) == 0 {
+		names = availableColorSchemes()
+	}
+	for _, name := range names {
+		if !slices.Contains(availableColorSchemes(), name) {
+			return fmt.Errorf("valid color schemes are: %s", strings.Join(availableColorSchemes(), ", "))
+		}
+	}
+	displayOptions := tree.DisplayOptions{
+		IBM850Graphics: flagIBM850,
+		UTF8Graphics:   utf8Graphics(),
+		VT100Graphics:  flagVT100,
+	}
+	for i, name := range names {
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		if err := tree.PreviewColorScheme(os.Stdout, logger.Logger, name, displayOptions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
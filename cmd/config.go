@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/logger"
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configExcludedFlags are options that select the configuration or do not display a tree,
+// and so cannot be set by the configuration file or an alias.
+var configExcludedFlags = []string{"alias", "config", "help-json", "version"}
+
+// applyConfig loads the configuration file, given by --config or found in the user
+// configuration directory, and applies its options and the aliases selected by --alias.
+// A missing configuration file in the user configuration directory is not an error.
+//
+// Parameters:
+//   - cmd: The command whose flags were parsed
+//
+// Returns:
+//   - error: Error if the configuration file is malformed, or an option or alias is invalid
+func applyConfig(cmd *cobra.Command) error {
+	initLogger()
+	colorThresholds = nil
+
+	path := flagConfig
+	if !cmd.Flags().Changed("config") {
+		if defaultPath, err := pstree.ConfigPath(); err == nil {
+			if _, err := os.Stat(defaultPath); err == nil {
+				path = defaultPath
+			}
+		}
+	}
+	if path == "" {
+		if len(flagAlias) > 0 {
+			return errors.New("--alias requires a configuration file")
+		}
+		return nil
+	}
+
+	config, err := pstree.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	colorThresholds = config.Thresholds
+	return applyConfigOptions(cmd.Flags(), config, path, slices.Clone(flagAlias))
+}
+
+// applyConfigOptions sets the options of the configuration file as the defaults of their
+// flags, then applies the options of each alias. Options given on the command line take
+// precedence over both.
+//
+// Options of the configuration file are not reported as changed, so that rules about options
+// given explicitly do not apply to them; options of aliases are, as if they had been given.
+// Options that are not supported, e.g., Linux-only options on macOS, are ignored with a warning.
+//
+// Parameters:
+//   - flags: The flags of the command
+//   - config: The configuration
+//   - path: Path of the configuration file, for error messages
+//   - aliases: Names of the aliases to apply, in order
+//
+// Returns:
+//   - error: Error if an option has an invalid value, or an alias is unknown or invalid
+func applyConfigOptions(flags *pflag.FlagSet, config *pstree.Config, path string, aliases []string) error {
+	explicit := saveFlags(flags)
+
+	args, err := config.OptionArgs()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	supported := []string{}
+	for _, arg := range args {
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if flags.Lookup(name) == nil || slices.Contains(configExcludedFlags, name) {
+			logger.Logger.Warn(fmt.Sprintf("%s: option '%s' is not supported, ignoring", path, name))
+			continue
+		}
+		supported = append(supported, arg)
+	}
+	if err := flags.Parse(supported); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		flag.Changed = explicit[flag.Name].changed
+	})
+
+	for _, name := range aliases {
+		aliasArgs, err := config.AliasArgs(name)
+		if err != nil {
+			return fmt.Errorf("invalid --alias: %w", err)
+		}
+		for _, arg := range aliasArgs {
+			for _, excluded := range configExcludedFlags {
+				if arg == "--"+excluded || strings.HasPrefix(arg, "--"+excluded+"=") {
+					return fmt.Errorf("alias '%s' cannot use --%s", name, excluded)
+				}
+			}
+		}
+		if err := flags.Parse(aliasArgs); err != nil {
+			return fmt.Errorf("alias '%s': %w", name, err)
+		}
+		if flags.NArg() > 0 {
+			return fmt.Errorf("alias '%s': unexpected argument '%s'", name, flags.Arg(0))
+		}
+	}
+
+	// Options given on the command line take precedence over the configuration file and aliases
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if state := explicit[flag.Name]; state.changed {
+			restoreFlag(flag, state)
+		}
+	})
+	return nil
+}
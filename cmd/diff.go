@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffBaseline      string // Baseline snapshot of pstree diff
+	flagDiffUnchanged bool
+	diffCmd           = &cobra.Command{
+		Use:   "diff <old.json> <new.json>",
+		Short: "show the processes added, removed, and changed between two snapshots",
+		Long: `Show the processes added (+), removed (-), and changed (~) between two snapshots saved with --snapshot.
+Changed processes show their CPU and memory deltas, e.g., (Δc:+1.20%, Δm:+3.00 MiB).`,
+		Args: cobra.ExactArgs(2),
+		RunE: pstreeDiffCmd,
+	}
+)
+
+// init registers the diff command and its flags.
+func init() {
+	GetDiffFlags(diffCmd)
+	diffCmd.SetUsageTemplate(`Usage: pstree diff [OPTIONS] <old.json> <new.json>
+
+Application Options:
+{{.LocalFlags.FlagUsages}}{{.InheritedFlags.FlagUsages}}`)
+	rootCmd.AddCommand(diffCmd)
+}
+
+// pstreeDiffCmd renders the tree of the later snapshot merged with the baseline snapshot.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: The baseline and later snapshot files
+//
+// Returns:
+//   - error: Any error encountered during execution
+func pstreeDiffCmd(cmd *cobra.Command, args []string) error {
+	if flagSnapshot != "" || flagFromSnapshot != "" {
+		return errors.New("pstree diff cannot be used with --snapshot or --from-snapshot")
+	}
+	diffBaseline = args[0]
+	flagFromSnapshot = args[1]
+	return pstreeRunCmd(cmd, nil)
+}
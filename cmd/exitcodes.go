@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+)
+
+//------------------------------------------------------------------------------
+// EXIT CODES
+//------------------------------------------------------------------------------
+// Functions in this section map the outcome of a command to the status pstree
+// exits with, so that shell scripts and health checks can tell a search that
+// found nothing from an error, as with grep.
+
+const (
+	// ExitFound is the status when processes were found, or no search was made
+	ExitFound = 0
+	// ExitNotFound is the status when the filters, e.g., --contains, or an assertion matched no processes as expected
+	ExitNotFound = 1
+	// ExitError is the status when the options are invalid or the processes could not be collected or rendered
+	ExitError = 2
+)
+
+// exitError is an error that makes pstree exit with a status other than ExitError.
+type exitError struct {
+	err  error // The error reported on stderr
+	code int   // The status pstree exits with
+}
+
+// Error returns the message of the wrapped error.
+func (exitErr *exitError) Error() string {
+	return exitErr.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (exitErr *exitError) Unwrap() error {
+	return exitErr.err
+}
+
+// notFound marks an error as a search or assertion that found no processes as expected.
+//
+// Parameters:
+//   - err: The error reported on stderr
+//
+// Returns:
+//   - error: The error, making pstree exit with ExitNotFound
+func notFound(err error) error {
+	return &exitError{err: err, code: ExitNotFound}
+}
+
+// ExitCode returns the status pstree exits with after a command returned.
+//
+// Parameters:
+//   - err: The error returned by the command, or nil
+//
+// Returns:
+//   - int: ExitFound if err is nil, ExitNotFound if nothing was found, or ExitError otherwise
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitFound
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitError
+}
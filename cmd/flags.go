@@ -6,8 +6,12 @@ import (
 	"strings"
 
 	"github.com/gdanko/pstree/pkg/color"
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/gdanko/pstree/util"
 	"github.com/giancarlosio/gorainbow"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // GetPersistentFlags configures all command-line flags for the pstree application.
@@ -23,17 +27,19 @@ import (
 //   - username: String containing the current user's username for privilege-based flags
 func GetPersistentFlags(cmd *cobra.Command, colorSupport bool, colorCount int, unicodeSupport bool, username string) {
 	// Drawing characters
+	cmd.PersistentFlags().BoolVarP(&flagASCII, "ascii", "", false, "use ASCII line drawing characters; by default, UTF-8 characters are used when the output is a terminal whose locale uses UTF-8")
 	if runtime.GOOS == "windows" || (username == "gdanko" || username == "gary.danko") { // I put this here to show all output for the usage section of the README
 		cmd.PersistentFlags().BoolVarP(&flagIBM850, "ibm-850", "i", false, "use IBM-850 line drawing characters; only supported on DOS/Windows")
 	}
 
 	if unicodeSupport {
-		cmd.PersistentFlags().BoolVarP(&flagUTF8, "utf-8", "u", false, "use UTF-8 (Unicode) line drawing characters")
+		cmd.PersistentFlags().BoolVarP(&flagUTF8, "utf-8", "u", false, "use UTF-8 (Unicode) line drawing characters, even when the output is not a terminal")
 	}
 	cmd.PersistentFlags().BoolVarP(&flagVT100, "vt-100", "v", false, "use VT-100 line drawing characters")
 
 	// Depth
-	cmd.PersistentFlags().IntVarP(&flagLevel, "level", "l", 0, "print tree to <level> level deep")
+	cmd.PersistentFlags().IntVarP(&flagLevel, "level", "l", 0, "print tree to <level> level deep, counted from the process selected with --pid or --root-cmd")
+	cmd.PersistentFlags().BoolVarP(&flagLevelFromRoot, "level-from-root", "", false, "count --level from the top of the tree rather than from the process selected with --pid or --root-cmd, as in earlier versions")
 
 	// Width
 	cmd.PersistentFlags().BoolVarP(&flagWide, "wide", "w", false, "wide output, not truncated to window width")
@@ -42,45 +48,157 @@ func GetPersistentFlags(cmd *cobra.Command, colorSupport bool, colorCount int, u
 	if colorSupport {
 		if colorCount >= 8 && colorCount < 256 {
 			cmd.PersistentFlags().BoolVarP(&flagColor, "color", "", false, fmt.Sprintf("add some beautiful %s to the pstree output; cannot be used with --color-attr", color.Print8ColorRainbow("color")))
-			cmd.PersistentFlags().StringVarP(&flagColorAttr, "color-attr", "k", "", fmt.Sprintf("color the process name by given attribute; implies --compact-not; valid options are: %s;\ncannot be used with --color", strings.Join(validAttributes, ", ")))
+			cmd.PersistentFlags().StringVarP(&flagColorAttr, "color-attr", "k", "", fmt.Sprintf("color the process name by given attribute; valid options are: %s;\ncannot be used with --color", strings.Join(validAttributes, ", ")))
 		} else if colorCount >= 256 {
 			cmd.PersistentFlags().BoolVarP(&flagColor, "color", "", false, gorainbow.Rainbow("add some beautiful color to the pstree output; cannot be used with --color-attr or --rainbow"))
 			cmd.PersistentFlags().BoolVarP(&flagRainbow, "rainbow", "r", false, "for the adventurous; cannot be used with --color-attr or --color")
-			cmd.PersistentFlags().StringVarP(&flagColorAttr, "color-attr", "k", "", fmt.Sprintf("color the process name by given attribute; implies --compact-not; valid options are: %s;\ncannot be used with --color or --rainbow", strings.Join(validAttributes, ", ")))
-			cmd.PersistentFlags().StringVarP(&flagColorScheme, "color-scheme", "q", "", fmt.Sprintf("override the default color scheme; valid options are: %s", strings.Join(validColorSchemes, ", ")))
+			cmd.PersistentFlags().StringVarP(&flagColorAttr, "color-attr", "k", "", fmt.Sprintf("color the process name by given attribute; valid options are: %s;\ncannot be used with --color or --rainbow", strings.Join(validAttributes, ", ")))
+			cmd.PersistentFlags().StringVarP(&flagColorScheme, "color-scheme", "q", "", fmt.Sprintf("override the default color scheme; valid options are: %s, and the schemes of --color-scheme-file", strings.Join(validColorSchemes, ", ")))
 		}
 	}
+	cmd.PersistentFlags().StringVarP(&flagColorSchemeFile, "color-scheme-file", "", "", "read additional color schemes from the YAML or JSON <file>; each scheme overrides colors of a built-in base scheme, e.g., 'solarized: {base: xterm, blue: \"#268bd2\"}'; see pstree colors list and preview")
 
 	// Optional information
 	cmd.PersistentFlags().BoolVarP(&flagShowAll, "all", "A", false, "equivalent to --show-owner --show-group --show-pids --show-pgids --age --cpu --memory --threads --arguments")
 	cmd.PersistentFlags().BoolVarP(&flagCompactNot, "compact-not", "c", false, "do not compact identical subtrees in output")
-	cmd.PersistentFlags().BoolVarP(&flagCpu, "cpu", "C", false, "show CPU utilization percentage with each process, e.g., (c:0.00%); implies --compact-not")
-	cmd.PersistentFlags().BoolVarP(&flagMemory, "memory", "m", false, "show the memory usage with each process, e.g., (m:x.y MiB); implies --compact-not")
+	cmd.PersistentFlags().StringVarP(&flagCompactRep, "compact-rep", "", "pid", fmt.Sprintf("choose which member represents a compact group and whose details are shown: the lowest pid, the oldest, or the highest cpu; valid options are: %s", strings.Join(validCompactRep, ", ")))
+	cmd.PersistentFlags().BoolVarP(&flagCompactShowPIDs, "compact-show-pids", "", false, fmt.Sprintf("list the PIDs of each compact group, e.g., 12*[nginx] (pids 102,103,…); truncated after %d PIDs, the full list is included in --output json and yaml", tree.CompactPIDsMax))
+	cmd.PersistentFlags().BoolVarP(&flagCpu, "cpu", "C", false, "show CPU utilization percentage with each process, e.g., (c:0.00%); compact groups show their combined usage")
+	cmd.PersistentFlags().BoolVarP(&flagMemory, "memory", "m", false, "show the memory usage with each process, e.g., (m:x.y MiB); compact groups show their combined usage")
+	cmd.PersistentFlags().BoolVarP(&flagCumulative, "cumulative", "", false, "show the total CPU utilization and memory usage of each process and its descendants, e.g., (Σc:12.50% / Σm:1.20 GiB), plus the total threads with --threads")
+	cmd.PersistentFlags().StringVarP(&flagFormat, "format", "", "", "print each process with the Go template <format> after the tree prefix instead of the default layout, e.g., '{{.PID}} {{.User}} {{.Command}}'; all process fields are available, plus .Depth, .Name, .RSS, and .User, and the functions base, bytes, join, lower, trunc, and upper")
+	cmd.PersistentFlags().BoolVarP(&flagSummary, "summary", "", false, "print the number of displayed processes out of all processes, their threads, CPU utilization, and memory usage, and the number of processes of each user after the tree")
+	cmd.PersistentFlags().BoolVarP(&flagFairShare, "fair-share", "", false, "print the share of each user of the CPU utilization and memory usage of the displayed processes after the tree, and color processes by the share of their owner; implies --color-attr share unless another coloring is selected")
 	cmd.PersistentFlags().BoolVarP(&flagShowOwner, "show-owner", "O", false, "show the owner of the process")
+	cmd.PersistentFlags().BoolVarP(&flagShowFDs, "show-fds", "", false, "show the number of open file descriptors with each process, e.g., (fd:12); counts above 256 and 1024 are highlighted")
+	cmd.PersistentFlags().BoolVarP(&flagShowIO, "show-io", "", false, "show the bytes each process read and wrote since it started, e.g., (io:1.20 MiB/340.00 KiB); more than 100 MiB and 1 GiB in total are highlighted")
+	cmd.PersistentFlags().BoolVarP(&flagShowPriority, "show-priority", "", false, "show the nice value of each process, e.g., (ni:10), followed by its CPU and I/O scheduling classes where they were changed, e.g., (ni:-5 sched:fifo/50 ionice:idle); scheduling classes are Linux-only (not supported on Windows)")
+	cmd.PersistentFlags().BoolVarP(&flagShowOpenFiles, "show-open-files", "", false, "show the files opened by each process, e.g., [files:/var/log/app.log]; truncated after 5 files unless --wide")
 	cmd.PersistentFlags().BoolVarP(&flagShowGroup, "show-group", "", false, "show the group of the process")
+	cmd.PersistentFlags().BoolVarP(&flagShowGroups, "show-groups", "", false, "show the supplementary groups of each process, e.g., [groups:adm,docker]; where they cannot be read, e.g., on macOS, the groups of the owner are shown")
+	cmd.PersistentFlags().BoolVarP(&flagShowRuntime, "show-runtime", "", false, "show the language runtime of each process, detected from its executable and mapped libraries, e.g., [rt:jvm]")
+	cmd.PersistentFlags().StringSliceVarP(&flagRuntime, "runtime", "", []string{}, fmt.Sprintf("show only processes running the language runtime <runtime>, plus their ancestors; implies --show-runtime; valid options are: %s; this option can be used more than once", strings.Join(pstree.Runtimes(), ", ")))
+	cmd.PersistentFlags().BoolVarP(&flagDBConnections, "db-connections", "", false, "count the client connections of postgres and mysql servers on the server process, e.g., [137 client backends]; in compact mode, postgres backends are collapsed into the count")
+	cmd.PersistentFlags().BoolVarP(&flagShowHeap, "show-heap", "", false, "show the maximum heap configured for JVM and Node.js processes by -Xmx or --max-old-space-size next to their resident memory, e.g., [heap 4.00 GiB cfg / 5.10 GiB rss]; processes exceeding their configured heap are flagged")
+	cmd.PersistentFlags().BoolVarP(&flagShowSaturation, "show-saturation", "", false, "show the running workers of nginx, Apache, gunicorn, and PHP-FPM masters against the maximum in their configuration, e.g., [workers 8/10 ████████░░ 80%]; masters at 90% or more are flagged")
+	cmd.PersistentFlags().StringSliceVarP(&flagShowEnv, "show-env", "", []string{}, "show the given environment variables with each process, e.g., --show-env PATH,JAVA_HOME shows (PATH=…, JAVA_HOME=…); secrets are redacted unless --redact=false")
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" || (username == "gdanko" || username == "gary.danko") {
+		cmd.PersistentFlags().BoolVarP(&flagShowDaemonStatus, "show-daemon-status", "", false, "mark session leaders and show whether each process has fully daemonized, e.g., [daemon], [session-leader, tty:pts/0], or [detached]; session leaders that detached without being reparented to init are flagged (Linux and macOS)")
+		cmd.PersistentFlags().BoolVarP(&flagShowTTY, "show-tty", "", false, "show the controlling terminal of each process that has one, e.g., [tty:pts/3] (Linux and macOS)")
+	}
+	cmd.PersistentFlags().BoolVarP(&flagShowDomain, "show-domain", "", false, "qualify owners with their domain, e.g., CORP\\alice; by default only the account name is shown")
+	if runtime.GOOS == "linux" || (username == "gdanko" || username == "gary.danko") { // I put this here to show all output for the usage section of the README
+		cmd.PersistentFlags().BoolVarP(&flagShowCgroup, "show-cgroup", "", false, "show the control group of each process, named after its systemd slice, scope, or service when it has one, e.g., [cg:nginx.service] (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowContainer, "show-container", "", false, "show the Docker, containerd, CRI-O, or Podman container of each containerized process, e.g., [docker:web] or [containerd:3f2a1b9c0d4e]; names are only known for Docker and Podman containers, usually as root (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowNs, "show-ns", "", false, "show the mnt, net, pid, and uts namespaces each process does not share with its parent, marking where containers and unshare'd workloads begin, e.g., [ns:net=4026532204,pid=4026532201] (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowDeps, "show-deps", "", false, "show the After= and Requires= dependencies of the systemd unit of each process on the other units in the tree, on the topmost process of the unit, e.g., [after:postgresql.service requires:php-fpm.service]; with --output dot, they are drawn as dashed edges (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowUnit, "show-unit", "", false, "show the systemd unit of each process where it differs from the unit of its parent, marking where each service begins, e.g., [unit:nginx.service] (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowLatency, "show-latency", "", false, "show the average scheduling delay per timeslice, e.g., (lat:1.25ms); a better CPU starvation signal than CPU% alone (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowCaps, "show-caps", "", false, "show the effective capabilities of each process, e.g., [caps:cap_net_bind_service,cap_net_raw], or [caps:all] when it holds every capability; processes holding dangerous capabilities, e.g., cap_sys_admin, without being an ordinary root process are flagged (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowSecurityContext, "show-security-context", "", false, "show the SELinux or AppArmor label of each process, like ps -Z, e.g., [ctx:system_u:system_r:sshd_t:s0] or [ctx:unconfined] (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowAffinity, "show-affinity", "", false, "show the CPUs each process may run on and the NUMA nodes holding its memory, e.g., [cpus:0-3 numa:0], highlighting pinned processes using nearly all of their CPUs and heavy processes split across NUMA nodes (Linux-only)")
+	}
 	cmd.PersistentFlags().BoolVarP(&flagShowPGIDs, "show-pgids", "g", false, "show process group IDs")
 	cmd.PersistentFlags().BoolVarP(&flagShowPIDs, "show-pids", "p", false, "show process IDs (or thread IDs when displaying threads on Linux)")
 	cmd.PersistentFlags().BoolVarP(&flagShowPPIDs, "show-ppids", "", false, "show parent process IDs")
+	cmd.PersistentFlags().BoolVarP(&flagShowState, "show-state", "", false, "show the state of each process as a ps code, e.g., (R) running, (S) sleeping, (D) uninterruptible sleep, or (Z) zombie; zombies and uninterruptible sleeps are shown in red, and the zombie children of each process as (z:N) (not supported on Windows)")
+	if runtime.GOOS == "windows" || (username == "gdanko" || username == "gary.danko") {
+		cmd.PersistentFlags().BoolVarP(&flagShowElevation, "show-elevation", "", false, "tag elevated processes with [admin] and system integrity processes with [system] (Windows-only)")
+		cmd.PersistentFlags().BoolVarP(&flagShowService, "show-service", "", false, "show the session ID of each process and the services it hosts, e.g., (s:0) svchost.exe [svc:Dnscache] (Windows-only)")
+	}
+	if runtime.GOOS == "darwin" || (username == "gdanko" || username == "gary.danko") {
+		cmd.PersistentFlags().BoolVarP(&flagShowSigning, "show-signing", "", false, "show the owning .app bundle and code signing status, e.g., [Safari.app, apple]; unsigned and ad-hoc signed executables are flagged (macOS-only)")
+	}
 	cmd.PersistentFlags().BoolVarP(&flagShowUIDTransitions, "uid-transitions", "I", false, "show processes where the user ID changes from the parent process, e.g., (uid→uid); cannot be used with --user-transitions")
 	cmd.PersistentFlags().BoolVarP(&flagShowUserTransitions, "user-transitions", "U", false, "show processes where the user changes from the parent process, e.g., (user→user); cannot be used with --uid-transitions")
 	cmd.PersistentFlags().BoolVarP(&flagThreads, "threads", "t", false, "show the number of threads with each process, e.g., (t:xx)")
 
-	if runtime.GOOS == "linux" || (username == "gdanko" || username == "gary.danko") { // I put this here to show all output for the usage section of the README
-		cmd.PersistentFlags().BoolVarP(&flagHideThreads, "hide-threads", "T", false, "hide threads, show only processes (Linux-only)")
+	if runtime.GOOS == "linux" || runtime.GOOS == "windows" || (username == "gdanko" || username == "gary.danko") { // I put this here to show all output for the usage section of the README
+		cmd.PersistentFlags().BoolVarP(&flagHideThreads, "hide-threads", "T", false, "hide threads, show only processes (Linux and Windows); cannot be used with --show-threads")
+		cmd.PersistentFlags().BoolVarP(&flagShowThreads, "show-threads", "", false, "show threads as children of their process in every output format, including json and yaml; threads are shown in text output by default (Linux and Windows); cannot be used with --hide-threads")
+	}
+	if runtime.GOOS == "linux" || (username == "gdanko" || username == "gary.danko") {
+		cmd.PersistentFlags().StringVarP(&flagThreadContains, "thread-contains", "", "", "show only threads whose name contains <pattern>, along with their parent processes (Linux-only); cannot be used with --hide-threads")
 	}
 
 	// Filtering and sorting
-	cmd.PersistentFlags().BoolVarP(&flagAge, "age", "G", false, "show the age of the process using the format (dd:hh:mm:ss)")
+	cmd.PersistentFlags().BoolVarP(&flagAge, "age", "G", false, "show the age of the process using the format (dd:hh:mm:ss), or the format chosen with --age-format")
+	cmd.PersistentFlags().StringVarP(&flagAgeFormat, "age-format", "", util.DurationClock, fmt.Sprintf("show ages in <format>, e.g., 03:04:05:06, 3d4h, P3DT4H5M6S, '3 days, 4 hours', or 273906; valid options are: %s", strings.Join(util.DurationFormats(), ", ")))
 	cmd.PersistentFlags().BoolVarP(&flagArguments, "arguments", "a", false, "show command line arguments")
+	cmd.PersistentFlags().BoolVarP(&flagHighlightSelf, "highlight-self", "", false, "highlight pstree itself and its ancestors, e.g., the shell it was started from; cannot be used with --highlight-pid")
+	cmd.PersistentFlags().StringVarP(&flagHighlight, "highlight", "", "", "highlight processes with <pattern> in the command line while keeping the full tree, unlike --contains, to see them in the context of the whole system")
+	cmd.PersistentFlags().Int32VarP(&flagHighlightPID, "highlight-pid", "", 0, "highlight process <pid> and its ancestors; cannot be used with --highlight-self")
 	cmd.PersistentFlags().BoolVarP(&flagExcludeRoot, "exclude-root", "X", false, "don't show branches containing only root processes; cannot be used with --user")
 	cmd.PersistentFlags().Int32VarP(&flagPid, "pid", "P", 0, "show only branches containing process <pid>")
+	cmd.PersistentFlags().StringVarP(&flagAncestors, "ancestors", "", "", "show only the chain of ancestors from the root of the tree down to the process <pid>, or to every process whose name or path matches the regular expression <regex> entirely, without siblings, descendants, or threads, like pstree -s; cannot be used with --pid or --root-cmd")
+	cmd.PersistentFlags().StringVarP(&flagRootCmd, "root-cmd", "", "", "show only branches containing the first process, with the lowest PID, whose name or path matches the regular expression <regex> entirely, e.g., sshd or 'php-fpm.*'; cannot be used with --pid")
+	cmd.PersistentFlags().BoolVarP(&flagRootCmdAll, "root-cmd-all", "", false, "with --root-cmd, show the branches of all the matching processes instead of the first")
+	cmd.PersistentFlags().StringVarP(&flagPidsFrom, "pids-from", "", "", "show only the processes whose PIDs are listed in <file>, or on stdin if <file> is -, plus their ancestors; PIDs are separated by whitespace or newlines, e.g., pgrep ssh | pstree --pids-from -")
 	cmd.PersistentFlags().StringSliceVarP(&flagUsername, "user", "", []string{}, "show only branches containing processes of <user>; this option can be used more than and cannot be used with --exclude-root")
-	cmd.PersistentFlags().StringVarP(&flagContains, "contains", "s", "", "show only branches containing processes with <pattern> in the command line; implies --compact-not")
+	cmd.PersistentFlags().StringVarP(&flagContains, "contains", "s", "", "show only branches containing processes with <pattern> in the command line")
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" || (username == "gdanko" || username == "gary.danko") {
+		cmd.PersistentFlags().StringSliceVarP(&flagTTY, "tty", "", []string{}, "show only processes attached to the terminal <tty>, e.g., pts/3 or /dev/pts/3, plus their ancestors; this option can be used more than once (Linux and macOS)")
+	}
+	if runtime.GOOS == "linux" || (username == "gdanko" || username == "gary.danko") { // I put this here to show all output for the usage section of the README
+		cmd.PersistentFlags().StringVarP(&flagWhoLocks, "who-locks", "", "", "show only processes holding a POSIX lock or flock on <file>, plus their ancestors (Linux-only)")
+		cmd.PersistentFlags().Int32VarP(&flagAsSeenBy, "as-seen-by", "", 0, "show only the processes visible in the PID namespace of process <pid>, with the PIDs they have in it, e.g., what a containerized process itself can see; cannot be used with --group-by-cgroup (Linux-only)")
+		cmd.PersistentFlags().StringSliceVarP(&flagNs, "ns", "", []string{}, fmt.Sprintf("show only processes in the namespace <type>=<inode>, e.g., net=4026532204, plus their ancestors; inodes are shown by --show-ns or readlink /proc/<pid>/ns/<type>; valid types are: %s; this option can be used more than once (Linux-only)", strings.Join(tree.NamespaceTypes, ", ")))
+		cmd.PersistentFlags().StringSliceVarP(&flagUnit, "unit", "", []string{}, "show only processes in the systemd unit <unit>, e.g., nginx.service, plus their ancestors; units without a type are services, e.g., nginx; this option can be used more than once (Linux-only)")
+		cmd.PersistentFlags().StringVarP(&flagContextContains, "context-contains", "", "", "show only processes whose SELinux or AppArmor label contains <text>, e.g., unconfined or sshd_t, plus their ancestors (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagPortConflicts, "port-conflicts", "", false, "show only processes listening on a port that is also listened on in another network namespace, e.g., by another container, plus their ancestors, and list the conflicts on stderr (Linux-only)")
+	}
+	if runtime.GOOS == "windows" || (username == "gdanko" || username == "gary.danko") {
+		cmd.PersistentFlags().BoolVarP(&flagElevated, "elevated", "", false, "show only elevated and system processes, plus their ancestors; implies --show-elevation (Windows-only)")
+	}
+	cmd.PersistentFlags().StringSliceVarP(&flagExcludePattern, "exclude-pattern", "", []string{}, "hide processes whose command line matches <regex>, and their subtrees unless a descendant matches another filter; this option can be used more than once")
+	cmd.PersistentFlags().StringSliceVarP(&flagExcludeUser, "exclude-user", "", []string{}, "hide processes owned by <user>, and their subtrees unless a descendant matches another filter; this option can be used more than once")
+	cmd.PersistentFlags().BoolVarP(&flagZombiesOnly, "zombies-only", "", false, "show only zombie processes, plus their ancestors")
+	cmd.PersistentFlags().BoolVarP(&flagZombieParents, "zombie-parents", "", false, "show only processes failing to reap zombie children, shown as (z:N), plus their zombies and ancestors")
+	cmd.PersistentFlags().StringSliceVarP(&flagInGroup, "in-group", "", []string{}, "show only processes running with the group <group>, given by name or ID, as their real, effective, or supplementary group, e.g., docker, plus their ancestors; this option can be used more than once")
+	cmd.PersistentFlags().Int32VarP(&flagNiceBelow, "nice-below", "", 0, "show only processes whose nice value is below <nice>, e.g., -5 for processes given a higher priority, plus their ancestors (not supported on Windows)")
+	cmd.PersistentFlags().Int32VarP(&flagNiceAbove, "nice-above", "", 0, "show only processes whose nice value is above <nice>, e.g., 0 for processes given a lower priority, plus their ancestors (not supported on Windows)")
+	cmd.PersistentFlags().StringSliceVarP(&flagRequireEnv, "require-env", "", []string{}, "show only processes defining the environment variable <KEY> or <KEY=VALUE>, plus their ancestors; this option can be used more than once")
+	cmd.PersistentFlags().StringSliceVarP(&flagEnvContains, "env-contains", "", []string{}, "show only processes whose environment variable <KEY> contains <TEXT>, given as <KEY>=<TEXT>, e.g., JAVA_OPTS=-Xmx8g, plus their ancestors; this option can be used more than once")
+	cmd.PersistentFlags().StringVarP(&flagTagsFile, "tags-file", "", "", "label processes using <file>; each line maps a command regex or pid:<pid> to a tag, e.g., 'nginx → web-team'")
+	cmd.PersistentFlags().StringSliceVarP(&flagTag, "tag", "", []string{}, "show only branches containing processes tagged with <tag>; this option can be used more than once and requires --tags-file")
+	cmd.PersistentFlags().BoolVarP(&flagDeclutter, "declutter", "", false, "collapse noisy desktop helpers, e.g., browser renderers and language servers, into a count on their parent, e.g., [+12 browser-helper], and hide crash reporters")
+	cmd.PersistentFlags().StringVarP(&flagDeclutterFile, "declutter-file", "", "", "add the rules in <file> to the built-in --declutter rules; each line is 'collapse <regex> <label>' or 'hide <regex>'; implies --declutter")
+	cmd.PersistentFlags().BoolVarP(&flagTimeline, "timeline", "", false, "order children by start time and show when each process started relative to the root of the tree or the --pid process, e.g., +0.0s, +2.3s; cannot be used with --order-by")
+	if runtime.GOOS == "linux" || (username == "gdanko" || username == "gary.danko") { // I put this here to show all output for the usage section of the README
+		cmd.PersistentFlags().BoolVarP(&flagGroupByCgroup, "group-by-cgroup", "", false, "root the tree at the control group hierarchy instead of PID 1, listing each process under its control group; cannot be used with --timeline (Linux-only)")
+		cmd.PersistentFlags().BoolVarP(&flagCollapseContainers, "collapse-containers", "", false, "collapse each container into its topmost process with a count of the processes it runs, e.g., [docker:web, +12 processes]; implies --show-container (Linux-only)")
+	}
 	cmd.PersistentFlags().StringVarP(&flagOrderBy, "order-by", "o", "", fmt.Sprintf("sort the results by <field>; valid options are: %s", strings.Join(validOrderBy, ", ")))
 
 	// Miscellaneous
+	cmd.PersistentFlags().StringVarP(&flagOutput, "output", "", "text", fmt.Sprintf("write the tree in <format>; valid options are: %s", strings.Join(tree.OutputFormats(), ", ")))
+	cmd.PersistentFlags().StringVarP(&flagTitle, "title", "", "", "print <title> above the tree and embed it in the html, dot, json, and yaml outputs, e.g., --title \"web01 production\"")
+	cmd.PersistentFlags().BoolVarP(&flagLabelRoot, "label-root", "", false, "label the root of the tree with the host and time the processes were collected, e.g., [web01 2025-06-01T12:00:00Z]")
+	cmd.PersistentFlags().BoolVarP(&flagStable, "stable", "", false, "produce byte-stable output for tests and golden files; zeroes age, cpu, memory, and thread counts, leaves out threads and pstree itself, sorts siblings by command, arguments, and pid, and fixes the width")
+	cmd.PersistentFlags().BoolVarP(&flagRedact, "redact", "", false, "mask passwords, tokens, and keys in arguments and environment variables; enabled by default with --stable, --output other than text, --redact-pattern, and --show-env")
+	cmd.PersistentFlags().StringSliceVarP(&flagRedactPattern, "redact-pattern", "", []string{}, "also mask text matching <regex>; a group named 'secret' limits masking to that group; this option can be used more than once")
+	cmd.PersistentFlags().BoolVarP(&flagAnonymize, "anonymize", "", false, "replace usernames, hostnames, and path components in every output format with stable pseudonyms, e.g., user-1a2b3c, so that the tree can be shared publicly; system accounts and directories, e.g., root and /usr/bin, are kept")
 	cmd.PersistentFlags().BoolVarP(&flagVersion, "version", "V", false, "display version information")
+	cmd.PersistentFlags().StringVarP(&flagConfig, "config", "", "", "read default options, --color-attr thresholds, and aliases from <file> instead of the user configuration directory, e.g., ~/.config/pstree/config.yaml; options given on the command line take precedence, and an empty <file> reads none")
+	cmd.PersistentFlags().StringSliceVarP(&flagAlias, "alias", "", []string{}, "apply the options of the alias <name> defined in the configuration file; options given on the command line take precedence; this option can be used more than once")
+	cmd.PersistentFlags().BoolVarP(&flagHelpJSON, "help-json", "", false, "describe the commands and flags as JSON, with their types, defaults, and valid values, for wrapper tools and completion generators")
+	cmd.PersistentFlags().StringVarP(&flagKill, "kill", "", "", "send <signal> to the --pid process and all its descendants, children first, e.g., TERM, KILL, or 15")
+	cmd.PersistentFlags().BoolVarP(&flagDryRun, "dry-run", "", false, "with --kill, print the signals that would be sent without sending them")
+	cmd.PersistentFlags().StringVarP(&flagSnapshot, "snapshot", "", "", "save the collected processes to <file> instead of displaying them, for rendering later with --from-snapshot; secrets are redacted unless --redact=false")
+	cmd.PersistentFlags().StringVarP(&flagFromSnapshot, "from-snapshot", "", "", "display the processes saved to <file> with --snapshot instead of the live processes; any display options can be used")
+	cmd.PersistentFlags().StringVarP(&flagRemote, "remote", "", "", "collect the processes of <host> over SSH, e.g., alice@db1, and display them with any display options; a pstree installed on <host> collects every metric, otherwise ps provides the owner, CPU and memory usage, age, and state")
+	cmd.PersistentFlags().StringVarP(&flagGantt, "gantt", "", "", "with --watch, record the lifetimes of the displayed processes and write them as a Gantt chart to <file> when interrupted; files ending in .html get an HTML page, anything else an SVG image")
+	cmd.PersistentFlags().IntVarP(&flagWatch, "watch", "", 0, "refresh the tree every <seconds> until interrupted, tagging processes that appeared with [new] and listing those that exited")
 	cmd.PersistentFlags().BoolVarP(&flagShowPGLs, "show-pgls", "S", false, "show process group leader indicators")
+	cmd.PersistentFlags().BoolVarP(&flagStrict, "strict", "", false, "exit with a non-zero status when the collected data has more anomalies, e.g., missing parents or negative PGIDs, than --strict-threshold; anomalies are summarized on stderr unless --quiet")
+	cmd.PersistentFlags().IntVarP(&flagStrictThreshold, "strict-threshold", "", 0, "with --strict, the number of anomalies to tolerate")
+	cmd.PersistentFlags().CountVarP(&flagQuiet, "quiet", "", "suppress warnings, e.g., about nonexistent users, headers, and summaries, e.g., of anomalies in the collected data; use twice to suppress error messages too, leaving only the exit status; cannot be used with --verbose")
+	cmd.PersistentFlags().CountVarP(&flagVerbose, "verbose", "", "show informational messages, e.g., how many processes were collected and how many could not be fully read due to permissions; use twice to list each of them; cannot be used with --quiet")
+	cmd.PersistentFlags().BoolVarP(&flagPager, "pager", "", false, "show trees longer than the terminal through $PAGER, or less if it is not set, keeping colors by setting LESS=-R unless LESS is set; only when the standard output is a terminal")
+	cmd.PersistentFlags().BoolVarP(&flagNoPager, "no-pager", "", false, "never show the tree through a pager, e.g., to override pager: true in the configuration file")
+	cmd.PersistentFlags().BoolVarP(&flagNoHeaders, "no-headers", "", false, "omit headers, e.g., the --watch banner and the column names of the --fair-share summary")
+	cmd.PersistentFlags().IntVarP(&flagCollectWorkers, "collect-workers", "", 0, "collect the details of <n> processes concurrently; 0 uses the number of CPUs")
+	cmd.PersistentFlags().BoolVarP(&flagCache, "cache", "", false, fmt.Sprintf("reuse the command, arguments, and owner of processes seen by an invocation in the last %d minutes instead of reading them again, speeding up repeated use on servers with many processes; they are saved in the user cache directory, e.g., ~/.cache/pstree, readable only by you", int(pstree.ProcessCacheMaxAge.Minutes())))
 
 	// Debugging and experimental features
 	if username == "gdanko" || username == "gary.danko" {
@@ -90,4 +208,69 @@ func GetPersistentFlags(cmd *cobra.Command, colorSupport bool, colorCount int, u
 			cmd.PersistentFlags().BoolVarP(&flagGenerateThreads, "generate-threads", "x", false, "generate threads for testing purposes (Darwin-only)")
 		}
 	}
+
+	// Older names of flags, accepted everywhere the current name is
+	cmd.SetGlobalNormalizationFunc(normalizeFlagName)
+}
+
+// normalizeFlagName maps the older names of renamed flags to their current names.
+//
+// Parameters:
+//   - flags: The flag set the name is looked up in
+//   - name: The flag name given on the command line or in the configuration file
+//
+// Returns:
+//   - The current name of the flag
+func normalizeFlagName(flags *pflag.FlagSet, name string) pflag.NormalizedName {
+	switch name {
+	case "deterministic":
+		name = "stable"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// GetDiffFlags configures the command-line flags specific to the diff command.
+//
+// Parameters:
+//   - cmd: The cobra command to which flags will be added
+func GetDiffFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&flagDiffUnchanged, "unchanged", "", false, "also show unchanged processes, not only the changed branches and their ancestors")
+}
+
+// GetBenchFlags configures the command-line flags specific to the bench command.
+//
+// Parameters:
+//   - cmd: The cobra command to which flags will be added
+func GetBenchFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&flagCPUProfile, "cpuprofile", "", "", "write a CPU profile of the iterations to <file>, to be read with go tool pprof")
+	cmd.Flags().IntVarP(&flagBenchIterations, "iterations", "", 5, "render the tree <count> times")
+	cmd.Flags().StringVarP(&flagMemProfile, "memprofile", "", "", "write a heap profile to <file> after the iterations, to be read with go tool pprof")
+}
+
+// GetServeFlags configures the command-line flags specific to the serve command.
+//
+// Parameters:
+//   - cmd: The cobra command to which flags will be added
+func GetServeFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&flagServeInterval, "interval", "", 15, "collect the processes every <seconds>")
+	cmd.Flags().StringVarP(&flagServeListen, "listen", "", ":9207", "serve the metrics at /metrics on <address>, e.g., :9207 or 127.0.0.1:9207")
+	cmd.Flags().IntVarP(&flagServeRetention, "retention", "", 1440, "remember exited processes in the /api/processes sightings for <minutes> after they were last seen")
+}
+
+// GetFreezeFlags configures the command-line flags specific to the freeze and thaw commands.
+//
+// Parameters:
+//   - cmd: The cobra command to which flags will be added
+func GetFreezeFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&flagFreezeDryRun, "dry-run", "", false, "print the PIDs that would be signaled, in order, without signaling them")
+	cmd.Flags().StringVarP(&flagFreezeOrder, "order", "", "", fmt.Sprintf("the order the processes are signaled in; valid options are: %s; freeze defaults to children-first and thaw to parents-first", strings.Join(validFreezeOrder, ", ")))
+}
+
+// GetAssertFlags configures the command-line flags specific to the assert command.
+//
+// Parameters:
+//   - cmd: The cobra command to which flags will be added
+func GetAssertFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&flagAssertCount, "count", "", ">=1", "the expected number of matching processes: an operator, one of ==, !=, >=, <=, >, or <, followed by a number, e.g., '==4'; a number alone must be matched exactly")
+	cmd.Flags().StringArrayVarP(&flagAssertFilter, "filter", "", []string{}, "count only processes matching <key>=<value>, where <key> is one of: args, command, pid, ppid, under, user; args, command, and under (the command of an ancestor) match text they contain; this option can be used more than once, and processes must match every filter")
 }
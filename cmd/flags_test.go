@@ -1,10 +1,24 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/gdanko/pstree/pkg/logger"
+	"github.com/gdanko/pstree/pkg/pstree"
 	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestFlagToDisplayOptions tests that the command-line flags correctly set the DisplayOptions
@@ -255,20 +269,27 @@ func TestFlagToDisplayOptions(t *testing.T) {
 // resetFlags resets all flag values to their defaults
 func resetFlags() {
 	flagAge = false
+	flagAncestors = ""
 	flagArguments = false
+	flagASCII = false
 	flagColor = false
 	flagColorAttr = ""
 	flagCompactNot = false
 	flagContains = ""
+	flagContextContains = ""
 	flagCpu = false
 	flagExcludeRoot = false
 	flagHideThreads = false
 	flagIBM850 = false
+	flagLabelRoot = false
 	flagLevel = 0
+	flagLevelFromRoot = false
 	flagMemory = false
 	flagOrderBy = ""
 	flagPid = 0
 	flagRainbow = false
+	flagRootCmd = ""
+	flagRootCmdAll = false
 	flagShowAll = false
 	flagShowOwner = false
 	flagShowPGIDs = false
@@ -277,6 +298,12 @@ func resetFlags() {
 	flagShowUIDTransitions = false
 	flagShowUserTransitions = false
 	flagThreads = false
+	flagTitle = ""
+	flagPager = false
+	flagHighlight = ""
+	flagSummary = false
+	flagNoPager = false
+	flagTTY = []string{}
 	flagUsername = []string{}
 	flagUTF8 = false
 	flagVT100 = false
@@ -333,3 +360,176 @@ func applyFlagsToDisplayOptions() {
 		WideDisplay:         flagWide,
 	}
 }
+
+// TestBatchFlagRestore tests that each batch query starts from the options given to pstree batch
+func TestBatchFlagRestore(t *testing.T) {
+	flags := pflag.NewFlagSet("batch", pflag.ContinueOnError)
+	level := flags.Int("level", 0, "")
+	users := flags.StringSlice("user", []string{}, "")
+	wide := flags.Bool("wide", false, "")
+	require.NoError(t, flags.Parse([]string{"--user", "alice"}))
+
+	saved := saveFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--level", "2", "--user", "bob", "--wide"}))
+	assert.Equal(t, []string{"alice", "bob"}, *users)
+
+	restoreFlags(flags, saved)
+	assert.Equal(t, 0, *level)
+	assert.Equal(t, []string{"alice"}, *users)
+	assert.False(t, *wide)
+	assert.False(t, flags.Changed("level"))
+	assert.True(t, flags.Changed("user"))
+}
+
+// TestConfigPrecedence tests that aliases override the configuration file and the command line overrides both
+func TestConfigPrecedence(t *testing.T) {
+	initLogger()
+	flags := pflag.NewFlagSet("pstree", pflag.ContinueOnError)
+	level := flags.Int("level", 0, "")
+	users := flags.StringSlice("user", []string{}, "")
+	wide := flags.Bool("wide", false, "")
+	pids := flags.Bool("show-pids", false, "")
+	flags.StringSlice("alias", []string{}, "")
+	require.NoError(t, flags.Parse([]string{"--user", "alice"}))
+
+	config := &pstree.Config{
+		Options: map[string]any{"level": 2, "user": []any{"bob"}, "wide": true, "show-ppids": true},
+		Aliases: map[string]string{"deep": "--level 5 --show-pids", "nested": "--alias deep"},
+	}
+	require.NoError(t, applyConfigOptions(flags, config, "config.yaml", []string{"deep"}))
+	assert.Equal(t, 5, *level)
+	assert.Equal(t, []string{"alice"}, *users)
+	assert.True(t, *wide)
+	assert.True(t, *pids)
+	assert.True(t, flags.Changed("level"))
+	assert.False(t, flags.Changed("wide"))
+
+	assert.EqualError(t, applyConfigOptions(flags, config, "config.yaml", []string{"nested"}), "alias 'nested' cannot use --alias")
+	assert.EqualError(t, applyConfigOptions(flags, config, "config.yaml", []string{"shallow"}), "invalid --alias: unknown alias 'shallow'; defined aliases are: deep, nested")
+	config.Options["level"] = "two"
+	assert.ErrorContains(t, applyConfigOptions(flags, config, "config.yaml", nil), `config.yaml: invalid argument "two" for "--level" flag`)
+}
+
+// TestNormalizeFlagName tests that the older names of flags set the current ones
+func TestNormalizeFlagName(t *testing.T) {
+	flags := pflag.NewFlagSet("pstree", pflag.ContinueOnError)
+	stable := flags.Bool("stable", false, "")
+	flags.SetNormalizeFunc(normalizeFlagName)
+	require.NoError(t, flags.Parse([]string{"--deterministic"}))
+	assert.True(t, *stable)
+	assert.True(t, flags.Changed("stable"))
+	assert.Equal(t, flags.Lookup("stable"), flags.Lookup("deterministic"))
+}
+
+// TestHelpJSON tests that --help-json describes the flags with typed defaults and valid values
+func TestHelpJSON(t *testing.T) {
+	var output bytes.Buffer
+	require.NoError(t, writeHelpJSON(&output, rootCmd))
+	assert.Contains(t, output.String(), "<level>", "descriptions are not HTML-escaped")
+
+	var schema HelpSchema
+	require.NoError(t, json.Unmarshal(output.Bytes(), &schema))
+	assert.Equal(t, helpSchemaVersion, schema.SchemaVersion)
+	assert.Equal(t, version, schema.Version)
+
+	flags := map[string]FlagSchema{}
+	for _, flag := range schema.Flags {
+		flags[flag.Name] = flag
+	}
+	assert.Equal(t, FlagSchema{Name: "level", Shorthand: "l", Type: FlagTypeInt, Default: float64(0), Description: "print tree to <level> level deep, counted from the process selected with --pid or --root-cmd"}, flags["level"])
+	assert.Equal(t, false, flags["arguments"].Default)
+	assert.Equal(t, FlagTypeStringList, flags["user"].Type)
+	assert.Equal(t, []any{}, flags["user"].Default)
+	assert.Equal(t, "text", flags["output"].Default)
+	assert.Equal(t, tree.OutputFormats(), flags["output"].Enum)
+	assert.NotContains(t, flags, "deterministic", "older names of flags are not listed")
+
+	commands := map[string]CommandSchema{}
+	for _, command := range schema.Commands {
+		commands[command.Name] = command
+	}
+	require.Contains(t, commands, "diff")
+	require.Len(t, commands["diff"].Flags, 1)
+	assert.Equal(t, "unchanged", commands["diff"].Flags[0].Name)
+	require.Contains(t, commands, "assert")
+	require.Len(t, commands["assert"].Flags, 2)
+	assert.Equal(t, ">=1", commands["assert"].Flags[0].Default)
+	assert.Equal(t, FlagTypeStringList, commands["assert"].Flags[1].Type)
+	require.Contains(t, commands, "freeze")
+	require.Contains(t, commands, "thaw")
+	assert.Equal(t, validFreezeOrder, commands["thaw"].Flags[1].Enum)
+}
+
+// TestVerbosity tests that --quiet and --verbose select the log level and suppress summaries and headers
+func TestVerbosity(t *testing.T) {
+	defer func() {
+		flagQuiet, flagVerbose, flagNoHeaders = 0, 0, false
+		rootCmd.SilenceErrors, rootCmd.SilenceUsage = false, false
+	}()
+
+	assert.Equal(t, slog.LevelInfo, logLevel())
+	assert.Equal(t, &bytes.Buffer{}, summaryOutput(&bytes.Buffer{}))
+	assert.True(t, showHeaders())
+
+	flagNoHeaders = true
+	assert.False(t, showHeaders())
+	assert.NotEqual(t, io.Discard, summaryOutput(&bytes.Buffer{}))
+
+	flagNoHeaders, flagVerbose = false, 3
+	assert.Equal(t, verbosityDetail, verbosity())
+	assert.Equal(t, logger.LevelDetail, logLevel())
+
+	flagQuiet = 1
+	assert.EqualError(t, applyVerbosity(rootCmd), "--quiet and --verbose cannot be used together")
+
+	flagVerbose = 0
+	assert.Equal(t, slog.LevelError, logLevel())
+	assert.Equal(t, io.Discard, summaryOutput(&bytes.Buffer{}))
+	assert.False(t, showHeaders())
+	require.NoError(t, applyVerbosity(rootCmd))
+	assert.False(t, rootCmd.SilenceErrors)
+
+	flagQuiet = 2
+	require.NoError(t, applyVerbosity(rootCmd))
+	assert.True(t, rootCmd.SilenceErrors)
+}
+
+func TestPager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test pager is a shell command")
+	}
+	t.Setenv("PAGER", "less -S")
+	assert.Equal(t, []string{"less", "-S"}, pagerCommand())
+	t.Setenv("PAGER", "")
+	assert.Equal(t, []string{"less"}, pagerCommand())
+
+	// The pager receives the tree, with LESS set to keep colors unless it is already set
+	path := filepath.Join(t.TempDir(), "paged")
+	t.Setenv("LESS", "")
+	os.Unsetenv("LESS")
+	require.NoError(t, runPager([]string{"sh", "-c", "echo \"LESS=$LESS\" > " + path + "; cat >> " + path}, strings.NewReader("-+- init\n")))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "LESS=-R\n-+- init\n", string(data))
+
+	t.Setenv("LESS", "-S")
+	require.NoError(t, runPager([]string{"sh", "-c", "echo \"LESS=$LESS\" > " + path}, strings.NewReader("")))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "LESS=-S\n", string(data))
+
+	assert.Error(t, runPager([]string{"/nonexistent/pager"}, strings.NewReader("")))
+
+	// The pager is only used when the output is a terminal
+	flagPager = true
+	defer func() { flagPager = false }()
+	assert.False(t, usePager())
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, ExitFound, ExitCode(nil))
+	assert.Equal(t, ExitError, ExitCode(errors.New("invalid --output")))
+	assert.Equal(t, ExitNotFound, ExitCode(notFound(errors.New("no processes matched the filters"))))
+	assert.Equal(t, ExitNotFound, ExitCode(fmt.Errorf("line 1: %w", notFound(errors.New("assertion failed")))))
+	assert.Equal(t, "assertion failed", notFound(errors.New("assertion failed")).Error())
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/logger"
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+)
+
+// freezeMaxPasses is the number of times the subtree is collected again to stop processes
+// started by descendants that were not stopped yet.
+const freezeMaxPasses = 5
+
+var (
+	flagFreezeDryRun bool
+	flagFreezeOrder  string
+	validFreezeOrder = []string{"children-first", "parents-first"}
+	freezeCmd        = &cobra.Command{
+		Use:   "freeze <pid>",
+		Short: "suspend a process and all its descendants with SIGSTOP",
+		Long: `Send SIGSTOP to a process and all its descendants, children first by default, e.g.:
+
+  pstree freeze --dry-run 4242
+
+The subtree is collected again after it was stopped, and processes started in the meantime
+are stopped too, so that no descendant keeps running. Resume the subtree with pstree thaw.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pstreeSignalSubtreeCmd(args, "STOP", "children-first")
+		},
+	}
+	thawCmd = &cobra.Command{
+		Use:   "thaw <pid>",
+		Short: "resume a process and all its descendants with SIGCONT",
+		Long: `Send SIGCONT to a process and all its descendants, parents first by default, e.g.:
+
+  pstree thaw 4242
+
+This resumes a subtree suspended with pstree freeze.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pstreeSignalSubtreeCmd(args, "CONT", "parents-first")
+		},
+	}
+)
+
+// init registers the freeze and thaw commands and their flags.
+func init() {
+	for _, cmd := range []*cobra.Command{freezeCmd, thawCmd} {
+		GetFreezeFlags(cmd)
+		cmd.SetUsageTemplate(fmt.Sprintf(`Usage: pstree %s [OPTIONS] <pid>
+
+Application Options:
+{{.LocalFlags.FlagUsages}}`, cmd.Name()))
+		rootCmd.AddCommand(cmd)
+	}
+}
+
+// pstreeSignalSubtreeCmd validates the options of pstree freeze and thaw and signals the subtree.
+//
+// Parameters:
+//   - args: The PID of the root of the subtree
+//   - signalName: The signal to send, STOP or CONT
+//   - defaultOrder: The order processes are signaled in unless --order is given
+//
+// Returns:
+//   - error: Error if the options are invalid or a process could not be signaled
+func pstreeSignalSubtreeCmd(args []string, signalName string, defaultOrder string) error {
+	initLogger()
+	pid, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid PID '%s'", args[0])
+	}
+	order := flagFreezeOrder
+	if order == "" {
+		order = defaultOrder
+	}
+	if !slices.Contains(validFreezeOrder, order) {
+		return fmt.Errorf("valid options for --order are: %s", strings.Join(validFreezeOrder, ", "))
+	}
+	signal, canonicalName, err := pstree.ParseSignal(signalName)
+	if err != nil {
+		return fmt.Errorf("cannot send SIG%s on this platform: %w", signalName, err)
+	}
+
+	// Descendants started while the subtree is being stopped are stopped in a later pass
+	passes := 1
+	if signalName == "STOP" && !flagFreezeDryRun {
+		passes = freezeMaxPasses
+	}
+	signaled := map[int32]bool{}
+	var errs []error
+	for pass := 0; pass < passes; pass++ {
+		processes := []tree.Process{}
+		pstree.GetProcessesWith(&processes, pstree.CollectOptions{Workers: flagCollectWorkers})
+		targets, err := pstree.SubtreeKillOrder(logger.Logger, processes, int32(pid))
+		if err != nil {
+			if pass > 0 {
+				break
+			}
+			return err
+		}
+		if order == "parents-first" {
+			slices.Reverse(targets)
+		}
+		targets = slices.DeleteFunc(targets, func(target tree.Process) bool {
+			return signaled[target.PID]
+		})
+		if len(targets) == 0 {
+			break
+		}
+		if err := pstree.SignalProcesses(targets, signal, canonicalName, flagFreezeDryRun, os.Stdout); err != nil {
+			errs = append(errs, err)
+		}
+		for _, target := range targets {
+			signaled[target.PID] = true
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/gdanko/pstree/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+//------------------------------------------------------------------------------
+// MACHINE-READABLE HELP
+//------------------------------------------------------------------------------
+// Functions in this section describe the commands and flags of pstree as JSON,
+// so that wrapper tools, GUIs, and completion generators can discover what the
+// installed version supports without parsing the usage text.
+
+// helpSchemaVersion is incremented whenever a field of the --help-json schema is
+// renamed or removed. Adding fields does not change it.
+const helpSchemaVersion = 1
+
+// ansiEscape matches the color codes in flag descriptions, e.g., the rainbow --color description.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
+
+// Flag types of the --help-json schema.
+const (
+	FlagTypeBool       = "bool"        // Takes no value
+	FlagTypeCount      = "count"       // Takes no value and counts its occurrences, e.g., -dd
+	FlagTypeInt        = "int"         // Takes an integer
+	FlagTypeString     = "string"      // Takes a string
+	FlagTypeStringList = "string_list" // Takes a comma-separated list and can be used more than once
+)
+
+// HelpSchema describes pstree and its commands for --help-json.
+type HelpSchema struct {
+	SchemaVersion int             `json:"schema_version"`
+	Name          string          `json:"name"`
+	Version       string          `json:"version"`
+	Description   string          `json:"description"`
+	Flags         []FlagSchema    `json:"flags"`
+	Commands      []CommandSchema `json:"commands"`
+}
+
+// CommandSchema describes a subcommand and the flags only it accepts. Subcommands also
+// accept the flags of pstree itself.
+type CommandSchema struct {
+	Name        string       `json:"name"`
+	Usage       string       `json:"usage"`
+	Description string       `json:"description"`
+	Flags       []FlagSchema `json:"flags"`
+}
+
+// FlagSchema describes a flag. Default holds a boolean, a number, a string, or a list
+// of strings, according to Type.
+type FlagSchema struct {
+	Name        string   `json:"name"`
+	Shorthand   string   `json:"shorthand,omitempty"`
+	Type        string   `json:"type"`
+	Default     any      `json:"default"`
+	Enum        []string `json:"enum,omitempty"`
+	Description string   `json:"description"`
+}
+
+// flagEnums returns the valid values of the flags that only accept a fixed set of values.
+//
+// Returns:
+//   - A map of flag names to their sorted valid values
+func flagEnums() map[string][]string {
+	return map[string][]string{
+		"age-format":   util.DurationFormats(),
+		"color-attr":   validAttributes,
+		"color-scheme": validColorSchemes,
+		"compact-rep":  validCompactRep,
+		"order":        validFreezeOrder,
+		"order-by":     validOrderBy,
+		"output":       tree.OutputFormats(),
+		"runtime":      pstree.Runtimes(),
+	}
+}
+
+// flagType maps the type of a pflag value to a --help-json flag type.
+//
+// Parameters:
+//   - flag: The flag
+//
+// Returns:
+//   - The flag type, or the pflag type name for types pstree does not use
+func flagType(flag *pflag.Flag) string {
+	switch flag.Value.Type() {
+	case "bool":
+		return FlagTypeBool
+	case "count":
+		return FlagTypeCount
+	case "int", "int32", "int64":
+		return FlagTypeInt
+	case "string":
+		return FlagTypeString
+	case "stringSlice", "stringArray":
+		return FlagTypeStringList
+	}
+	return flag.Value.Type()
+}
+
+// flagDefault converts the default value of a flag from its text form to the JSON type
+// matching its flag type, e.g., false rather than "false".
+//
+// Parameters:
+//   - flag: The flag
+//   - kind: The flag type returned by flagType
+//
+// Returns:
+//   - The default value
+func flagDefault(flag *pflag.Flag, kind string) any {
+	switch kind {
+	case FlagTypeBool:
+		if value, err := strconv.ParseBool(flag.DefValue); err == nil {
+			return value
+		}
+	case FlagTypeCount, FlagTypeInt:
+		if value, err := strconv.ParseInt(flag.DefValue, 10, 64); err == nil {
+			return value
+		}
+	case FlagTypeStringList:
+		values := []string{}
+		if list := strings.Trim(flag.DefValue, "[]"); list != "" {
+			values = strings.Split(list, ",")
+		}
+		return values
+	}
+	return flag.DefValue
+}
+
+// describeFlags describes the visible flags of a flag set, sorted by name.
+//
+// Parameters:
+//   - flags: The flag set
+//
+// Returns:
+//   - The flag descriptions
+func describeFlags(flags *pflag.FlagSet) []FlagSchema {
+	enums := flagEnums()
+	schemas := []FlagSchema{}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+		kind := flagType(flag)
+		schemas = append(schemas, FlagSchema{
+			Name:        flag.Name,
+			Shorthand:   flag.Shorthand,
+			Type:        kind,
+			Default:     flagDefault(flag, kind),
+			Enum:        enums[flag.Name],
+			Description: ansiEscape.ReplaceAllString(flag.Usage, ""),
+		})
+	})
+	return schemas
+}
+
+// writeHelpJSON writes the --help-json description of pstree and its subcommands.
+//
+// Only the flags supported on the current platform are described, since the others
+// are not registered.
+//
+// Parameters:
+//   - output: Writer to write the description to
+//   - root: The root command
+//
+// Returns:
+//   - error: Error if the description could not be written
+func writeHelpJSON(output io.Writer, root *cobra.Command) error {
+	schema := HelpSchema{
+		SchemaVersion: helpSchemaVersion,
+		Name:          root.Name(),
+		Version:       version,
+		Description:   "Display a tree of processes.",
+		Flags:         describeFlags(root.PersistentFlags()),
+		Commands:      []CommandSchema{},
+	}
+	for _, command := range root.Commands() {
+		if !command.IsAvailableCommand() {
+			continue
+		}
+		schema.Commands = append(schema.Commands, CommandSchema{
+			Name:        command.Name(),
+			Usage:       command.Use,
+			Description: command.Short,
+			Flags:       describeFlags(command.LocalNonPersistentFlags()),
+		})
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
@@ -8,7 +8,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -329,3 +332,42 @@ func TestFlagCombinationsRealOutput(t *testing.T) {
 		})
 	}
 }
+
+// TestFreezeDryRunRealOutput tests the order pstree freeze and thaw signal a real subtree in
+func TestFreezeDryRunRealOutput(t *testing.T) {
+	// Skip this test if we're running in a CI environment without process access
+	if testing.Short() || runtime.GOOS == "windows" {
+		t.Skip("Skipping integration test in short mode or on Windows")
+	}
+
+	// A shell waiting on a child gives a subtree of two processes
+	shell := exec.Command("sh", "-c", "sleep 30 & wait")
+	require.NoError(t, shell.Start())
+	defer func() {
+		exec.Command("pkill", "-P", fmt.Sprint(shell.Process.Pid)).Run()
+		shell.Process.Kill()
+		shell.Wait()
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	run := func(args ...string) string {
+		cmd := exec.Command(binaryPath, append(args, "--dry-run", fmt.Sprint(shell.Process.Pid))...)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+		return string(output)
+	}
+	shellLine := fmt.Sprintf("to %d (", shell.Process.Pid)
+
+	output := run("freeze")
+	require.Contains(t, output, shellLine)
+	require.Contains(t, output, "(sleep)")
+	assert.Less(t, strings.Index(output, "(sleep)"), strings.Index(output, shellLine), "freeze stops children first")
+	assert.Contains(t, output, "would send SIGSTOP")
+
+	output = run("thaw")
+	assert.Less(t, strings.Index(output, shellLine), strings.Index(output, "(sleep)"), "thaw resumes parents first")
+	assert.Contains(t, output, "would send SIGCONT")
+
+	output = run("freeze", "--order", "parents-first")
+	assert.Less(t, strings.Index(output, shellLine), strings.Index(output, "(sleep)"))
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/logger"
+	"github.com/gdanko/pstree/pkg/terminal"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+)
+
+//------------------------------------------------------------------------------
+// PAGER
+//------------------------------------------------------------------------------
+// Functions in this section implement --pager, which shows trees longer than the
+// terminal through $PAGER instead of letting them scroll away.
+
+// usePager returns true if the tree should be shown through the pager: --pager is set
+// without --no-pager, the standard output is a terminal, and a single tree is printed.
+//
+// Returns:
+//   - true if the tree should be paged when it is longer than the terminal
+func usePager() bool {
+	return flagPager && !flagNoPager && terminal.Interactive() && flagWatch == 0 && serveAddress == "" && flagKill == ""
+}
+
+// pagerCommand returns the command line of the pager, from the PAGER environment variable.
+//
+// Returns:
+//   - []string: The pager and its arguments, e.g., less -S, or less, or more on Windows, if PAGER is not set
+func pagerCommand() []string {
+	if command := strings.Fields(os.Getenv("PAGER")); len(command) > 0 {
+		return command
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"more"}
+	}
+	return []string{"less"}
+}
+
+// pageTree renders the tree into a buffer, then shows it through the pager if it has more
+// lines than the terminal, or writes it to the standard output otherwise.
+//
+// Parameters:
+//   - cmd: The cobra command
+//   - renderer: The renderer for the selected output format
+//
+// Returns:
+//   - error: Error if the tree cannot be rendered
+func pageTree(cmd *cobra.Command, renderer tree.Renderer) error {
+	var buffer bytes.Buffer
+	treeOutput = &buffer
+	defer func() {
+		treeOutput = os.Stdout
+	}()
+
+	// A tree rendered before an error, e.g., from --strict, is shown all the same
+	err := renderTree(cmd, renderer, nil)
+	if bytes.Count(buffer.Bytes(), []byte("\n")) < terminal.Height() {
+		os.Stdout.Write(buffer.Bytes())
+		return err
+	}
+	if pageErr := runPager(pagerCommand(), &buffer); pageErr != nil {
+		logger.Logger.Warn(fmt.Sprintf("could not start the pager: %v", pageErr))
+		os.Stdout.Write(buffer.Bytes())
+	}
+	return err
+}
+
+// runPager shows the output through the pager and waits for the user to quit it. Colors
+// are preserved by setting LESS to -R, unless LESS is already set.
+//
+// Parameters:
+//   - command: The pager and its arguments
+//   - output: The output to show
+//
+// Returns:
+//   - error: Error if the pager cannot be started
+func runPager(command []string, output io.Reader) error {
+	pager := exec.Command(command[0], command[1:]...)
+	pager.Stdin = output
+	pager.Stdout = os.Stdout
+	pager.Stderr = os.Stderr
+	pager.Env = os.Environ()
+	if _, found := os.LookupEnv("LESS"); !found {
+		pager.Env = append(pager.Env, "LESS=-R")
+	}
+	if err := pager.Start(); err != nil {
+		return err
+	}
+	// Quitting the pager before the end of the tree is not an error
+	pager.Wait()
+	return nil
+}
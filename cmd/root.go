@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log/slog"
+	"io"
 	"os"
+	"os/signal"
+	"regexp"
+	"runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gdanko/pstree/pkg/globals"
 	"github.com/gdanko/pstree/pkg/logger"
 	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/terminal"
 	"github.com/gdanko/pstree/pkg/tree"
 	"github.com/gdanko/pstree/util"
 	"github.com/shirou/gopsutil/v4/mem"
@@ -18,70 +26,187 @@ import (
 )
 
 var (
+	anonymizer              *pstree.Anonymizer // Created once, so that pseudonyms are stable across --watch samples
 	colorCount              int
+	colorThresholds         map[string]tree.Threshold // --color-attr thresholds read from the configuration file
 	colorSupport            bool
 	debugLevel              int
 	displayOptions          tree.DisplayOptions
 	errorMessage            string
 	flagAge                 bool
+	flagAlias               []string
+	flagAgeFormat           string
+	flagAncestors           string
+	flagAnonymize           bool
 	flagArguments           bool
+	flagASCII               bool
 	flagColor               bool
 	flagColorAttr           string
+	flagCollectWorkers      int
 	flagColorScheme         string
+	flagColorSchemeFile     string
+	flagAsSeenBy            int32
+	flagCache               bool
+	flagCollapseContainers  bool
 	flagCompactNot          bool
+	flagCompactRep          string
+	flagCompactShowPIDs     bool
+	flagConfig              string
 	flagContains            string
+	flagContextContains     string
 	flagCpu                 bool
+	flagCumulative          bool
+	flagDBConnections       bool
+	flagDeclutter           bool
+	flagDeclutterFile       string
+	flagDryRun              bool
+	flagElevated            bool
+	flagEnvContains         []string
+	flagExcludePattern      []string
 	flagExcludeRoot         bool
+	flagExcludeUser         []string
+	flagFairShare           bool
+	flagFormat              string
+	flagFromSnapshot        string
+	flagGantt               string
 	flagGenerateThreads     bool // Generate threads for testing purposes
+	flagGroupByCgroup       bool
+	flagHelpJSON            bool
 	flagHideThreads         bool
+	flagHighlight           string
+	flagHighlightPID        int32
+	flagInGroup             []string
+	flagHighlightSelf       bool
+	flagKill                string
+	flagLabelRoot           bool
 	flagIBM850              bool
 	flagLevel               int
+	flagLevelFromRoot       bool
 	flagMapBasedTree        bool // Experimental map-based tree structure
 	flagMemory              bool
+	flagNiceAbove           int32
+	flagNiceBelow           int32
+	flagNoHeaders           bool
+	flagNoPager             bool
+	flagNs                  []string
 	flagOrderBy             string
+	flagOutput              string
+	flagPager               bool
 	flagPid                 int32
+	flagPidsFrom            string
+	flagPortConflicts       bool
+	flagQuiet               int
 	flagRainbow             bool
+	flagRedact              bool
+	flagRedactPattern       []string
+	flagRemote              string
+	flagRequireEnv          []string
+	flagRootCmd             string
+	flagRootCmdAll          bool
+	flagRuntime             []string
+	flagShowAffinity        bool
 	flagShowAll             bool
+	flagShowCaps            bool
+	flagShowCgroup          bool
+	flagShowContainer       bool
+	flagShowDaemonStatus    bool
+	flagShowDeps            bool
+	flagShowDomain          bool
+	flagShowElevation       bool
+	flagShowEnv             []string
+	flagShowFDs             bool
+	flagShowLatency         bool
 	flagShowGroup           bool
+	flagShowGroups          bool
+	flagShowNs              bool
+	flagShowOpenFiles       bool
 	flagShowOwner           bool
 	flagShowPGIDs           bool
 	flagShowPGLs            bool
 	flagShowPIDs            bool
 	flagShowPPIDs           bool
+	flagShowPriority        bool
+	flagShowHeap            bool
+	flagShowIO              bool
+	flagShowRuntime         bool
+	flagShowSaturation      bool
+	flagShowSecurityContext bool
+	flagShowState           bool
+	flagShowTTY             bool
+	flagShowThreads         bool
+	flagShowService         bool
+	flagShowSigning         bool
 	flagShowUIDTransitions  bool
+	flagShowUnit            bool
 	flagShowUserTransitions bool
+	flagSnapshot            string
+	flagStable              bool
+	flagStrict              bool
+	flagStrictThreshold     int
+	flagSummary             bool
+	flagTag                 []string
+	flagTagsFile            string
+	flagThreadContains      string
 	flagThreads             bool
+	flagTimeline            bool
+	flagTitle               string
+	flagTTY                 []string
+	flagUnit                []string
 	flagUsername            []string
 	flagUTF8                bool
+	flagVerbose             int
 	flagVersion             bool
 	flagVT100               bool
+	flagWatch               int
+	flagWhoLocks            string
 	flagWide                bool
+	flagZombieParents       bool
+	flagZombiesOnly         bool
 	installedMemory         *mem.VirtualMemoryStat
+	liveOnlyFlags           []string = []string{"as-seen-by", "context-contains", "elevated", "gantt", "highlight-self", "kill", "port-conflicts", "runtime", "show-affinity", "show-caps", "show-daemon-status", "show-deps", "show-elevation", "show-heap", "show-runtime", "show-saturation", "show-security-context", "show-service", "show-signing", "show-tty", "tty", "watch", "who-locks"}
+	namespaceFilters        map[string]uint64
+	niceAbove               *int32 // Limit of --nice-above, or nil if not given
+	niceBelow               *int32 // Limit of --nice-below, or nil if not given
+	processCache            *pstree.ProcessCache
 	processes               []tree.Process
+	selectedPIDs            []int32 // PIDs read by --pids-from, once even with --watch
 	processTree             *tree.ProcessTree
 	processMap              *tree.ProcessMap // New variable for the map-based tree
 	screenWidth             int
-	sorted                  []tree.Process
+	sightings               *pstree.SightingTracker // Sightings of the displayed processes, recorded by pstree serve
+	stableWidth             int                     = 132
+	treeOutput              io.Writer               = os.Stdout // Writer the rendered tree is written to
 	unicodeSupport          bool
 	usageTemplate           string
 	username                string
-	validAttributes         []string = []string{"age", "cpu", "mem"}
-	validColorSchemes       []string = []string{"darwin", "linux", "powershell", "windows10", "xterm"}
-	validOrderBy            []string = []string{"age", "cmd", "cpu", "mem", "pid", "threads", "user"}
+	validAttributes         []string = []string{"age", "cpu", "fds", "io", "latency", "mem", "share"}
+	validColorSchemes       []string = []string{"darwin", "deuteranopia", "linux", "powershell", "protanopia", "windows10", "xterm"}
+	validCompactRep         []string = []string{"cpu", "oldest", "pid"}
+	validOrderBy            []string = []string{"age", "cmd", "cpu", "io", "mem", "pid", "threads", "user"}
 	version                 string   = "0.8.2"
 	versionString           string
+	watchSample             *pstree.Event // Sample published by the --watch collector, or nil
 	rootCmd                 = &cobra.Command{
 		Use:    "pstree",
 		Short:  "",
 		Long:   fmt.Sprintf("pstree $Revision: %s $ by Gary Danko (C) 2025", version),
 		PreRun: pstreePreRunCmd,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			globals.SetDebugLevel(debugLevel)
-			if debugLevel > 0 {
-				fmt.Printf("Debug level: %d\n", debugLevel)
+			if err := applyVerbosity(cmd); err != nil {
+				return err
 			}
+			if err := applyConfig(cmd); err != nil {
+				return err
+			}
+			logger.Logger.Debug(fmt.Sprintf("Debug level: %d", debugLevel))
+			return nil
 		},
 		RunE: pstreeRunCmd,
+		// Positional arguments were always ignored; without this, cobra rejects them as unknown subcommands
+		Args: cobra.ArbitraryArgs,
+		// Completions are not part of the documented interface
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
 	}
 )
 
@@ -97,8 +222,9 @@ func Execute() error {
 // then sets up the command-line interface with appropriate usage instructions.
 func init() {
 	username = util.DetermineUsername()
-	colorSupport, colorCount = util.HasColorSupport()
-	unicodeSupport = util.HasUnicodeSupport()
+	colorCount = terminal.Colors()
+	colorSupport = colorCount > 0
+	unicodeSupport = terminal.Unicode()
 
 	GetPersistentFlags(rootCmd, colorSupport, colorCount, unicodeSupport, username)
 
@@ -107,7 +233,10 @@ func init() {
 Display a tree of processes.
 
 Application Options:
-{{.Flags.FlagUsages}}
+{{.Flags.FlagUsages}}{{if .HasAvailableSubCommands}}
+Commands:{{range .Commands}}{{if .IsAvailableCommand}}
+  {{rpad .Use 28}} {{.Short}}{{end}}{{end}}
+{{end}}
 Process group leaders are marked with '%s' for ASCII, '%s' for IBM-850, '%s' for VT-100, and '%s' for UTF-8.
 `, tree.TreeStyles["ascii"].PGL, tree.TreeStyles["pc850"].PGL, tree.TreeStyles["vt100"].PGL, tree.TreeStyles["utf8"].PGL)
 
@@ -125,6 +254,12 @@ Process group leaders are marked with '%s' for ASCII, '%s' for IBM-850, '%s' for
 func pstreePreRunCmd(cmd *cobra.Command, args []string) {
 }
 
+// initLogger initializes the logger at the level selected by --debug, --quiet, and --verbose.
+func initLogger() {
+	logger.Init(logLevel())
+	globals.SetLogger(logger.Logger)
+}
+
 // pstreeRunCmd is the main execution function for the pstree command.
 // It initializes the logger, validates command flags, processes system information,
 // and displays the process tree according to the specified options.
@@ -136,24 +271,53 @@ func pstreePreRunCmd(cmd *cobra.Command, args []string) {
 // Returns:
 //   - error: Any error encountered during execution
 func pstreeRunCmd(cmd *cobra.Command, args []string) error {
-	if debugLevel > 0 {
-		logger.Init(slog.LevelDebug)
-	} else {
-		logger.Init(slog.LevelInfo)
-	}
-	globals.SetLogger(logger.Logger)
+	initLogger()
 	installedMemory, _ = util.GetTotalMemory()
 
+	// Like --help, --help-json describes the flags without validating them
+	if flagHelpJSON {
+		return writeHelpJSON(os.Stdout, cmd.Root())
+	}
+
 	// Flag conflict rules
 	// to show if a flag is set, use cmd.Flags().Changed("flag")
 	//
 	// 1. --user cannot be used with --exclude-root
 	// 2. only one of --color-attr, --colorize, and --rainbow can be used
-	// 3. only one of --ibm-850, --utf-8, and --vt-100 can be use
-	// 4. valid options for --color-attr are: age, cpu, mem
+	// 3. only one of --ascii, --ibm-850, --utf-8, and --vt-100 can be used
+	// 4. valid options for --color-attr are: age, cpu, fds, io, latency, mem, share
 	// 5. only one of --uid-transitions and --user-transitions can be used
 	// 6. --level cannot be set to less than 1
-	// 7. valid options for --color-scheme are: darwin, linux, windows10, xterm
+	// 7. valid options for --color-scheme are: darwin, deuteranopia, linux, powershell, protanopia, windows10, xterm, and the schemes of --color-scheme-file
+	// 8. --tag requires --tags-file
+	// 9. --thread-contains cannot be used with --hide-threads
+	// 10. valid options for --output are: dot, html, json, prometheus, text, yaml
+	// 11. --compact-show-pids cannot be used with --compact-not
+	// 12. valid options for --compact-rep are: cpu, oldest, pid
+	// 13. --watch cannot be set to less than 1 and requires --output text
+	// 14. --kill requires --pid and a supported signal, and cannot be used with --watch; --dry-run requires --kill
+	// 15. --exclude-pattern must be a valid regular expression
+	// 16. --timeline cannot be used with --order-by
+	// 17. --gantt requires --watch
+	// 18. --collect-workers cannot be set to less than 0
+	// 19. --snapshot and --from-snapshot cannot be used together, and --from-snapshot cannot be used with options that inspect live processes
+	// 20. --strict-threshold cannot be set to less than 0 and requires --strict
+	// 21. valid options for --age-format are: clock, human, iso8601, long, seconds
+	// 22. valid options for --runtime are: dotnet, go, jvm, node, python
+	// 23. --highlight-self and --highlight-pid cannot be used together
+	// 24. --group-by-cgroup cannot be used with --timeline
+	// 25. --ns must be <type>=<inode>, where <type> is one of: mnt, net, pid, uts
+	// 26. --as-seen-by cannot be used with --group-by-cgroup
+	// 27. --remote cannot be used with --from-snapshot, --cache, or options that inspect local processes
+	// 28. --fair-share and --summary require --output text
+	// 29. --format must be a valid template and requires --output text
+	// 30. --env-contains must be <KEY>=<TEXT>
+	// 31. --anonymize cannot be used with --snapshot
+	// 32. --show-threads cannot be used with --hide-threads
+	// 33. --root-cmd must be a valid regular expression and cannot be used with --pid; --root-cmd-all requires --root-cmd
+	// 34. --ancestors must be a PID or a valid regular expression and cannot be used with --pid or --root-cmd
+	// 35. --nice-below must be greater than --nice-above
+	// 36. --pager and --no-pager cannot be used together
 
 	// Rule 1: --user root cannot be used with --exclude-root
 	if cmd.Flags().Changed("user") && flagExcludeRoot {
@@ -165,12 +329,12 @@ func pstreeRunCmd(cmd *cobra.Command, args []string) error {
 		return errors.New("only one of --color-attr, --color, and --rainbow can be used")
 	}
 
-	// Rule 3: only one of --ibm-850, --utf-8, and --vt-100 can be used
-	if (util.BtoI(flagIBM850) + util.BtoI(flagUTF8) + util.BtoI(flagVT100)) > 1 {
-		return errors.New("only one of --ibm-850, --utf-8, and --vt-100 can be used")
+	// Rule 3: only one of --ascii, --ibm-850, --utf-8, and --vt-100 can be used
+	if (util.BtoI(flagASCII) + util.BtoI(flagIBM850) + util.BtoI(flagUTF8) + util.BtoI(flagVT100)) > 1 {
+		return errors.New("only one of --ascii, --ibm-850, --utf-8, and --vt-100 can be used")
 	}
 
-	// Rule 4: valid options for --color-attr are: age, cpu, mem
+	// Rule 4: valid options for --color-attr are: age, cpu, fds, io, latency, mem, share
 	if flagColorAttr != "" && !slices.Contains(validAttributes, flagColorAttr) {
 		return fmt.Errorf("valid options for --color-attr are: %s", strings.Join(validAttributes, ", "))
 	}
@@ -185,9 +349,250 @@ func pstreeRunCmd(cmd *cobra.Command, args []string) error {
 		return errors.New("--level cannot be set to less than 1")
 	}
 
-	// Rule 7: valid options for --color-scheme are: darwin, linux, windows10, xterm
-	if flagColorScheme != "" && !slices.Contains(validColorSchemes, flagColorScheme) {
-		return fmt.Errorf("valid options for --color-scheme are: %s", strings.Join(validColorSchemes, ", "))
+	// Rule 7: valid options for --color-scheme are: darwin, deuteranopia, linux, powershell, protanopia, windows10, xterm, and the schemes of --color-scheme-file
+	if err := loadColorSchemeFile(); err != nil {
+		return err
+	}
+	if flagColorScheme != "" && !slices.Contains(availableColorSchemes(), flagColorScheme) {
+		return fmt.Errorf("valid options for --color-scheme are: %s", strings.Join(availableColorSchemes(), ", "))
+	}
+
+	// Rule 8: --tag requires --tags-file
+	if len(flagTag) > 0 && flagTagsFile == "" {
+		return errors.New("--tag requires --tags-file")
+	}
+
+	// Rule 9: --thread-contains cannot be used with --hide-threads
+	if flagThreadContains != "" && flagHideThreads {
+		return errors.New("--thread-contains and --hide-threads cannot be used together")
+	}
+
+	// Rule 10: valid options for --output are: dot, html, json, prometheus, text, yaml
+	renderer, err := tree.NewRenderer(flagOutput)
+	if err != nil {
+		return err
+	}
+
+	// Rule 11: --compact-show-pids cannot be used with --compact-not
+	if flagCompactShowPIDs && flagCompactNot {
+		return errors.New("--compact-show-pids and --compact-not cannot be used together")
+	}
+
+	// Rule 12: valid options for --compact-rep are: cpu, oldest, pid
+	if !slices.Contains(validCompactRep, flagCompactRep) {
+		return fmt.Errorf("valid options for --compact-rep are: %s", strings.Join(validCompactRep, ", "))
+	}
+
+	// Rule 13: --watch cannot be set to less than 1 and requires --output text
+	if cmd.Flags().Changed("watch") && (flagWatch < 1 || flagOutput != "text") {
+		return errors.New("--watch cannot be set to less than 1 and requires --output text")
+	}
+
+	// Rule 14: --kill requires --pid and a supported signal, and cannot be used with --watch; --dry-run requires --kill
+	if flagDryRun && flagKill == "" {
+		return errors.New("--dry-run requires --kill")
+	}
+	if flagKill != "" {
+		if !cmd.Flags().Changed("pid") {
+			return errors.New("--kill requires --pid")
+		}
+		if flagWatch > 0 {
+			return errors.New("--kill and --watch cannot be used together")
+		}
+		if _, _, err := pstree.ParseSignal(flagKill); err != nil {
+			return err
+		}
+	}
+
+	// Rule 15: --exclude-pattern must be a valid regular expression
+	for _, expr := range flagExcludePattern {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid --exclude-pattern '%s': %w", expr, err)
+		}
+	}
+
+	// Rule 16: --timeline cannot be used with --order-by
+	if flagTimeline && flagOrderBy != "" {
+		return errors.New("--timeline and --order-by cannot be used together")
+	}
+
+	// Rule 17: --gantt requires --watch
+	if flagGantt != "" && flagWatch == 0 {
+		return errors.New("--gantt requires --watch")
+	}
+
+	// Rule 18: --collect-workers cannot be set to less than 0
+	if flagCollectWorkers < 0 {
+		return errors.New("--collect-workers cannot be set to less than 0")
+	}
+
+	// Rule 19: --snapshot and --from-snapshot cannot be used together, and --from-snapshot cannot be used with options that inspect live processes
+	if flagSnapshot != "" && flagFromSnapshot != "" {
+		return errors.New("--snapshot and --from-snapshot cannot be used together")
+	}
+	if flagFromSnapshot != "" {
+		for _, name := range liveOnlyFlags {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--from-snapshot cannot be used with --%s, which inspects live processes", name)
+			}
+		}
+	}
+
+	// Rule 20: --strict-threshold cannot be set to less than 0 and requires --strict
+	if flagStrictThreshold < 0 {
+		return errors.New("--strict-threshold cannot be set to less than 0")
+	}
+	if cmd.Flags().Changed("strict-threshold") && !flagStrict {
+		return errors.New("--strict-threshold requires --strict")
+	}
+
+	// Rule 21: valid options for --age-format are: clock, human, iso8601, long, seconds
+	if !slices.Contains(util.DurationFormats(), flagAgeFormat) {
+		return fmt.Errorf("valid options for --age-format are: %s", strings.Join(util.DurationFormats(), ", "))
+	}
+
+	// Rule 22: valid options for --runtime are: dotnet, go, jvm, node, python
+	for _, name := range flagRuntime {
+		if !slices.Contains(pstree.Runtimes(), name) {
+			return fmt.Errorf("valid options for --runtime are: %s", strings.Join(pstree.Runtimes(), ", "))
+		}
+	}
+
+	// Rule 23: --highlight-self and --highlight-pid cannot be used together
+	if flagHighlightSelf && flagHighlightPID != 0 {
+		return errors.New("--highlight-self and --highlight-pid cannot be used together")
+	}
+
+	// Rule 24: --group-by-cgroup cannot be used with --timeline
+	if flagGroupByCgroup && flagTimeline {
+		return errors.New("--group-by-cgroup and --timeline cannot be used together")
+	}
+
+	// Rule 25: --ns must be <type>=<inode>, where <type> is one of: mnt, net, pid, uts
+	namespaceFilters, err = pstree.ParseNamespaceFilters(flagNs)
+	if err != nil {
+		return fmt.Errorf("invalid --ns: %w", err)
+	}
+
+	// Rule 26: --as-seen-by cannot be used with --group-by-cgroup
+	if cmd.Flags().Changed("as-seen-by") && flagGroupByCgroup {
+		return errors.New("--as-seen-by and --group-by-cgroup cannot be used together")
+	}
+
+	// Rule 27: --remote cannot be used with --from-snapshot, --cache, or options that inspect local processes
+	if flagRemote != "" {
+		if flagFromSnapshot != "" || flagCache {
+			return errors.New("--remote cannot be used with --from-snapshot or --cache")
+		}
+		for _, name := range liveOnlyFlags {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--remote cannot be used with --%s, which inspects local processes", name)
+			}
+		}
+		if _, err := pstree.NewSSHTransport(flagRemote); err != nil {
+			return fmt.Errorf("invalid --remote: %w", err)
+		}
+	}
+
+	// Rule 28: --fair-share and --summary require --output text
+	if flagFairShare && flagOutput != "text" {
+		return errors.New("--fair-share requires --output text")
+	}
+	if flagSummary && flagOutput != "text" {
+		return errors.New("--summary requires --output text")
+	}
+
+	// Rule 29: --format must be a valid template and requires --output text
+	if flagFormat != "" {
+		if flagOutput != "text" {
+			return errors.New("--format requires --output text")
+		}
+		if _, err := tree.ParseFormat(flagFormat); err != nil {
+			return fmt.Errorf("invalid --format: %w", err)
+		}
+	}
+
+	// Rule 30: --env-contains must be <KEY>=<TEXT>
+	for _, requirement := range flagEnvContains {
+		if key, _, found := strings.Cut(requirement, "="); !found || key == "" {
+			return fmt.Errorf("invalid --env-contains '%s': must be <KEY>=<TEXT>", requirement)
+		}
+	}
+
+	// Rule 31: --anonymize cannot be used with --snapshot
+	if flagAnonymize && flagSnapshot != "" {
+		return errors.New("--anonymize cannot be used with --snapshot; anonymize when rendering it with --from-snapshot instead")
+	}
+
+	// Rule 32: --show-threads cannot be used with --hide-threads
+	if flagShowThreads && flagHideThreads {
+		return errors.New("--show-threads and --hide-threads cannot be used together")
+	}
+
+	// Rule 33: --root-cmd must be a valid regular expression and cannot be used with --pid
+	if flagRootCmd != "" {
+		if _, err := regexp.Compile(flagRootCmd); err != nil {
+			return fmt.Errorf("invalid --root-cmd '%s': %w", flagRootCmd, err)
+		}
+		if flagPid > 0 {
+			return errors.New("--root-cmd and --pid cannot be used together")
+		}
+	}
+	if flagRootCmdAll && flagRootCmd == "" {
+		return errors.New("--root-cmd-all requires --root-cmd")
+	}
+
+	// Rule 34: --ancestors must be a PID or a valid regular expression and cannot be used with --pid or --root-cmd
+	if flagAncestors != "" {
+		if pid, err := strconv.ParseInt(flagAncestors, 10, 32); err == nil {
+			if pid < 1 {
+				return fmt.Errorf("invalid --ancestors '%s': PIDs start at 1", flagAncestors)
+			}
+		} else if _, err := regexp.Compile(flagAncestors); err != nil {
+			return fmt.Errorf("invalid --ancestors '%s': %w", flagAncestors, err)
+		}
+		if flagPid > 0 || flagRootCmd != "" {
+			return errors.New("--ancestors cannot be used with --pid or --root-cmd")
+		}
+	}
+
+	// Rule 35: --nice-below must be greater than --nice-above
+	niceAbove, niceBelow = nil, nil
+	if cmd.Flags().Changed("nice-above") {
+		niceAbove = &flagNiceAbove
+	}
+	if cmd.Flags().Changed("nice-below") {
+		niceBelow = &flagNiceBelow
+	}
+	if niceAbove != nil && niceBelow != nil && *niceBelow <= *niceAbove {
+		return errors.New("--nice-below must be greater than --nice-above")
+	}
+
+	// Rule 36: --pager and --no-pager cannot be used together
+	if flagPager && flagNoPager && cmd.Flags().Changed("pager") && cmd.Flags().Changed("no-pager") {
+		return errors.New("--pager and --no-pager cannot be used together")
+	}
+
+	// Options needing a platform feature this host lacks fail instead of showing partial data
+	if flagFromSnapshot == "" {
+		if err := checkCapabilities(cmd); err != nil {
+			return err
+		}
+	}
+
+	// Processes are colored by the share of their owner unless another coloring is selected
+	if flagFairShare && flagColorAttr == "" && !flagColor && !flagRainbow {
+		flagColorAttr = "share"
+	}
+
+	// Units given without a type are services, as with systemctl
+	for i, unit := range flagUnit {
+		flagUnit[i] = pstree.UnitName(unit)
+	}
+
+	// Terminals may be given as device paths, e.g., /dev/pts/3
+	for i, tty := range flagTTY {
+		flagTTY[i] = pstree.TTYName(tty)
 	}
 
 	if flagVersion {
@@ -218,19 +623,401 @@ For more information about these matters, see the file named LICENSE.`,
 		}
 	}
 
-	screenWidth = util.GetScreenWidth()
-	pstree.GetProcesses(&processes, flagGenerateThreads)
+	selectedPIDs = nil
+	if flagPidsFrom != "" {
+		pids, err := pstree.LoadPIDs(flagPidsFrom)
+		if err != nil {
+			return err
+		}
+		if len(pids) == 0 {
+			return fmt.Errorf("no PIDs were read from '%s'", flagPidsFrom)
+		}
+		selectedPIDs = pids
+	}
+
+	if flagKill != "" {
+		return killSubtree()
+	}
+	if benchmark != nil {
+		return benchTree(cmd, renderer)
+	}
+	if serveAddress != "" {
+		return serveMetrics(cmd, renderer)
+	}
+	if flagWatch > 0 {
+		return watchTree(cmd, renderer)
+	}
+	if usePager() {
+		return pageTree(cmd, renderer)
+	}
+	return renderTree(cmd, renderer, nil)
+}
+
+// collectProcesses collects the live processes, reusing the facts of the processes
+// cached by --cache, and saves the cache for the next invocation. With --remote, the
+// processes of the remote host are collected instead.
+//
+// Parameters:
+//   - target: Pointer to the slice to populate
+//   - metricSet: The optional metrics to collect
+//
+// Returns:
+//   - string: The operating system the processes were collected on, e.g., linux
+//   - error: Error if the processes of the remote host could not be collected
+func collectProcesses(target *[]tree.Process, metricSet pstree.MetricSet) (string, error) {
+	if flagRemote != "" {
+		transport, err := pstree.NewSSHTransport(flagRemote)
+		if err != nil {
+			return "", err
+		}
+		snapshot, err := pstree.CollectRemote(transport)
+		if err != nil {
+			return "", err
+		}
+		*target = append(*target, snapshot.Processes...)
+		return snapshot.OS, nil
+	}
+
+	// The cache is loaded once, and kept in memory between --watch samples
+	if flagCache && processCache == nil {
+		path, err := pstree.ProcessCachePath()
+		if err != nil {
+			logger.Logger.Warn(fmt.Sprintf("the process cache is disabled: %v", err))
+		} else {
+			processCache = pstree.LoadProcessCache(path)
+		}
+	}
+	var cache *pstree.ProcessCache
+	if flagCache {
+		cache = processCache
+	}
+	started := time.Now()
+	pstree.GetProcessesWith(target, pstree.CollectOptions{Cache: cache, GenerateThreads: flagGenerateThreads, Metrics: metricSet, Workers: flagCollectWorkers})
+	logger.Verbose(fmt.Sprintf("collected %d processes in %s", len(*target), time.Since(started).Round(time.Millisecond)))
+	reportUnreadable(*target, metricSet)
+	if cache != nil {
+		if err := cache.Save(); err != nil {
+			logger.Logger.Warn(fmt.Sprintf("failed to save the process cache: %v", err))
+		}
+	}
+	return runtime.GOOS, nil
+}
+
+// requiredMetrics maps the active flags to the optional metrics they need, so that
+// collection skips expensive metrics no option displays, sorts, or filters on.
+//
+// Returns:
+//   - pstree.MetricSet: The metrics to collect for each process
+func requiredMetrics() pstree.MetricSet {
+	// Snapshots are rendered later with display flags that are not known yet
+	if flagSnapshot != "" {
+		return pstree.MetricsAll
+	}
+	metricSet := pstree.MetricsNone
+	if flagCpu || flagShowAll || flagSummary || flagColorAttr == "cpu" || flagColorAttr == "share" || flagShowAffinity || flagOrderBy == "cpu" || flagCompactRep == "cpu" || flagCumulative || flagOutput == "json" || flagOutput == "prometheus" || flagOutput == "yaml" {
+		metricSet |= pstree.MetricCPU
+	}
+	if flagShowCgroup || flagShowUnit || flagShowDeps || len(flagUnit) > 0 || flagGroupByCgroup || flagShowContainer || flagCollapseContainers || flagOutput == "json" || flagOutput == "yaml" {
+		metricSet |= pstree.MetricCgroup
+	}
+	if len(flagShowEnv) > 0 || len(flagRequireEnv) > 0 || len(flagEnvContains) > 0 || flagShowHeap || flagShowSaturation || flagShowContainer || flagCollapseContainers || flagOutput == "html" {
+		metricSet |= pstree.MetricEnvironment
+	}
+	if flagShowGroup || flagShowGroups || len(flagInGroup) > 0 || flagShowAll || flagOutput == "json" || flagOutput == "yaml" {
+		metricSet |= pstree.MetricGroup
+	}
+	if flagShowIO || flagColorAttr == "io" || flagOrderBy == "io" {
+		metricSet |= pstree.MetricIO
+	}
+	if flagMemory || flagShowAll || flagSummary || flagColorAttr == "mem" || flagColorAttr == "share" || flagShowAffinity || flagOrderBy == "mem" || flagCumulative || flagShowHeap || flagOutput == "json" || flagOutput == "prometheus" || flagOutput == "yaml" || flagOutput == "html" {
+		metricSet |= pstree.MetricMemory
+	}
+	if flagShowNs || len(flagNs) > 0 || flagAsSeenBy != 0 || flagOutput == "json" || flagOutput == "yaml" {
+		metricSet |= pstree.MetricNamespaces
+	}
+	if flagShowFDs || flagColorAttr == "fds" || flagOutput == "prometheus" {
+		metricSet |= pstree.MetricNumFDs
+	}
+	if flagThreads || flagShowAll || flagSummary || flagOrderBy == "threads" || flagGenerateThreads || flagCumulative || flagOutput == "json" || flagOutput == "prometheus" || flagOutput == "yaml" {
+		metricSet |= pstree.MetricNumThreads
+	}
+	if flagShowOpenFiles {
+		metricSet |= pstree.MetricOpenFiles
+	}
+	if flagShowPriority || niceAbove != nil || niceBelow != nil {
+		metricSet |= pstree.MetricPriority
+	}
+	if flagShowLatency || flagColorAttr == "latency" {
+		metricSet |= pstree.MetricSchedLatency
+	}
+	// Fields of the --format template
+	metricSet |= pstree.TemplateMetrics(flagFormat)
+	// Zombie children are only counted on their parents with the options showing the state
+	if flagShowState || flagZombiesOnly || flagZombieParents || flagOutput == "json" || flagOutput == "yaml" {
+		metricSet |= pstree.MetricState
+	}
+	// Threads are shown with their processes unless hidden, and listed in every output with --show-threads
+	if (!flagHideThreads && flagOutput == "text") || flagShowThreads {
+		metricSet |= pstree.MetricThreads
+	}
+	// MySQL connections are counted by thread name
+	if flagDBConnections {
+		metricSet |= pstree.MetricThreads
+	}
+	return metricSet
+}
+
+// remoteHostname returns the host of --remote without the user, e.g., web01 for ops@web01.
+//
+// Returns:
+//   - string: The host, or "" when collecting locally
+func remoteHostname() string {
+	remoteHost := flagRemote
+	if _, host, found := strings.Cut(remoteHost, "@"); found {
+		remoteHost = host
+	}
+	return remoteHost
+}
+
+// collectionHost returns the host the processes are collected on: the --remote host, or the
+// local host.
+//
+// Returns:
+//   - string: The hostname
+func collectionHost() string {
+	if remoteHost := remoteHostname(); remoteHost != "" {
+		return remoteHost
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+// sessionAnonymizer returns the anonymizer of --anonymize, creating it on first use.
+//
+// Returns:
+//   - *pstree.Anonymizer: The anonymizer, replacing the local and --remote hostnames too
+func sessionAnonymizer() *pstree.Anonymizer {
+	if anonymizer == nil {
+		hostname, _ := os.Hostname()
+		anonymizer = pstree.NewAnonymizer(hostname, remoteHostname())
+	}
+	return anonymizer
+}
+
+// renderTree collects the processes, builds the tree, and renders it once.
+//
+// Parameters:
+//   - cmd: The command whose flags were parsed
+//   - renderer: The renderer selected by --output
+//   - watch: The state of the previous --watch sample, or nil when not watching
+//
+// Returns:
+//   - error: Error if collecting, annotating, or rendering the processes failed
+func renderTree(cmd *cobra.Command, renderer tree.Renderer, watch *pstree.WatchState) error {
+	processes = []tree.Process{}
+	screenWidth = terminal.Width()
+
+	// Machine-readable output is never colored
+	if flagOutput != "text" {
+		colorSupport = false
+	}
+	collectedOn := runtime.GOOS
+	collectedAt := time.Now()
+	collectedHost := collectionHost()
+	// Each phase is timed for pstree bench
+	phaseStarted := time.Now()
+	if batchProcesses != nil {
+		// pstree batch collects once and renders each query from a copy
+		processes = slices.Clone(batchProcesses)
+	} else if watchSample != nil {
+		// --watch renders the samples published by its collector
+		processes = watchSample.Processes
+		collectedOn = watchSample.OS
+		collectedAt = watchSample.Time
+	} else if flagFromSnapshot != "" {
+		snapshot, err := pstree.LoadSnapshot(flagFromSnapshot)
+		if err != nil {
+			return err
+		}
+		processes = snapshot.Processes
+		collectedOn = snapshot.OS
+		collectedAt = snapshot.Taken
+		collectedHost = snapshot.Hostname
+	} else {
+		hostOS, err := collectProcesses(&processes, requiredMetrics())
+		if err != nil {
+			return err
+		}
+		collectedOn = hostOS
+	}
+	benchmark.Record(pstree.BenchCollection, phaseStarted)
+	phaseStarted = time.Now()
+
+	// pstree batch reports the anomalies of its collection once, not for every query, and pstree bench none
+	anomalies := []pstree.Anomaly{}
+	if batchProcesses == nil && benchmark == nil {
+		anomalies = pstree.DetectAnomalies(processes, collectedOn)
+	}
+
+	// pstree diff merges the baseline snapshot into the later one, marking what changed
+	if diffBaseline != "" {
+		baseline, err := pstree.LoadSnapshot(diffBaseline)
+		if err != nil {
+			return err
+		}
+		processes = pstree.DiffProcesses(baseline.Processes, processes)
+	}
+
+	// Stable output is byte-stable across runs and terminals
+	if flagStable {
+		pstree.StabilizeProcesses(&processes)
+		screenWidth = stableWidth
+		colorSupport = false
+	}
+
+	// Redaction is on by default for modes whose output is meant to be saved or shared
+	redact := flagRedact
+	if !cmd.Flags().Changed("redact") {
+		redact = flagStable || flagOutput != "text" || len(flagRedactPattern) > 0 || len(flagShowEnv) > 0 || flagSnapshot != ""
+	}
+	if redact {
+		redactor, err := pstree.NewRedactor(flagRedactPattern)
+		if err != nil {
+			return err
+		}
+		pstree.RedactProcesses(&processes, redactor)
+	}
+
+	if flagSnapshot != "" {
+		if err := pstree.SaveSnapshot(flagSnapshot, processes); err != nil {
+			return err
+		}
+		return reportAnomalies(anomalies)
+	}
+
+	if flagTagsFile != "" {
+		rules, err := pstree.LoadTagRules(flagTagsFile)
+		if err != nil {
+			return err
+		}
+		pstree.ApplyTags(&processes, rules)
+	}
+
+	if flagDeclutter || flagDeclutterFile != "" {
+		// Rules from the declutter file take precedence over the built-in ones
+		rules := pstree.DefaultDeclutterRules()
+		if flagDeclutterFile != "" {
+			fileRules, err := pstree.LoadDeclutterRules(flagDeclutterFile)
+			if err != nil {
+				return err
+			}
+			rules = append(fileRules, rules...)
+		}
+		pstree.ApplyDeclutter(&processes, rules)
+	}
+
+	if flagShowElevation || flagElevated {
+		if err := pstree.AnnotateElevation(&processes); err != nil {
+			return err
+		}
+	}
+
+	if flagShowService {
+		if err := pstree.AnnotateServices(&processes); err != nil {
+			return err
+		}
+	}
+
+	if flagShowRuntime || len(flagRuntime) > 0 || flagShowHeap {
+		pstree.AnnotateRuntimes(&processes)
+	}
+
+	if flagShowHeap {
+		pstree.AnnotateHeap(&processes)
+	}
+
+	if flagDBConnections {
+		pstree.AnnotateDatabases(&processes)
+	}
+
+	if flagShowSaturation {
+		pstree.AnnotateSaturation(&processes)
+	}
+
+	if flagShowContainer || flagCollapseContainers || flagOutput == "json" || flagOutput == "yaml" {
+		pstree.AnnotateContainers(&processes)
+	}
+
+	onlineCPUs := 0
+	if flagShowAffinity {
+		if err := pstree.AnnotateAffinity(&processes); err != nil {
+			return err
+		}
+		onlineCPUs = pstree.OnlineCPUs()
+	}
+
+	kernelCapabilities := 0
+	if flagShowCaps {
+		if err := pstree.AnnotateCapabilities(&processes); err != nil {
+			return err
+		}
+		kernelCapabilities = pstree.KernelCapabilities()
+	}
+
+	if flagShowSigning {
+		if err := pstree.AnnotateSigning(&processes); err != nil {
+			return err
+		}
+	}
+
+	if flagShowDaemonStatus || flagShowTTY || len(flagTTY) > 0 {
+		if err := pstree.AnnotateDaemonStatus(&processes); err != nil {
+			return err
+		}
+	}
+
+	if flagShowSecurityContext || flagContextContains != "" {
+		if err := pstree.AnnotateSecurityContext(&processes); err != nil {
+			return err
+		}
+	}
+
+	if flagShowDeps {
+		if err := pstree.AnnotateDependencies(&processes); err != nil {
+			return err
+		}
+	}
+
+	if flagWhoLocks != "" {
+		holders, err := pstree.FindLockHolders(flagWhoLocks)
+		if err != nil {
+			return err
+		}
+		if len(holders) == 0 {
+			logger.Logger.Warn(fmt.Sprintf("no process is holding a lock on '%s'", flagWhoLocks))
+		}
+		pstree.MarkLockHolders(&processes, holders)
+	}
+
+	if flagPortConflicts {
+		listeners, err := pstree.FindListeners(processes)
+		if err != nil {
+			return err
+		}
+		conflicts := pstree.FindPortConflicts(listeners)
+		if len(conflicts) == 0 {
+			logger.Logger.Warn("no port is listened on in more than one network namespace")
+		}
+		pstree.MarkPortConflicts(&processes, conflicts)
+		pstree.WritePortConflicts(summaryOutput(os.Stderr), conflicts, processes)
+	}
 
 	if flagOrderBy != "" {
 		if !slices.Contains(validOrderBy, flagOrderBy) {
 			errorMessage = fmt.Sprintf("valid options for --order-by are: %s", strings.Join(validOrderBy, ", "))
 			return errors.New(errorMessage)
 		}
-		proc, err := pstree.GetProcessByPid(&processes, 1)
-		if err != nil {
-			panic(err)
-		}
-		sorted = []tree.Process{proc}
+		// Root processes are ordered like their children, as each one is a separate tree
 		switch flagOrderBy {
 		case "age":
 			flagAge = true
@@ -240,6 +1027,9 @@ For more information about these matters, see the file named LICENSE.`,
 		case "cpu":
 			flagCpu = true
 			pstree.SortProcsByCpu(&processes)
+		case "io":
+			flagShowIO = true
+			pstree.SortProcsByIO(&processes)
 		case "mem":
 			flagMemory = true
 			pstree.SortProcsByMemory(&processes)
@@ -252,26 +1042,28 @@ For more information about these matters, see the file named LICENSE.`,
 		case "user":
 			flagShowOwner = true
 			pstree.SortProcsByUsername(&processes)
-		default:
-			sorted = processes
 		}
+	}
 
-		for _, proc := range processes {
-			if proc.PID != 1 {
-				sorted = append(sorted, proc)
-			}
+	if flagTimeline {
+		// Children are listed in the order they started, reconstructing the startup sequence
+		pstree.SortProcsByCreateTime(&processes)
+	}
+
+	// Only the processes visible in the PID namespace of --as-seen-by are shown, with their PIDs in it
+	if cmd.Flags().Changed("as-seen-by") {
+		if err := pstree.AsSeenBy(&processes, flagAsSeenBy); err != nil {
+			return err
 		}
-		processes = sorted
 	}
 
-	if flagLevel == 0 {
-		flagLevel = 999
+	// The tree is rooted at the control group hierarchy instead of PID 1
+	if flagGroupByCgroup {
+		pstree.GroupByCgroup(&processes)
 	}
 
-	// If any of the following flags are set, then compact mode should be disabled
-	// This is because some of the results or offenders may be buried in collapsed subtrees
-	if flagColorAttr != "" || flagCpu || flagMemory || flagContains != "" {
-		flagCompactNot = true
+	if flagLevel == 0 {
+		flagLevel = 999
 	}
 
 	if flagShowAll {
@@ -286,86 +1078,332 @@ For more information about these matters, see the file named LICENSE.`,
 		flagThreads = true
 	}
 
+	// Highlight pstree itself and its ancestors, e.g., the shell and terminal it was started from
+	highlightPID := flagHighlightPID
+	if flagHighlightSelf {
+		highlightPID = int32(os.Getpid())
+	}
+
+	// Label the root with where and when the processes were collected, so that saved outputs are self-describing
+	rootLabel := ""
+	if flagLabelRoot {
+		if flagAnonymize {
+			collectedHost = sessionAnonymizer().Host(collectedHost)
+		}
+		rootLabel = fmt.Sprintf("%s %s", collectedHost, collectedAt.UTC().Format(time.RFC3339))
+	}
+
 	displayOptions = tree.DisplayOptions{
-		ColorAttr:           flagColorAttr,
-		ColorCount:          colorCount,
-		ColorizeOutput:      flagColor,
-		ColorScheme:         flagColorScheme,
-		ColorSupport:        colorSupport,
-		CompactMode:         !flagCompactNot,
-		Contains:            flagContains,
-		ExcludeRoot:         flagExcludeRoot,
-		HideThreads:         flagHideThreads,
-		IBM850Graphics:      flagIBM850,
-		InstalledMemory:     installedMemory.Total,
-		MaxDepth:            flagLevel,
-		OrderBy:             flagOrderBy,
-		RainbowOutput:       flagRainbow,
-		RootPID:             flagPid,
-		ScreenWidth:         screenWidth,
-		ShowArguments:       flagArguments,
-		ShowCpuPercent:      flagCpu,
-		ShowGroup:           flagShowGroup,
-		ShowMemoryUsage:     flagMemory,
-		ShowNumThreads:      flagThreads,
-		ShowOwner:           flagShowOwner,
-		ShowPGIDs:           flagShowPGIDs,
-		ShowPGLs:            flagShowPGLs,
-		ShowPIDs:            flagShowPIDs,
-		ShowPPIDs:           flagShowPPIDs,
-		ShowProcessAge:      flagAge,
-		ShowUIDTransitions:  flagShowUIDTransitions,
-		ShowUserTransitions: flagShowUserTransitions,
-		Usernames:           flagUsername,
-		UTF8Graphics:        flagUTF8,
-		VT100Graphics:       flagVT100,
-		WideDisplay:         flagWide,
+		AgeFormat:             flagAgeFormat,
+		Ancestors:             flagAncestors,
+		Capabilities:          kernelCapabilities,
+		CollapseContainers:    flagCollapseContainers,
+		ColorAttr:             flagColorAttr,
+		ColorCount:            colorCount,
+		ColorizeOutput:        flagColor,
+		ColorScheme:           flagColorScheme,
+		ColorSupport:          colorSupport,
+		CompactMode:           !flagCompactNot,
+		CompactRepresentative: flagCompactRep,
+		CompactShowPIDs:       flagCompactShowPIDs,
+		Contains:              flagContains,
+		ContextContains:       flagContextContains,
+		Cumulative:            flagCumulative || flagOutput == "prometheus",
+		Declutter:             flagDeclutter || flagDeclutterFile != "",
+		ElevatedOnly:          flagElevated,
+		EnvContains:           flagEnvContains,
+		ExcludePatterns:       flagExcludePattern,
+		ExcludeRoot:           flagExcludeRoot,
+		ExcludeUsers:          flagExcludeUser,
+		FairShare:             flagFairShare,
+		Format:                flagFormat,
+		HideThreads:           flagHideThreads,
+		Highlight:             flagHighlight,
+		HighlightPID:          highlightPID,
+		IBM850Graphics:        flagIBM850,
+		InGroups:              flagInGroup,
+		InstalledMemory:       installedMemory.Total,
+		LevelFromRoot:         flagLevelFromRoot,
+		MaxDepth:              flagLevel,
+		NamespaceFilters:      namespaceFilters,
+		NiceAbove:             niceAbove,
+		NiceBelow:             niceBelow,
+		NoHeaders:             flagNoHeaders,
+		OnlineCPUs:            onlineCPUs,
+		OrderBy:               flagOrderBy,
+		PortConflicts:         flagPortConflicts,
+		RainbowOutput:         flagRainbow,
+		RequireEnv:            flagRequireEnv,
+		RootCommand:           flagRootCmd,
+		RootCommandAll:        flagRootCmdAll,
+		RootLabel:             rootLabel,
+		RootPID:               flagPid,
+		Runtimes:              flagRuntime,
+		ScreenWidth:           screenWidth,
+		SelectedPIDs:          selectedPIDs,
+		ShowAffinity:          flagShowAffinity,
+		ShowArguments:         flagArguments,
+		ShowCaps:              flagShowCaps,
+		ShowCgroup:            flagShowCgroup,
+		ShowContainer:         flagShowContainer || flagCollapseContainers,
+		ShowCpuPercent:        flagCpu,
+		ShowDaemonStatus:      flagShowDaemonStatus,
+		ShowDBConnections:     flagDBConnections,
+		ShowDeps:              flagShowDeps,
+		ShowDiff:              diffBaseline != "",
+		ShowDomain:            flagShowDomain,
+		ShowElevation:         flagShowElevation || flagElevated,
+		ShowEnv:               flagShowEnv,
+		ShowFDs:               flagShowFDs,
+		ShowGroup:             flagShowGroup,
+		ShowGroups:            flagShowGroups,
+		ShowHeap:              flagShowHeap,
+		ShowIO:                flagShowIO,
+		ShowMemoryUsage:       flagMemory,
+		ShowNamespaces:        flagShowNs,
+		ShowNumThreads:        flagThreads,
+		ShowOpenFiles:         flagShowOpenFiles,
+		ShowOwner:             flagShowOwner,
+		ShowPGIDs:             flagShowPGIDs,
+		ShowPGLs:              flagShowPGLs,
+		ShowPIDs:              flagShowPIDs,
+		ShowPPIDs:             flagShowPPIDs,
+		ShowPriority:          flagShowPriority,
+		ShowProcessAge:        flagAge,
+		ShowRuntime:           flagShowRuntime || len(flagRuntime) > 0,
+		ShowSaturation:        flagShowSaturation,
+		ShowSchedLatency:      flagShowLatency,
+		ShowSecurityContext:   flagShowSecurityContext,
+		ShowService:           flagShowService,
+		ShowSigning:           flagShowSigning,
+		ShowState:             flagShowState,
+		ShowTTY:               flagShowTTY,
+		ShowUnchanged:         flagDiffUnchanged,
+		ShowUIDTransitions:    flagShowUIDTransitions,
+		ShowUnit:              flagShowUnit,
+		ShowUserTransitions:   flagShowUserTransitions,
+		Tags:                  flagTag,
+		ThreadContains:        flagThreadContains,
+		Thresholds:            colorThresholds,
+		Timeline:              flagTimeline,
+		Title:                 flagTitle,
+		TTYs:                  flagTTY,
+		Usernames:             flagUsername,
+		UTF8Graphics:          utf8Graphics(),
+		Units:                 flagUnit,
+		VT100Graphics:         flagVT100,
+		WhoLocks:              flagWhoLocks,
+		WideDisplay:           flagWide,
+		ZombieParents:         flagZombieParents,
+		ZombiesOnly:           flagZombiesOnly,
 	}
 
+	benchmark.Record(pstree.BenchAnnotation, phaseStarted)
+	phaseStarted = time.Now()
+
 	// Choose between traditional array-based tree or new map-based tree
-	// Filtering by PID, username, etc. is not currently working with the map-based implementation
+	var model tree.TreeModel
 	if flagMapBasedTree {
 		// Use the new map-based tree structure
 		logger.Logger.Debug("Using map-based tree structure")
 
-		// Build the process map
+		// Build the process map; its processes are marked and formatted by a ProcessTree,
+		// which the steps below share with the array-based tree
 		processMap = tree.NewProcessMap(logger.Logger, processes, displayOptions)
-
-		// Mark processes to be displayed
-		processMap.FindPrintable()
-		// pretty.Println(processMap.Nodes)
-
-		// Drop unmarked processes
-		// processMap.DropUnmarked()
-
-		// Show processes that will be displayed
-		processMap.ShowPrintable()
-
-		// Print the process tree with simple indentation based on depth
-		processMap.PrintTree()
-
+		processTree = processMap.ProcessTree()
+		model = processMap
 	} else {
 		// Use the traditional array-based tree structure
 		logger.Logger.Debug("Using traditional array-based tree structure")
 
 		// Generate the process tree
 		processTree = tree.NewProcessTree(debugLevel, logger.Logger, processes, displayOptions)
+		model = processTree
+	}
+
+	// Mark processes to be displayed
+	model.Mark()
 
-		// Mark processes to be displayed
-		processTree.MarkProcesses()
+	// Drop unmarked processes
+	model.Drop()
+	benchmark.Record(pstree.BenchConstruction, phaseStarted)
 
-		// Drop unmarked processes
-		processTree.DropUnmarked()
+	// Replace names with pseudonyms after filtering, so that filters match the real names
+	if flagAnonymize {
+		pstree.AnonymizeProcesses(&processTree.Nodes, sessionAnonymizer())
+	}
+
+	// Highlight the processes that appeared since the previous --watch sample
+	var exited []tree.Process
+	if watch != nil {
+		exited = watch.Compare(&processTree.Nodes)
+	}
 
-		// Show processes that will be displayed
-		if processTree.DebugLevel > 2 {
+	// Record when pstree serve first and last saw the displayed processes
+	if sightings != nil && watchSample != nil {
+		sightings.Observe(processTree.Nodes, watchSample.Time)
+	}
+
+	// Show processes that will be displayed
+	if debugLevel > 2 {
+		if flagMapBasedTree {
+			processMap.ShowPrintable()
+		} else {
 			processTree.ShowPrintable()
-			os.Exit(0)
 		}
+		os.Exit(0)
+	}
 
-		// Print the tree
-		processTree.PrintTree(0, "")
+	// Print the tree; the map-based tree only renders text
+	phaseStarted = time.Now()
+	if flagMapBasedTree {
+		if err := model.Render(treeOutput); err != nil {
+			return err
+		}
+	} else if err := renderer.Render(treeOutput, processTree); err != nil {
+		return err
+	}
+	if flagFairShare {
+		processTree.PrintFairShare()
+	}
+	if flagSummary {
+		processTree.PrintSummary()
+	}
+	if watch != nil {
+		processTree.PrintExited(exited)
+	}
+	benchmark.Record(pstree.BenchRendering, phaseStarted)
+
+	if err := reportAnomalies(anomalies); err != nil {
+		return err
 	}
 
+	// A single tree whose filters matched nothing is reported by the exit status, as with grep
+	if watch == nil && watchSample == nil && batchProcesses == nil && processTree.MatchedNone() {
+		cmd.Root().SilenceUsage = true
+		return notFound(errors.New("no processes matched the filters"))
+	}
 	return nil
 }
+
+// utf8Graphics returns true if the tree should be drawn with UTF-8 line drawing characters:
+// with --utf-8, or by default when the text tree is written to a terminal whose locale uses
+// UTF-8, like Linux pstree does, unless another style, or --stable, is selected.
+//
+// Returns:
+//   - bool: true if UTF-8 line drawing characters should be used
+func utf8Graphics() bool {
+	if flagUTF8 {
+		return true
+	}
+	if flagASCII || flagIBM850 || flagVT100 || flagStable || flagOutput != "text" {
+		return false
+	}
+	return unicodeSupport && terminal.Interactive()
+}
+
+// reportAnomalies writes a summary of the anomalies found in the collected data to stderr.
+//
+// Parameters:
+//   - anomalies: The anomalies found by pstree.DetectAnomalies
+//
+// Returns:
+//   - error: Error if --strict is enabled and there are more anomalies than --strict-threshold
+func reportAnomalies(anomalies []pstree.Anomaly) error {
+	pstree.WriteAnomalySummary(summaryOutput(os.Stderr), anomalies)
+	if flagStrict && len(anomalies) > flagStrictThreshold {
+		return fmt.Errorf("found %d anomalies in the collected data, more than the --strict-threshold of %d", len(anomalies), flagStrictThreshold)
+	}
+	return nil
+}
+
+// watchTree re-collects and re-renders the tree every --watch seconds until interrupted,
+// clearing the screen between refreshes.
+//
+// Parameters:
+//   - cmd: The command whose flags were parsed
+//   - renderer: The renderer selected by --output
+//
+// Returns:
+//   - error: Error if a refresh failed
+func watchTree(cmd *cobra.Command, renderer tree.Renderer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	state := pstree.NewWatchState()
+	if flagGantt != "" {
+		state.Record()
+	}
+	interval := time.Duration(flagWatch) * time.Second
+
+	// The tree is rendered from each sample the collector publishes
+	bus := pstree.NewEventBus()
+	bus.Subscribe(func(event pstree.Event) error {
+		if event.Kind != pstree.EventSnapshot {
+			return nil
+		}
+		watchSample = &event
+		defer func() { watchSample = nil }()
+
+		// Move the cursor home and clear the screen
+		fmt.Fprint(os.Stdout, "\033[H\033[2J")
+		if showHeaders() {
+			fmt.Fprintf(os.Stdout, "Every %s: pstree %s\n\n", interval, event.Time.Format(time.DateTime))
+		}
+		return renderTree(cmd, renderer, state)
+	})
+
+	collect := func() ([]tree.Process, string, error) {
+		sample := []tree.Process{}
+		collectedOn, err := collectProcesses(&sample, requiredMetrics())
+		return sample, collectedOn, err
+	}
+	if err := pstree.RunCollector(ctx, interval, collect, bus); err != nil {
+		return err
+	}
+	if flagGantt != "" {
+		return writeGantt(state.Recording())
+	}
+	return nil
+}
+
+// writeGantt writes the process lifetimes recorded during --watch to the --gantt file.
+//
+// Parameters:
+//   - recording: The recorded lifetimes
+//
+// Returns:
+//   - error: Error if the file could not be written
+func writeGantt(recording pstree.Recording) error {
+	file, err := os.Create(flagGantt)
+	if err != nil {
+		return err
+	}
+	if err := pstree.WriteGantt(file, recording, flagGantt); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(summaryOutput(os.Stdout), "\nWrote the lifetimes of %d processes to %s\n", len(recording.Lifetimes), flagGantt)
+	return nil
+}
+
+// killSubtree sends the --kill signal to the --pid process and all its descendants, children first.
+//
+// Returns:
+//   - error: Error if the subtree could not be determined or a process could not be signaled
+func killSubtree() error {
+	signal, signalName, err := pstree.ParseSignal(flagKill)
+	if err != nil {
+		return err
+	}
+	processes = []tree.Process{}
+	pstree.GetProcessesWith(&processes, pstree.CollectOptions{Workers: flagCollectWorkers})
+	targets, err := pstree.SubtreeKillOrder(logger.Logger, processes, flagPid)
+	if err != nil {
+		return err
+	}
+	return pstree.SignalProcesses(targets, signal, signalName, flagDryRun, os.Stdout)
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gdanko/pstree/pkg/logger"
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagServeInterval  int
+	flagServeListen    string
+	flagServeRetention int
+	serveAddress       string // Address pstree serve listens on, or empty when not serving
+	serveCmd           = &cobra.Command{
+		Use:   "serve",
+		Short: "expose the metrics of the processes and their subtrees to Prometheus",
+		Long: `Collect the processes every --interval seconds and serve their metrics in the Prometheus
+text format at /metrics, e.g.:
+
+  pstree serve --listen :9207 --user postgres
+
+Each process exports its CPU usage, resident memory, threads, open file descriptors, and number
+of children, and the totals of its subtree. The options of pstree select which processes are
+exported, as they select which processes are displayed.
+
+The times each selected process was first and last seen are served as JSON at /api/processes,
+and the processes that appear and exit are streamed as newline-delimited JSON at /api/events,
+for uptime and restart analytics. Each process is identified by a stable node ID,
+<pid>-<creation time>, so that a restarted process, or a reused PID, is a new node:
+
+  curl 'http://localhost:9207/api/processes?command=nginx&exited=true'
+  curl -N http://localhost:9207/api/events`,
+		Args: cobra.NoArgs,
+		RunE: pstreeServeCmd,
+	}
+	// Options that do not collect live samples or do not export metrics
+	serveExcludedFlags = []string{"from-snapshot", "gantt", "kill", "snapshot", "watch"}
+)
+
+// init registers the serve command and its flags.
+func init() {
+	GetServeFlags(serveCmd)
+	serveCmd.SetUsageTemplate(`Usage: pstree serve [OPTIONS]
+
+Application Options:
+{{.LocalFlags.FlagUsages}}{{.InheritedFlags.FlagUsages}}`)
+	rootCmd.AddCommand(serveCmd)
+}
+
+// pstreeServeCmd validates the options of pstree serve and starts serving the metrics.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: Unused
+//
+// Returns:
+//   - error: Error if the options are invalid or the metrics could not be served
+func pstreeServeCmd(cmd *cobra.Command, args []string) error {
+	for _, name := range serveExcludedFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("pstree serve cannot be used with --%s", name)
+		}
+	}
+	if cmd.Flags().Changed("output") && flagOutput != "prometheus" {
+		return errors.New("pstree serve requires --output prometheus")
+	}
+	if flagServeInterval < 1 {
+		return errors.New("--interval cannot be set to less than 1")
+	}
+	if flagServeListen == "" {
+		return errors.New("--listen requires an address, e.g., :9207")
+	}
+	if flagServeRetention < 0 {
+		return errors.New("--retention cannot be set to less than 0")
+	}
+	flagOutput = "prometheus"
+	serveAddress = flagServeListen
+	return pstreeRunCmd(cmd, nil)
+}
+
+// serveMetrics collects the processes every --interval seconds and serves the metrics of
+// the latest sample at /metrics, and the sightings of the processes at /api/processes and
+// /api/events, until interrupted.
+//
+// Parameters:
+//   - cmd: The command whose flags were parsed
+//   - renderer: The Prometheus renderer
+//
+// Returns:
+//   - error: Error if the address could not be listened on, or a sample could not be collected or rendered
+func serveMetrics(cmd *cobra.Command, renderer tree.Renderer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	listener, err := net.Listen("tcp", serveAddress)
+	if err != nil {
+		return err
+	}
+
+	// Scrapes are answered from the latest rendered sample, never from one being rendered
+	var (
+		mutex   sync.RWMutex
+		metrics []byte
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(writer http.ResponseWriter, request *http.Request) {
+		mutex.RLock()
+		body := metrics
+		mutex.RUnlock()
+		if body == nil {
+			http.Error(writer, "no sample has been collected yet", http.StatusServiceUnavailable)
+			return
+		}
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writer.Write(body)
+	})
+	sightings = pstree.NewSightingTracker(time.Duration(flagServeRetention) * time.Minute)
+	defer func() { sightings = nil }()
+	mux.HandleFunc("/api/processes", serveSightings)
+	mux.HandleFunc("/api/events", func(writer http.ResponseWriter, request *http.Request) {
+		streamSightings(ctx, writer, request)
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	serveErrors := make(chan error, 1)
+	go func() {
+		err := server.Serve(listener)
+		if !errors.Is(err, http.ErrServerClosed) {
+			cancel()
+		}
+		serveErrors <- err
+	}()
+	logger.Logger.Info(fmt.Sprintf("serving metrics at http://%s/metrics", listener.Addr()))
+
+	// The metrics are rendered from each sample the collector publishes
+	bus := pstree.NewEventBus()
+	bus.Subscribe(func(event pstree.Event) error {
+		if event.Kind != pstree.EventSnapshot {
+			return nil
+		}
+		var buffer bytes.Buffer
+		watchSample = &event
+		treeOutput = &buffer
+		defer func() {
+			watchSample = nil
+			treeOutput = os.Stdout
+		}()
+		if err := renderTree(cmd, renderer, nil); err != nil {
+			return err
+		}
+		mutex.Lock()
+		metrics = buffer.Bytes()
+		mutex.Unlock()
+		return nil
+	})
+
+	collect := func() ([]tree.Process, string, error) {
+		sample := []tree.Process{}
+		collectedOn, err := collectProcesses(&sample, requiredMetrics())
+		return sample, collectedOn, err
+	}
+	collectErr := pstree.RunCollector(ctx, time.Duration(flagServeInterval)*time.Second, collect, bus)
+
+	// End the /api/events streams before shutting down, also when the collector failed,
+	// otherwise the server waits for them until the shutdown times out
+	cancel()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-serveErrors; !errors.Is(err, http.ErrServerClosed) {
+		return errors.Join(collectErr, err)
+	}
+	return collectErr
+}
+
+// serveSightings answers /api/processes with the sightings of the displayed processes as
+// JSON, optionally filtered by the pid, command, and exited query parameters.
+//
+// Parameters:
+//   - writer: The response writer
+//   - request: The request
+func serveSightings(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	var (
+		pid     int64 = -1
+		command *regexp.Regexp
+		exited  *bool
+		err     error
+	)
+	if value := query.Get("pid"); value != "" {
+		if pid, err = strconv.ParseInt(value, 10, 32); err != nil {
+			http.Error(writer, fmt.Sprintf("invalid pid %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("command"); value != "" {
+		if command, err = regexp.Compile(value); err != nil {
+			http.Error(writer, fmt.Sprintf("invalid command pattern %q: %s", value, err), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("exited"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(writer, fmt.Sprintf("invalid exited %q", value), http.StatusBadRequest)
+			return
+		}
+		exited = &parsed
+	}
+
+	all, sampled := sightings.Sightings()
+	if sampled.IsZero() {
+		http.Error(writer, "no sample has been collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	selected := []pstree.Sighting{}
+	for _, sighting := range all {
+		if (pid >= 0 && int64(sighting.PID) != pid) ||
+			(command != nil && !command.MatchString(sighting.Command)) ||
+			(exited != nil && sighting.Exited != *exited) {
+			continue
+		}
+		selected = append(selected, sighting)
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(struct {
+		Sampled   time.Time         `json:"sampled"`
+		Processes []pstree.Sighting `json:"processes"`
+	}{sampled, selected})
+}
+
+// streamSightings answers /api/events with the processes that appear and exit from now on,
+// one JSON object per line, until the client disconnects or pstree serve stops.
+//
+// Parameters:
+//   - ctx: Context that is done when pstree serve stops
+//   - writer: The response writer
+//   - request: The request
+func streamSightings(ctx context.Context, writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		http.Error(writer, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	events, unsubscribe := sightings.Listen(256)
+	defer unsubscribe()
+
+	writer.Header().Set("Content-Type", "application/x-ndjson")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	encoder := json.NewEncoder(writer)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-request.Context().Done():
+			return
+		case event := <-events:
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
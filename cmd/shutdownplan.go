@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/gdanko/pstree/pkg/logger"
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+)
+
+var shutdownPlanCmd = &cobra.Command{
+	Use:   "shutdown-plan <pid>",
+	Short: "print the order in which a process and its descendants should be terminated",
+	Long: `Print the order in which a process and all its descendants should be terminated, leaves
+first, with how each process reacts to shutdown signals, e.g.:
+
+  pstree shutdown-plan 4242
+
+Processes of the same wave can be terminated together once the previous wave has exited, so
+that no process outlives its parent. Processes that handle SIGTERM shut down gracefully and
+need time to exit, and processes that ignore it need SIGKILL. Signal dispositions are read
+on Linux only.`,
+	Args: cobra.ExactArgs(1),
+	RunE: pstreeShutdownPlanCmd,
+}
+
+// init registers the shutdown-plan command.
+func init() {
+	shutdownPlanCmd.SetUsageTemplate(`Usage: pstree shutdown-plan <pid>
+`)
+	rootCmd.AddCommand(shutdownPlanCmd)
+}
+
+// pstreeShutdownPlanCmd collects the processes and prints the shutdown plan of a subtree.
+//
+// Parameters:
+//   - cmd: The command being executed
+//   - args: The PID of the root of the subtree
+//
+// Returns:
+//   - error: Error if the PID is invalid or does not exist
+func pstreeShutdownPlanCmd(cmd *cobra.Command, args []string) error {
+	initLogger()
+	pid, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid PID '%s'", args[0])
+	}
+	processes := []tree.Process{}
+	pstree.GetProcessesWith(&processes, pstree.CollectOptions{Workers: flagCollectWorkers})
+	steps, err := pstree.ShutdownPlan(logger.Logger, processes, int32(pid))
+	if err != nil {
+		return err
+	}
+	pstree.WriteShutdownPlan(os.Stdout, steps)
+	return nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/gdanko/pstree/pkg/logger"
+	"github.com/gdanko/pstree/pkg/pstree"
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/spf13/cobra"
+)
+
+//------------------------------------------------------------------------------
+// VERBOSITY
+//------------------------------------------------------------------------------
+// Functions in this section implement --quiet, --verbose, and --no-headers, which
+// govern every message pstree writes besides its output across all commands:
+// warnings, e.g., about nonexistent users, headers, summaries, and errors.
+
+const (
+	// verbositySilent also suppresses error messages; only the exit status reports failures
+	verbositySilent = -2
+	// verbosityQuiet suppresses warnings, headers, and summaries
+	verbosityQuiet = -1
+	// verbosityNormal shows warnings, headers, and summaries
+	verbosityNormal = 0
+	// verbosityVerbose also shows informational messages, e.g., how many processes were collected
+	verbosityVerbose = 1
+	// verbosityDetail also shows details, e.g., each process that could not be fully read
+	verbosityDetail = 2
+)
+
+// verbosity returns the verbosity selected by --quiet and --verbose.
+//
+// Returns:
+//   - int: The verbosity, from verbositySilent to verbosityDetail
+func verbosity() int {
+	return min(max(flagVerbose-flagQuiet, verbositySilent), verbosityDetail)
+}
+
+// applyVerbosity validates --quiet and --verbose and silences the errors of
+// the command at verbositySilent.
+//
+// Parameters:
+//   - cmd: The command being executed
+//
+// Returns:
+//   - error: Error if both --quiet and --verbose are set
+func applyVerbosity(cmd *cobra.Command) error {
+	if flagQuiet > 0 && flagVerbose > 0 {
+		return errors.New("--quiet and --verbose cannot be used together")
+	}
+	if verbosity() <= verbositySilent {
+		cmd.Root().SilenceErrors = true
+		cmd.Root().SilenceUsage = true
+	}
+	return nil
+}
+
+// logLevel returns the level of the logger for --debug and the verbosity.
+//
+// Returns:
+//   - slog.Level: The minimum level of the messages to show
+func logLevel() slog.Level {
+	switch {
+	case debugLevel > 0:
+		return slog.LevelDebug
+	case verbosity() <= verbosityQuiet:
+		return slog.LevelError
+	case verbosity() >= verbosityDetail:
+		return logger.LevelDetail
+	case verbosity() >= verbosityVerbose:
+		return logger.LevelVerbose
+	}
+	return slog.LevelInfo
+}
+
+// summaryOutput returns the writer for summaries, e.g., of anomalies in the collected data.
+//
+// Parameters:
+//   - output: The writer summaries are written to
+//
+// Returns:
+//   - io.Writer: output, or io.Discard with --quiet
+func summaryOutput(output io.Writer) io.Writer {
+	if verbosity() <= verbosityQuiet {
+		return io.Discard
+	}
+	return output
+}
+
+// showHeaders returns true if headers, e.g., the --watch banner, should be written.
+//
+// Returns:
+//   - true unless --quiet or --no-headers is set
+func showHeaders() bool {
+	return verbosity() > verbosityQuiet && !flagNoHeaders
+}
+
+// reportUnreadable logs the processes whose requested metrics could not be collected,
+// e.g., due to permissions: their number with --verbose, and each of them with
+// --verbose --verbose.
+//
+// Parameters:
+//   - processes: The collected processes
+//   - metricSet: The metrics that were requested
+func reportUnreadable(processes []tree.Process, metricSet pstree.MetricSet) {
+	if verbosity() < verbosityVerbose {
+		return
+	}
+	fields := metricSet.Fields()
+	unreadable := 0
+	for i := range processes {
+		if processes[i].Unavailable&fields == 0 {
+			continue
+		}
+		unreadable++
+		logger.Detail(fmt.Sprintf("could not read all the details of PID %d (%s)", processes[i].PID, filepath.Base(processes[i].Command)))
+	}
+	if unreadable > 0 {
+		logger.Verbose(fmt.Sprintf("%d of %d processes could not be fully read, e.g., due to permissions; their missing metrics are shown as -", unreadable, len(processes)))
+	}
+}
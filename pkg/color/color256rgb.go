@@ -82,3 +82,19 @@ func Color256Yellow(cs ColorScheme, text *string) {
 func Color256YellowBold(cs ColorScheme, text *string) {
 	color256(cs.YellowBold, text)
 }
+
+func Color256OK(cs ColorScheme, text *string) {
+	color256(cs.OK, text)
+}
+
+func Color256Info(cs ColorScheme, text *string) {
+	color256(cs.Info, text)
+}
+
+func Color256Warn(cs ColorScheme, text *string) {
+	color256(cs.Warn, text)
+}
+
+func Color256Crit(cs ColorScheme, text *string) {
+	color256(cs.Crit, text)
+}
@@ -73,6 +73,22 @@ func Color8WhiteBold(cs ColorScheme, text *string) {
 	color8(cs.WhiteBold, text)
 }
 
+func Color8OK(cs ColorScheme, text *string) {
+	color8(cs.OK, text)
+}
+
+func Color8Info(cs ColorScheme, text *string) {
+	color8(cs.Info, text)
+}
+
+func Color8Warn(cs ColorScheme, text *string) {
+	color8(cs.Warn, text)
+}
+
+func Color8Crit(cs ColorScheme, text *string) {
+	color8(cs.Crit, text)
+}
+
 func Print8ColorRainbow(text string) string {
 	cs := ColorSchemes["ansi8"]
 
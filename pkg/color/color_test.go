@@ -79,3 +79,72 @@ func TestColorize(t *testing.T) {
 	// Test with an empty text
 	assert.Empty(t, Colorize("", "red"))
 }
+
+func TestSemanticSlots(t *testing.T) {
+	// Every scheme defines the semantic slots used for thresholds and warnings
+	for name, scheme := range ColorSchemes {
+		for _, slot := range []ColorMap{scheme.OK, scheme.Info, scheme.Warn, scheme.Crit} {
+			assert.NotEqual(t, ColorMap{}, slot, "scheme %s is missing a semantic slot", name)
+		}
+	}
+
+	// Color-blind safe schemes keep healthy and critical values off the red-green axis
+	for _, name := range []string{"deuteranopia", "protanopia"} {
+		scheme := ColorSchemes[name]
+		assert.Greater(t, scheme.OK.B, scheme.OK.R, name)
+		assert.NotEqual(t, scheme.Warn, scheme.Crit, name)
+	}
+
+	// Semantic colors follow the scheme
+	ok, crit := "ok", "crit"
+	Color256OK(ColorSchemes["deuteranopia"], &ok)
+	Color256Crit(ColorSchemes["deuteranopia"], &crit)
+	assert.Equal(t, "\033[1;38;2;0;114;178mok"+AnsiReset, ok)
+	assert.Equal(t, "\033[1;38;2;213;94;0mcrit"+AnsiReset, crit)
+
+	// Basic terminals keep the ANSI semantic slots of color-blind safe schemes only
+	assert.Equal(t, AnsiBlue, ANSI8Scheme("deuteranopia").OK.Ansi)
+	assert.Equal(t, AnsiGreen, ANSI8Scheme("linux").OK.Ansi)
+	assert.Equal(t, AnsiGreen, ANSI8Scheme("").OK.Ansi)
+	assert.Equal(t, AnsiRedBold, ANSI8Scheme("deuteranopia").RedBold.Ansi)
+}
+
+func TestParseColorSchemes(t *testing.T) {
+	// YAML schemes override the colors of their base scheme
+	schemes, err := parseColorSchemes([]byte("solarized:\n  base: linux\n  blue: \"#268bd2\"\n  crit: 220, 50, 47\n"))
+	assert.NoError(t, err)
+	solarized := schemes["solarized"]
+	assert.Equal(t, ColorMap{R: 38, G: 139, B: 210}, solarized.Blue)
+	assert.Equal(t, ColorMap{R: 220, G: 50, B: 47}, solarized.Crit)
+	assert.Equal(t, ColorSchemes["linux"].Green, solarized.Green)
+
+	// JSON is accepted too, and the base defaults to xterm
+	schemes, err = parseColorSchemes([]byte(`{"mono": {"ok": "#ffffff"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, ColorSchemes["xterm"].Red, schemes["mono"].Red)
+	assert.Equal(t, ColorMap{R: 255, G: 255, B: 255}, schemes["mono"].OK)
+
+	// Malformed schemes are rejected
+	for _, data := range []string{
+		"linux:\n  blue: \"#000000\"\n",
+		"custom:\n  base: ansi8\n",
+		"custom:\n  base: nope\n",
+		"custom:\n  purple: \"#000000\"\n",
+		"custom:\n  blue: \"#00000\"\n",
+		"custom:\n  blue: 1,2,256\n",
+		"custom: [blue]\n",
+	} {
+		_, err := parseColorSchemes([]byte(data))
+		assert.Error(t, err, data)
+	}
+
+	// Registered schemes can be selected and replaced, unlike built-in ones
+	RegisterColorSchemes(schemes)
+	defer func() {
+		delete(ColorSchemes, "mono")
+		delete(customSchemes, "mono")
+	}()
+	assert.Equal(t, []string{"mono"}, CustomSchemeNames())
+	_, err = parseColorSchemes([]byte("mono:\n  ok: \"#000000\"\n"))
+	assert.NoError(t, err)
+}
@@ -4,6 +4,10 @@ const (
 	// Reset
 	AnsiReset = "\033[0m"
 
+	// Attributes
+	AnsiBold    = "\033[1m"
+	AnsiReverse = "\033[7m"
+
 	// Regular Colors
 	AnsiBlack   = "\033[30m"
 	AnsiRed     = "\033[31m"
@@ -29,81 +33,74 @@ type ColorFunc func(cs ColorScheme, text *string)
 
 var Colorizers = map[string]Colorizer{
 	"8color": {
-		Age:                Color8GreenBold,
-		Args:               Color8Red,
-		Command:            Color8BlueBold,
-		CompactStr:         Color8BlackBold,
-		Connector:          Color8BlackBold,
-		CPU:                Color8YellowBold,
-		Memory:             Color8RedBold,
-		NumThreads:         Color8WhiteBold,
-		Owner:              Color8CyanBold,
-		OwnerTransition:    Color8BlackBold,
-		PIDPGID:            Color8MagentaBold,
-		Prefix:             Color8Green,
-		ProcessAgeLow:      Color8Red,
-		ProcessAgeMedium:   Color8Yellow,
-		ProcessAgeHigh:     Color8Cyan,
-		ProcessAgeVeryHigh: Color8Green,
-		CPULow:             Color8Green,
-		CPUMedium:          Color8Yellow,
-		CPUHigh:            Color8Red,
-		MemoryLow:          Color8Green,
-		MemoryMedium:       Color8Yellow,
-		MemoryHigh:         Color8Red,
-		Default:            Color8Green,
+		Age:             Color8GreenBold,
+		Args:            Color8Red,
+		Command:         Color8BlueBold,
+		CompactStr:      Color8BlackBold,
+		Connector:       Color8BlackBold,
+		CPU:             Color8YellowBold,
+		Memory:          Color8RedBold,
+		NumThreads:      Color8WhiteBold,
+		Owner:           Color8CyanBold,
+		OwnerTransition: Color8BlackBold,
+		PIDPGID:         Color8MagentaBold,
+		Prefix:          Color8Green,
+		Default:         Color8Green,
+		Tag:             Color8YellowBold,
+		Unavailable:     Color8BlackBold,
+		OK:              Color8OK,
+		Info:            Color8Info,
+		Warn:            Color8Warn,
+		Crit:            Color8Crit,
 	},
 	"256color": {
-		Age:                Color256Green,
-		Args:               Color256Red,
-		Command:            Color256Blue,
-		CompactStr:         Color256BlackBold,
-		Connector:          Color256BlackBold,
-		CPU:                Color256Yellow,
-		Memory:             Color256Orange,
-		NumThreads:         Color256White,
-		Owner:              Color256Cyan,
-		OwnerTransition:    Color256BlackBold,
-		PIDPGID:            Color256Magenta,
-		Prefix:             Color256Green,
-		ProcessAgeLow:      Color256Red,
-		ProcessAgeMedium:   Color256Yellow,
-		ProcessAgeHigh:     Color256Cyan,
-		ProcessAgeVeryHigh: Color256Green,
-		CPULow:             Color256Green,
-		CPUMedium:          Color256Yellow,
-		CPUHigh:            Color256Red,
-		MemoryLow:          Color256Green,
-		MemoryMedium:       Color256Yellow,
-		MemoryHigh:         Color256Red,
-		Default:            Color256Green,
+		Age:             Color256Green,
+		Args:            Color256Red,
+		Command:         Color256Blue,
+		CompactStr:      Color256BlackBold,
+		Connector:       Color256BlackBold,
+		CPU:             Color256Yellow,
+		Memory:          Color256Orange,
+		NumThreads:      Color256White,
+		Owner:           Color256Cyan,
+		OwnerTransition: Color256BlackBold,
+		PIDPGID:         Color256Magenta,
+		Prefix:          Color256Green,
+		Default:         Color256Green,
+		Tag:             Color256OrangeBold,
+		Unavailable:     Color256BlackBold,
+		OK:              Color256OK,
+		Info:            Color256Info,
+		Warn:            Color256Warn,
+		Crit:            Color256Crit,
 	},
 }
 
+// Colorizer maps the roles of the fields in a line to color functions.
+//
+// Thresholds and warnings use the semantic roles OK, Info, Warn, and Crit, which each
+// color scheme maps to colors of its own, so that a scheme can keep them distinguishable,
+// e.g., for color-blind users, by defining only its semantic slots.
 type Colorizer struct {
-	Age                ColorFunc
-	Args               ColorFunc
-	Command            ColorFunc
-	CompactStr         ColorFunc
-	Connector          ColorFunc
-	CPU                ColorFunc
-	Memory             ColorFunc
-	NumThreads         ColorFunc
-	Owner              ColorFunc
-	OwnerTransition    ColorFunc
-	PIDPGID            ColorFunc
-	Prefix             ColorFunc
-	ProcessAgeLow      ColorFunc
-	ProcessAgeMedium   ColorFunc
-	ProcessAgeHigh     ColorFunc
-	ProcessAgeVeryHigh ColorFunc
-	CPULow             ColorFunc
-	CPUMedium          ColorFunc
-	CPUHigh            ColorFunc
-	MemoryLow          ColorFunc
-	MemoryMedium       ColorFunc
-	MemoryHigh         ColorFunc
-	Default            ColorFunc
+	Age             ColorFunc
+	Args            ColorFunc
+	Command         ColorFunc
+	CompactStr      ColorFunc
+	Connector       ColorFunc
+	CPU             ColorFunc
+	Memory          ColorFunc
+	NumThreads      ColorFunc
+	Owner           ColorFunc
+	OwnerTransition ColorFunc
+	PIDPGID         ColorFunc
+	Prefix          ColorFunc
+	Default         ColorFunc
+	Tag             ColorFunc
+	Unavailable     ColorFunc
+	OK              ColorFunc // Healthy values, e.g., low CPU usage
+	Info            ColorFunc // Noteworthy but harmless values, e.g., processes older than an hour
+	Warn            ColorFunc // Values worth a look, e.g., medium CPU usage
+	Crit            ColorFunc // Values needing attention, e.g., high CPU usage or unsigned executables
 }
 
 type ColorMap struct {
@@ -132,6 +129,11 @@ type ColorScheme struct {
 	WhiteBold   ColorMap
 	Yellow      ColorMap
 	YellowBold  ColorMap
+	// Semantic slots used for thresholds and warnings
+	OK   ColorMap
+	Info ColorMap
+	Warn ColorMap
+	Crit ColorMap
 }
 
 // https://en.wikipedia.org/wiki/ANSI_escape_code#Colors
@@ -157,6 +159,11 @@ var ColorSchemes map[string]ColorScheme = map[string]ColorScheme{
 		// Not part of the standard 16 colors
 		Orange:     ColorMap{R: 215, G: 95, B: 0},
 		OrangeBold: ColorMap{R: 255, G: 135, B: 0},
+		// Semantic slots
+		OK:   ColorMap{R: 19, G: 161, B: 14},
+		Info: ColorMap{R: 58, G: 150, B: 221},
+		Warn: ColorMap{R: 193, G: 156, B: 0},
+		Crit: ColorMap{R: 197, G: 15, B: 31},
 	},
 	"powershell": {
 		Black:       ColorMap{R: 0, G: 0, B: 0},
@@ -178,6 +185,11 @@ var ColorSchemes map[string]ColorScheme = map[string]ColorScheme{
 		// Not part of the standard 16 colors
 		Orange:     ColorMap{R: 215, G: 95, B: 0},
 		OrangeBold: ColorMap{R: 255, G: 135, B: 0},
+		// Semantic slots
+		OK:   ColorMap{R: 0, G: 128, B: 0},
+		Info: ColorMap{R: 0, G: 128, B: 128},
+		Warn: ColorMap{R: 237, G: 237, B: 240},
+		Crit: ColorMap{R: 128, G: 0, B: 0},
 	},
 	"darwin": {
 		Black:       ColorMap{R: 0, G: 0, B: 0},
@@ -199,6 +211,11 @@ var ColorSchemes map[string]ColorScheme = map[string]ColorScheme{
 		// Not part of the standard 16 colors
 		Orange:     ColorMap{R: 215, G: 95, B: 0},
 		OrangeBold: ColorMap{R: 255, G: 135, B: 0},
+		// Semantic slots
+		OK:   ColorMap{R: 0, G: 166, B: 0},
+		Info: ColorMap{R: 0, G: 166, B: 178},
+		Warn: ColorMap{R: 153, G: 153, B: 0},
+		Crit: ColorMap{R: 153, G: 0, B: 0},
 	},
 	"linux": {
 		Black:       ColorMap{R: 1, G: 1, B: 1},
@@ -220,6 +237,11 @@ var ColorSchemes map[string]ColorScheme = map[string]ColorScheme{
 		// Not part of the standard 16 colors
 		Orange:     ColorMap{R: 215, G: 95, B: 0},
 		OrangeBold: ColorMap{R: 255, G: 135, B: 0},
+		// Semantic slots
+		OK:   ColorMap{R: 57, G: 181, B: 74},
+		Info: ColorMap{R: 41, G: 181, B: 233},
+		Warn: ColorMap{R: 255, G: 199, B: 6},
+		Crit: ColorMap{R: 222, G: 56, B: 43},
 	},
 	"xterm": {
 		Black:       ColorMap{R: 0, G: 0, B: 0},
@@ -241,6 +263,65 @@ var ColorSchemes map[string]ColorScheme = map[string]ColorScheme{
 		// Not part of the standard 16 colors
 		Orange:     ColorMap{R: 215, G: 95, B: 0},
 		OrangeBold: ColorMap{R: 255, G: 135, B: 0},
+		// Semantic slots
+		OK:   ColorMap{R: 0, G: 205, B: 0},
+		Info: ColorMap{R: 0, G: 205, B: 205},
+		Warn: ColorMap{R: 205, G: 205, B: 0},
+		Crit: ColorMap{R: 205, G: 0, B: 0},
+	},
+	// Color-blind safe scheme for deuteranopia, the most common form of red-green color blindness
+	"deuteranopia": {
+		Black:       ColorMap{R: 0, G: 0, B: 0},
+		BlackBold:   ColorMap{R: 127, G: 127, B: 127},
+		Blue:        ColorMap{R: 0, G: 0, B: 238},
+		BlueBold:    ColorMap{R: 92, G: 92, B: 255},
+		Cyan:        ColorMap{R: 0, G: 205, B: 205},
+		CyanBold:    ColorMap{R: 0, G: 255, B: 255},
+		Green:       ColorMap{R: 0, G: 205, B: 0},
+		GreenBold:   ColorMap{R: 0, G: 255, B: 0},
+		Magenta:     ColorMap{R: 205, G: 0, B: 205},
+		MagentaBold: ColorMap{R: 255, G: 0, B: 255},
+		Red:         ColorMap{R: 205, G: 0, B: 0},
+		RedBold:     ColorMap{R: 255, G: 0, B: 0},
+		White:       ColorMap{R: 229, G: 229, B: 229},
+		WhiteBold:   ColorMap{R: 255, G: 255, B: 255},
+		Yellow:      ColorMap{R: 205, G: 205, B: 0},
+		YellowBold:  ColorMap{R: 255, G: 255, B: 0},
+		// Not part of the standard 16 colors
+		Orange:     ColorMap{R: 215, G: 95, B: 0},
+		OrangeBold: ColorMap{R: 255, G: 135, B: 0},
+		// Semantic slots from the Okabe-Ito palette, distinguishable without red-green vision
+		OK:   ColorMap{R: 0, G: 114, B: 178, Ansi: AnsiBlue},
+		Info: ColorMap{R: 86, G: 180, B: 233, Ansi: AnsiCyan},
+		Warn: ColorMap{R: 240, G: 228, B: 66, Ansi: AnsiYellow},
+		Crit: ColorMap{R: 213, G: 94, B: 0, Ansi: AnsiMagentaBold},
+	},
+	// Color-blind safe scheme for protanopia; critical values are brighter because reds appear dark
+	"protanopia": {
+		Black:       ColorMap{R: 0, G: 0, B: 0},
+		BlackBold:   ColorMap{R: 127, G: 127, B: 127},
+		Blue:        ColorMap{R: 0, G: 0, B: 238},
+		BlueBold:    ColorMap{R: 92, G: 92, B: 255},
+		Cyan:        ColorMap{R: 0, G: 205, B: 205},
+		CyanBold:    ColorMap{R: 0, G: 255, B: 255},
+		Green:       ColorMap{R: 0, G: 205, B: 0},
+		GreenBold:   ColorMap{R: 0, G: 255, B: 0},
+		Magenta:     ColorMap{R: 205, G: 0, B: 205},
+		MagentaBold: ColorMap{R: 255, G: 0, B: 255},
+		Red:         ColorMap{R: 205, G: 0, B: 0},
+		RedBold:     ColorMap{R: 255, G: 0, B: 0},
+		White:       ColorMap{R: 229, G: 229, B: 229},
+		WhiteBold:   ColorMap{R: 255, G: 255, B: 255},
+		Yellow:      ColorMap{R: 205, G: 205, B: 0},
+		YellowBold:  ColorMap{R: 255, G: 255, B: 0},
+		// Not part of the standard 16 colors
+		Orange:     ColorMap{R: 215, G: 95, B: 0},
+		OrangeBold: ColorMap{R: 255, G: 135, B: 0},
+		// Semantic slots from the Okabe-Ito palette, distinguishable without red-green vision
+		OK:   ColorMap{R: 0, G: 114, B: 178, Ansi: AnsiBlue},
+		Info: ColorMap{R: 86, G: 180, B: 233, Ansi: AnsiCyan},
+		Warn: ColorMap{R: 240, G: 228, B: 66, Ansi: AnsiYellow},
+		Crit: ColorMap{R: 230, G: 159, B: 0, Ansi: AnsiMagentaBold},
 	},
 	"ansi8": {
 		Black:       ColorMap{Ansi: AnsiBlack},
@@ -259,5 +340,32 @@ var ColorSchemes map[string]ColorScheme = map[string]ColorScheme{
 		WhiteBold:   ColorMap{Ansi: AnsiWhiteBold},
 		Yellow:      ColorMap{Ansi: AnsiYellow},
 		YellowBold:  ColorMap{Ansi: AnsiYellowBold},
+		// Semantic slots
+		OK:   ColorMap{Ansi: AnsiGreen},
+		Info: ColorMap{Ansi: AnsiCyan},
+		Warn: ColorMap{Ansi: AnsiYellow},
+		Crit: ColorMap{Ansi: AnsiRed},
 	},
 }
+
+// ANSI8Scheme returns the scheme used on terminals with only 8 or 16 colors.
+//
+// The RGB values of the named scheme cannot be displayed, but its semantic slots are
+// kept when it defines ANSI codes for them, so that color-blind safe schemes remain
+// distinguishable on basic terminals.
+//
+// Parameters:
+//   - name: Name of the selected color scheme, or an empty string for the default
+//
+// Returns:
+//   - ColorScheme: The ansi8 scheme, with the semantic slots of the named scheme if available
+func ANSI8Scheme(name string) ColorScheme {
+	scheme := ColorSchemes["ansi8"]
+	if selected, ok := ColorSchemes[name]; ok && selected.OK.Ansi != "" {
+		scheme.OK = selected.OK
+		scheme.Info = selected.Info
+		scheme.Warn = selected.Warn
+		scheme.Crit = selected.Crit
+	}
+	return scheme
+}
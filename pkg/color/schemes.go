@@ -0,0 +1,189 @@
+package color
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+// CUSTOM COLOR SCHEMES
+//------------------------------------------------------------------------------
+// Functions in this section load color schemes from a YAML or JSON file given by
+// --color-scheme-file, so that users can match pstree to the palette of their
+// terminal without changing the built-in schemes.
+
+// customSchemes records the names of the schemes added by RegisterColorSchemes, so that
+// they can be replaced when the file is loaded again but built-in schemes cannot.
+var customSchemes = map[string]bool{}
+
+// schemeSlots returns the colors of a scheme by the name used in color scheme files.
+//
+// Parameters:
+//   - scheme: The scheme whose colors are returned
+//
+// Returns:
+//   - Pointers to the colors of the scheme, keyed by name, e.g., blue-bold or crit
+func schemeSlots(scheme *ColorScheme) map[string]*ColorMap {
+	return map[string]*ColorMap{
+		"black":        &scheme.Black,
+		"black-bold":   &scheme.BlackBold,
+		"blue":         &scheme.Blue,
+		"blue-bold":    &scheme.BlueBold,
+		"cyan":         &scheme.Cyan,
+		"cyan-bold":    &scheme.CyanBold,
+		"green":        &scheme.Green,
+		"green-bold":   &scheme.GreenBold,
+		"magenta":      &scheme.Magenta,
+		"magenta-bold": &scheme.MagentaBold,
+		"orange":       &scheme.Orange,
+		"orange-bold":  &scheme.OrangeBold,
+		"red":          &scheme.Red,
+		"red-bold":     &scheme.RedBold,
+		"white":        &scheme.White,
+		"white-bold":   &scheme.WhiteBold,
+		"yellow":       &scheme.Yellow,
+		"yellow-bold":  &scheme.YellowBold,
+		"ok":           &scheme.OK,
+		"info":         &scheme.Info,
+		"warn":         &scheme.Warn,
+		"crit":         &scheme.Crit,
+	}
+}
+
+// parseRGB parses a color given as #rrggbb or as r,g,b.
+//
+// Parameters:
+//   - value: The color, e.g., #268bd2 or 38,139,210
+//
+// Returns:
+//   - ColorMap: The color
+//   - error: Error if the color is malformed
+func parseRGB(value string) (ColorMap, error) {
+	value = strings.TrimSpace(value)
+	if hex, ok := strings.CutPrefix(value, "#"); ok {
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return ColorMap{}, fmt.Errorf("invalid color '%s', expected #rrggbb", value)
+		}
+		return ColorMap{R: int(rgb >> 16), G: int(rgb >> 8 & 0xff), B: int(rgb & 0xff)}, nil
+	}
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return ColorMap{}, fmt.Errorf("invalid color '%s', expected #rrggbb or r,g,b", value)
+	}
+	components := [3]int{}
+	for i, part := range parts {
+		component, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || component < 0 || component > 255 {
+			return ColorMap{}, fmt.Errorf("invalid color '%s', components must be from 0 to 255", value)
+		}
+		components[i] = component
+	}
+	return ColorMap{R: components[0], G: components[1], B: components[2]}, nil
+}
+
+// LoadColorSchemes reads a color scheme file and returns the schemes it defines.
+//
+// Parameters:
+//   - path: Path of the color scheme file
+//
+// Returns:
+//   - map[string]ColorScheme: The schemes, keyed by name
+//   - error: Error if the file could not be read or is malformed
+func LoadColorSchemes(path string) (map[string]ColorScheme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read color scheme file: %w", err)
+	}
+	schemes, err := parseColorSchemes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schemes, nil
+}
+
+// parseColorSchemes parses the contents of a color scheme file, in YAML or JSON:
+//
+//	solarized:
+//	  base: xterm
+//	  blue: "#268bd2"
+//	  crit: 220,50,47
+//
+// Each scheme starts from the colors of its base, a built-in scheme that defaults to xterm,
+// and overrides the colors it names: black, blue, cyan, green, magenta, orange, red, white,
+// and yellow, each with a -bold variant, plus the semantic colors ok, info, warn, and crit.
+//
+// Parameters:
+//   - data: Contents of the color scheme file
+//
+// Returns:
+//   - map[string]ColorScheme: The schemes, keyed by name
+//   - error: Error if the file is malformed, or a scheme names an unknown base or color
+func parseColorSchemes(data []byte) (map[string]ColorScheme, error) {
+	definitions := map[string]map[string]string{}
+	if err := yaml.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("malformed color scheme file: %w", err)
+	}
+
+	schemes := make(map[string]ColorScheme, len(definitions))
+	for name, definition := range definitions {
+		if _, exists := ColorSchemes[name]; name == "" || (exists && !customSchemes[name]) {
+			return nil, fmt.Errorf("scheme '%s': the name is reserved for a built-in scheme", name)
+		}
+		base := "xterm"
+		if value, ok := definition["base"]; ok {
+			base = value
+		}
+		scheme, ok := ColorSchemes[base]
+		if !ok || customSchemes[base] || base == "ansi8" {
+			return nil, fmt.Errorf("scheme '%s': unknown base scheme '%s'", name, base)
+		}
+		slots := schemeSlots(&scheme)
+		for slot, value := range definition {
+			if slot == "base" {
+				continue
+			}
+			colorMap, ok := slots[slot]
+			if !ok {
+				return nil, fmt.Errorf("scheme '%s': unknown color '%s'", name, slot)
+			}
+			parsed, err := parseRGB(value)
+			if err != nil {
+				return nil, fmt.Errorf("scheme '%s': %s: %w", name, slot, err)
+			}
+			*colorMap = parsed
+		}
+		schemes[name] = scheme
+	}
+	return schemes, nil
+}
+
+// RegisterColorSchemes adds custom schemes to ColorSchemes, replacing custom schemes
+// of the same name.
+//
+// Parameters:
+//   - schemes: The schemes, as returned by LoadColorSchemes
+func RegisterColorSchemes(schemes map[string]ColorScheme) {
+	for name, scheme := range schemes {
+		ColorSchemes[name] = scheme
+		customSchemes[name] = true
+	}
+}
+
+// CustomSchemeNames returns the names of the schemes added by RegisterColorSchemes.
+//
+// Returns:
+//   - A sorted slice of scheme names
+func CustomSchemeNames() []string {
+	names := make([]string, 0, len(customSchemes))
+	for name := range customSchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
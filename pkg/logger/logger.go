@@ -4,9 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"sync"
 )
 
+const (
+	// LevelVerbose is the level of the informational messages shown with --verbose
+	LevelVerbose = slog.LevelInfo - 1
+	// LevelDetail is the level of the detailed messages shown with --verbose --verbose
+	LevelDetail = slog.LevelInfo - 2
+)
+
 var (
 	Logger *slog.Logger
 	once   sync.Once
@@ -35,7 +43,8 @@ func (h *CustomHandler) Enabled(_ context.Context, level slog.Level) bool {
 // Handle processes a log record by formatting and printing it.
 //
 // This method implements the slog.Handler interface and is called to process a log record.
-// It formats the record with its level and message and prints it to standard output.
+// It formats the record with its level and message and prints it to standard error, so that
+// messages never mix with the tree. The verbose levels are printed as INFO.
 //
 // Parameters:
 //   - _: Context (unused)
@@ -44,7 +53,11 @@ func (h *CustomHandler) Enabled(_ context.Context, level slog.Level) bool {
 // Returns:
 //   - error: nil if successful, or an error if the record could not be processed
 func (h *CustomHandler) Handle(_ context.Context, r slog.Record) error {
-	fmt.Printf("[%s] %s\n", r.Level, r.Message)
+	level := r.Level.String()
+	if r.Level == LevelVerbose || r.Level == LevelDetail {
+		level = slog.LevelInfo.String()
+	}
+	fmt.Fprintf(os.Stderr, "[%s] %s\n", level, r.Message)
 	return nil
 }
 
@@ -89,3 +102,19 @@ func Init(level slog.Level) {
 		Logger = slog.New(&CustomHandler{level: level})
 	})
 }
+
+// Verbose logs an informational message shown with --verbose.
+//
+// Parameters:
+//   - message: The message to log
+func Verbose(message string) {
+	Logger.Log(context.Background(), LevelVerbose, message)
+}
+
+// Detail logs a detailed message shown with --verbose --verbose.
+//
+// Parameters:
+//   - message: The message to log
+func Detail(message string) {
+	Logger.Log(context.Background(), LevelDetail, message)
+}
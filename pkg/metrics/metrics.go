@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os/user"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/gdanko/pstree/pkg/globals"
 	"github.com/shirou/gopsutil/v4/cpu"
@@ -12,6 +14,66 @@ import (
 	"github.com/shirou/gopsutil/v4/process"
 )
 
+var (
+	// groupNames caches the names of groups by GID, or an empty string for unknown groups,
+	// so that each group is looked up once rather than once per process
+	groupNames = map[uint32]string{}
+	// ownerGroups caches the groups each user is a member of, by UID
+	ownerGroups = map[uint32][]uint32{}
+	// groupCacheMutex guards groupNames and ownerGroups, which are used by concurrent collectors
+	groupCacheMutex sync.Mutex
+)
+
+// LookupGroupName returns the name of a group. Names are cached for the lifetime of the process.
+//
+// Parameters:
+//   - gid: The group ID
+//
+// Returns:
+//   - string: The name of the group
+//   - bool: Whether the group exists
+func LookupGroupName(gid uint32) (string, bool) {
+	groupCacheMutex.Lock()
+	defer groupCacheMutex.Unlock()
+	name, cached := groupNames[gid]
+	if !cached {
+		if group, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10)); err == nil {
+			name = group.Name
+		}
+		groupNames[gid] = name
+	}
+	return name, name != ""
+}
+
+// ownerGroupIDs returns the groups a user is a member of, from the user database. Groups are
+// cached for the lifetime of the process.
+//
+// Parameters:
+//   - uid: The user ID
+//
+// Returns:
+//   - []uint32: The group IDs, or nil if the user is unknown
+func ownerGroupIDs(uid uint32) []uint32 {
+	groupCacheMutex.Lock()
+	defer groupCacheMutex.Unlock()
+	if gids, cached := ownerGroups[uid]; cached {
+		return gids
+	}
+	var gids []uint32
+	if owner, err := user.LookupId(strconv.FormatUint(uint64(uid), 10)); err == nil {
+		if ids, err := owner.GroupIds(); err == nil {
+			for _, id := range ids {
+				// Windows group IDs are SIDs, not numbers
+				if gid, err := strconv.ParseUint(id, 10, 32); err == nil {
+					gids = append(gids, uint32(gid))
+				}
+			}
+		}
+	}
+	ownerGroups[uid] = gids
+	return gids
+}
+
 // ProcessArgs sends a function to the provided channel that retrieves command line arguments for a process.
 // This function is designed to be used with goroutines to gather process information concurrently.
 //
@@ -143,9 +205,8 @@ func ProcessGIDs(c chan func(ctx context.Context, proc *process.Process) (gids [
 		}
 		groupsMap = make(map[uint32]string, len(gids))
 		for _, gid := range gids {
-			groupName, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10))
-			if err == nil {
-				groupsMap[gid] = groupName.Name
+			if groupName, ok := LookupGroupName(gid); ok {
+				groupsMap[gid] = groupName
 			}
 		}
 		return gids, groupsMap, nil
@@ -153,6 +214,8 @@ func ProcessGIDs(c chan func(ctx context.Context, proc *process.Process) (gids [
 }
 
 // ProcessGroups sends a function to the provided channel that retrieves supplementary group IDs for a process.
+// Where the supplementary groups of a process cannot be read, e.g., on macOS, the groups its owner is a
+// member of in the user database are returned instead.
 // This function is designed to be used with goroutines to gather process information concurrently.
 //
 // Parameters:
@@ -160,7 +223,14 @@ func ProcessGIDs(c chan func(ctx context.Context, proc *process.Process) (gids [
 func ProcessGroups(c chan func(ctx context.Context, proc *process.Process) (groups []uint32, err error)) {
 	c <- (func(ctx context.Context, proc *process.Process) (groups []uint32, err error) {
 		groups, err = proc.GroupsWithContext(ctx)
-		return groups, err
+		if err == nil {
+			return groups, nil
+		}
+		uids, uidErr := proc.UidsWithContext(ctx)
+		if uidErr != nil || len(uids) == 0 {
+			return []uint32{}, err
+		}
+		return ownerGroupIDs(uids[0]), nil
 	})
 }
 
@@ -235,6 +305,51 @@ func ProcessNumFDs(c chan func(ctx context.Context, proc *process.Process) (numF
 	})
 }
 
+// ProcessIOCounters sends a function to the provided channel that retrieves the bytes and operations read and written by a process.
+// This function is designed to be used with goroutines to gather process information concurrently.
+//
+// Parameters:
+//   - c: Channel to send the function through
+func ProcessIOCounters(c chan func(ctx context.Context, proc *process.Process) (ioCounters *process.IOCountersStat, err error)) {
+	c <- (func(ctx context.Context, proc *process.Process) (ioCounters *process.IOCountersStat, err error) {
+		ioCounters, err = proc.IOCountersWithContext(ctx)
+		return ioCounters, err
+	})
+}
+
+// ProcessNice sends a function to the provided channel that retrieves the nice value of a process,
+// from -20 (highest priority) to 19. This function is designed to be used with goroutines to gather
+// process information concurrently. Windows priority classes are not nice values, so this is not
+// supported on Windows.
+//
+// Parameters:
+//   - c: Channel to send the function through
+func ProcessNice(c chan func(ctx context.Context, proc *process.Process) (nice int32, err error)) {
+	c <- getNiceFunc()
+}
+
+// ProcessSchedPolicy sends a function to the provided channel that retrieves the CPU scheduling class
+// of a process, e.g., other or fifo, and its real-time priority. This function is designed to be used
+// with goroutines to gather process information concurrently.
+// This functionality is only supported on Linux.
+//
+// Parameters:
+//   - c: Channel to send the function through
+func ProcessSchedPolicy(c chan func(ctx context.Context, proc *process.Process) (policy string, rtPriority int32, err error)) {
+	c <- getSchedPolicyFunc()
+}
+
+// ProcessIOPriority sends a function to the provided channel that retrieves the I/O scheduling class
+// of a process, as set by ionice, e.g., be, and its priority within the class. This function is
+// designed to be used with goroutines to gather process information concurrently.
+// This functionality is only supported on Linux.
+//
+// Parameters:
+//   - c: Channel to send the function through
+func ProcessIOPriority(c chan func(ctx context.Context, proc *process.Process) (class string, level int32, err error)) {
+	c <- getIOPriorityFunc()
+}
+
 // ProcessNumThreads sends a function to the provided channel that retrieves the number of threads used by a process.
 // This function is designed to be used with goroutines to gather process information concurrently.
 //
@@ -259,6 +374,17 @@ func ProcessOpenFiles(c chan func(ctx context.Context, proc *process.Process) (o
 	})
 }
 
+// ProcessSchedLatency sends a function to the provided channel that retrieves the average
+// scheduling delay of a process, i.e., how long it waited on a run queue per timeslice.
+// This function is designed to be used with goroutines to gather process information concurrently.
+// This functionality is only supported on Linux.
+//
+// Parameters:
+//   - c: Channel to send the function through
+func ProcessSchedLatency(c chan func(ctx context.Context, proc *process.Process) (schedLatency float64, err error)) {
+	c <- getSchedLatencyFunc()
+}
+
 // ProcessStatus sends a function to the provided channel that retrieves the status of a process.
 // This function is designed to be used with goroutines to gather process information concurrently.
 //
@@ -277,10 +403,17 @@ func ProcessStatus(c chan func(ctx context.Context, proc *process.Process) (stat
 // Parameters:
 //   - c: Channel to send the function through
 func ProcessThreads(c chan func(ctx context.Context, proc *process.Process) (status map[int32]*cpu.TimesStat, err error)) {
-	c <- (func(ctx context.Context, proc *process.Process) (status map[int32]*cpu.TimesStat, err error) {
-		status, err = proc.ThreadsWithContext(ctx)
-		return status, err
-	})
+	c <- getThreadsFunc()
+}
+
+// ProcessThreadNames sends a function to the provided channel that retrieves the names of the threads of a process.
+// This function is designed to be used with goroutines to gather process information concurrently.
+// This functionality is only supported on Linux.
+//
+// Parameters:
+//   - c: Channel to send the function through
+func ProcessThreadNames(c chan func(ctx context.Context, proc *process.Process) (names map[int32]string, err error)) {
+	c <- getThreadNamesFunc()
 }
 
 // ProcessUsername sends a function to the provided channel that retrieves the username of the process owner.
@@ -306,3 +439,134 @@ func ProcessUIDs(c chan func(ctx context.Context, proc *process.Process) (uids [
 		return uids, err
 	})
 }
+
+// parseSchedStat parses the contents of /proc/<pid>/schedstat and returns the average
+// run queue delay per timeslice in nanoseconds.
+//
+// The file contains three fields: time spent on the CPU (ns), time spent waiting on a
+// run queue (ns), and the number of timeslices run on this CPU.
+//
+// Parameters:
+//   - data: Contents of the schedstat file
+//
+// Returns:
+//   - float64: Average scheduling delay in nanoseconds, or -1 if the data is malformed
+//   - error: Error if the data could not be parsed
+func parseSchedStat(data string) (float64, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 3 {
+		return -1, fmt.Errorf("unexpected schedstat format: %q", data)
+	}
+	runDelay, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return -1, err
+	}
+	timeslices, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return -1, err
+	}
+	if timeslices == 0 {
+		return 0, nil
+	}
+	return float64(runDelay) / float64(timeslices), nil
+}
+
+// schedPolicies names the CPU scheduling classes by their number in /proc/<pid>/stat.
+var schedPolicies = []string{"other", "fifo", "rr", "batch", "iso", "idle", "deadline"}
+
+// statField returns a field of the contents of /proc/<pid>/stat, numbered from 1 as in proc(5).
+//
+// The command name in the second field is in parentheses and may contain spaces, so the
+// fields are counted from the last closing parenthesis.
+//
+// Parameters:
+//   - data: Contents of the stat file
+//   - number: Number of the field, from 3, the state
+//
+// Returns:
+//   - string: The field
+//   - error: Error if the data has fewer fields
+func statField(data string, number int) (string, error) {
+	end := strings.LastIndex(data, ")")
+	if end == -1 {
+		return "", fmt.Errorf("unexpected stat format: %q", data)
+	}
+	// The fields after the command start with the state, the 3rd field
+	fields := strings.Fields(data[end+1:])
+	if number < 3 || number-3 >= len(fields) {
+		return "", fmt.Errorf("unexpected stat format: %q", data)
+	}
+	return fields[number-3], nil
+}
+
+// parseNice parses the contents of /proc/<pid>/stat and returns the nice value of the
+// process, the 19th field.
+//
+// Parameters:
+//   - data: Contents of the stat file
+//
+// Returns:
+//   - int32: Nice value, from -20 (highest priority) to 19
+//   - error: Error if the data could not be parsed
+func parseNice(data string) (int32, error) {
+	field, err := statField(data, 19)
+	if err != nil {
+		return 0, err
+	}
+	nice, err := strconv.ParseInt(field, 10, 32)
+	return int32(nice), err
+}
+
+// parseSchedPolicy parses the contents of /proc/<pid>/stat and returns the CPU scheduling
+// class and real-time priority of the process, the 41st and 40th fields.
+//
+// Parameters:
+//   - data: Contents of the stat file
+//
+// Returns:
+//   - string: Scheduling class, e.g., other, batch, idle, fifo, rr, or deadline
+//   - int32: Real-time priority of the fifo and rr classes, from 1 to 99, or 0
+//   - error: Error if the data could not be parsed
+func parseSchedPolicy(data string) (string, int32, error) {
+	rtField, err := statField(data, 40)
+	if err != nil {
+		return "", 0, err
+	}
+	policyField, err := statField(data, 41)
+	if err != nil {
+		return "", 0, err
+	}
+	rtPriority, err := strconv.ParseInt(rtField, 10, 32)
+	if err != nil {
+		return "", 0, err
+	}
+	policy, err := strconv.Atoi(policyField)
+	if err != nil {
+		return "", 0, err
+	}
+	if policy < 0 || policy >= len(schedPolicies) {
+		return fmt.Sprintf("policy-%d", policy), int32(rtPriority), nil
+	}
+	return schedPolicies[policy], int32(rtPriority), nil
+}
+
+// ioPriorityClasses names the I/O scheduling classes by their number in the value of ioprio_get.
+var ioPriorityClasses = []string{"none", "rt", "be", "idle"}
+
+// parseIOPriority splits the value returned by ioprio_get into the I/O scheduling class,
+// in its upper bits, and the priority within the class, in its lower 13 bits.
+//
+// Parameters:
+//   - value: The value returned by ioprio_get
+//
+// Returns:
+//   - string: Scheduling class: none, i.e., derived from the nice value, rt, be, or idle
+//   - int32: Priority of the rt and be classes, from 0 (highest) to 7
+func parseIOPriority(value int) (string, int32) {
+	class := value >> 13
+	level := int32(value & (1<<13 - 1))
+	if class < 0 || class >= len(ioPriorityClasses) {
+		return fmt.Sprintf("class-%d", class), level
+	}
+	return ioPriorityClasses[class], level
+}
@@ -0,0 +1,106 @@
+//go:build linux
+// +build linux
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/sys/unix"
+)
+
+// ioprioWhoProcess selects a single process for ioprio_get.
+const ioprioWhoProcess = 1
+
+// getSchedLatencyFunc returns a function that reads /proc/<pid>/schedstat for a process
+// and computes the average time the process spent waiting on a run queue per timeslice.
+//
+// Returns:
+//   - A function that returns the average scheduling delay in nanoseconds
+func getSchedLatencyFunc() func(ctx context.Context, proc *process.Process) (float64, error) {
+	return func(ctx context.Context, proc *process.Process) (float64, error) {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/schedstat", proc.Pid))
+		if err != nil {
+			return -1, err
+		}
+		return parseSchedStat(string(data))
+	}
+}
+
+// getThreadNamesFunc returns a function that reads /proc/<pid>/task/<tid>/comm for
+// every thread of a process.
+//
+// Returns:
+//   - A function that returns a map of thread IDs to thread names
+func getThreadNamesFunc() func(ctx context.Context, proc *process.Process) (map[int32]string, error) {
+	return func(ctx context.Context, proc *process.Process) (map[int32]string, error) {
+		paths, err := filepath.Glob(fmt.Sprintf("/proc/%d/task/*/comm", proc.Pid))
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[int32]string, len(paths))
+		for _, path := range paths {
+			var tid int32
+			if _, err := fmt.Sscanf(filepath.Base(filepath.Dir(path)), "%d", &tid); err != nil {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			names[tid] = strings.TrimSpace(string(data))
+		}
+		return names, nil
+	}
+}
+
+// getNiceFunc returns a function that reads /proc/<pid>/stat for a process and returns its
+// nice value, which gopsutil reports offset by the default priority on Linux.
+//
+// Returns:
+//   - A function that returns the nice value, from -20 (highest priority) to 19
+func getNiceFunc() func(ctx context.Context, proc *process.Process) (int32, error) {
+	return func(ctx context.Context, proc *process.Process) (int32, error) {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", proc.Pid))
+		if err != nil {
+			return 0, err
+		}
+		return parseNice(string(data))
+	}
+}
+
+// getSchedPolicyFunc returns a function that reads /proc/<pid>/stat for a process and returns
+// its CPU scheduling class and real-time priority.
+//
+// Returns:
+//   - A function that returns the scheduling class, e.g., fifo, and the real-time priority
+func getSchedPolicyFunc() func(ctx context.Context, proc *process.Process) (string, int32, error) {
+	return func(ctx context.Context, proc *process.Process) (string, int32, error) {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", proc.Pid))
+		if err != nil {
+			return "", 0, err
+		}
+		return parseSchedPolicy(string(data))
+	}
+}
+
+// getIOPriorityFunc returns a function that calls ioprio_get for a process and returns its
+// I/O scheduling class and priority, as set by ionice.
+//
+// Returns:
+//   - A function that returns the I/O scheduling class, e.g., be, and the priority within it
+func getIOPriorityFunc() func(ctx context.Context, proc *process.Process) (string, int32, error) {
+	return func(ctx context.Context, proc *process.Process) (string, int32, error) {
+		value, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(proc.Pid), 0)
+		if errno != 0 {
+			return "", 0, errno
+		}
+		class, level := parseIOPriority(int(value))
+		return class, level, nil
+	}
+}
@@ -0,0 +1,84 @@
+//go:build !linux
+// +build !linux
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// getSchedLatencyFunc returns a function that attempts to get the average scheduling
+// delay for a given process.
+//
+// Scheduler statistics are only exposed through /proc/<pid>/schedstat on Linux,
+// so this function always returns an error on other platforms.
+//
+// Returns:
+//   - A function that returns (-1, error) when called
+func getSchedLatencyFunc() func(ctx context.Context, proc *process.Process) (float64, error) {
+	return func(ctx context.Context, proc *process.Process) (float64, error) {
+		return -1, errors.New("schedstat not supported on this platform")
+	}
+}
+
+// getThreadNamesFunc returns a function that attempts to get the names of the
+// threads of a given process.
+//
+// Thread names are only exposed through /proc/<pid>/task/<tid>/comm on Linux,
+// so this function always returns an error on other platforms.
+//
+// Returns:
+//   - A function that returns (nil, error) when called
+func getThreadNamesFunc() func(ctx context.Context, proc *process.Process) (map[int32]string, error) {
+	return func(ctx context.Context, proc *process.Process) (map[int32]string, error) {
+		return nil, errors.New("thread names not supported on this platform")
+	}
+}
+
+// getSchedPolicyFunc returns a function that attempts to get the CPU scheduling class of a
+// given process.
+//
+// Scheduling classes are only exposed through /proc/<pid>/stat on Linux,
+// so this function always returns an error on other platforms.
+//
+// Returns:
+//   - A function that returns ("", 0, error) when called
+func getSchedPolicyFunc() func(ctx context.Context, proc *process.Process) (string, int32, error) {
+	return func(ctx context.Context, proc *process.Process) (string, int32, error) {
+		return "", 0, errors.New("scheduling classes not supported on this platform")
+	}
+}
+
+// getIOPriorityFunc returns a function that attempts to get the I/O scheduling class of a
+// given process.
+//
+// I/O scheduling classes are only exposed through ioprio_get on Linux,
+// so this function always returns an error on other platforms.
+//
+// Returns:
+//   - A function that returns ("", 0, error) when called
+func getIOPriorityFunc() func(ctx context.Context, proc *process.Process) (string, int32, error) {
+	return func(ctx context.Context, proc *process.Process) (string, int32, error) {
+		return "", 0, errors.New("I/O priorities not supported on this platform")
+	}
+}
+
+// getNiceFunc returns a function that retrieves the nice value of a given process.
+//
+// Windows schedules processes by priority class rather than nice value, so this function
+// always returns an error on Windows.
+//
+// Returns:
+//   - A function that returns the nice value, from -20 (highest priority) to 19
+func getNiceFunc() func(ctx context.Context, proc *process.Process) (int32, error) {
+	return func(ctx context.Context, proc *process.Process) (int32, error) {
+		if runtime.GOOS == "windows" {
+			return 0, errors.New("nice values not supported on Windows")
+		}
+		return proc.NiceWithContext(ctx)
+	}
+}
@@ -1,11 +1,13 @@
-//go:build linux || darwin || freebsd
-// +build linux darwin freebsd
+//go:build unix
+// +build unix
 
 package metrics
 
 import (
+	"context"
 	"syscall"
 
+	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
@@ -21,3 +23,14 @@ func getPGIDFunc() func(proc *process.Process) (int, error) {
 		return syscall.Getpgid(int(proc.Pid))
 	}
 }
+
+// getThreadsFunc returns a function that retrieves the threads of a given process and their
+// CPU times through gopsutil.
+//
+// Returns:
+//   - A function that returns the CPU times of each thread keyed by thread ID
+func getThreadsFunc() func(ctx context.Context, proc *process.Process) (map[int32]*cpu.TimesStat, error) {
+	return func(ctx context.Context, proc *process.Process) (map[int32]*cpu.TimesStat, error) {
+		return proc.ThreadsWithContext(ctx)
+	}
+}
@@ -2,6 +2,8 @@ package metrics
 
 import (
 	"context"
+	"os/user"
+	"strings"
 	"testing"
 
 	"github.com/shirou/gopsutil/v4/process"
@@ -92,3 +94,73 @@ func TestProcessMetricsFunctions(t *testing.T) {
 		assert.NotNil(t, fn)
 	})
 }
+
+func TestParseSchedStat(t *testing.T) {
+	// Average delay is run_delay / timeslices
+	latency, err := parseSchedStat("1000000 500000 10\n")
+	assert.NoError(t, err)
+	assert.Equal(t, 50000.0, latency)
+
+	// A process that never ran has no delay
+	latency, err = parseSchedStat("0 0 0")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, latency)
+
+	// Malformed input is reported as an error
+	latency, err = parseSchedStat("garbage")
+	assert.Error(t, err)
+	assert.Equal(t, -1.0, latency)
+}
+
+func TestParseStat(t *testing.T) {
+	// The fields are counted from the end of the command, which may contain spaces and parentheses
+	stat := "812 (my (odd) cmd) S 1 812 812 0 -1 4194560 108 0 0 0 0 0 0 0 -2 0 1 0 892502 2703360 287 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 3 50 1 0 0 0 1 1 1 1 1 1 1 0\n"
+	policy, rtPriority, err := parseSchedPolicy(stat)
+	assert.NoError(t, err)
+	assert.Equal(t, "fifo", policy)
+	assert.Equal(t, int32(50), rtPriority)
+
+	policy, rtPriority, err = parseSchedPolicy(strings.Replace(stat, " 17 3 50 1 ", " 17 3 0 5 ", 1))
+	assert.NoError(t, err)
+	assert.Equal(t, "idle", policy)
+	assert.Equal(t, int32(0), rtPriority)
+
+	// Malformed input is reported as an error
+	_, _, err = parseSchedPolicy("812 (cmd) S 1")
+	assert.Error(t, err)
+
+	// The nice value is the 19th field, after the kernel priority
+	nice, err := parseNice(stat)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), nice)
+	nice, err = parseNice(strings.Replace(stat, " -2 0 1 ", " 30 10 1 ", 1))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10), nice)
+}
+
+func TestParseIOPriority(t *testing.T) {
+	class, level := parseIOPriority(0)
+	assert.Equal(t, "none", class)
+	assert.Equal(t, int32(0), level)
+
+	class, level = parseIOPriority(2<<13 | 4)
+	assert.Equal(t, "be", class)
+	assert.Equal(t, int32(4), level)
+
+	class, _ = parseIOPriority(3 << 13)
+	assert.Equal(t, "idle", class)
+}
+
+func TestLookupGroupName(t *testing.T) {
+	// Names are looked up once and cached, including unknown groups
+	expected, err := user.LookupGroupId("0")
+	name, ok := LookupGroupName(0)
+	if err == nil {
+		assert.True(t, ok)
+		assert.Equal(t, expected.Name, name)
+	}
+	_, ok = LookupGroupName(4294967294)
+	assert.False(t, ok)
+	_, cached := groupNames[4294967294]
+	assert.True(t, cached)
+}
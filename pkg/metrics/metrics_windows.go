@@ -4,11 +4,18 @@
 package metrics
 
 import (
+	"context"
 	"errors"
+	"unsafe"
 
+	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/sys/windows"
 )
 
+// procGetThreadTimes is GetThreadTimes from kernel32.dll, which golang.org/x/sys/windows does not wrap.
+var procGetThreadTimes = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetThreadTimes")
+
 // PGIDFunc is a function type that retrieves the process group ID for a given process.
 type PGIDFunc func(proc *process.Process) (int, error)
 
@@ -17,6 +24,7 @@ type PGIDFunc func(proc *process.Process) (int, error)
 //
 // Since Windows does not support process groups in the same way as Unix-like systems,
 // this function always returns an error indicating that the operation is not supported.
+// Process groups are emulated from the ancestry of the processes once all are collected.
 //
 // Returns:
 //   - PGIDFunc: A function that returns (0, error) when called on Windows
@@ -25,3 +33,76 @@ func getPGIDFunc() PGIDFunc {
 		return 0, errors.New("getpgid not supported on Windows")
 	}
 }
+
+// getThreadsFunc returns a function that lists the threads of a given process and their
+// CPU times on Windows systems, where gopsutil does not implement them.
+//
+// Threads are enumerated from a Toolhelp snapshot, which covers every thread of the system,
+// and the ones owned by the process are kept. The CPU times of a thread that cannot be
+// opened, e.g., one owned by a protected process, are left at zero.
+//
+// Returns:
+//   - A function that returns the CPU times of each thread keyed by thread ID
+func getThreadsFunc() func(ctx context.Context, proc *process.Process) (map[int32]*cpu.TimesStat, error) {
+	return func(ctx context.Context, proc *process.Process) (map[int32]*cpu.TimesStat, error) {
+		snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer windows.CloseHandle(snapshot)
+
+		threads := map[int32]*cpu.TimesStat{}
+		entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+		for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+			if int32(entry.OwnerProcessID) == proc.Pid {
+				threads[int32(entry.ThreadID)] = threadTimes(entry.ThreadID)
+			}
+		}
+		if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+			return nil, err
+		}
+		return threads, nil
+	}
+}
+
+// threadTimes returns the CPU times of a thread.
+//
+// Parameters:
+//   - tid: The thread ID
+//
+// Returns:
+//   - *cpu.TimesStat: The user and system times in seconds, or zero if the thread cannot be queried
+func threadTimes(tid uint32) *cpu.TimesStat {
+	times := &cpu.TimesStat{CPU: "all"}
+	handle, err := windows.OpenThread(windows.THREAD_QUERY_LIMITED_INFORMATION, false, tid)
+	if err != nil {
+		return times
+	}
+	defer windows.CloseHandle(handle)
+
+	var creation, exit, kernel, user windows.Filetime
+	result, _, _ := procGetThreadTimes.Call(
+		uintptr(handle),
+		uintptr(unsafe.Pointer(&creation)),
+		uintptr(unsafe.Pointer(&exit)),
+		uintptr(unsafe.Pointer(&kernel)),
+		uintptr(unsafe.Pointer(&user)),
+	)
+	if result == 0 {
+		return times
+	}
+	times.User = filetimeSeconds(user)
+	times.System = filetimeSeconds(kernel)
+	return times
+}
+
+// filetimeSeconds converts a FILETIME holding a duration in 100-nanosecond intervals to seconds.
+//
+// Parameters:
+//   - filetime: The duration
+//
+// Returns:
+//   - float64: The duration in seconds
+func filetimeSeconds(filetime windows.Filetime) float64 {
+	return float64(uint64(filetime.HighDateTime)<<32|uint64(filetime.LowDateTime)) / 1e7
+}
@@ -0,0 +1,96 @@
+package pstree
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// CPU AFFINITY AND NUMA PLACEMENT
+//------------------------------------------------------------------------------
+// Functions in this section read the CPUs each process may run on and the NUMA
+// nodes holding its memory, so that --show-affinity can point out pinned
+// services that are starved by their pinning or split across NUMA nodes.
+
+// parseCPUList parses a list of CPU or NUMA node ranges, e.g., 0-3,8,10-11, as used by
+// /proc/<pid>/status and /sys/devices/system/cpu/online.
+//
+// Parameters:
+//   - list: The list
+//
+// Returns:
+//   - []int: The numbers in ascending order, without duplicates
+//   - error: Error if a range is malformed
+func parseCPUList(list string) ([]int, error) {
+	cpus := []int{}
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return cpus, nil
+	}
+	for _, part := range strings.Split(list, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("malformed CPU list '%s'", list)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil || end < start {
+				return nil, fmt.Errorf("malformed CPU list '%s'", list)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	slices.Sort(cpus)
+	return slices.Compact(cpus), nil
+}
+
+// parseAllowedCPUs extracts the CPUs a process may run on from the contents of /proc/<pid>/status.
+//
+// Parameters:
+//   - data: Contents of /proc/<pid>/status
+//
+// Returns:
+//   - []int: The CPUs from the Cpus_allowed_list line
+//   - error: Error if the line is missing or malformed
+func parseAllowedCPUs(data string) ([]int, error) {
+	for _, line := range strings.Split(data, "\n") {
+		if value, found := strings.CutPrefix(line, "Cpus_allowed_list:"); found {
+			return parseCPUList(value)
+		}
+	}
+	return nil, errors.New("no Cpus_allowed_list line found")
+}
+
+// parseNUMAMaps extracts the NUMA nodes holding pages of a process from the contents of
+// /proc/<pid>/numa_maps, where each mapping lists its pages per node, e.g., N0=120 N1=4.
+//
+// Parameters:
+//   - data: Contents of /proc/<pid>/numa_maps
+//
+// Returns:
+//   - The nodes in ascending order
+func parseNUMAMaps(data string) []int {
+	nodes := []int{}
+	for _, field := range strings.Fields(data) {
+		key, pages, found := strings.Cut(field, "=")
+		node, isNode := strings.CutPrefix(key, "N")
+		if !found || !isNode {
+			continue
+		}
+		number, err := strconv.Atoi(node)
+		if err != nil {
+			continue
+		}
+		if count, err := strconv.Atoi(pages); err == nil && count > 0 {
+			nodes = append(nodes, number)
+		}
+	}
+	slices.Sort(nodes)
+	return slices.Compact(nodes)
+}
@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// OnlineCPUs returns the number of online CPUs, as listed in /sys/devices/system/cpu/online,
+// rather than the CPUs pstree itself may run on.
+//
+// Returns:
+//   - The number of online CPUs
+func OnlineCPUs() int {
+	data, err := os.ReadFile("/sys/devices/system/cpu/online")
+	if err != nil {
+		return runtime.NumCPU()
+	}
+	cpus, err := parseCPUList(string(data))
+	if err != nil || len(cpus) == 0 {
+		return runtime.NumCPU()
+	}
+	return len(cpus)
+}
+
+// AnnotateAffinity reads the CPUs each process may run on from /proc/<pid>/status, and the
+// NUMA nodes holding its memory from /proc/<pid>/numa_maps. Reading the memory placement of
+// other users' processes requires root; it is left unknown when it cannot be read.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always nil on Linux
+func AnnotateAffinity(processes *[]tree.Process) error {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", proc.PID)); err == nil {
+			if cpus, err := parseAllowedCPUs(string(data)); err == nil {
+				proc.CPUAffinity = cpus
+			}
+		}
+		// Kernels without NUMA support have no numa_maps
+		if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/numa_maps", proc.PID)); err == nil {
+			if nodes := parseNUMAMaps(string(data)); len(nodes) > 0 {
+				proc.NUMANodes = nodes
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"errors"
+	"runtime"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// OnlineCPUs returns the number of CPUs pstree may run on.
+//
+// Returns:
+//   - The number of CPUs
+func OnlineCPUs() int {
+	return runtime.NumCPU()
+}
+
+// AnnotateAffinity reads the CPUs each process may run on and the NUMA nodes holding its memory.
+//
+// CPU affinity and NUMA placement are only inspected on Linux, so this always returns an
+// error on other platforms.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always an error explaining that affinity is not supported
+func AnnotateAffinity(processes *[]tree.Process) error {
+	return errors.New("--show-affinity is only supported on Linux")
+}
@@ -0,0 +1,174 @@
+package pstree
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// ANOMALY ACCOUNTING
+//------------------------------------------------------------------------------
+// Functions in this section detect collected data that cannot be trusted, e.g.,
+// negative PGIDs or parents that were never collected, so that it is reported
+// instead of being rendered silently, and so that --strict can fail automation.
+
+// Kinds of anomalies found by DetectAnomalies.
+const (
+	AnomalyDuplicatePID   = "duplicate-pid"   // Two processes were collected with the same PID
+	AnomalyMissingParent  = "missing-parent"  // The parent of a process was not collected
+	AnomalyNegativePGID   = "negative-pgid"   // The process group of a process could not be read
+	AnomalyNegativePPID   = "negative-ppid"   // The parent of a process could not be read
+	AnomalyParentCycle    = "parent-cycle"    // A process is its own ancestor
+	AnomalyUnknownCommand = "unknown-command" // The command of a process could not be read
+)
+
+// anomalySummaryMax is the number of anomalies listed individually in the summary.
+const anomalySummaryMax = 10
+
+// Anomaly is a problem found in the collected data of a process.
+type Anomaly struct {
+	Kind   string // Kind of anomaly, e.g., missing-parent
+	PID    int32  // PID of the affected process
+	Detail string // Human-readable description of the anomaly
+}
+
+// DetectAnomalies checks the collected processes for data that cannot be trusted.
+//
+// Windows has no process groups and does not re-parent orphans, so negative PGIDs and
+// missing parents are expected there and are not reported.
+//
+// Parameters:
+//   - processes: The collected processes
+//   - goos: Operating system the processes were collected on, e.g., linux
+//
+// Returns:
+//   - []Anomaly: The anomalies found, ordered by PID and then by kind
+func DetectAnomalies(processes []tree.Process, goos string) []Anomaly {
+	anomalies := []Anomaly{}
+	parents := make(map[int32]int32, len(processes))
+	for _, proc := range processes {
+		if _, exists := parents[proc.PID]; exists {
+			anomalies = append(anomalies, Anomaly{Kind: AnomalyDuplicatePID, PID: proc.PID, Detail: fmt.Sprintf("PID %d was collected more than once", proc.PID)})
+			continue
+		}
+		parents[proc.PID] = proc.PPID
+	}
+
+	for _, proc := range processes {
+		switch {
+		case proc.PPID < 0:
+			anomalies = append(anomalies, Anomaly{Kind: AnomalyNegativePPID, PID: proc.PID, Detail: fmt.Sprintf("PID %d has PPID %d", proc.PID, proc.PPID)})
+		case goos != "windows" && proc.PPID > 0 && proc.PPID != proc.PID:
+			if _, exists := parents[proc.PPID]; !exists {
+				anomalies = append(anomalies, Anomaly{Kind: AnomalyMissingParent, PID: proc.PID, Detail: fmt.Sprintf("PID %d has PPID %d, which was not collected", proc.PID, proc.PPID)})
+			}
+		}
+		if goos != "windows" && proc.PGID < 0 {
+			anomalies = append(anomalies, Anomaly{Kind: AnomalyNegativePGID, PID: proc.PID, Detail: fmt.Sprintf("PID %d has PGID %d", proc.PID, proc.PGID)})
+		}
+		if proc.Command == "?" || proc.Command == "" {
+			anomalies = append(anomalies, Anomaly{Kind: AnomalyUnknownCommand, PID: proc.PID, Detail: fmt.Sprintf("the command of PID %d could not be read", proc.PID)})
+		}
+	}
+
+	for _, pid := range findParentCycles(parents) {
+		anomalies = append(anomalies, Anomaly{Kind: AnomalyParentCycle, PID: pid, Detail: fmt.Sprintf("PID %d is its own ancestor", pid)})
+	}
+
+	slices.SortStableFunc(anomalies, func(a, b Anomaly) int {
+		if a.PID != b.PID {
+			return int(a.PID) - int(b.PID)
+		}
+		return strings.Compare(a.Kind, b.Kind)
+	})
+	return anomalies
+}
+
+// findParentCycles returns the processes that are their own ancestor.
+//
+// A process that is its own parent is a root, not a cycle, matching BuildTree.
+//
+// Parameters:
+//   - parents: The PPID of each collected PID
+//
+// Returns:
+//   - []int32: The PIDs on a cycle
+func findParentCycles(parents map[int32]int32) []int32 {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int32]int, len(parents))
+	cycles := []int32{}
+	for start := range parents {
+		path := []int32{}
+		pid := start
+		for {
+			if state[pid] == done {
+				break
+			}
+			if state[pid] == visiting {
+				// The path from the first occurrence of pid onward is the cycle
+				cycles = append(cycles, path[slices.Index(path, pid):]...)
+				break
+			}
+			state[pid] = visiting
+			path = append(path, pid)
+			ppid, exists := parents[pid]
+			if !exists || ppid == pid {
+				break
+			}
+			if _, collected := parents[ppid]; !collected {
+				break
+			}
+			pid = ppid
+		}
+		for _, visited := range path {
+			state[visited] = done
+		}
+	}
+	return cycles
+}
+
+// WriteAnomalySummary writes a summary of the anomalies, counting them by kind and
+// listing the first few individually.
+//
+// Parameters:
+//   - output: Writer to write the summary to, usually stderr
+//   - anomalies: The anomalies found by DetectAnomalies
+func WriteAnomalySummary(output io.Writer, anomalies []Anomaly) {
+	if len(anomalies) == 0 {
+		return
+	}
+	counts := map[string]int{}
+	for _, anomaly := range anomalies {
+		counts[anomaly.Kind]++
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+	}
+
+	noun := "anomalies"
+	if len(anomalies) == 1 {
+		noun = "anomaly"
+	}
+	fmt.Fprintf(output, "pstree: %d %s in the collected data: %s\n", len(anomalies), noun, strings.Join(parts, ", "))
+	for i, anomaly := range anomalies {
+		if i == anomalySummaryMax {
+			fmt.Fprintf(output, "  ... and %d more\n", len(anomalies)-anomalySummaryMax)
+			break
+		}
+		fmt.Fprintf(output, "  %s: %s\n", anomaly.Kind, anomaly.Detail)
+	}
+}
@@ -0,0 +1,300 @@
+package pstree
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// ANONYMIZATION
+//------------------------------------------------------------------------------
+// Functions in this section replace usernames, hostnames, and path components
+// with pseudonyms before any output, so that trees can be pasted into public
+// issues. Each name is always replaced by the same pseudonym, so that the
+// structure of the tree, e.g., which processes share an owner, is preserved.
+
+// anonymousSystemUIDs is the first UID of regular users; accounts below it, e.g., root or
+// www-data, are the same on every system and are kept.
+const anonymousSystemUIDs = 1000
+
+// wellKnownAccounts are accounts without a UID that are kept, e.g., on Windows.
+var wellKnownAccounts = map[string]bool{
+	"LOCAL SERVICE":   true,
+	"NETWORK SERVICE": true,
+	"SYSTEM":          true,
+	"root":            true,
+}
+
+// keptPathComponents are directories found on every system, kept so that paths remain
+// recognizable, e.g., /usr/sbin/nginx or /home/user-1a2b3c/app-4d5e6f.
+var keptPathComponents = map[string]bool{
+	"Applications": true, "Contents": true, "Frameworks": true, "Library": true, "MacOS": true,
+	"Program Files": true, "Program Files (x86)": true, "ProgramData": true, "System": true,
+	"System32": true, "Users": true, "Volumes": true, "Windows": true,
+	"bin": true, "boot": true, "cache": true, "dev": true, "etc": true, "home": true,
+	"lib": true, "lib32": true, "lib64": true, "libexec": true, "local": true, "log": true,
+	"media": true, "mnt": true, "opt": true, "proc": true, "root": true, "run": true,
+	"sbin": true, "share": true, "spool": true, "srv": true, "sys": true, "tmp": true,
+	"usr": true, "var": true,
+}
+
+var (
+	// urlPattern matches URLs, capturing the scheme, the user, the host, the port, and the path
+	urlPattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*://)(?:([^@/\s]*)@)?(\[[0-9a-fA-F:.]*\]|[^/:\s]*)(:\d+)?(/.*)?$`)
+	// userHostPattern matches user@host, e.g., ssh alice@db1 or scp alice@db1:/backup
+	userHostPattern = regexp.MustCompile(`^([\w.-]+)@([\w.-]+)(:.*)?$`)
+	// hostnamePattern matches fully qualified hostnames with a common top-level domain, which
+	// file names, e.g., config.yaml, do not have
+	hostnamePattern = regexp.MustCompile(`(?i)^(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+(?:app|cloud|com|corp|dev|home|internal|intra|io|lan|local|localdomain|net|org|svc)$`)
+	// pathSeparatorPattern splits paths on either separator
+	pathSeparatorPattern = regexp.MustCompile(`[/\\]`)
+	// extensionPattern matches the extension of a file name, kept in its pseudonym, e.g., .py
+	extensionPattern = regexp.MustCompile(`\.[A-Za-z0-9]{1,5}$`)
+)
+
+// Anonymizer replaces names with pseudonyms derived from a key chosen when it is created,
+// so that pseudonyms are consistent within one invocation, e.g., across --watch samples,
+// but cannot be reversed by hashing candidate names.
+type Anonymizer struct {
+	hosts map[string]bool // Hostnames to replace wherever they appear, e.g., the local hostname
+	key   []byte          // Key of the pseudonyms
+	users map[string]bool // Usernames to replace wherever they appear, e.g., in /home/<user>
+}
+
+// NewAnonymizer creates an Anonymizer with a random key.
+//
+// Parameters:
+//   - hostnames: Hostnames to replace wherever they appear, e.g., the local hostname
+//
+// Returns:
+//   - *Anonymizer: The anonymizer
+func NewAnonymizer(hostnames ...string) *Anonymizer {
+	key := make([]byte, 32)
+	// crypto/rand never fails on supported platforms
+	_, _ = rand.Read(key)
+	anonymizer := &Anonymizer{hosts: map[string]bool{}, key: key, users: map[string]bool{}}
+	for _, hostname := range hostnames {
+		if hostname != "" {
+			anonymizer.hosts[strings.ToLower(hostname)] = true
+			// The short name of a fully qualified hostname, e.g., db1 for db1.example.com
+			short, _, _ := strings.Cut(hostname, ".")
+			anonymizer.hosts[strings.ToLower(short)] = true
+		}
+	}
+	return anonymizer
+}
+
+// pseudonym returns the pseudonym of a name, e.g., user-1a2b3c.
+//
+// Parameters:
+//   - kind: Kind of the name, used as the prefix of the pseudonym, e.g., user, host, or path
+//   - name: The name to replace
+//
+// Returns:
+//   - The pseudonym
+func (anonymizer *Anonymizer) pseudonym(kind string, name string) string {
+	mac := hmac.New(sha256.New, anonymizer.key)
+	mac.Write([]byte(kind + ":" + name))
+	return kind + "-" + hex.EncodeToString(mac.Sum(nil))[:6]
+}
+
+// User returns the pseudonym of a username, or the username itself for system accounts.
+//
+// Parameters:
+//   - username: The username, e.g., alice or CORP\alice
+//   - uids: The UIDs of the process, if known
+//
+// Returns:
+//   - The pseudonym of the username
+func (anonymizer *Anonymizer) User(username string, uids []uint32) string {
+	if username == "" || wellKnownAccounts[username] || (len(uids) > 0 && uids[0] < anonymousSystemUIDs) {
+		return username
+	}
+	anonymizer.users[username] = true
+	return anonymizer.pseudonym("user", username)
+}
+
+// Host returns the pseudonym of a hostname or IP address, or the address itself for
+// loopback and unspecified addresses.
+//
+// Parameters:
+//   - host: The hostname or IP address
+//
+// Returns:
+//   - The pseudonym of the host
+func (anonymizer *Anonymizer) Host(host string) string {
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil && (ip.IsLoopback() || ip.IsUnspecified()) {
+		return host
+	}
+	if host == "" || strings.EqualFold(host, "localhost") {
+		return host
+	}
+	return anonymizer.pseudonym("host", strings.ToLower(host))
+}
+
+// word returns the pseudonym of a word that is a username, a hostname, or an IP address, or
+// the word itself.
+//
+// Parameters:
+//   - word: The word, e.g., a path component or an argument
+//
+// Returns:
+//   - The pseudonym of the word, or the word itself
+func (anonymizer *Anonymizer) word(word string) string {
+	switch {
+	case anonymizer.users[word]:
+		return anonymizer.pseudonym("user", word)
+	case anonymizer.hosts[strings.ToLower(word)] || hostnamePattern.MatchString(word) || net.ParseIP(word) != nil:
+		return anonymizer.Host(word)
+	}
+	return word
+}
+
+// Path returns a path with its components replaced by pseudonyms, except for the directories
+// found on every system. The name of a file in such a directory is kept too, so that system
+// executables remain recognizable, e.g., /usr/sbin/nginx, while /home/alice/app.py becomes
+// /home/user-1a2b3c/path-4d5e6f.py.
+//
+// Parameters:
+//   - path: The path, with / or \ separators
+//
+// Returns:
+//   - The anonymized path
+func (anonymizer *Anonymizer) Path(path string) string {
+	separators := pathSeparatorPattern.FindAllString(path, -1)
+	components := pathSeparatorPattern.Split(path, -1)
+	// Whether every directory so far is found on every system, and at least one was
+	kept, system := true, false
+	var builder strings.Builder
+	for i, component := range components {
+		last := i == len(components)-1
+		switch {
+		case keptPathComponents[component] && !last:
+			system = true
+		case component == "" || keptPathComponents[component] || (i == 0 && strings.HasSuffix(component, ":")):
+			// Directories found on every system and Windows drives are kept
+		case strings.Trim(component, ".~") == "":
+			// Relative paths are kept, but the directory they start from is unknown
+			kept = false
+		case anonymizer.word(component) != component:
+			component = anonymizer.word(component)
+			kept = false
+		case last && kept && system:
+			// Files in system directories, e.g., /usr/sbin/nginx
+		default:
+			extension := extensionPattern.FindString(component)
+			component = anonymizer.pseudonym("path", strings.TrimSuffix(component, extension)) + extension
+			kept = false
+		}
+		builder.WriteString(component)
+		if !last {
+			builder.WriteString(separators[i])
+		}
+	}
+	return builder.String()
+}
+
+// String returns a command line argument or an environment variable with its usernames,
+// hostnames, and path components replaced by pseudonyms, e.g., --config=/home/alice/app.yaml,
+// https://alice@git.example.com/repo, or alice@db1:/backup.
+//
+// Parameters:
+//   - value: The argument or variable
+//
+// Returns:
+//   - The anonymized value
+func (anonymizer *Anonymizer) String(value string) string {
+	// The value of an option or variable, e.g., --config=<value> or HOME=<value>
+	if key, rest, found := strings.Cut(value, "="); found && !strings.ContainsAny(key, `/\:`) {
+		return key + "=" + anonymizer.String(rest)
+	}
+	if match := urlPattern.FindStringSubmatch(value); match != nil {
+		scheme, user, host, port, path := match[1], match[2], match[3], match[4], match[5]
+		if user != "" {
+			// Passwords were already masked by redaction
+			name, secret, hasSecret := strings.Cut(user, ":")
+			user = anonymizer.pseudonym("user", name)
+			if hasSecret {
+				user += ":" + secret
+			}
+			user += "@"
+		}
+		if path != "" {
+			path = anonymizer.Path(path)
+		}
+		return scheme + user + anonymizer.Host(host) + port + path
+	}
+	if match := userHostPattern.FindStringSubmatch(value); match != nil {
+		path := match[3]
+		if path != "" {
+			path = ":" + anonymizer.Path(path[1:])
+		}
+		return anonymizer.pseudonym("user", match[1]) + "@" + anonymizer.Host(match[2]) + path
+	}
+	if strings.ContainsAny(value, `/\`) {
+		return anonymizer.Path(value)
+	}
+	return anonymizer.word(value)
+}
+
+// AnonymizeProcesses replaces the owners, groups, commands, arguments, environment variables,
+// and open files of every process and thread with pseudonyms.
+//
+// Owners are replaced first, so that their names are replaced wherever else they appear,
+// e.g., in /home/<user>.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to anonymize
+//   - anonymizer: The anonymizer to apply
+func AnonymizeProcesses(processes *[]tree.Process, anonymizer *Anonymizer) {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		proc.Username = anonymizer.User(proc.Username, proc.UIDs)
+	}
+	for i := range *processes {
+		proc := &(*processes)[i]
+		proc.Command = anonymizer.String(proc.Command)
+		args := make([]string, len(proc.Args))
+		for a, arg := range proc.Args {
+			args[a] = anonymizer.String(arg)
+		}
+		proc.Args = args
+		if len(proc.Groups) > 0 {
+			// Groups are often named after their only member, e.g., alice
+			groups := make(map[uint32]string, len(proc.Groups))
+			for gid, name := range proc.Groups {
+				groups[gid] = anonymizer.word(name)
+			}
+			proc.Groups = groups
+		}
+		proc.Group = anonymizer.word(proc.Group)
+		if proc.ProcessDetails != nil {
+			// Details may be shared with other copies of the process, e.g., a cached sample
+			details := *proc.ProcessDetails
+			details.Environment = make([]string, len(proc.Environment))
+			for e, variable := range proc.Environment {
+				details.Environment[e] = anonymizer.String(variable)
+			}
+			details.OpenFiles = append(details.OpenFiles[:0:0], proc.OpenFiles...)
+			for f := range details.OpenFiles {
+				details.OpenFiles[f].Path = anonymizer.Path(details.OpenFiles[f].Path)
+			}
+			proc.ProcessDetails = &details
+		}
+		// Threads are named after the base name of the command of their process
+		components := pathSeparatorPattern.Split(proc.Command, -1)
+		proc.Threads = slices.Clone(proc.Threads)
+		for t := range proc.Threads {
+			proc.Threads[t].Args = proc.Args
+			proc.Threads[t].Command = components[len(components)-1]
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package pstree
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// PROCESS TREE ASSERTIONS
+//------------------------------------------------------------------------------
+// Functions in this section check that the processes matching a filter are
+// present in the expected number, so that integration tests and CI health checks
+// can verify the process hierarchy of the services they spawn, either from Go
+// with Assert or from a shell with pstree assert.
+
+// TestingT is the part of *testing.T used by Assert, so that assertions can also be
+// reported through other test frameworks.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// ProcessFilter selects the processes counted by an assertion. A process matches when
+// it matches every field that is set.
+type ProcessFilter struct {
+	Args     string // Text contained in the command line, e.g., --port 8080
+	Command  string // Text contained in the command, e.g., nginx
+	PID      int32  // PID of the process
+	PPID     int32  // PID of the parent process
+	Under    string // Text contained in the command of an ancestor, e.g., supervisord
+	Username string // Owner of the process
+}
+
+// processFilterKeys are the keys of the filter terms accepted by ParseProcessFilter.
+var processFilterKeys = []string{"args", "command", "pid", "ppid", "under", "user"}
+
+// ParseProcessFilter parses filter terms of the form <key>=<value>, e.g., command=nginx.
+//
+// Parameters:
+//   - terms: The filter terms; valid keys are args, command, pid, ppid, under, and user
+//
+// Returns:
+//   - ProcessFilter: The filter matching every term
+//   - error: Error if a term has an unknown key, an invalid PID, or is given twice
+func ParseProcessFilter(terms []string) (ProcessFilter, error) {
+	var filter ProcessFilter
+	seen := map[string]bool{}
+	for _, term := range terms {
+		key, value, found := strings.Cut(term, "=")
+		if !found || value == "" {
+			return ProcessFilter{}, fmt.Errorf("filter '%s' must be <key>=<value>", term)
+		}
+		if !slices.Contains(processFilterKeys, key) {
+			return ProcessFilter{}, fmt.Errorf("unknown filter key '%s'; valid keys are: %s", key, strings.Join(processFilterKeys, ", "))
+		}
+		if seen[key] {
+			return ProcessFilter{}, fmt.Errorf("filter key '%s' is given more than once", key)
+		}
+		seen[key] = true
+		switch key {
+		case "args":
+			filter.Args = value
+		case "command":
+			filter.Command = value
+		case "pid", "ppid":
+			pid, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || pid < 0 {
+				return ProcessFilter{}, fmt.Errorf("invalid PID '%s'", value)
+			}
+			if key == "pid" {
+				filter.PID = int32(pid)
+			} else {
+				filter.PPID = int32(pid)
+			}
+		case "under":
+			filter.Under = value
+		case "user":
+			filter.Username = value
+		}
+	}
+	return filter, nil
+}
+
+// String returns the filter in the form accepted by ParseProcessFilter, e.g.,
+// command=nginx, user=www-data.
+//
+// Returns:
+//   - The filter terms, or "all processes" if no field is set
+func (filter ProcessFilter) String() string {
+	terms := []string{}
+	if filter.Args != "" {
+		terms = append(terms, "args="+filter.Args)
+	}
+	if filter.Command != "" {
+		terms = append(terms, "command="+filter.Command)
+	}
+	if filter.PID != 0 {
+		terms = append(terms, fmt.Sprintf("pid=%d", filter.PID))
+	}
+	if filter.PPID != 0 {
+		terms = append(terms, fmt.Sprintf("ppid=%d", filter.PPID))
+	}
+	if filter.Under != "" {
+		terms = append(terms, "under="+filter.Under)
+	}
+	if filter.Username != "" {
+		terms = append(terms, "user="+filter.Username)
+	}
+	if len(terms) == 0 {
+		return "all processes"
+	}
+	return strings.Join(terms, ", ")
+}
+
+// matches returns true if a process matches the filter.
+//
+// Parameters:
+//   - processTree: The tree the process belongs to, used to find its ancestors
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the process matches every field that is set
+func (filter ProcessFilter) matches(processTree *tree.ProcessTree, pidIndex int) bool {
+	process := &processTree.Nodes[pidIndex]
+	if filter.Args != "" && !strings.Contains(strings.Join(process.Args, " "), filter.Args) {
+		return false
+	}
+	if filter.Command != "" && !strings.Contains(process.Command, filter.Command) {
+		return false
+	}
+	if (filter.PID != 0 && process.PID != filter.PID) || (filter.PPID != 0 && process.PPID != filter.PPID) {
+		return false
+	}
+	if filter.Username != "" && process.Username != filter.Username {
+		return false
+	}
+	if filter.Under != "" {
+		// The depth is bounded in case PID reuse left a cycle in the collected parents
+		for parent, depth := process.Parent, 0; parent != -1 && depth < len(processTree.Nodes); parent, depth = processTree.Nodes[parent].Parent, depth+1 {
+			if strings.Contains(processTree.Nodes[parent].Command, filter.Under) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Comparison operators of an Expectation.
+const (
+	ExpectEqual     = "=="
+	ExpectNotEqual  = "!="
+	ExpectAtLeast   = ">="
+	ExpectAtMost    = "<="
+	ExpectMoreThan  = ">"
+	ExpectFewerThan = "<"
+)
+
+// Expectation is the number of matching processes an assertion expects, e.g., at least one.
+type Expectation struct {
+	Operator string // One of ExpectEqual, ExpectNotEqual, ExpectAtLeast, ExpectAtMost, ExpectMoreThan, or ExpectFewerThan
+	Count    int    // The number of processes compared against
+}
+
+// Exactly expects exactly count matching processes; Exactly(0) expects none.
+//
+// Parameters:
+//   - count: The number of processes
+//
+// Returns:
+//   - The expectation
+func Exactly(count int) Expectation {
+	return Expectation{Operator: ExpectEqual, Count: count}
+}
+
+// AtLeast expects count or more matching processes.
+//
+// Parameters:
+//   - count: The minimum number of processes
+//
+// Returns:
+//   - The expectation
+func AtLeast(count int) Expectation {
+	return Expectation{Operator: ExpectAtLeast, Count: count}
+}
+
+// AtMost expects count or fewer matching processes.
+//
+// Parameters:
+//   - count: The maximum number of processes
+//
+// Returns:
+//   - The expectation
+func AtMost(count int) Expectation {
+	return Expectation{Operator: ExpectAtMost, Count: count}
+}
+
+// ParseExpectation parses an expected count, e.g., >=1, ==3, <5, or 0. A count without
+// an operator must be matched exactly.
+//
+// Parameters:
+//   - spec: The operator, one of ==, !=, >=, <=, >, or <, followed by a count
+//
+// Returns:
+//   - Expectation: The parsed expectation
+//   - error: Error if the operator or count is invalid
+func ParseExpectation(spec string) (Expectation, error) {
+	value := strings.TrimSpace(spec)
+	operator := ExpectEqual
+	// Two-character operators are tried first, so that >= is not read as >
+	for _, candidate := range []string{ExpectEqual, ExpectNotEqual, ExpectAtLeast, ExpectAtMost, ExpectMoreThan, ExpectFewerThan, "="} {
+		if rest, found := strings.CutPrefix(value, candidate); found {
+			if candidate != "=" {
+				operator = candidate
+			}
+			value = strings.TrimSpace(rest)
+			break
+		}
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return Expectation{}, fmt.Errorf("invalid count '%s'; expected an operator, one of ==, !=, >=, <=, >, or <, followed by a number, e.g., >=1", spec)
+	}
+	return Expectation{Operator: operator, Count: count}, nil
+}
+
+// Met returns true if a number of matching processes meets the expectation.
+//
+// Parameters:
+//   - count: The number of matching processes
+//
+// Returns:
+//   - true if the expectation is met
+func (expectation Expectation) Met(count int) bool {
+	switch expectation.Operator {
+	case ExpectNotEqual:
+		return count != expectation.Count
+	case ExpectAtLeast:
+		return count >= expectation.Count
+	case ExpectAtMost:
+		return count <= expectation.Count
+	case ExpectMoreThan:
+		return count > expectation.Count
+	case ExpectFewerThan:
+		return count < expectation.Count
+	}
+	return count == expectation.Count
+}
+
+// String returns the expectation in the form accepted by ParseExpectation, e.g., >=1.
+//
+// Returns:
+//   - The operator followed by the count
+func (expectation Expectation) String() string {
+	operator := expectation.Operator
+	if operator == "" {
+		operator = ExpectEqual
+	}
+	return fmt.Sprintf("%s%d", operator, expectation.Count)
+}
+
+// AssertionResult is the outcome of checking an assertion against a set of processes.
+type AssertionResult struct {
+	Expectation Expectation    // The expected number of matching processes
+	Filter      ProcessFilter  // The filter the processes were matched against
+	Matched     []tree.Process // The matching processes, in PID order
+	Subtree     string         // The matching processes and their ancestors, rendered as a text tree
+}
+
+// Passed returns true if the number of matching processes met the expectation.
+//
+// Returns:
+//   - true if the assertion passed
+func (result AssertionResult) Passed() bool {
+	return result.Expectation.Met(len(result.Matched))
+}
+
+// String describes the result, followed by the rendered subtree of the matching processes.
+//
+// Returns:
+//   - A description of the result, e.g., "expected >=1 processes matching command=nginx, found 0"
+func (result AssertionResult) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "expected %s processes matching %s, found %d", result.Expectation, result.Filter, len(result.Matched))
+	if len(result.Matched) > 0 {
+		builder.WriteString("\n\n")
+		builder.WriteString(result.Subtree)
+	}
+	return builder.String()
+}
+
+// CheckAssertion counts the processes matching a filter and renders them, with their
+// ancestors, as a text tree.
+//
+// The process calling CheckAssertion is never counted, so that pstree assert does not
+// match its own command line.
+//
+// Parameters:
+//   - processes: The processes to check
+//   - filter: The filter selecting the processes to count
+//   - expectation: The expected number of matching processes
+//
+// Returns:
+//   - The result of the assertion
+func CheckAssertion(processes []tree.Process, filter ProcessFilter, expectation Expectation) AssertionResult {
+	result := AssertionResult{Expectation: expectation, Filter: filter, Matched: []tree.Process{}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	processTree := tree.NewProcessTree(0, logger, processes, tree.DisplayOptions{})
+	myPid := int32(os.Getpid())
+	selected := []int32{}
+	for pidIndex := range processTree.Nodes {
+		if processTree.Nodes[pidIndex].PID != myPid && filter.matches(processTree, pidIndex) {
+			result.Matched = append(result.Matched, processes[pidIndex])
+			selected = append(selected, processTree.Nodes[pidIndex].PID)
+		}
+	}
+	slices.SortFunc(result.Matched, func(a, b tree.Process) int { return cmp.Compare(a.PID, b.PID) })
+	if len(selected) == 0 {
+		return result
+	}
+
+	options := tree.DisplayOptions{MaxDepth: 999, SelectedPIDs: selected, ShowArguments: true, ShowOwner: true, ShowPIDs: true, WideDisplay: true}
+	processTree = tree.NewProcessTree(0, logger, processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	var subtree strings.Builder
+	renderer := &tree.TextRenderer{}
+	if err := renderer.Render(&subtree, processTree); err == nil {
+		result.Subtree = subtree.String()
+	}
+	return result
+}
+
+// Assert collects the running processes and reports a test failure, with the rendered
+// subtree of the matching processes, unless the number matching the filter meets the
+// expectation. For example, a test starting nginx can check for its workers with:
+//
+//	pstree.Assert(t, pstree.ProcessFilter{Command: "nginx", Under: "nginx", Username: "www-data"}, pstree.AtLeast(1))
+//
+// Parameters:
+//   - t: The test, e.g., *testing.T
+//   - filter: The filter selecting the processes to count
+//   - expectation: The expected number of matching processes
+//
+// Returns:
+//   - true if the assertion passed
+func Assert(t TestingT, filter ProcessFilter, expectation Expectation) bool {
+	t.Helper()
+	processes := []tree.Process{}
+	GetProcessesWith(&processes, CollectOptions{})
+	result := CheckAssertion(processes, filter, expectation)
+	if !result.Passed() {
+		t.Errorf("pstree assertion failed: %s", result)
+	}
+	return result.Passed()
+}
@@ -0,0 +1,115 @@
+package pstree
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// BATCH QUERIES
+//------------------------------------------------------------------------------
+// Functions in this section load the queries of `pstree batch`, which renders
+// several views of a single collection pass, e.g., JSON for monitoring and text
+// for humans from one cron job.
+
+// BatchQuery is one view rendered by `pstree batch`.
+type BatchQuery struct {
+	Line        int      // Line of the batch file the query was read from
+	Destination string   // File the view is written to, or - for stdout
+	Args        []string // pstree options selecting the filters and output format
+}
+
+// LoadBatch reads a batch file and returns the queries it contains.
+//
+// Parameters:
+//   - path: Path of the batch file
+//
+// Returns:
+//   - []BatchQuery: The parsed queries, in file order
+//   - error: Error if the file could not be read or contains a malformed query
+func LoadBatch(path string) ([]BatchQuery, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	return parseBatch(string(data))
+}
+
+// parseBatch parses the contents of a batch file.
+//
+// Each non-empty line that does not start with '#' names a destination followed by
+// the pstree options of the view written to it:
+//
+//	/var/lib/pstree/tree.json   --output json
+//	-                           --user www-data --contains 'php-fpm: pool'
+//
+// Options are split on whitespace; single or double quotes group words containing spaces.
+//
+// Parameters:
+//   - data: Contents of the batch file
+//
+// Returns:
+//   - []BatchQuery: The parsed queries, in file order
+//   - error: Error if a line has unbalanced quotes
+func parseBatch(data string) ([]BatchQuery, error) {
+	queries := []BatchQuery{}
+	for lineNumber, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields, err := splitQuoted(line)
+		if err != nil {
+			return nil, fmt.Errorf("batch file line %d: %w", lineNumber+1, err)
+		}
+		queries = append(queries, BatchQuery{Line: lineNumber + 1, Destination: fields[0], Args: fields[1:]})
+	}
+	return queries, nil
+}
+
+// splitQuoted splits a line into words on whitespace, keeping quoted text together.
+//
+// Parameters:
+//   - line: The line to split
+//
+// Returns:
+//   - []string: The words, with their quotes removed
+//   - error: Error if a quote is not closed
+func splitQuoted(line string) ([]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		inWord  bool
+		quote   rune
+	)
+	for _, char := range line {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+		case char == '\'' || char == '"':
+			quote = char
+			inWord = true
+		case char == ' ' || char == '\t':
+			if inWord {
+				fields = append(fields, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(char)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed %c quote", quote)
+	}
+	if inWord {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
@@ -0,0 +1,174 @@
+package pstree
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// BENCHMARKS
+//------------------------------------------------------------------------------
+// Functions in this section time the phases of rendering a tree for pstree bench,
+// so that performance issues can be reported with actionable data and regressions
+// can be tracked across releases.
+
+const (
+	BenchCollection   = "collection"   // Reading the processes from the system, a snapshot, or a remote host
+	BenchAnnotation   = "annotation"   // Enriching, sorting, and preparing the processes for the tree
+	BenchConstruction = "construction" // Building the tree, marking the processes to display, and pruning the others
+	BenchRendering    = "rendering"    // Writing the tree in the selected output format
+	BenchTotal        = "total"        // All phases of an iteration
+)
+
+// BenchPhases are the phases timed by pstree bench, in the order they run.
+var BenchPhases = []string{BenchCollection, BenchAnnotation, BenchConstruction, BenchRendering}
+
+// Benchmark records how long each phase took in each iteration of pstree bench.
+type Benchmark struct {
+	Processes int                        // Number of processes collected in the latest iteration
+	Timings   map[string][]time.Duration // Duration of each iteration, keyed by phase
+}
+
+// PhaseStats summarizes the durations of a phase across iterations.
+type PhaseStats struct {
+	Phase  string        // Name of the phase, e.g., collection, or total
+	Min    time.Duration // Fastest iteration
+	Median time.Duration // Median iteration
+	Mean   time.Duration // Average iteration
+	Max    time.Duration // Slowest iteration
+}
+
+// NewBenchmark creates a benchmark that has timed no iteration yet.
+//
+// Returns:
+//   - *Benchmark: The benchmark
+func NewBenchmark() *Benchmark {
+	return &Benchmark{Timings: map[string][]time.Duration{}}
+}
+
+// Add records the duration of a phase in the current iteration.
+//
+// Parameters:
+//   - phase: The phase, e.g., BenchCollection
+//   - duration: How long the phase took
+func (benchmark *Benchmark) Add(phase string, duration time.Duration) {
+	benchmark.Timings[phase] = append(benchmark.Timings[phase], duration)
+}
+
+// Record records the time elapsed since a phase started. A nil benchmark records nothing,
+// so that the phases can be timed unconditionally when pstree bench is not running.
+//
+// Parameters:
+//   - phase: The phase, e.g., BenchCollection
+//   - started: When the phase started
+func (benchmark *Benchmark) Record(phase string, started time.Time) {
+	if benchmark == nil {
+		return
+	}
+	benchmark.Add(phase, time.Since(started))
+}
+
+// Stats summarizes each phase that was timed, followed by the total of the iterations.
+//
+// Returns:
+//   - []PhaseStats: The statistics of each phase, in the order of BenchPhases, then the total
+func (benchmark *Benchmark) Stats() []PhaseStats {
+	stats := []PhaseStats{}
+	totals := []time.Duration{}
+	for _, phase := range BenchPhases {
+		durations := benchmark.Timings[phase]
+		if len(durations) == 0 {
+			continue
+		}
+		stats = append(stats, phaseStats(phase, durations))
+		for iteration, duration := range durations {
+			if iteration == len(totals) {
+				totals = append(totals, 0)
+			}
+			totals[iteration] += duration
+		}
+	}
+	if len(totals) > 0 {
+		stats = append(stats, phaseStats(BenchTotal, totals))
+	}
+	return stats
+}
+
+// phaseStats computes the minimum, median, mean, and maximum of the durations of a phase.
+//
+// Parameters:
+//   - phase: The name of the phase
+//   - durations: The duration of each iteration; it must not be empty
+//
+// Returns:
+//   - PhaseStats: The statistics of the phase
+func phaseStats(phase string, durations []time.Duration) PhaseStats {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, duration := range sorted {
+		sum += duration
+	}
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+	return PhaseStats{
+		Phase:  phase,
+		Min:    sorted[0],
+		Median: median,
+		Mean:   sum / time.Duration(len(sorted)),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// WriteBenchmark writes a table of the statistics of each phase, under a line describing
+// what was measured, e.g.:
+//
+//	pstree 0.8.2, linux/amd64, go1.23.4, 8 CPUs: 5 iterations of 412 processes
+//
+//	Phase                Min      Median        Mean         Max
+//	collection       120.3ms     125.1ms       126ms     131.9ms
+//
+// Parameters:
+//   - output: Writer to write the table to
+//   - benchmark: The benchmark
+//   - environment: Description of the build and host, e.g., "pstree 0.8.2, linux/amd64, go1.23.4, 8 CPUs"
+//
+// Returns:
+//   - error: Error if the table could not be written
+func WriteBenchmark(output io.Writer, benchmark *Benchmark, environment string) error {
+	stats := benchmark.Stats()
+	iterations := 0
+	if len(stats) > 0 {
+		iterations = len(benchmark.Timings[stats[0].Phase])
+	}
+	if _, err := fmt.Fprintf(output, "%s: %d iterations of %d processes\n\n", environment, iterations, benchmark.Processes); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(output, "%-12s %11s %11s %11s %11s\n", "Phase", "Min", "Median", "Mean", "Max"); err != nil {
+		return err
+	}
+	for _, phase := range stats {
+		if _, err := fmt.Fprintf(output, "%-12s %11s %11s %11s %11s\n", phase.Phase, benchDuration(phase.Min), benchDuration(phase.Median), benchDuration(phase.Mean), benchDuration(phase.Max)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchDuration formats a duration with a precision suited to the phases, e.g., 125.1ms or 84µs.
+//
+// Parameters:
+//   - duration: The duration
+//
+// Returns:
+//   - string: The duration rounded to a tenth of a millisecond, or to a microsecond below a millisecond
+func benchDuration(duration time.Duration) string {
+	if duration < time.Millisecond {
+		return duration.Round(time.Microsecond).String()
+	}
+	return duration.Round(100 * time.Microsecond).String()
+}
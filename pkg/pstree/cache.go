@@ -0,0 +1,176 @@
+package pstree
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// PROCESS CACHE
+//------------------------------------------------------------------------------
+// Functions in this section keep the facts that never change during the life of
+// a process on disk between invocations, so that running pstree repeatedly on a
+// server with thousands of processes only reads them for new processes.
+
+// ProcessCacheVersion is the version of the cache file format.
+const ProcessCacheVersion = 1
+
+// ProcessCacheMaxAge is how long a saved cache is used. Owners can change after a process
+// starts, e.g., when a daemon drops privileges, so the cache is only trusted for repeated
+// invocations in a short window.
+const ProcessCacheMaxAge = 5 * time.Minute
+
+// CachedProcess holds the facts cached for a process.
+type CachedProcess struct {
+	Args     []string `json:"args"`     // Command line arguments
+	Command  string   `json:"command"`  // Executable path
+	UIDs     []uint32 `json:"uids"`     // User IDs
+	Username string   `json:"username"` // Name of the owner
+}
+
+// ProcessCache caches the facts of processes by PID and creation time, so that a
+// process reusing the PID of an exited one is never mistaken for it. It is safe for
+// concurrent use by the workers collecting processes.
+type ProcessCache struct {
+	mutex   sync.Mutex
+	path    string
+	entries map[string]CachedProcess // Entries loaded from the file or the previous collection
+	live    map[string]CachedProcess // Entries of the processes seen by the current collection
+}
+
+// processCacheFile is the contents of the cache file.
+type processCacheFile struct {
+	Version int                      `json:"version"`
+	Saved   time.Time                `json:"saved"`
+	Entries map[string]CachedProcess `json:"entries"`
+}
+
+// ProcessCachePath returns the path of the cache file, in the user cache directory,
+// e.g., $XDG_CACHE_HOME/pstree/processes.json or ~/.cache/pstree/processes.json on Linux.
+//
+// Returns:
+//   - string: The path of the cache file
+//   - error: Error if the user cache directory cannot be determined
+func ProcessCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pstree", "processes.json"), nil
+}
+
+// LoadProcessCache loads the cache file. A missing, malformed, or expired cache file
+// is not an error; the cache starts empty and is filled by the next collection.
+//
+// Parameters:
+//   - path: Path of the cache file
+//
+// Returns:
+//   - *ProcessCache: The cache
+func LoadProcessCache(path string) *ProcessCache {
+	cache := &ProcessCache{path: path, entries: map[string]CachedProcess{}, live: map[string]CachedProcess{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	var file processCacheFile
+	if json.Unmarshal(data, &file) != nil || file.Version != ProcessCacheVersion || time.Since(file.Saved) > ProcessCacheMaxAge || file.Entries == nil {
+		return cache
+	}
+	cache.entries = file.Entries
+	return cache
+}
+
+// processCacheKey returns the key of a process in the cache.
+//
+// Parameters:
+//   - pid: PID of the process
+//   - createTime: Creation time of the process
+//
+// Returns:
+//   - The key, e.g., 1234@1717171717
+func processCacheKey(pid int32, createTime int64) string {
+	return fmt.Sprintf("%d@%d", pid, createTime)
+}
+
+// Lookup returns the cached facts of a process. A nil cache never has an entry.
+//
+// Parameters:
+//   - pid: PID of the process
+//   - createTime: Creation time of the process, or 0 if unknown
+//
+// Returns:
+//   - CachedProcess: The cached facts
+//   - bool: true if the process was cached
+func (cache *ProcessCache) Lookup(pid int32, createTime int64) (CachedProcess, bool) {
+	if cache == nil || createTime == 0 {
+		return CachedProcess{}, false
+	}
+	key := processCacheKey(pid, createTime)
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, exists := cache.entries[key]
+	if exists {
+		cache.live[key] = entry
+	}
+	return entry, exists
+}
+
+// Store caches the facts of a process. Storing in a nil cache does nothing.
+//
+// Parameters:
+//   - pid: PID of the process
+//   - createTime: Creation time of the process; processes without one are not cached
+//   - entry: The facts to cache
+func (cache *ProcessCache) Store(pid int32, createTime int64, entry CachedProcess) {
+	if cache == nil || createTime == 0 {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.live[processCacheKey(pid, createTime)] = entry
+}
+
+// Save writes the processes seen by the last collection to the cache file, dropping
+// those that exited, and keeps them for the next collection, e.g., with --watch.
+//
+// The file is only readable by its owner, since arguments can contain secrets.
+//
+// Returns:
+//   - error: Error if the cache file could not be written
+func (cache *ProcessCache) Save() error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries, cache.live = cache.live, map[string]CachedProcess{}
+
+	data, err := json.Marshal(processCacheFile{Version: ProcessCacheVersion, Saved: time.Now().UTC(), Entries: cache.entries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cache.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	// The file is replaced atomically, so that concurrent invocations never read a partial file
+	temp, err := os.CreateTemp(filepath.Dir(cache.path), ".processes-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(temp.Name(), cache.path); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package pstree
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gdanko/pstree/pkg/terminal"
+)
+
+//------------------------------------------------------------------------------
+// CAPABILITY PROBING
+//------------------------------------------------------------------------------
+// Functions in this section probe the host at runtime for the platform features
+// some options depend on, e.g., /proc or the control group hierarchy, instead of
+// assuming them from the platform the binary was built for. A single binary then
+// behaves predictably across distributions and minimal containers, and options
+// needing a missing feature fail with a clear error.
+
+const (
+	// CapabilityProcfs is the /proc filesystem, needed for most Linux-only details
+	CapabilityProcfs = "procfs"
+	// CapabilityThreads is the list of the threads of a process and their names
+	CapabilityThreads = "threads"
+	// CapabilityCgroups is the control group hierarchy, v1, v2, or both (hybrid)
+	CapabilityCgroups = "cgroups"
+	// CapabilitySystemd is a running systemd, queried with systemctl
+	CapabilitySystemd = "systemd"
+	// CapabilityNamespaces is the namespaces of a process, read from /proc/<pid>/ns
+	CapabilityNamespaces = "namespaces"
+	// CapabilitySchedstat is the scheduler statistics of a process, read from /proc/<pid>/schedstat
+	CapabilitySchedstat = "schedstat"
+	// CapabilityLocks is the table of file locks in /proc/locks
+	CapabilityLocks = "locks"
+	// CapabilityTerminfo is the terminfo entry of the terminal, read to detect its number of colors
+	CapabilityTerminfo = "terminfo"
+	// CapabilitySSH is the ssh command, used to collect the processes of remote hosts
+	CapabilitySSH = "ssh"
+)
+
+// Capability is a platform feature whose availability was probed on this host.
+type Capability struct {
+	// Name of the capability, e.g., cgroups
+	Name string `json:"name" yaml:"name"`
+	// Whether the capability is available
+	Supported bool `json:"supported" yaml:"supported"`
+	// What was found, e.g., "v2 (unified)", or why the capability is unavailable
+	Detail string `json:"detail" yaml:"detail"`
+	// Options that need the capability, without their leading dashes
+	Flags []string `json:"flags" yaml:"flags"`
+}
+
+// capabilityProbe probes a single capability.
+type capabilityProbe struct {
+	name  string
+	flags []string
+	probe func(prober *capabilityProber) (bool, string)
+}
+
+// capabilityProber probes the capabilities of a host whose filesystem is mounted at root,
+// using getenv to read its environment and lookPath to find commands, so that probes can be
+// tested against a fake host.
+type capabilityProber struct {
+	getenv   func(key string) string
+	lookPath func(file string) (string, error)
+	root     string
+}
+
+// capabilityProbes lists the capabilities in the order they are reported.
+var capabilityProbes = []capabilityProbe{
+	{CapabilityProcfs, []string{"show-affinity"}, (*capabilityProber).probeProcfs},
+	{CapabilityThreads, []string{"show-threads", "thread-contains"}, (*capabilityProber).probeThreads},
+	{CapabilityCgroups, []string{"collapse-containers", "group-by-cgroup", "show-cgroup", "show-container", "show-unit", "unit"}, (*capabilityProber).probeCgroups},
+	{CapabilitySystemd, []string{"show-deps"}, (*capabilityProber).probeSystemd},
+	{CapabilityNamespaces, []string{"as-seen-by", "ns", "port-conflicts", "show-ns"}, (*capabilityProber).probeNamespaces},
+	{CapabilitySchedstat, []string{"show-latency"}, (*capabilityProber).probeSchedstat},
+	{CapabilityLocks, []string{"who-locks"}, (*capabilityProber).probeLocks},
+	{CapabilityTerminfo, []string{}, (*capabilityProber).probeTerminfo},
+	{CapabilitySSH, []string{"remote"}, (*capabilityProber).probeSSH},
+}
+
+// ProbeCapabilities probes this host for the platform features some options depend on.
+//
+// Returns:
+//   - []Capability: Every capability, in a fixed order, with whether it is available
+func ProbeCapabilities() []Capability {
+	return (&capabilityProber{getenv: os.Getenv, lookPath: exec.LookPath, root: "/"}).probeAll()
+}
+
+// probeAll runs every probe.
+//
+// Returns:
+//   - []Capability: Every capability, in the order of capabilityProbes
+func (prober *capabilityProber) probeAll() []Capability {
+	capabilities := make([]Capability, 0, len(capabilityProbes))
+	for _, probe := range capabilityProbes {
+		supported, detail := probe.probe(prober)
+		capabilities = append(capabilities, Capability{Name: probe.name, Supported: supported, Detail: detail, Flags: probe.flags})
+	}
+	return capabilities
+}
+
+// path returns the path of a file of the probed host.
+func (prober *capabilityProber) path(name string) string {
+	return filepath.Join(prober.root, name)
+}
+
+// exists returns true if a file of the probed host exists.
+func (prober *capabilityProber) exists(name string) bool {
+	_, err := os.Stat(prober.path(name))
+	return err == nil
+}
+
+// probeProcfs checks that the details of this process can be read from /proc.
+func (prober *capabilityProber) probeProcfs() (bool, string) {
+	if !prober.exists("/proc/self/stat") {
+		return false, "/proc is not mounted"
+	}
+	return true, "/proc is mounted"
+}
+
+// probeThreads checks that the threads of this process can be listed.
+func (prober *capabilityProber) probeThreads() (bool, string) {
+	if !prober.exists("/proc/self/task") {
+		return false, "/proc/<pid>/task is not available"
+	}
+	return true, "threads are listed from /proc/<pid>/task"
+}
+
+// probeCgroups detects the version of the control group hierarchy from the control
+// groups of this process: v2 has a single line starting with "0::", and v1 has a
+// line for each hierarchy of controllers.
+func (prober *capabilityProber) probeCgroups() (bool, string) {
+	data, err := os.ReadFile(prober.path("/proc/self/cgroup"))
+	if err != nil {
+		return false, "/proc/self/cgroup is not available"
+	}
+	var v1, v2 bool
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			v2 = true
+		} else if line != "" {
+			v1 = true
+		}
+	}
+	switch {
+	case v1 && v2:
+		return true, "v1 and v2 (hybrid)"
+	case v2:
+		return true, "v2 (unified)"
+	case v1:
+		return true, "v1"
+	}
+	return false, "this process is in no control group"
+}
+
+// probeSystemd checks that systemd is running and that systemctl can query it.
+func (prober *capabilityProber) probeSystemd() (bool, string) {
+	if !prober.exists("/run/systemd/system") {
+		return false, "systemd is not running"
+	}
+	systemctl, err := prober.lookPath("systemctl")
+	if err != nil {
+		return false, "systemctl was not found in PATH"
+	}
+	return true, fmt.Sprintf("systemd is running, queried with %s", systemctl)
+}
+
+// probeNamespaces checks that the namespaces of this process can be read.
+func (prober *capabilityProber) probeNamespaces() (bool, string) {
+	if !prober.exists("/proc/self/ns") {
+		return false, "/proc/<pid>/ns is not available"
+	}
+	return true, "namespaces are read from /proc/<pid>/ns"
+}
+
+// probeSchedstat checks that the kernel keeps scheduler statistics.
+func (prober *capabilityProber) probeSchedstat() (bool, string) {
+	if !prober.exists("/proc/self/schedstat") {
+		return false, "the kernel was built without CONFIG_SCHED_INFO"
+	}
+	return true, "scheduler statistics are read from /proc/<pid>/schedstat"
+}
+
+// probeLocks checks that the table of file locks can be read.
+func (prober *capabilityProber) probeLocks() (bool, string) {
+	if !prober.exists("/proc/locks") {
+		return false, "/proc/locks is not available"
+	}
+	return true, "locks are read from /proc/locks"
+}
+
+// probeTerminfo checks that the terminfo entry of the terminal can be read. Without it,
+// the number of colors is guessed from the name of the terminal.
+func (prober *capabilityProber) probeTerminfo() (bool, string) {
+	term := prober.getenv("TERM")
+	if term == "" {
+		return false, "TERM is not set; colors are disabled"
+	}
+	path := terminal.FindTerminfo(prober.root, term, prober.getenv)
+	if path == "" {
+		return false, fmt.Sprintf("no terminfo entry for %s; colors are guessed from its name", term)
+	}
+	return true, fmt.Sprintf("colors are read from %s", strings.TrimPrefix(path, strings.TrimSuffix(prober.root, "/")))
+}
+
+// probeSSH checks that ssh is available to collect the processes of remote hosts.
+func (prober *capabilityProber) probeSSH() (bool, string) {
+	ssh, err := prober.lookPath("ssh")
+	if err != nil {
+		return false, "ssh was not found in PATH"
+	}
+	return true, fmt.Sprintf("remote hosts are reached with %s", ssh)
+}
+
+// MissingCapability returns the first capability needed by one of the given options that
+// is not available on this host.
+//
+// Parameters:
+//   - capabilities: The probed capabilities
+//   - changed: Returns true if an option, without its leading dashes, was set
+//
+// Returns:
+//   - string: The option needing a missing capability, or an empty string
+//   - Capability: The missing capability
+func MissingCapability(capabilities []Capability, changed func(flag string) bool) (string, Capability) {
+	for _, capability := range capabilities {
+		if capability.Supported {
+			continue
+		}
+		for _, flag := range capability.Flags {
+			if changed(flag) {
+				return flag, capability
+			}
+		}
+	}
+	return "", Capability{}
+}
+
+// WriteCapabilities writes the capabilities as a table, with the options needing each one.
+//
+// Parameters:
+//   - output: Writer to write the table to
+//   - capabilities: The probed capabilities
+//   - header: Whether to write the column names
+//
+// Returns:
+//   - error: Error if the table could not be written
+func WriteCapabilities(output io.Writer, capabilities []Capability, header bool) error {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	if header {
+		fmt.Fprintln(writer, "CAPABILITY\tSUPPORTED\tDETAIL\tOPTIONS")
+	}
+	for _, capability := range capabilities {
+		supported := "no"
+		if capability.Supported {
+			supported = "yes"
+		}
+		flags := make([]string, len(capability.Flags))
+		for i, flag := range capability.Flags {
+			flags[i] = "--" + flag
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", capability.Name, supported, capability.Detail, strings.Join(flags, ", "))
+	}
+	return writer.Flush()
+}
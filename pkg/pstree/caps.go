@@ -0,0 +1,31 @@
+package pstree
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// PROCESS CAPABILITIES
+//------------------------------------------------------------------------------
+// Functions in this section read the effective Linux capabilities of each
+// process, so that --show-caps can point out processes holding dangerous ones,
+// e.g., cap_sys_admin, without running as an ordinary root.
+
+// parseCapEff extracts the effective capability mask from the contents of /proc/<pid>/status.
+//
+// Parameters:
+//   - data: Contents of /proc/<pid>/status
+//
+// Returns:
+//   - uint64: The mask, with bit n set for capability n
+//   - error: Error if the CapEff line is missing or malformed
+func parseCapEff(data string) (uint64, error) {
+	for _, line := range strings.Split(data, "\n") {
+		if value, found := strings.CutPrefix(line, "CapEff:"); found {
+			return strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		}
+	}
+	return 0, errors.New("no CapEff line found")
+}
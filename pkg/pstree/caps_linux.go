@@ -0,0 +1,50 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// KernelCapabilities returns the number of capabilities known to the running kernel, from
+// the highest one listed in /proc/sys/kernel/cap_last_cap.
+//
+// Returns:
+//   - The number of capabilities, or 0 if it cannot be read
+func KernelCapabilities() int {
+	data, err := os.ReadFile("/proc/sys/kernel/cap_last_cap")
+	if err != nil {
+		return 0
+	}
+	last, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return last + 1
+}
+
+// AnnotateCapabilities reads the effective capabilities of each process from the CapEff
+// line of /proc/<pid>/status. They are left empty when they cannot be read.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always nil on Linux
+func AnnotateCapabilities(processes *[]tree.Process) error {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", proc.PID)); err == nil {
+			if mask, err := parseCapEff(string(data)); err == nil {
+				proc.CapEff = mask
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"errors"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// KernelCapabilities returns the number of capabilities known to the running kernel.
+//
+// Returns:
+//   - Always 0, as capabilities are Linux-only
+func KernelCapabilities() int {
+	return 0
+}
+
+// AnnotateCapabilities reads the effective capabilities of each process.
+//
+// Capabilities are only inspected on Linux, so this always returns an error on other
+// platforms.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always an error explaining that capabilities are not supported
+func AnnotateCapabilities(processes *[]tree.Process) error {
+	return errors.New("--show-caps is only supported on Linux")
+}
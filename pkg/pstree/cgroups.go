@@ -0,0 +1,82 @@
+package pstree
+
+import (
+	"path"
+	"sort"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// CONTROL GROUP HIERARCHY
+//------------------------------------------------------------------------------
+// Functions in this section re-root the process tree at the control group
+// hierarchy for --group-by-cgroup, so that the processes of each service,
+// session, or container are listed under the control group that contains them.
+
+// processCgroup returns the absolute control group path of a process, or the root control
+// group if it could not be collected, e.g., on platforms without control groups.
+func processCgroup(proc *tree.Process) string {
+	return path.Join("/", proc.Cgroup)
+}
+
+// GroupByCgroup inserts a node for every control group containing a process, and its
+// parent control groups up to the root, and moves each process under its control group.
+//
+// Control group nodes are given negative PIDs, which are never shown, and the root
+// control group comes first, so that the tree is rendered from it. A process stays
+// under its parent process when both are in the same control group, so that the process
+// hierarchy within each service is kept.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs, with their control groups collected
+func GroupByCgroup(processes *[]tree.Process) {
+	pidToCgroup := make(map[int32]string, len(*processes))
+	paths := map[string]bool{}
+	for i := range *processes {
+		cgroup := processCgroup(&(*processes)[i])
+		pidToCgroup[(*processes)[i].PID] = cgroup
+		for dir := cgroup; !paths[dir]; dir = path.Dir(dir) {
+			paths[dir] = true
+		}
+	}
+	// Parents sort before their children, starting with the root control group
+	sorted := make([]string, 0, len(paths))
+	for cgroup := range paths {
+		sorted = append(sorted, cgroup)
+	}
+	sort.Strings(sorted)
+
+	cgroupPIDs := make(map[string]int32, len(sorted))
+	nodes := make([]tree.Process, 0, len(sorted)+len(*processes))
+	for i, cgroup := range sorted {
+		pid := int32(-1 - i)
+		cgroupPIDs[cgroup] = pid
+		ppid := pid
+		if cgroup != "/" {
+			ppid = cgroupPIDs[path.Dir(cgroup)]
+		}
+		nodes = append(nodes, tree.Process{
+			Cgroup:      cgroup,
+			CgroupNode:  true,
+			Child:       -1,
+			Command:     cgroup,
+			Parent:      -1,
+			PGID:        -1,
+			PID:         pid,
+			PPID:        ppid,
+			Sister:      -1,
+			Unavailable: ^tree.Field(0),
+			Unit:        unitFromCgroup(cgroup),
+		})
+	}
+
+	for i := range *processes {
+		proc := &(*processes)[i]
+		cgroup := pidToCgroup[proc.PID]
+		if parentCgroup, exists := pidToCgroup[proc.PPID]; !exists || parentCgroup != cgroup || proc.PPID == proc.PID {
+			proc.PPID = cgroupPIDs[cgroup]
+		}
+	}
+	*processes = append(nodes, *processes...)
+}
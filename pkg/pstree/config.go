@@ -0,0 +1,175 @@
+package pstree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+// CONFIGURATION FILE
+//------------------------------------------------------------------------------
+// Functions in this section load the configuration file, which sets the default
+// options of pstree, the thresholds of --color-attr, and aliases naming common
+// combinations of filters, so that they do not have to be typed every time.
+
+// Config holds the contents of the configuration file.
+type Config struct {
+	Options    map[string]any            `yaml:"options"`    // Default values of pstree options, keyed by their long name
+	Thresholds map[string]tree.Threshold `yaml:"thresholds"` // Thresholds of --color-attr, keyed by attribute
+	Aliases    map[string]string         `yaml:"aliases"`    // pstree options selected by --alias, keyed by alias name
+}
+
+// ConfigPath returns the path of the configuration file, in the user configuration directory,
+// e.g., $XDG_CONFIG_HOME/pstree/config.yaml or ~/.config/pstree/config.yaml on Linux.
+//
+// Returns:
+//   - string: The path of the configuration file
+//   - error: Error if the user configuration directory cannot be determined
+func ConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pstree", "config.yaml"), nil
+}
+
+// LoadConfig reads a configuration file and returns its contents.
+//
+// Parameters:
+//   - path: Path of the configuration file
+//
+// Returns:
+//   - *Config: The parsed configuration
+//   - error: Error if the file could not be read or is malformed
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	config, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// parseConfig parses the contents of a configuration file:
+//
+//	options:
+//	  color-scheme: deuteranopia
+//	  show-pids: true
+//	  exclude-user: [nobody]
+//	thresholds:
+//	  cpu: {medium: 25, high: 75}
+//	aliases:
+//	  web: --user www-data --contains 'php-fpm: pool'
+//
+// Options are checked against the flags of pstree when they are applied; see Config.OptionArgs.
+//
+// Parameters:
+//   - data: Contents of the configuration file
+//
+// Returns:
+//   - *Config: The parsed configuration
+//   - error: Error if the file is not valid YAML, has unknown keys, or has an invalid threshold or alias
+func parseConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	attributes := []string{}
+	for attribute := range tree.DefaultThresholds {
+		attributes = append(attributes, attribute)
+	}
+	slices.Sort(attributes)
+	for attribute, threshold := range config.Thresholds {
+		if !slices.Contains(attributes, attribute) {
+			return nil, fmt.Errorf("valid thresholds are: %s", strings.Join(attributes, ", "))
+		}
+		if threshold.Medium < 0 || threshold.High < threshold.Medium {
+			return nil, fmt.Errorf("threshold '%s' must have 0 <= medium <= high", attribute)
+		}
+	}
+	for name, options := range config.Aliases {
+		if _, err := config.AliasArgs(name); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(options) == "" {
+			return nil, fmt.Errorf("alias '%s' has no options", name)
+		}
+	}
+	return config, nil
+}
+
+// OptionArgs returns the options of the configuration file as command-line arguments,
+// e.g., --show-pids=true, sorted by option name. A list is given as one argument per element.
+//
+// Returns:
+//   - []string: The arguments
+//   - error: Error if an option has no value or a value that is neither a scalar nor a list
+func (config *Config) OptionArgs() ([]string, error) {
+	names := make([]string, 0, len(config.Options))
+	for name := range config.Options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	args := []string{}
+	for _, name := range names {
+		values, ok := config.Options[name].([]any)
+		if !ok {
+			values = []any{config.Options[name]}
+		}
+		for _, value := range values {
+			switch value.(type) {
+			case nil, []any, map[string]any:
+				return nil, fmt.Errorf("option '%s' must be a string, number, boolean, or list of them", name)
+			}
+			args = append(args, fmt.Sprintf("--%s=%v", name, value))
+		}
+	}
+	return args, nil
+}
+
+// AliasArgs returns the options selected by an alias as command-line arguments.
+//
+// Options are split on whitespace; single or double quotes group words containing spaces.
+//
+// Parameters:
+//   - name: The name of the alias
+//
+// Returns:
+//   - []string: The arguments
+//   - error: Error if the alias is not defined or has unbalanced quotes
+func (config *Config) AliasArgs(name string) ([]string, error) {
+	options, ok := config.Aliases[name]
+	if !ok {
+		names := make([]string, 0, len(config.Aliases))
+		for alias := range config.Aliases {
+			names = append(names, alias)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown alias '%s'; the configuration file defines no aliases", name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown alias '%s'; defined aliases are: %s", name, strings.Join(names, ", "))
+	}
+	// Long aliases can be folded over several lines
+	args, err := splitQuoted(strings.ReplaceAll(options, "\n", " "))
+	if err != nil {
+		return nil, fmt.Errorf("alias '%s': %w", name, err)
+	}
+	return args, nil
+}
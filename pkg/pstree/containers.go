@@ -0,0 +1,148 @@
+package pstree
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// CONTAINER DETECTION
+//------------------------------------------------------------------------------
+// Functions in this section recognize the processes running in Docker,
+// containerd, CRI-O, and Podman containers from their control groups or
+// environment, so that --show-container can label them with their container.
+
+// containerCgroup matches the container ID in the control group path of a containerized
+// process, e.g., /system.slice/docker-<id>.scope, /docker/<id>, /machine.slice/libpod-<id>.scope,
+// or /kubepods.slice/...:cri-containerd:<id>.
+var containerCgroup = regexp.MustCompile(`(?:^|[/:])(docker|libpod|cri-containerd|crio)[-/:]([0-9a-f]{64})`)
+
+// containerRuntimes maps the prefixes of container control groups to their runtime.
+var containerRuntimes = map[string]string{
+	"cri-containerd": "containerd",
+	"crio":           "cri-o",
+	"docker":         "docker",
+	"libpod":         "podman",
+}
+
+// Where Docker and Podman store the configuration of their containers.
+const (
+	dockerRoot        = "/var/lib/docker"
+	podmanStorageRoot = "/var/lib/containers/storage"
+)
+
+// ContainerFromCgroup returns the container runtime and ID of a process from its control group.
+//
+// With nested containers, the innermost container is returned.
+//
+// Parameters:
+//   - cgroup: The control group path
+//
+// Returns:
+//   - string: The runtime, e.g., docker, or empty if the process is not in a container
+//   - string: The 64-character container ID
+func ContainerFromCgroup(cgroup string) (string, string) {
+	matches := containerCgroup.FindAllStringSubmatch(cgroup, -1)
+	if len(matches) == 0 {
+		return "", ""
+	}
+	match := matches[len(matches)-1]
+	return containerRuntimes[match[1]], match[2]
+}
+
+// dockerContainerName reads the name of a Docker container from its configuration.
+//
+// Parameters:
+//   - root: The Docker data directory, e.g., /var/lib/docker
+//   - id: The container ID
+//
+// Returns:
+//   - The container name, or an empty string if it could not be read
+func dockerContainerName(root string, id string) string {
+	data, err := os.ReadFile(filepath.Join(root, "containers", id, "config.v2.json"))
+	if err != nil {
+		return ""
+	}
+	var config struct {
+		Name string `json:"Name"`
+	}
+	if json.Unmarshal(data, &config) != nil {
+		return ""
+	}
+	return strings.TrimPrefix(config.Name, "/")
+}
+
+// podmanContainerNames reads the names of the Podman containers from the container store.
+//
+// Parameters:
+//   - root: The container storage directory, e.g., /var/lib/containers/storage
+//
+// Returns:
+//   - A map of container IDs to names, empty if the store could not be read
+func podmanContainerNames(root string) map[string]string {
+	names := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(root, "overlay-containers", "containers.json"))
+	if err != nil {
+		return names
+	}
+	var containers []struct {
+		ID    string   `json:"id"`
+		Names []string `json:"names"`
+	}
+	if json.Unmarshal(data, &containers) != nil {
+		return names
+	}
+	for _, container := range containers {
+		if len(container.Names) > 0 {
+			names[container.ID] = container.Names[0]
+		}
+	}
+	return names
+}
+
+// AnnotateContainers records the container runtime, ID, and name of each containerized process.
+//
+// Containers are recognized by their control group, which requires control groups to be
+// collected. Processes whose control group is unknown, e.g., in a nested container, are
+// recognized by the container variable that Podman and systemd-based images set, without an ID.
+// Names are read from the Docker and Podman configuration, which usually requires root;
+// otherwise only the ID is recorded.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+func AnnotateContainers(processes *[]tree.Process) {
+	dockerNames := map[string]string{}
+	var podmanNames map[string]string
+	for i := range *processes {
+		proc := &(*processes)[i]
+		runtime, id := ContainerFromCgroup(proc.Cgroup)
+		if runtime == "" {
+			for _, variable := range proc.EnvironmentVariables() {
+				if value, found := strings.CutPrefix(variable, "container="); found && value != "" {
+					proc.ContainerRuntime = value
+					break
+				}
+			}
+			continue
+		}
+		proc.ContainerRuntime = runtime
+		proc.ContainerID = id
+		switch runtime {
+		case "docker":
+			if _, cached := dockerNames[id]; !cached {
+				dockerNames[id] = dockerContainerName(dockerRoot, id)
+			}
+			proc.ContainerName = dockerNames[id]
+		case "podman":
+			if podmanNames == nil {
+				podmanNames = podmanContainerNames(podmanStorageRoot)
+			}
+			proc.ContainerName = podmanNames[id]
+		}
+	}
+}
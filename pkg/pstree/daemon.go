@@ -0,0 +1,131 @@
+package pstree
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// DAEMON DETACHMENT STATUS
+//------------------------------------------------------------------------------
+// Functions in this section determine the session and controlling terminal of
+// each process, to verify that services detach properly from their terminal.
+
+// sessionInfo is the session and controlling terminal of a process.
+type sessionInfo struct {
+	SID int32  // Session ID
+	TTY string // Controlling terminal, e.g., pts/0, or empty if there is none
+}
+
+// parseProcStat extracts the session ID and terminal device number from /proc/<pid>/stat.
+//
+// The command name is enclosed in parentheses and may itself contain spaces or
+// parentheses, so fields are counted from the last closing parenthesis.
+//
+// Parameters:
+//   - data: Contents of /proc/<pid>/stat
+//
+// Returns:
+//   - int32: The session ID
+//   - uint64: The controlling terminal device number, or 0 if there is none
+//   - error: Error if the contents could not be parsed
+func parseProcStat(data string) (int32, uint64, error) {
+	end := strings.LastIndex(data, ")")
+	if end < 0 {
+		return 0, 0, errors.New("malformed stat: missing command name")
+	}
+	// state ppid pgrp session tty_nr ...
+	fields := strings.Fields(data[end+1:])
+	if len(fields) < 5 {
+		return 0, 0, errors.New("malformed stat: too few fields")
+	}
+	sid, err := strconv.ParseInt(fields[3], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed stat session: %w", err)
+	}
+	ttyNr, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed stat tty_nr: %w", err)
+	}
+	return int32(sid), uint64(ttyNr), nil
+}
+
+// ttyName converts a Linux terminal device number to its name.
+//
+// Parameters:
+//   - ttyNr: The device number from /proc/<pid>/stat
+//
+// Returns:
+//   - The terminal name, e.g., pts/3 or tty1, or empty if ttyNr is 0
+func ttyName(ttyNr uint64) string {
+	if ttyNr == 0 {
+		return ""
+	}
+	// The minor number is split across bits 0-7 and 20-31
+	major := (ttyNr >> 8) & 0xfff
+	minor := (ttyNr & 0xff) | ((ttyNr >> 12) & 0xfff00)
+	switch {
+	case major >= 136 && major <= 143:
+		return fmt.Sprintf("pts/%d", (major-136)*256+minor)
+	case major == 4 && minor < 64:
+		return fmt.Sprintf("tty%d", minor)
+	case major == 4:
+		return fmt.Sprintf("ttyS%d", minor-64)
+	default:
+		return fmt.Sprintf("tty(%d,%d)", major, minor)
+	}
+}
+
+// classifyDaemon returns the daemon status of a process.
+//
+// Parameters:
+//   - proc: The process, with SID and TTY set
+//
+// Returns:
+//   - "daemon" for a session leader without a controlling terminal whose parent is init,
+//     "attached" for a process with a controlling terminal, or "detached" otherwise
+func classifyDaemon(proc *tree.Process) string {
+	if proc.TTY != "" {
+		return "attached"
+	}
+	if proc.SID == proc.PID && proc.PPID == 1 {
+		return "daemon"
+	}
+	return "detached"
+}
+
+// annotateDaemonStatus sets the session, terminal, and daemon status of every process.
+//
+// Processes whose session cannot be inspected, e.g., because they exited, are left unannotated.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//   - sessions: Session information by PID
+func annotateDaemonStatus(processes *[]tree.Process, sessions map[int32]sessionInfo) {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		session, exists := sessions[proc.PID]
+		if !exists {
+			continue
+		}
+		proc.SID = session.SID
+		proc.TTY = session.TTY
+		proc.DaemonStatus = classifyDaemon(proc)
+	}
+}
+
+// TTYName normalizes a terminal given on the command line to the name shown for
+// processes, e.g., /dev/pts/3 to pts/3.
+//
+// Parameters:
+//   - name: The terminal name or device path
+//
+// Returns:
+//   - The terminal name without the /dev/ prefix
+func TTYName(name string) string {
+	return strings.TrimPrefix(strings.TrimSpace(name), "/dev/")
+}
@@ -0,0 +1,51 @@
+//go:build darwin
+// +build darwin
+
+package pstree
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/gdanko/pstree/util"
+	"golang.org/x/sys/unix"
+)
+
+// AnnotateDaemonStatus determines the session leader and controlling terminal of every process.
+//
+// Session IDs come from getsid(2), and controlling terminals from a single ps(1) call,
+// since macOS does not expose them through a filesystem.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Error if ps could not be run
+func AnnotateDaemonStatus(processes *[]tree.Process) error {
+	output, err := exec.Command("ps", "-axo", "pid=,tty=").Output()
+	if err != nil {
+		return err
+	}
+	ttys := map[int32]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if fields[1] != "??" {
+			ttys[util.StrToInt32(fields[0])] = fields[1]
+		}
+	}
+
+	sessions := map[int32]sessionInfo{}
+	for _, proc := range *processes {
+		sid, err := unix.Getsid(int(proc.PID))
+		if err != nil {
+			continue
+		}
+		sessions[proc.PID] = sessionInfo{SID: int32(sid), TTY: ttys[proc.PID]}
+	}
+	annotateDaemonStatus(processes, sessions)
+	return nil
+}
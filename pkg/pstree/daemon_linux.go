@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateDaemonStatus determines the session leader and controlling terminal of every process.
+//
+// The session ID and terminal device number are read from /proc/<pid>/stat.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always nil on Linux
+func AnnotateDaemonStatus(processes *[]tree.Process) error {
+	sessions := map[int32]sessionInfo{}
+	for _, proc := range *processes {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", proc.PID))
+		if err != nil {
+			continue
+		}
+		sid, ttyNr, err := parseProcStat(string(data))
+		if err != nil {
+			continue
+		}
+		sessions[proc.PID] = sessionInfo{SID: sid, TTY: ttyName(ttyNr)}
+	}
+	annotateDaemonStatus(processes, sessions)
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package pstree
+
+import (
+	"errors"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateDaemonStatus determines the session leader and controlling terminal of every process.
+//
+// Sessions and controlling terminals are only inspected on Linux and macOS, so this
+// always returns an error on other platforms.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always an error explaining that daemon status is not supported
+func AnnotateDaemonStatus(processes *[]tree.Process) error {
+	return errors.New("--show-daemon-status, --show-tty, and --tty are only supported on Linux and macOS")
+}
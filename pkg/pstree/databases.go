@@ -0,0 +1,82 @@
+package pstree
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// DATABASE CONNECTIONS
+//------------------------------------------------------------------------------
+// Functions in this section recognize database servers and count their client
+// connections, so that --db-connections can summarize hundreds of per-connection
+// processes on the server, e.g., [137 client backends].
+
+// postgresBackendTitle matches the process title of a PostgreSQL client backend, e.g.,
+// "postgres: alice app 10.0.0.5(51234) idle" or "postgres: main: alice app [local] SELECT".
+// Auxiliary processes such as the checkpointer or autovacuum workers have no client address.
+var postgresBackendTitle = regexp.MustCompile(`^postgres: (\S+: )?\S+ \S+ (\[local\]|\S+\(\d+\))( |$)`)
+
+// postgresExecutables and mysqlExecutables are the names of the database server executables.
+var (
+	postgresExecutables = []string{"postgres", "postmaster"}
+	mysqlExecutables    = []string{"mariadbd", "mysqld"}
+)
+
+// mysqlConnectionThread is the name MySQL gives the threads serving client connections.
+const mysqlConnectionThread = "connection"
+
+// isPostgresBackend returns true if a process is a PostgreSQL client backend.
+//
+// PostgreSQL rewrites the command line of each backend with its process title, which
+// names the user, database, and client address of the connection.
+//
+// Parameters:
+//   - proc: The process
+//
+// Returns:
+//   - true if the process serves a client connection
+func isPostgresBackend(proc *tree.Process) bool {
+	return slices.Contains(postgresExecutables, filepath.Base(proc.Command)) && postgresBackendTitle.MatchString(strings.Join(proc.Args, " "))
+}
+
+// AnnotateDatabases counts the client connections of each PostgreSQL and MySQL server.
+//
+// PostgreSQL forks one backend process per connection, which is marked as a database
+// backend and counted on the server. MySQL serves each connection with a thread named
+// "connection", so its connections can only be counted when threads were collected.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+func AnnotateDatabases(processes *[]tree.Process) {
+	pidToIndex := make(map[int32]int, len(*processes))
+	for i := range *processes {
+		pidToIndex[(*processes)[i].PID] = i
+	}
+
+	for i := range *processes {
+		proc := &(*processes)[i]
+		executable := filepath.Base(proc.Command)
+		if isPostgresBackend(proc) {
+			parentIndex, exists := pidToIndex[proc.PPID]
+			if !exists || !slices.Contains(postgresExecutables, filepath.Base((*processes)[parentIndex].Command)) {
+				continue
+			}
+			parent := &(*processes)[parentIndex]
+			proc.DBBackend = true
+			parent.Database = tree.DatabasePostgres
+			parent.DBConnections++
+		} else if slices.Contains(mysqlExecutables, executable) && len(proc.Threads) > 0 {
+			proc.Database = tree.DatabaseMySQL
+			for _, thread := range proc.Threads {
+				if thread.Name == mysqlConnectionThread {
+					proc.DBConnections++
+				}
+			}
+		}
+	}
+}
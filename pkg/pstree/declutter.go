@@ -0,0 +1,133 @@
+package pstree
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// DECLUTTERING
+//------------------------------------------------------------------------------
+// Functions in this section recognize noisy desktop helper processes, e.g.,
+// browser renderers or IDE language servers, so that --declutter can collapse
+// them into a count on their parent or hide them.
+
+// DeclutterRule matches noisy helper processes by command line.
+type DeclutterRule struct {
+	Action  string         // tree.DeclutterCollapse or tree.DeclutterHide
+	Label   string         // Label shown in the count of collapsed processes, e.g., browser-helper
+	Pattern *regexp.Regexp // Regular expression matched against the command and its arguments
+}
+
+// builtinDeclutterRules is the knowledge base of noisy desktop helper processes.
+var builtinDeclutterRules = []struct {
+	action  string
+	pattern string
+	label   string
+}{
+	// Crash reporters are never interesting
+	{tree.DeclutterHide, `crashpad_handler|--type=crashpad-handler|CrashReporter`, "crash-reporter"},
+	// Chromium and Electron subprocesses, e.g., Chrome, Brave, Edge, VS Code, and Slack
+	{tree.DeclutterCollapse, `--type=(renderer|gpu-process|utility|zygote|broker|extensionHost)\b`, "browser-helper"},
+	{tree.DeclutterCollapse, `Helper \((Renderer|GPU|Plugin|Alerts)\)`, "browser-helper"},
+	// Firefox content processes
+	{tree.DeclutterCollapse, `\s-contentproc\b`, "browser-helper"},
+	// Language servers started by editors and IDEs
+	{tree.DeclutterCollapse, `(^|/)(gopls|rust-analyzer|pyright-langserver|typescript-language-server|tsserver(\.js)?|clangd|jdtls|lua-language-server|OmniSharp|solargraph)(\s|$)`, "language-server"},
+	// Desktop session services
+	{tree.DeclutterCollapse, `(^|/)(xdg-desktop-portal[\w-]*|xdg-document-portal|xdg-permission-store|gvfsd[\w-]*|gvfs-[\w-]+|at-spi[\w-]*|dconf-service|evolution-[\w-]+|tracker-miner-[\w-]+|gsd-[\w-]+)(\s|$)`, "desktop-service"},
+}
+
+// DefaultDeclutterRules returns the built-in knowledge base of noisy desktop helper processes.
+//
+// Returns:
+//   - []DeclutterRule: The built-in rules
+func DefaultDeclutterRules() []DeclutterRule {
+	rules := make([]DeclutterRule, 0, len(builtinDeclutterRules))
+	for _, builtin := range builtinDeclutterRules {
+		rules = append(rules, DeclutterRule{Action: builtin.action, Label: builtin.label, Pattern: regexp.MustCompile(builtin.pattern)})
+	}
+	return rules
+}
+
+// LoadDeclutterRules reads a declutter file and returns the rules it contains.
+//
+// Parameters:
+//   - path: Path of the declutter file
+//
+// Returns:
+//   - []DeclutterRule: The parsed rules, in file order
+//   - error: Error if the file could not be read or contains an invalid rule
+func LoadDeclutterRules(path string) ([]DeclutterRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read declutter file: %w", err)
+	}
+	return parseDeclutterRules(string(data))
+}
+
+// parseDeclutterRules parses the contents of a declutter file.
+//
+// Each non-empty line that does not start with '#' names an action, a regular expression
+// matched against the command and its arguments, and for collapse a label:
+//
+//	collapse  ^/opt/slack/slack\s.*--type=   slack-helper
+//	hide      ^/usr/libexec/tracker-extract
+//
+// Parameters:
+//   - data: Contents of the declutter file
+//
+// Returns:
+//   - []DeclutterRule: The parsed rules, in file order
+//   - error: Error if a line is malformed or a pattern does not compile
+func parseDeclutterRules(data string) ([]DeclutterRule, error) {
+	rules := []DeclutterRule{}
+	for lineNumber, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[0] == tree.DeclutterCollapse:
+		case len(fields) == 2 && fields[0] == tree.DeclutterHide:
+		default:
+			return nil, fmt.Errorf("declutter file line %d: expected 'collapse <pattern> <label>' or 'hide <pattern>'", lineNumber+1)
+		}
+
+		pattern, err := regexp.Compile(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("declutter file line %d: %w", lineNumber+1, err)
+		}
+		rule := DeclutterRule{Action: fields[0], Pattern: pattern}
+		if len(fields) == 3 {
+			rule.Label = fields[2]
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ApplyDeclutter records on each process the first rule matching its command line.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//   - rules: The rules to apply, in order of precedence
+func ApplyDeclutter(processes *[]tree.Process, rules []DeclutterRule) {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		commandLine := strings.Join(append([]string{proc.Command}, proc.Args...), " ")
+		for _, rule := range rules {
+			if rule.Pattern.MatchString(commandLine) {
+				proc.Clutter = rule.Label
+				proc.ClutterAction = rule.Action
+				break
+			}
+		}
+	}
+}
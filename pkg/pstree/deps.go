@@ -0,0 +1,109 @@
+package pstree
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// SYSTEMD UNIT DEPENDENCIES
+//------------------------------------------------------------------------------
+// Functions in this section read the After= and Requires= dependencies declared
+// by the systemd units of the processes, so that the runtime tree can be compared
+// with the declared startup ordering when troubleshooting boot or restart issues.
+
+// unitDependencies are the dependencies declared by a systemd unit.
+type unitDependencies struct {
+	After    []string // Units this unit is started after
+	Requires []string // Units this unit cannot run without
+}
+
+// parseUnitDependencies parses the output of `systemctl show --property=Id,After,Requires`,
+// which lists the properties of each unit in a block followed by an empty line:
+//
+//	Id=nginx.service
+//	Requires=system.slice sysinit.target
+//	After=network.target php-fpm.service
+//
+// Parameters:
+//   - output: Output of systemctl show
+//
+// Returns:
+//   - map[string]unitDependencies: The dependencies, keyed by unit name
+func parseUnitDependencies(output string) map[string]unitDependencies {
+	dependencies := map[string]unitDependencies{}
+	for _, block := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n\n") {
+		var (
+			id   string
+			deps unitDependencies
+		)
+		for _, line := range strings.Split(block, "\n") {
+			key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+			if !found {
+				continue
+			}
+			switch key {
+			case "Id":
+				id = value
+			case "After":
+				deps.After = strings.Fields(value)
+			case "Requires":
+				deps.Requires = strings.Fields(value)
+			}
+		}
+		if id != "" {
+			dependencies[id] = deps
+		}
+	}
+	return dependencies
+}
+
+// processUnits returns the systemd units of the processes, sorted.
+//
+// Parameters:
+//   - processes: The processes, with their units collected
+//
+// Returns:
+//   - []string: The unit names, e.g., ["nginx.service", "sshd.service"]
+func processUnits(processes []tree.Process) []string {
+	units := []string{}
+	for _, proc := range processes {
+		if proc.Unit != "" && !proc.CgroupNode && !slices.Contains(units, proc.Unit) {
+			units = append(units, proc.Unit)
+		}
+	}
+	slices.Sort(units)
+	return units
+}
+
+// annotateDependencies attaches to each process the dependencies of its unit on the other
+// units running processes, which are the dependencies that can be seen in the tree.
+// Dependencies on targets, slices, and units without processes are left out.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//   - dependencies: The dependencies of each unit, as returned by parseUnitDependencies
+func annotateDependencies(processes *[]tree.Process, dependencies map[string]unitDependencies) {
+	units := processUnits(*processes)
+	running := func(names []string, self string) []string {
+		shown := []string{}
+		for _, name := range names {
+			if name != self && slices.Contains(units, name) && !slices.Contains(shown, name) {
+				shown = append(shown, name)
+			}
+		}
+		slices.Sort(shown)
+		return shown
+	}
+	for i := range *processes {
+		proc := &(*processes)[i]
+		deps, ok := dependencies[proc.Unit]
+		if proc.Unit == "" || !ok {
+			continue
+		}
+		proc.UnitAfter = running(deps.After, proc.Unit)
+		proc.UnitRequires = running(deps.Requires, proc.Unit)
+	}
+}
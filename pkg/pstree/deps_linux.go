@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateDependencies reads the After= and Requires= dependencies of the systemd unit of
+// every process with systemctl(1).
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate, with their units collected
+//
+// Returns:
+//   - error: Error if systemctl is not available or failed
+func AnnotateDependencies(processes *[]tree.Process) error {
+	units := processUnits(*processes)
+	if len(units) == 0 {
+		return nil
+	}
+	systemctl, err := exec.LookPath("systemctl")
+	if err != nil {
+		return fmt.Errorf("--show-deps requires systemctl: %w", err)
+	}
+	args := append([]string{"show", "--property=Id,After,Requires", "--"}, units...)
+	output, err := exec.Command(systemctl, args...).Output()
+	if err != nil {
+		return fmt.Errorf("failed to read the dependencies of the systemd units: %w", err)
+	}
+	annotateDependencies(processes, parseUnitDependencies(string(output)))
+	return nil
+}
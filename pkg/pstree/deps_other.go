@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"errors"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateDependencies reads the dependencies of the systemd unit of every process.
+//
+// Systemd only exists on Linux, so this function always returns an error on other platforms.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Error indicating that the operation is not supported
+func AnnotateDependencies(processes *[]tree.Process) error {
+	return errors.New("--show-deps is only supported on Linux")
+}
@@ -0,0 +1,78 @@
+package pstree
+
+import (
+	"math"
+	"slices"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// SNAPSHOT DIFFS
+//------------------------------------------------------------------------------
+// Functions in this section compare two snapshots and merge them into a single
+// process list whose processes record what changed, for `pstree diff`.
+
+const (
+	// DiffCPUThreshold is the change in CPU usage, in percentage points, above which a process is changed
+	DiffCPUThreshold = 1.0
+	// DiffMemoryThreshold is the change in resident memory, in bytes, above which a process is changed
+	DiffMemoryThreshold = 1024 * 1024
+)
+
+// DiffProcesses merges a baseline snapshot and a later one, recording on each process
+// whether it was added, changed, or removed.
+//
+// A process is the same in both snapshots if its PID and start time match; a reused PID
+// is reported as an added process, and the baseline process it replaced is omitted so that
+// PIDs stay unique. A process changed if its CPU usage or resident memory moved by more
+// than DiffCPUThreshold or DiffMemoryThreshold, or its command line differs.
+//
+// Parameters:
+//   - before: The processes of the baseline snapshot
+//   - after: The processes of the later snapshot
+//
+// Returns:
+//   - []tree.Process: The processes of both snapshots, ordered by PID
+func DiffProcesses(before []tree.Process, after []tree.Process) []tree.Process {
+	baseline := make(map[int32]tree.Process, len(before))
+	for _, proc := range before {
+		baseline[proc.PID] = proc
+	}
+
+	merged := make([]tree.Process, 0, len(after)+len(before))
+	current := make(map[int32]bool, len(after))
+	for _, proc := range after {
+		current[proc.PID] = true
+		old, exists := baseline[proc.PID]
+		if !exists || old.CreateTime != proc.CreateTime {
+			proc.Diff = tree.DiffAdded
+			merged = append(merged, proc)
+			continue
+		}
+
+		if proc.Available(tree.FieldCPUPercent) && old.Available(tree.FieldCPUPercent) {
+			proc.CPUDelta = proc.CPUPercent - old.CPUPercent
+		}
+		if proc.Available(tree.FieldMemory) && old.Available(tree.FieldMemory) && proc.MemoryInfo != nil && old.MemoryInfo != nil {
+			proc.RSSDelta = int64(proc.MemoryInfo.RSS) - int64(old.MemoryInfo.RSS)
+		}
+		if math.Abs(proc.CPUDelta) >= DiffCPUThreshold || proc.RSSDelta >= DiffMemoryThreshold || proc.RSSDelta <= -DiffMemoryThreshold ||
+			proc.Command != old.Command || !slices.Equal(proc.Args, old.Args) {
+			proc.Diff = tree.DiffChanged
+		}
+		merged = append(merged, proc)
+	}
+
+	for _, proc := range before {
+		if !current[proc.PID] {
+			proc.Diff = tree.DiffRemoved
+			merged = append(merged, proc)
+		}
+	}
+
+	slices.SortStableFunc(merged, func(a, b tree.Process) int {
+		return int(a.PID) - int(b.PID)
+	})
+	return merged
+}
@@ -0,0 +1,58 @@
+package pstree
+
+//------------------------------------------------------------------------------
+// WINDOWS ELEVATION
+//------------------------------------------------------------------------------
+// Functions in this section classify processes by token elevation and
+// mandatory integrity level.
+
+// Mandatory integrity level RIDs, see
+// https://learn.microsoft.com/en-us/windows/win32/secauthz/mandatory-integrity-control
+const (
+	integrityUntrusted = 0x0000
+	integrityLow       = 0x1000
+	integrityMedium    = 0x2000
+	integrityHigh      = 0x3000
+	integritySystem    = 0x4000
+)
+
+// integrityLevelName returns a short name for a mandatory integrity level RID.
+//
+// Parameters:
+//   - rid: The last sub-authority of the integrity level SID
+//
+// Returns:
+//   - One of "untrusted", "low", "medium", "high", or "system"
+func integrityLevelName(rid uint32) string {
+	switch {
+	case rid >= integritySystem:
+		return "system"
+	case rid >= integrityHigh:
+		return "high"
+	case rid >= integrityMedium:
+		return "medium"
+	case rid >= integrityLow:
+		return "low"
+	default:
+		return "untrusted"
+	}
+}
+
+// elevationTag returns the tag shown for a process with the given elevation and integrity level.
+//
+// Parameters:
+//   - elevated: Whether the process token is elevated from a UAC perspective
+//   - integrityLevel: The name returned by integrityLevelName
+//
+// Returns:
+//   - "system" for system integrity, "admin" for elevated or high integrity, otherwise ""
+func elevationTag(elevated bool, integrityLevel string) string {
+	switch {
+	case integrityLevel == "system":
+		return "system"
+	case elevated || integrityLevel == "high":
+		return "admin"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package pstree
+
+import (
+	"errors"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateElevation sets the token elevation and integrity level of every process.
+//
+// Token elevation and integrity levels only exist on Windows, so this function always
+// returns an error on other platforms.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Error indicating that the operation is not supported
+func AnnotateElevation(processes *[]tree.Process) error {
+	return errors.New("--show-elevation and --elevated are only supported on Windows")
+}
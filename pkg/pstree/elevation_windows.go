@@ -0,0 +1,71 @@
+//go:build windows
+// +build windows
+
+package pstree
+
+import (
+	"unsafe"
+
+	"github.com/gdanko/pstree/pkg/tree"
+	"golang.org/x/sys/windows"
+)
+
+// AnnotateElevation sets the token elevation and integrity level of every process.
+// Processes whose token cannot be opened, usually due to permissions, are left untagged.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always nil on Windows
+func AnnotateElevation(processes *[]tree.Process) error {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		elevated, integrityLevel, err := queryElevation(uint32(proc.PID))
+		if err != nil {
+			continue
+		}
+		proc.IntegrityLevel = integrityLevel
+		proc.Elevation = elevationTag(elevated, integrityLevel)
+	}
+	return nil
+}
+
+// queryElevation opens the token of a process and reads its elevation and integrity level.
+//
+// Parameters:
+//   - pid: PID of the process to query
+//
+// Returns:
+//   - bool: Whether the token is elevated
+//   - string: The integrity level name
+//   - error: Error if the process or its token could not be opened
+func queryElevation(pid uint32) (bool, string, error) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return false, "", err
+	}
+	defer windows.CloseHandle(handle)
+
+	var token windows.Token
+	if err = windows.OpenProcessToken(handle, windows.TOKEN_QUERY, &token); err != nil {
+		return false, "", err
+	}
+	defer token.Close()
+
+	// The label is variable length, so ask for the required size first
+	var size uint32
+	_ = windows.GetTokenInformation(token, windows.TokenIntegrityLevel, nil, 0, &size)
+	if size == 0 {
+		return token.IsElevated(), "", nil
+	}
+	buffer := make([]byte, size)
+	if err = windows.GetTokenInformation(token, windows.TokenIntegrityLevel, &buffer[0], size, &size); err != nil {
+		return token.IsElevated(), "", nil
+	}
+	label := (*windows.Tokenmandatorylabel)(unsafe.Pointer(&buffer[0]))
+	sid := label.Label.Sid
+	rid := sid.SubAuthority(uint32(sid.SubAuthorityCount()) - 1)
+
+	return token.IsElevated(), integrityLevelName(rid), nil
+}
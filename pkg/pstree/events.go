@@ -0,0 +1,184 @@
+package pstree
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// EVENT BUS
+//------------------------------------------------------------------------------
+// Functions in this section run the refresh loop shared by every mode that
+// samples processes repeatedly, e.g., --watch. The collector publishes each
+// sample, and what changed since the previous one, to the subscribers of an
+// event bus, so that renderers and alerting only react to events instead of
+// each running a refresh loop of their own.
+
+// EventKind identifies what an Event describes.
+type EventKind int
+
+const (
+	// EventSnapshot carries every collected process of a sample
+	EventSnapshot EventKind = iota
+	// EventDelta carries the processes that appeared and exited since the previous sample
+	EventDelta
+)
+
+// Event is published to the subscribers of an EventBus for each sample.
+type Event struct {
+	Kind      EventKind      // What the event describes
+	Sequence  int            // Number of the sample, starting at 1
+	Time      time.Time      // Time the sample was collected
+	OS        string         // Operating system the processes were collected on, e.g., linux
+	Processes []tree.Process // EventSnapshot: the collected processes
+	Appeared  []tree.Process // EventDelta: processes that were not in the previous sample, in PID order
+	Exited    []tree.Process // EventDelta: processes of the previous sample that are gone, in PID order
+}
+
+// Subscriber handles the events published to an EventBus. An error stops the collector.
+type Subscriber func(event Event) error
+
+// EventBus delivers published events to its subscribers.
+type EventBus struct {
+	mutex       sync.Mutex
+	subscribers []Subscriber
+}
+
+// Collector collects the processes of a sample.
+//
+// Returns:
+//   - []tree.Process: The collected processes
+//   - string: The operating system they were collected on, e.g., linux
+//   - error: Error if the processes could not be collected
+type Collector func() ([]tree.Process, string, error)
+
+// NewEventBus creates an event bus without subscribers.
+//
+// Returns:
+//   - *EventBus: The event bus
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: []Subscriber{}}
+}
+
+// Subscribe adds a subscriber, which receives every event published from now on.
+//
+// Parameters:
+//   - subscriber: The function handling the events
+func (bus *EventBus) Subscribe(subscriber Subscriber) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+	bus.subscribers = append(bus.subscribers, subscriber)
+}
+
+// Publish delivers an event to every subscriber, one after the other in the order they
+// subscribed. Each subscriber receives its own copy of the processes of a snapshot, so
+// that one can mark or sort them without affecting the others. A subscriber that fails
+// does not keep the event from the others.
+//
+// Parameters:
+//   - event: The event to deliver
+//
+// Returns:
+//   - error: The errors returned by the subscribers, joined
+func (bus *EventBus) Publish(event Event) error {
+	bus.mutex.Lock()
+	subscribers := append([]Subscriber{}, bus.subscribers...)
+	bus.mutex.Unlock()
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		copied := event
+		if event.Processes != nil {
+			copied.Processes = slices.Clone(event.Processes)
+		}
+		if err := subscriber(copied); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// diffSamples returns the processes that appeared and exited between two samples.
+//
+// Parameters:
+//   - previous: The processes of the previous sample
+//   - current: The processes of the current sample
+//
+// Returns:
+//   - []tree.Process: The processes of the current sample missing from the previous one, in PID order
+//   - []tree.Process: The processes of the previous sample missing from the current one, in PID order
+func diffSamples(previous []tree.Process, current []tree.Process) ([]tree.Process, []tree.Process) {
+	keys := func(processes []tree.Process) map[watchKey]bool {
+		set := make(map[watchKey]bool, len(processes))
+		for _, proc := range processes {
+			set[watchKey{PID: proc.PID, CreateTime: proc.CreateTime}] = true
+		}
+		return set
+	}
+	previousKeys, currentKeys := keys(previous), keys(current)
+
+	appeared := []tree.Process{}
+	for _, proc := range current {
+		if !previousKeys[watchKey{PID: proc.PID, CreateTime: proc.CreateTime}] {
+			appeared = append(appeared, proc)
+		}
+	}
+	exited := []tree.Process{}
+	for _, proc := range previous {
+		if !currentKeys[watchKey{PID: proc.PID, CreateTime: proc.CreateTime}] {
+			exited = append(exited, proc)
+		}
+	}
+	SortProcsByPid(&appeared)
+	SortProcsByPid(&exited)
+	return appeared, exited
+}
+
+// RunCollector collects a sample every interval and publishes it until the context is done.
+//
+// Each sample is published as an EventSnapshot, followed from the second sample on by an
+// EventDelta with the processes that appeared and exited since the previous sample. The
+// first sample is collected immediately.
+//
+// Parameters:
+//   - ctx: Context whose cancellation stops the collector, e.g., on an interrupt
+//   - interval: Time to wait between samples
+//   - collect: The function collecting the processes of a sample
+//   - bus: The event bus to publish the samples to
+//
+// Returns:
+//   - error: Error if a sample could not be collected or a subscriber failed, or nil once the context is done
+func RunCollector(ctx context.Context, interval time.Duration, collect Collector, bus *EventBus) error {
+	var previous []tree.Process
+	for sequence := 1; ; sequence++ {
+		processes, collectedOn, err := collect()
+		if err != nil {
+			return err
+		}
+		sampled := time.Now()
+
+		snapshot := Event{Kind: EventSnapshot, Sequence: sequence, Time: sampled, OS: collectedOn, Processes: processes}
+		if err := bus.Publish(snapshot); err != nil {
+			return err
+		}
+		if previous != nil {
+			appeared, exited := diffSamples(previous, processes)
+			delta := Event{Kind: EventDelta, Sequence: sequence, Time: sampled, OS: collectedOn, Appeared: appeared, Exited: exited}
+			if err := bus.Publish(delta); err != nil {
+				return err
+			}
+		}
+		previous = processes
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
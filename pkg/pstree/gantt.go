@@ -0,0 +1,208 @@
+package pstree
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// GANTT CHART EXPORT
+//------------------------------------------------------------------------------
+// Functions in this section render the process lifetimes recorded during --watch
+// as a Gantt-like chart, one bar per process in tree order, to analyze flaky
+// service startups and the process behavior of CI jobs.
+
+const (
+	ganttMargin     = 10  // Space around the chart in pixels
+	ganttLabelWidth = 280 // Width of the process label column in pixels
+	ganttChartWidth = 800 // Width of the time axis in pixels
+	ganttAxisHeight = 30  // Height of the time axis labels in pixels
+	ganttRowHeight  = 20  // Height of each process row in pixels
+	ganttIndent     = 12  // Label indentation per tree level in pixels
+	ganttMaxTicks   = 10  // Maximum number of time axis ticks
+)
+
+// ganttTickIntervals are the candidate spacings of the time axis ticks, smallest first.
+var ganttTickIntervals = []time.Duration{
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// ganttRow is a lifetime positioned in the chart.
+type ganttRow struct {
+	Lifetime Lifetime // The process lifetime
+	Depth    int      // Depth of the process among the recorded processes
+}
+
+// WriteGantt writes the recorded lifetimes as a Gantt chart.
+//
+// The time axis spans the recording. Processes started before the recording began are
+// clipped at its start, and processes still running at its end extend to the right edge.
+// Files ending in .html or .htm get an HTML page embedding the chart; anything else is
+// written as a standalone SVG image.
+//
+// Parameters:
+//   - output: Writer to write the chart to
+//   - recording: The recorded lifetimes
+//   - filename: Name of the file being written, used to choose between HTML and SVG
+//
+// Returns:
+//   - error: Error if the chart could not be written
+func WriteGantt(output io.Writer, recording Recording, filename string) error {
+	writer := bufio.NewWriter(output)
+	asHTML := false
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm":
+		asHTML = true
+	}
+
+	if asHTML {
+		fmt.Fprintln(writer, "<!DOCTYPE html>")
+		fmt.Fprintln(writer, `<html><head><meta charset="utf-8"><title>pstree process lifetimes</title></head><body>`)
+	}
+	writeGanttSVG(writer, recording)
+	if asHTML {
+		fmt.Fprintln(writer, "</body></html>")
+	}
+	return writer.Flush()
+}
+
+// writeGanttSVG writes the chart as an SVG element.
+//
+// Parameters:
+//   - writer: Writer to write the SVG to
+//   - recording: The recorded lifetimes
+func writeGanttSVG(writer io.Writer, recording Recording) {
+	rows := ganttRows(recording.Lifetimes)
+	span := recording.End.Sub(recording.Start)
+	if span <= 0 {
+		// A single sample has no duration, so give the axis a nominal width
+		span = time.Second
+	}
+	chartLeft := ganttMargin + ganttLabelWidth
+	width := chartLeft + ganttChartWidth + ganttMargin
+	height := ganttMargin + ganttAxisHeight + len(rows)*ganttRowHeight + ganttMargin
+
+	// x converts a time to a horizontal position, clipped to the recording
+	x := func(t time.Time) int {
+		offset := t.Sub(recording.Start)
+		if offset < 0 {
+			offset = 0
+		} else if offset > span {
+			offset = span
+		}
+		return chartLeft + int(float64(offset)/float64(span)*ganttChartWidth)
+	}
+
+	fmt.Fprintf(writer, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintln(writer, `<style>text{font-family:monospace;font-size:12px} .axis{stroke:#bbb} .running{fill:#4a90d9} .exited{fill:#d9534f}</style>`)
+
+	// Time axis, with ticks as offsets from the start of the recording
+	interval := ganttTickIntervals[len(ganttTickIntervals)-1]
+	for _, candidate := range ganttTickIntervals {
+		if span/candidate <= ganttMaxTicks {
+			interval = candidate
+			break
+		}
+	}
+	for offset := time.Duration(0); offset <= span; offset += interval {
+		tickX := x(recording.Start.Add(offset))
+		fmt.Fprintf(writer, `<line class="axis" x1="%d" y1="%d" x2="%d" y2="%d"/>`+"\n", tickX, ganttMargin+ganttAxisHeight-8, tickX, height-ganttMargin)
+		fmt.Fprintf(writer, `<text x="%d" y="%d" text-anchor="middle">+%s</text>`+"\n", tickX, ganttMargin+12, offset)
+	}
+
+	for i, row := range rows {
+		lifetime := row.Lifetime
+		top := ganttMargin + ganttAxisHeight + i*ganttRowHeight
+		class, end := "running", recording.End
+		if !lifetime.End.IsZero() {
+			class, end = "exited", lifetime.End
+		}
+		left, right := x(lifetime.Start), x(end)
+		// Keep processes that lived between two samples visible
+		if right-left < 1 {
+			right = left + 1
+		}
+		fmt.Fprintf(writer, `<text x="%d" y="%d">%s</text>`+"\n", ganttMargin+row.Depth*ganttIndent, top+14, html.EscapeString(ganttLabel(lifetime)))
+		fmt.Fprintf(writer, `<rect class="%s" x="%d" y="%d" width="%d" height="%d"><title>%s</title></rect>`+"\n", class, left, top+3, right-left, ganttRowHeight-6, html.EscapeString(ganttTitle(lifetime)))
+	}
+	fmt.Fprintln(writer, "</svg>")
+}
+
+// ganttRows orders lifetimes depth-first so that children follow their parents.
+//
+// Lifetimes whose parent was not recorded are roots. Siblings keep the order of the
+// input, i.e., by start time.
+//
+// Parameters:
+//   - lifetimes: The recorded lifetimes, ordered by start time
+//
+// Returns:
+//   - []ganttRow: The lifetimes in tree order with their depth
+func ganttRows(lifetimes []Lifetime) []ganttRow {
+	recorded := map[int32]bool{}
+	for _, lifetime := range lifetimes {
+		recorded[lifetime.PID] = true
+	}
+	children := map[int32][]Lifetime{}
+	roots := []Lifetime{}
+	for _, lifetime := range lifetimes {
+		if lifetime.PPID != lifetime.PID && recorded[lifetime.PPID] {
+			children[lifetime.PPID] = append(children[lifetime.PPID], lifetime)
+		} else {
+			roots = append(roots, lifetime)
+		}
+	}
+
+	rows := []ganttRow{}
+	visited := map[Lifetime]bool{}
+	var visit func(lifetime Lifetime, depth int)
+	visit = func(lifetime Lifetime, depth int) {
+		// A reused PID can make a process appear to be its own ancestor
+		if visited[lifetime] {
+			return
+		}
+		visited[lifetime] = true
+		rows = append(rows, ganttRow{Lifetime: lifetime, Depth: depth})
+		for _, child := range children[lifetime.PID] {
+			visit(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		visit(root, 0)
+	}
+	return rows
+}
+
+// ganttLabel returns the label of a row, e.g., nginx (1234).
+//
+// Parameters:
+//   - lifetime: The process lifetime
+//
+// Returns:
+//   - The command name and PID
+func ganttLabel(lifetime Lifetime) string {
+	return fmt.Sprintf("%s (%d)", filepath.Base(lifetime.Command), lifetime.PID)
+}
+
+// ganttTitle returns the tooltip of a bar with the start and end times of the process.
+//
+// Parameters:
+//   - lifetime: The process lifetime
+//
+// Returns:
+//   - The tooltip text
+func ganttTitle(lifetime Lifetime) string {
+	const layout = "15:04:05.000"
+	if lifetime.End.IsZero() {
+		return fmt.Sprintf("%s: started %s, still running", ganttLabel(lifetime), lifetime.Start.Format(layout))
+	}
+	return fmt.Sprintf("%s: started %s, exited %s", ganttLabel(lifetime), lifetime.Start.Format(layout), lifetime.End.Format(layout))
+}
@@ -0,0 +1,60 @@
+package pstree
+
+import (
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// EMULATED PROCESS GROUPS
+//------------------------------------------------------------------------------
+// Functions in this section give processes a process group on platforms that
+// have none, e.g., Windows, so that --show-pgids, group leader highlighting, and
+// the anomaly checks behave as they do on Unix-like systems.
+
+// emulateProcessGroups sets the PGID of every process whose group could not be read to the
+// PID of its topmost ancestor in the same logon session. The ancestor plays the part of the
+// session leader, e.g., explorer.exe for the applications a user started.
+//
+// Windows reuses PIDs and does not re-parent orphans, so a parent that started after its
+// child is a different process that reused the PID, and ends the walk like a missing parent.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to update
+//   - session: Function returning the session ID of a PID
+func emulateProcessGroups(processes *[]tree.Process, session func(pid int32) (uint32, error)) {
+	indexes := make(map[int32]int, len(*processes))
+	sessions := make(map[int32]uint32, len(*processes))
+	for i, proc := range *processes {
+		indexes[proc.PID] = i
+		if sessionID, err := session(proc.PID); err == nil {
+			sessions[proc.PID] = sessionID
+		}
+	}
+
+	for i := range *processes {
+		proc := &(*processes)[i]
+		sessionID, found := sessions[proc.PID]
+		if proc.PGID >= 0 || !found {
+			continue
+		}
+
+		leader := proc
+		// Bounded by the number of processes in case of a PPID cycle
+		for range *processes {
+			parentIndex, exists := indexes[leader.PPID]
+			if !exists || leader.PPID == leader.PID {
+				break
+			}
+			parent := &(*processes)[parentIndex]
+			if parentSession, found := sessions[parent.PID]; !found || parentSession != sessionID || parent.CreateTime > leader.CreateTime {
+				break
+			}
+			leader = parent
+		}
+
+		proc.PGID = leader.PID
+		for t := range proc.Threads {
+			proc.Threads[t].PGID = leader.PID
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package pstree
+
+import (
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// assignProcessGroups leaves the process groups as they are, since they are read from the
+// kernel on platforms other than Windows.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to update
+func assignProcessGroups(processes *[]tree.Process) {}
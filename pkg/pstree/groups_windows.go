@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package pstree
+
+import (
+	"github.com/gdanko/pstree/pkg/tree"
+	"golang.org/x/sys/windows"
+)
+
+// assignProcessGroups emulates process groups, which Windows does not have, from the
+// ancestry and logon session of every process.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to update
+func assignProcessGroups(processes *[]tree.Process) {
+	emulateProcessGroups(processes, sessionID)
+}
+
+// sessionID returns the logon session a process runs in.
+//
+// Parameters:
+//   - pid: The process ID
+//
+// Returns:
+//   - uint32: The session ID, e.g., 0 for services and 1 for the first interactive user
+//   - error: Error if the process could not be queried
+func sessionID(pid int32) (uint32, error) {
+	var id uint32
+	err := windows.ProcessIdToSessionId(uint32(pid), &id)
+	return id, err
+}
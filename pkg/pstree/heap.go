@@ -0,0 +1,120 @@
+package pstree
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// HEAP LIMITS
+//------------------------------------------------------------------------------
+// Functions in this section read the maximum heap configured for JVM and Node.js
+// processes from their options, without attaching to them, so that --show-heap
+// can compare it with the resident memory actually used.
+
+// heapOptionVariables lists the environment variables each runtime reads options from,
+// in the order they are applied.
+var heapOptionVariables = map[string][]string{
+	RuntimeJVM:  {"JAVA_TOOL_OPTIONS", "JDK_JAVA_OPTIONS"},
+	RuntimeNode: {"NODE_OPTIONS"},
+}
+
+// parseJVMSize parses a JVM memory size, e.g., 4g, 512M, or 1048576.
+//
+// Parameters:
+//   - value: Number of bytes, optionally followed by k, m, g, or t in either case
+//
+// Returns:
+//   - uint64: The size in bytes
+//   - bool: true if the size is valid
+func parseJVMSize(value string) (uint64, bool) {
+	multiplier := uint64(1)
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		case 't', 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier != 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	size, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || size == 0 {
+		return 0, false
+	}
+	return size * multiplier, true
+}
+
+// ConfiguredHeap returns the maximum heap configured by the options of a JVM or Node.js process.
+//
+// JVM options are -Xmx<size> and -XX:MaxHeapSize=<size>; Node.js options are
+// --max-old-space-size=<MiB>, which limits the old generation, the bulk of the heap.
+// When an option is given more than once, the last one wins, as in the runtimes.
+//
+// Parameters:
+//   - runtime: Runtime of the process, RuntimeJVM or RuntimeNode
+//   - options: Options of the process, in the order they are applied
+//
+// Returns:
+//   - The configured maximum heap in bytes, or 0 if none is configured
+func ConfiguredHeap(runtime string, options []string) uint64 {
+	var heapLimit uint64
+	for i, option := range options {
+		switch runtime {
+		case RuntimeJVM:
+			value, found := strings.CutPrefix(option, "-Xmx")
+			if !found {
+				value, found = strings.CutPrefix(option, "-XX:MaxHeapSize=")
+			}
+			if size, valid := parseJVMSize(value); found && valid {
+				heapLimit = size
+			}
+		case RuntimeNode:
+			// Node.js accepts underscores in place of dashes and the value as the next argument
+			option = strings.ReplaceAll(option, "_", "-")
+			value, found := strings.CutPrefix(option, "--max-old-space-size=")
+			if !found && option == "--max-old-space-size" && i+1 < len(options) {
+				value, found = options[i+1], true
+			}
+			if mebibytes, err := strconv.ParseUint(value, 10, 64); found && err == nil && mebibytes > 0 {
+				heapLimit = mebibytes << 20
+			}
+		}
+	}
+	return heapLimit
+}
+
+// AnnotateHeap records the configured maximum heap of each JVM and Node.js process.
+//
+// Options from the environment, e.g., JAVA_TOOL_OPTIONS or NODE_OPTIONS, are applied
+// before the command line arguments, which take precedence. Runtimes must already be
+// annotated by AnnotateRuntimes.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+func AnnotateHeap(processes *[]tree.Process) {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		variables, supported := heapOptionVariables[proc.Runtime]
+		if !supported {
+			continue
+		}
+		options := []string{}
+		for _, name := range variables {
+			for _, variable := range proc.EnvironmentVariables() {
+				if value, found := strings.CutPrefix(variable, name+"="); found {
+					options = append(options, strings.Fields(value)...)
+				}
+			}
+		}
+		proc.HeapLimit = ConfiguredHeap(proc.Runtime, append(options, proc.Args...))
+	}
+}
@@ -0,0 +1,120 @@
+package pstree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// SUBTREE SIGNALING
+//------------------------------------------------------------------------------
+// Functions in this section send a signal to a process and all its descendants,
+// children first, so that parents cannot respawn workers that were already signaled.
+
+// ParseSignal converts a signal name or number to a signal.
+//
+// Names are case-insensitive and may omit the SIG prefix, e.g., TERM, sigterm, or 15.
+// The supported signals depend on the platform; see signalNames.
+//
+// Parameters:
+//   - name: The signal name or number
+//
+// Returns:
+//   - syscall.Signal: The signal
+//   - string: The canonical name of the signal, e.g., SIGTERM
+//   - error: Error if the signal is not supported on this platform
+func ParseSignal(name string) (syscall.Signal, string, error) {
+	upper := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG")
+	if signal, exists := signalNames[upper]; exists {
+		return signal, "SIG" + upper, nil
+	}
+	if number, err := strconv.Atoi(upper); err == nil {
+		for signalName, signal := range signalNames {
+			if int(signal) == number {
+				return signal, "SIG" + signalName, nil
+			}
+		}
+	}
+
+	supported := []string{}
+	for signalName := range signalNames {
+		supported = append(supported, signalName)
+	}
+	slices.Sort(supported)
+	return 0, "", fmt.Errorf("unsupported signal '%s'; valid options are: %s", name, strings.Join(supported, ", "))
+}
+
+// SubtreeKillOrder returns a process and all its descendants in the order they should be
+// signaled: children before their parents. The pstree process itself is never included.
+//
+// The subtree is built from all processes, so display filters and --level do not hide
+// descendants from the signal.
+//
+// Parameters:
+//   - logger: Logger for the temporary process tree
+//   - processes: All collected processes
+//   - pid: PID of the root of the subtree
+//
+// Returns:
+//   - []tree.Process: The processes to signal, children first
+//   - error: Error if the PID does not exist or is PID 1
+func SubtreeKillOrder(logger *slog.Logger, processes []tree.Process, pid int32) ([]tree.Process, error) {
+	if pid <= 1 {
+		return nil, errors.New("refusing to signal PID 1 and all of its descendants")
+	}
+	processTree := tree.NewProcessTree(0, logger, processes, tree.DisplayOptions{})
+	start, exists := processTree.PidToIndexMap[pid]
+	if !exists {
+		return nil, fmt.Errorf("no process with PID %d", pid)
+	}
+
+	myPid := int32(os.Getpid())
+	targets := []tree.Process{}
+	processTree.WalkWithOptions(tree.WalkOptions{Order: tree.PostOrder, Start: start, IgnoreMark: true}, func(node tree.Node) tree.WalkDecision {
+		if node.Process.PID != myPid {
+			targets = append(targets, *node.Process)
+		}
+		return tree.WalkContinue
+	})
+	return targets, nil
+}
+
+// SignalProcesses sends a signal to each process in order, reporting every process as it goes.
+//
+// A process that cannot be signaled, e.g., because it already exited or belongs to another
+// user, does not stop the remaining processes from being signaled.
+//
+// Parameters:
+//   - targets: The processes to signal, as returned by SubtreeKillOrder
+//   - signal: The signal to send
+//   - signalName: The canonical name of the signal, used in messages
+//   - dryRun: Only print what would be sent
+//   - output: Destination for the progress messages
+//
+// Returns:
+//   - error: The combined errors of all processes that could not be signaled
+func SignalProcesses(targets []tree.Process, signal syscall.Signal, signalName string, dryRun bool, output io.Writer) error {
+	var errs []error
+	for _, target := range targets {
+		if dryRun {
+			fmt.Fprintf(output, "would send %s to %d (%s)\n", signalName, target.PID, filepath.Base(target.Command))
+			continue
+		}
+		if err := sendSignal(target.PID, signal); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send %s to %d (%s): %w", signalName, target.PID, filepath.Base(target.Command), err))
+			continue
+		}
+		fmt.Fprintf(output, "sent %s to %d (%s)\n", signalName, target.PID, filepath.Base(target.Command))
+	}
+	return errors.Join(errs...)
+}
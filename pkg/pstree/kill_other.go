@@ -0,0 +1,33 @@
+//go:build !windows
+// +build !windows
+
+package pstree
+
+import (
+	"syscall"
+)
+
+// signalNames maps the supported signal names, without the SIG prefix, to their signals.
+var signalNames = map[string]syscall.Signal{
+	"CONT": syscall.SIGCONT,
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"QUIT": syscall.SIGQUIT,
+	"STOP": syscall.SIGSTOP,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// sendSignal sends a signal to a process.
+//
+// Parameters:
+//   - pid: PID of the process
+//   - signal: The signal to send
+//
+// Returns:
+//   - error: Error if the signal could not be delivered
+func sendSignal(pid int32, signal syscall.Signal) error {
+	return syscall.Kill(int(pid), signal)
+}
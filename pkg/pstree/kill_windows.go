@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package pstree
+
+import (
+	"os"
+	"syscall"
+)
+
+// signalNames maps the supported signal names, without the SIG prefix, to their signals.
+// Windows has no signals, so both names terminate the process.
+var signalNames = map[string]syscall.Signal{
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+// sendSignal terminates a process, since Windows cannot deliver signals.
+//
+// Parameters:
+//   - pid: PID of the process
+//   - signal: Ignored; every supported signal terminates the process
+//
+// Returns:
+//   - error: Error if the process could not be terminated
+func sendSignal(pid int32, signal syscall.Signal) error {
+	process, err := os.FindProcess(int(pid))
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
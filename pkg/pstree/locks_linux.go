@@ -0,0 +1,36 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// FindLockHolders returns the processes holding a POSIX lock, OFD lock, or flock on a file.
+//
+// The file is identified by its device and inode, which are then matched against the
+// entries in /proc/locks.
+//
+// Parameters:
+//   - path: Path of the file to inspect
+//
+// Returns:
+//   - map[int32][]string: Lock descriptions (e.g., "FLOCK WRITE") keyed by PID
+//   - error: Error if the file or /proc/locks could not be read
+func FindLockHolders(path string) (map[int32][]string, error) {
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile("/proc/locks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc/locks: %w", err)
+	}
+
+	return parseProcLocks(string(data), unix.Major(uint64(stat.Dev)), unix.Minor(uint64(stat.Dev)), stat.Ino), nil
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"errors"
+)
+
+// FindLockHolders returns the processes holding a lock on a file.
+//
+// Lock holders are discovered through /proc/locks, which only exists on Linux,
+// so this function always returns an error on other platforms.
+//
+// Parameters:
+//   - path: Path of the file to inspect
+//
+// Returns:
+//   - map[int32][]string: Always nil
+//   - error: Error indicating that the operation is not supported
+func FindLockHolders(path string) (map[int32][]string, error) {
+	return nil, errors.New("--who-locks is only supported on Linux")
+}
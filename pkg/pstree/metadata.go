@@ -0,0 +1,163 @@
+package pstree
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+//------------------------------------------------------------------------------
+// ON-DEMAND METADATA
+//------------------------------------------------------------------------------
+// Functions in this section implement tree.MetadataSource against the live
+// system, so that expensive attributes are only fetched for the nodes that need them.
+
+// unitSuffixes are the systemd unit types that can own processes.
+var unitSuffixes = []string{".service", ".scope", ".socket", ".mount", ".swap"}
+
+// LiveMetadata fetches process attributes from the running system.
+type LiveMetadata struct{}
+
+// NewLiveMetadata creates a LiveMetadata source.
+//
+// Returns:
+//   - *LiveMetadata: The metadata source
+func NewLiveMetadata() *LiveMetadata {
+	return &LiveMetadata{}
+}
+
+// CPUPercent returns the CPU usage percentage of a process.
+//
+// Parameters:
+//   - pid: PID of the process
+//
+// Returns:
+//   - float64: The CPU usage percentage
+//   - error: Error if the process does not exist or cannot be inspected
+func (source *LiveMetadata) CPUPercent(pid int32) (float64, error) {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return 0, err
+	}
+	return proc.CPUPercent()
+}
+
+// RSS returns the resident memory of a process in bytes.
+//
+// Parameters:
+//   - pid: PID of the process
+//
+// Returns:
+//   - uint64: The resident memory in bytes
+//   - error: Error if the process does not exist or cannot be inspected
+func (source *LiveMetadata) RSS(pid int32) (uint64, error) {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return 0, err
+	}
+	memoryInfo, err := proc.MemoryInfo()
+	if err != nil {
+		return 0, err
+	}
+	return memoryInfo.RSS, nil
+}
+
+// Cgroup returns the control group path of a process.
+//
+// Parameters:
+//   - pid: PID of the process
+//
+// Returns:
+//   - string: The control group path, e.g., /system.slice/nginx.service
+//   - error: Error if control groups are not supported or cannot be read
+func (source *LiveMetadata) Cgroup(pid int32) (string, error) {
+	return readCgroup(pid)
+}
+
+// Unit returns the systemd unit of a process, derived from its control group.
+//
+// Parameters:
+//   - pid: PID of the process
+//
+// Returns:
+//   - string: The systemd unit, e.g., nginx.service, or empty if the process belongs to no unit
+//   - error: Error if control groups are not supported or cannot be read
+func (source *LiveMetadata) Unit(pid int32) (string, error) {
+	cgroup, err := readCgroup(pid)
+	if err != nil {
+		return "", err
+	}
+	return unitFromCgroup(cgroup), nil
+}
+
+// parseCgroup extracts the control group path from the contents of /proc/<pid>/cgroup.
+//
+// The cgroup v2 entry (0::<path>) is preferred; on cgroup v1 hosts the path of the
+// name=systemd hierarchy is used instead.
+//
+// Parameters:
+//   - data: Contents of /proc/<pid>/cgroup
+//
+// Returns:
+//   - string: The control group path
+//   - error: Error if no usable entry was found
+func parseCgroup(data string) (string, error) {
+	fallback := ""
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			return fields[2], nil
+		}
+		if fields[1] == "name=systemd" {
+			fallback = fields[2]
+		}
+	}
+	if fallback != "" {
+		return fallback, nil
+	}
+	return "", errors.New("no cgroup v2 or systemd cgroup entry found")
+}
+
+// unitFromCgroup returns the innermost systemd unit in a control group path.
+//
+// For example, /user.slice/user-1000.slice/user@1000.service/app.slice/foo.service
+// belongs to foo.service, while the slices containing it are not units that own processes.
+//
+// Parameters:
+//   - cgroup: The control group path
+//
+// Returns:
+//   - The systemd unit, or empty if the path contains none
+func unitFromCgroup(cgroup string) string {
+	for dir := cgroup; dir != "/" && dir != "." && dir != ""; dir = path.Dir(dir) {
+		name := path.Base(dir)
+		for _, suffix := range unitSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// UnitName completes the name of a systemd unit given without its type, e.g., nginx
+// for nginx.service, as systemctl does.
+//
+// Parameters:
+//   - name: The unit name, with or without its type
+//
+// Returns:
+//   - The unit name with its type
+func UnitName(name string) string {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return name
+		}
+	}
+	return name + ".service"
+}
@@ -0,0 +1,25 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+)
+
+// readCgroup returns the control group path of a process from /proc/<pid>/cgroup.
+//
+// Parameters:
+//   - pid: PID of the process
+//
+// Returns:
+//   - string: The control group path
+//   - error: Error if the file could not be read or parsed
+func readCgroup(pid int32) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	return parseCgroup(string(data))
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"errors"
+)
+
+// readCgroup returns the control group path of a process.
+//
+// Control groups only exist on Linux, so this always returns an error on other platforms.
+//
+// Parameters:
+//   - pid: PID of the process
+//
+// Returns:
+//   - string: Always empty
+//   - error: Always an error explaining that control groups are Linux-only
+func readCgroup(pid int32) (string, error) {
+	return "", errors.New("control groups are only supported on Linux")
+}
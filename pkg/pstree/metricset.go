@@ -0,0 +1,138 @@
+package pstree
+
+import (
+	"regexp"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// METRIC SELECTION
+//------------------------------------------------------------------------------
+// Functions in this section select which optional metrics GenerateProcess collects,
+// so that pstree only pays for the metrics the active options need.
+
+// MetricSet is a set of optional per-process metrics to collect.
+//
+// The attributes needed to build, filter, and compact the tree (command, arguments,
+// owner, PIDs, and creation time) are always collected. Metrics are bit flags so
+// that the metrics required by several options can be combined.
+type MetricSet uint32
+
+const (
+	// MetricCPU collects the CPU usage percentage and CPU times
+	MetricCPU MetricSet = 1 << iota
+	// MetricCgroup collects the control group and systemd unit (Linux-only)
+	MetricCgroup
+	// MetricEnvironment collects the environment variables
+	MetricEnvironment
+	// MetricGroup collects the group IDs and the name of the primary group
+	MetricGroup
+	// MetricIO collects the bytes and operations read and written
+	MetricIO
+	// MetricMemory collects the memory usage and percentage
+	MetricMemory
+	// MetricNamespaces collects the namespace inodes (Linux-only)
+	MetricNamespaces
+	// MetricNumFDs collects the number of open file descriptors
+	MetricNumFDs
+	// MetricNumThreads collects the number of threads
+	MetricNumThreads
+	// MetricOpenFiles collects the files opened by the process
+	MetricOpenFiles
+	// MetricPriority collects the nice value (not supported on Windows), and the CPU and I/O scheduling classes on Linux
+	MetricPriority
+	// MetricSchedLatency collects the average scheduling latency (Linux-only)
+	MetricSchedLatency
+	// MetricState collects the process state, e.g., running or zombie (not supported on Windows)
+	MetricState
+	// MetricThreads collects the threads and their names
+	MetricThreads
+
+	// MetricsNone collects only the attributes that are always collected
+	MetricsNone MetricSet = 0
+	// MetricsAll collects every optional metric
+	MetricsAll = MetricCPU | MetricCgroup | MetricEnvironment | MetricGroup | MetricIO | MetricMemory | MetricNamespaces | MetricNumFDs | MetricNumThreads | MetricOpenFiles | MetricPriority | MetricSchedLatency | MetricState | MetricThreads
+)
+
+// Has reports whether all the given metrics are in the set.
+//
+// Parameters:
+//   - metric: The metrics to check
+//
+// Returns:
+//   - true if every metric in metric is in the set
+func (set MetricSet) Has(metric MetricSet) bool {
+	return set&metric == metric
+}
+
+// metricFields maps the metrics to the fields of a process they fill.
+var metricFields = map[MetricSet]tree.Field{
+	MetricCPU:          tree.FieldCPUPercent,
+	MetricCgroup:       tree.FieldCgroup | tree.FieldUnit,
+	MetricIO:           tree.FieldIO,
+	MetricMemory:       tree.FieldMemory,
+	MetricNamespaces:   tree.FieldNamespaces,
+	MetricNumFDs:       tree.FieldNumFDs,
+	MetricNumThreads:   tree.FieldNumThreads,
+	MetricPriority:     tree.FieldPriority,
+	MetricSchedLatency: tree.FieldSchedLatency,
+	MetricState:        tree.FieldState,
+}
+
+// Fields returns the fields of a process filled by the metrics in the set, which are
+// only unavailable when collecting them failed, e.g., due to permissions.
+//
+// Returns:
+//   - tree.Field: The fields, including the age, which is always collected
+func (set MetricSet) Fields() tree.Field {
+	fields := tree.FieldAge
+	for metric, field := range metricFields {
+		if set.Has(metric) {
+			fields |= field
+		}
+	}
+	return fields
+}
+
+// templateFieldMetrics maps the fields of a --format template to the metrics that fill them.
+var templateFieldMetrics = map[string]MetricSet{
+	"CPUPercent":    MetricCPU,
+	"CPUTimes":      MetricCPU,
+	"Cgroup":        MetricCgroup,
+	"Environment":   MetricEnvironment,
+	"GIDs":          MetricGroup,
+	"Group":         MetricGroup,
+	"Groups":        MetricGroup,
+	"IOCounters":    MetricIO,
+	"MemoryInfo":    MetricMemory,
+	"MemoryPercent": MetricMemory,
+	"Namespaces":    MetricNamespaces,
+	"NumFDs":        MetricNumFDs,
+	"NumThreads":    MetricNumThreads,
+	"OpenFiles":     MetricOpenFiles,
+	"Priority":      MetricPriority,
+	"RSS":           MetricMemory,
+	"SchedLatency":  MetricSchedLatency,
+	"Status":        MetricState,
+	"Threads":       MetricThreads,
+	"Unit":          MetricCgroup,
+}
+
+// templateField matches the fields referenced by a template, e.g., .MemoryInfo in {{.MemoryInfo.RSS}}.
+var templateField = regexp.MustCompile(`\.([A-Z][A-Za-z]*)`)
+
+// TemplateMetrics returns the metrics needed by the fields a --format template refers to.
+//
+// Parameters:
+//   - format: The template
+//
+// Returns:
+//   - MetricSet: The metrics to collect for the template
+func TemplateMetrics(format string) MetricSet {
+	metricSet := MetricsNone
+	for _, match := range templateField.FindAllStringSubmatch(format, -1) {
+		metricSet |= templateFieldMetrics[match[1]]
+	}
+	return metricSet
+}
@@ -0,0 +1,210 @@
+package pstree
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// NAMESPACES
+//------------------------------------------------------------------------------
+// Functions in this section read the namespaces each process runs in, so that
+// --show-ns can show where containers and unshare'd workloads enter their own
+// namespaces, --ns can select the processes of a single namespace, and
+// --as-seen-by can show the tree from inside a PID namespace.
+
+// parseNamespaceLink extracts the inode of a namespace from the target of its link in
+// /proc/<pid>/ns, e.g., net:[4026531840].
+//
+// Parameters:
+//   - nsType: The namespace type, e.g., net
+//   - link: The link target
+//
+// Returns:
+//   - uint64: The namespace inode
+//   - error: Error if the link does not name a namespace of the given type
+func parseNamespaceLink(nsType string, link string) (uint64, error) {
+	value, found := strings.CutPrefix(link, nsType+":[")
+	if !found || !strings.HasSuffix(value, "]") {
+		return 0, fmt.Errorf("malformed %s namespace link '%s'", nsType, link)
+	}
+	return strconv.ParseUint(strings.TrimSuffix(value, "]"), 10, 64)
+}
+
+// ParseNamespaceFilters parses the namespaces given to --ns, e.g., net=4026532204 or
+// net=net:[4026532204], as printed by readlink /proc/<pid>/ns/net.
+//
+// Parameters:
+//   - specs: The namespaces, as <type>=<inode>
+//
+// Returns:
+//   - map[string]uint64: The namespace inodes by type
+//   - error: Error if a namespace has an unknown type or an invalid inode, or a type is given twice
+func ParseNamespaceFilters(specs []string) (map[string]uint64, error) {
+	filters := map[string]uint64{}
+	for _, spec := range specs {
+		nsType, value, found := strings.Cut(spec, "=")
+		if !found || !slices.Contains(tree.NamespaceTypes, nsType) {
+			return nil, fmt.Errorf("invalid namespace '%s'; expected <type>=<inode>, where <type> is one of: %s", spec, strings.Join(tree.NamespaceTypes, ", "))
+		}
+		if _, exists := filters[nsType]; exists {
+			return nil, fmt.Errorf("the %s namespace can only be given once", nsType)
+		}
+		inode, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			if inode, err = parseNamespaceLink(nsType, value); err != nil {
+				return nil, fmt.Errorf("invalid %s namespace inode '%s'", nsType, value)
+			}
+		}
+		filters[nsType] = inode
+	}
+	return filters, nil
+}
+
+// parseNSpids extracts the PIDs and process group IDs of a process in each of its nested
+// PID namespaces from the contents of /proc/<pid>/status, outermost first.
+//
+// Parameters:
+//   - data: Contents of /proc/<pid>/status
+//
+// Returns:
+//   - []int32: The PIDs from the NSpid line
+//   - []int32: The process group IDs from the NSpgid line
+//   - error: Error if the NSpid line is missing or malformed, e.g., on kernels before 4.1
+func parseNSpids(data string) ([]int32, []int32, error) {
+	var pids, pgids []int32
+	for _, line := range strings.Split(data, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found || (key != "NSpid" && key != "NSpgid") {
+			continue
+		}
+		ids := []int32{}
+		for _, field := range strings.Fields(value) {
+			id, err := strconv.ParseInt(field, 10, 32)
+			if err != nil {
+				return nil, nil, fmt.Errorf("malformed %s line '%s'", key, line)
+			}
+			ids = append(ids, int32(id))
+		}
+		if key == "NSpid" {
+			pids = ids
+		} else {
+			pgids = ids
+		}
+	}
+	if len(pids) == 0 {
+		return nil, nil, errors.New("no NSpid line found")
+	}
+	return pids, pgids, nil
+}
+
+// AsSeenBy reduces the processes to those visible in the PID namespace of a process,
+// with the PIDs they have in it, so that the tree shows what a containerized process
+// itself can see.
+//
+// A process is visible if it runs in the PID namespace of the target, or in a namespace
+// nested in it, as recognized by an ancestor running in the target's namespace. Processes
+// whose parent is outside the namespace, e.g., those started by docker exec, are shown
+// under the init process of the namespace. Namespaces must already be collected.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to reduce
+//   - pid: PID of the target process, as seen by pstree
+//
+// Returns:
+//   - error: Error if the target does not exist or its PID namespace cannot be read
+func AsSeenBy(processes *[]tree.Process, pid int32) error {
+	pidToIndex := make(map[int32]int, len(*processes))
+	for i := range *processes {
+		pidToIndex[(*processes)[i].PID] = i
+	}
+	targetIndex, exists := pidToIndex[pid]
+	if !exists {
+		return fmt.Errorf("process %d does not exist", pid)
+	}
+	targetPIDs, _, err := readNSpids(pid)
+	if err != nil {
+		return fmt.Errorf("failed to read the namespace PIDs of process %d: %w", pid, err)
+	}
+	level := len(targetPIDs) - 1
+	namespace, known := (*processes)[targetIndex].Namespaces["pid"]
+	if !known && level > 0 {
+		return fmt.Errorf("the PID namespace of process %d could not be read; reading the namespaces of other users' processes requires root", pid)
+	}
+
+	// Processes in nested namespaces are found through their ancestors
+	inNamespace := func(i int) bool {
+		for visited := 0; visited < len(*processes); visited++ {
+			proc := &(*processes)[i]
+			if proc.Namespaces["pid"] == namespace {
+				return true
+			}
+			parentIndex, exists := pidToIndex[proc.PPID]
+			if !exists || parentIndex == i {
+				return false
+			}
+			i = parentIndex
+		}
+		return false
+	}
+
+	hostToNamespace := map[int32]int32{}
+	visible := []tree.Process{}
+	for i := range *processes {
+		proc := (*processes)[i]
+		// Every process is visible in the initial namespace, even if its namespaces could not be read
+		if level > 0 && !inNamespace(i) {
+			continue
+		}
+		pids, pgids, err := readNSpids(proc.PID)
+		if err != nil || len(pids) <= level {
+			// The process exited, or runs in another namespace at the same depth
+			continue
+		}
+		hostToNamespace[proc.PID] = pids[level]
+		proc.PID = pids[level]
+		proc.PGID = 0
+		if len(pgids) > level {
+			proc.PGID = pgids[level]
+		}
+		visible = append(visible, proc)
+	}
+
+	for i := range visible {
+		proc := &visible[i]
+		ppid, exists := hostToNamespace[proc.PPID]
+		switch {
+		case proc.PID == 1 || proc.PPID == 0:
+			proc.PPID = 0
+		case exists:
+			proc.PPID = ppid
+		default:
+			proc.PPID = 1
+		}
+		threads := []tree.Thread{}
+		for _, thread := range proc.Threads {
+			tids, _, err := readNSpids(thread.TID)
+			if err != nil || len(tids) <= level {
+				continue
+			}
+			thread.PID = proc.PID
+			thread.PPID = proc.PPID
+			thread.PGID = proc.PGID
+			thread.TID = tids[level]
+			threads = append(threads, thread)
+		}
+		proc.Threads = threads
+	}
+
+	// The init process of the namespace is the root of the tree
+	slices.SortFunc(visible, func(a, b tree.Process) int {
+		return int(a.PID) - int(b.PID)
+	})
+	*processes = visible
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// readNamespaces returns the namespaces of a process from the links in /proc/<pid>/ns.
+//
+// Reading the namespaces of processes owned by other users requires root.
+//
+// Parameters:
+//   - pid: PID of the process
+//
+// Returns:
+//   - map[string]uint64: The namespace inodes by type
+//   - error: Error if none of the namespaces could be read
+func readNamespaces(pid int32) (map[string]uint64, error) {
+	namespaces := map[string]uint64{}
+	var errs []error
+	for _, nsType := range tree.NamespaceTypes {
+		link, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/%s", pid, nsType))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		inode, err := parseNamespaceLink(nsType, link)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		namespaces[nsType] = inode
+	}
+	if len(namespaces) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return namespaces, nil
+}
+
+// readNSpids returns the PIDs and process group IDs of a process or thread in each of
+// its nested PID namespaces from /proc/<pid>/status, outermost first.
+//
+// Parameters:
+//   - pid: PID of the process, or TID of the thread
+//
+// Returns:
+//   - []int32: The PIDs, the last one in the namespace of the process
+//   - []int32: The process group IDs, in the same order
+//   - error: Error if the file could not be read or has no NSpid line
+func readNSpids(pid int32) ([]int32, []int32, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseNSpids(string(data))
+}
@@ -0,0 +1,38 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"errors"
+)
+
+// readNamespaces returns the namespaces of a process.
+//
+// Namespaces only exist on Linux, so this always returns an error on other platforms.
+//
+// Parameters:
+//   - pid: PID of the process
+//
+// Returns:
+//   - map[string]uint64: Always nil
+//   - error: Always an error explaining that namespaces are Linux-only
+func readNamespaces(pid int32) (map[string]uint64, error) {
+	return nil, errors.New("namespaces are only supported on Linux")
+}
+
+// readNSpids returns the PIDs and process group IDs of a process in each of its nested
+// PID namespaces.
+//
+// Namespaces only exist on Linux, so this always returns an error on other platforms.
+//
+// Parameters:
+//   - pid: PID of the process, or TID of the thread
+//
+// Returns:
+//   - []int32: Always nil
+//   - []int32: Always nil
+//   - error: Always an error explaining that namespaces are Linux-only
+func readNSpids(pid int32) ([]int32, []int32, error) {
+	return nil, nil, errors.New("namespaces are only supported on Linux")
+}
@@ -0,0 +1,61 @@
+package pstree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+//------------------------------------------------------------------------------
+// PID LISTS
+//------------------------------------------------------------------------------
+// Functions in this section read the PIDs selected with --pids-from, so that
+// pstree can show the processes found by other tools, e.g., pgrep ssh | pstree --pids-from -.
+
+// ReadPIDs reads a list of PIDs separated by whitespace or newlines, e.g., the output
+// of pgrep or lsof -t.
+//
+// Parameters:
+//   - input: Reader to read the PIDs from
+//
+// Returns:
+//   - []int32: The PIDs, in input order
+//   - error: Error if the input could not be read or contains something other than a PID
+func ReadPIDs(input io.Reader) ([]int32, error) {
+	pids := []int32{}
+	scanner := bufio.NewScanner(input)
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		pid, err := strconv.ParseInt(scanner.Text(), 10, 32)
+		if err != nil || pid < 0 {
+			return nil, fmt.Errorf("invalid PID '%s'", scanner.Text())
+		}
+		pids = append(pids, int32(pid))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read PIDs: %w", err)
+	}
+	return pids, nil
+}
+
+// LoadPIDs reads a list of PIDs from a file, or from stdin if the path is -.
+//
+// Parameters:
+//   - path: Path of the file, or - for stdin
+//
+// Returns:
+//   - []int32: The PIDs, in input order
+//   - error: Error if the file could not be read or contains something other than a PID
+func LoadPIDs(path string) ([]int32, error) {
+	if path == "-" {
+		return ReadPIDs(os.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PID file: %w", err)
+	}
+	defer file.Close()
+	return ReadPIDs(file)
+}
@@ -0,0 +1,176 @@
+package pstree
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// PORT CONFLICT DETECTION
+//------------------------------------------------------------------------------
+// Functions in this section find ports that are listened on in more than one
+// network namespace, e.g., by two containers, which explains "port already in
+// use" errors when the port is published on the host.
+
+// Listener is a process listening on a port in a network namespace.
+type Listener struct {
+	PID       int32  // PID of the listening process
+	Namespace string // Network namespace of the process, e.g., net:[4026531840]
+	Protocol  string // Protocol of the socket, tcp or udp
+	Port      uint32 // Local port of the socket
+}
+
+// PortConflict is a port listened on in more than one network namespace.
+type PortConflict struct {
+	Protocol  string     // Protocol of the port, tcp or udp
+	Port      uint32     // The port
+	Listeners []Listener // The processes listening on the port, ordered by namespace and PID
+}
+
+// Name returns the protocol and port of the conflict, e.g., tcp/8080.
+//
+// Returns:
+//   - The protocol and port separated by a slash
+func (conflict PortConflict) Name() string {
+	return fmt.Sprintf("%s/%d", conflict.Protocol, conflict.Port)
+}
+
+// parseProcNet parses a socket table such as /proc/net/tcp or /proc/net/udp6 and returns
+// the local port of each listening socket, keyed by socket inode.
+//
+// Each line of the table looks like:
+//
+//	0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000 0 0 131090 ...
+//
+// where the second field is the local address with the port in hex, the fourth field is
+// the socket state, and the tenth field is the inode. TCP sockets listen in state 0A;
+// UDP sockets are bound without a peer in state 07.
+//
+// Parameters:
+//   - data: Contents of the socket table
+//   - protocol: Protocol of the table, tcp or udp
+//
+// Returns:
+//   - map[uint64]uint32: Local ports keyed by socket inode
+func parseProcNet(data string, protocol string) map[uint64]uint32 {
+	listening := "0A"
+	if protocol == "udp" {
+		listening = "07"
+	}
+	ports := make(map[uint64]uint32)
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || fields[3] != listening {
+			continue
+		}
+		_, portHex, found := strings.Cut(fields[1], ":")
+		if !found {
+			continue
+		}
+		port, err := strconv.ParseUint(portHex, 16, 16)
+		if err != nil || port == 0 {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil || inode == 0 {
+			continue
+		}
+		ports[inode] = uint32(port)
+	}
+	return ports
+}
+
+// FindPortConflicts groups the listeners by port and returns the ports listened on in more
+// than one network namespace.
+//
+// Processes sharing a socket in the same namespace, e.g., the workers of a web server,
+// are not conflicts.
+//
+// Parameters:
+//   - listeners: The listening processes, as returned by FindListeners
+//
+// Returns:
+//   - []PortConflict: The conflicts, ordered by protocol and port
+func FindPortConflicts(listeners []Listener) []PortConflict {
+	byPort := map[string][]Listener{}
+	for _, listener := range listeners {
+		key := fmt.Sprintf("%s/%d", listener.Protocol, listener.Port)
+		if !slices.Contains(byPort[key], listener) {
+			byPort[key] = append(byPort[key], listener)
+		}
+	}
+
+	conflicts := []PortConflict{}
+	for _, group := range byPort {
+		namespaces := map[string]bool{}
+		for _, listener := range group {
+			namespaces[listener.Namespace] = true
+		}
+		if len(namespaces) < 2 {
+			continue
+		}
+		slices.SortFunc(group, func(a, b Listener) int {
+			if a.Namespace != b.Namespace {
+				return strings.Compare(a.Namespace, b.Namespace)
+			}
+			return int(a.PID) - int(b.PID)
+		})
+		conflicts = append(conflicts, PortConflict{Protocol: group[0].Protocol, Port: group[0].Port, Listeners: group})
+	}
+	slices.SortFunc(conflicts, func(a, b PortConflict) int {
+		if a.Protocol != b.Protocol {
+			return strings.Compare(a.Protocol, b.Protocol)
+		}
+		return int(a.Port) - int(b.Port)
+	})
+	return conflicts
+}
+
+// MarkPortConflicts records the conflicting ports each process listens on.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//   - conflicts: The conflicts found by FindPortConflicts
+func MarkPortConflicts(processes *[]tree.Process, conflicts []PortConflict) {
+	ports := map[int32][]string{}
+	for _, conflict := range conflicts {
+		for _, listener := range conflict.Listeners {
+			if !slices.Contains(ports[listener.PID], conflict.Name()) {
+				ports[listener.PID] = append(ports[listener.PID], conflict.Name())
+			}
+		}
+	}
+	for i := range *processes {
+		if names, ok := ports[(*processes)[i].PID]; ok {
+			(*processes)[i].PortConflicts = names
+		}
+	}
+}
+
+// WritePortConflicts writes a warning for each conflict, grouping its listeners by
+// network namespace.
+//
+// Parameters:
+//   - output: Writer to write the warnings to, usually stderr
+//   - conflicts: The conflicts found by FindPortConflicts
+//   - processes: The collected processes, used to name the listeners
+func WritePortConflicts(output io.Writer, conflicts []PortConflict, processes []tree.Process) {
+	commands := make(map[int32]string, len(processes))
+	for _, proc := range processes {
+		commands[proc.PID] = proc.Command
+	}
+	for _, conflict := range conflicts {
+		fmt.Fprintf(output, "pstree: %s is listened on in more than one network namespace\n", conflict.Name())
+		for i, listener := range conflict.Listeners {
+			if i == 0 || listener.Namespace != conflict.Listeners[i-1].Namespace {
+				fmt.Fprintf(output, "  %s:\n", listener.Namespace)
+			}
+			fmt.Fprintf(output, "    PID %d (%s)\n", listener.PID, commands[listener.PID])
+		}
+	}
+}
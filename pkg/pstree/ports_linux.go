@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// FindListeners returns the processes listening on a TCP or UDP port, together with the
+// network namespace each one runs in.
+//
+// The socket tables of each namespace are read once, through the first process found in
+// it, and matched against the socket inodes in /proc/<pid>/fd. Processes whose namespace
+// or descriptors cannot be read, e.g., due to permissions, are skipped.
+//
+// Parameters:
+//   - processes: The collected processes
+//
+// Returns:
+//   - []Listener: The listening processes
+//   - error: Error if no network namespace could be read
+func FindListeners(processes []tree.Process) ([]Listener, error) {
+	tables := map[string]map[string]map[uint64]uint32{}
+	listeners := []Listener{}
+	for _, proc := range processes {
+		procDir := fmt.Sprintf("/proc/%d", proc.PID)
+		namespace, err := os.Readlink(procDir + "/ns/net")
+		if err != nil {
+			continue
+		}
+		if _, exists := tables[namespace]; !exists {
+			tables[namespace] = readSocketTables(procDir)
+		}
+
+		entries, err := os.ReadDir(procDir + "/fd")
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			target, err := os.Readlink(procDir + "/fd/" + entry.Name())
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			for protocol, ports := range tables[namespace] {
+				if port, ok := ports[inode]; ok {
+					listeners = append(listeners, Listener{PID: proc.PID, Namespace: namespace, Protocol: protocol, Port: port})
+				}
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("failed to read the network namespace of any process")
+	}
+	return listeners, nil
+}
+
+// readSocketTables reads the IPv4 and IPv6 socket tables of the network namespace of a process.
+//
+// Parameters:
+//   - procDir: The /proc directory of the process
+//
+// Returns:
+//   - map[string]map[uint64]uint32: Local ports keyed by socket inode, for tcp and udp
+func readSocketTables(procDir string) map[string]map[uint64]uint32 {
+	tables := map[string]map[uint64]uint32{"tcp": {}, "udp": {}}
+	for protocol := range tables {
+		for _, table := range []string{protocol, protocol + "6"} {
+			data, err := os.ReadFile(procDir + "/net/" + table)
+			if err != nil {
+				continue
+			}
+			for inode, port := range parseProcNet(string(data), protocol) {
+				tables[protocol][inode] = port
+			}
+		}
+	}
+	return tables
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"errors"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// FindListeners returns the processes listening on a port and their network namespaces.
+//
+// Network namespaces only exist on Linux, so this function always returns an error on
+// other platforms.
+//
+// Parameters:
+//   - processes: The collected processes
+//
+// Returns:
+//   - []Listener: Always nil
+//   - error: Error indicating that the operation is not supported
+func FindListeners(processes []tree.Process) ([]Listener, error) {
+	return nil, errors.New("--port-conflicts is only supported on Linux")
+}
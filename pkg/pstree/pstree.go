@@ -6,20 +6,24 @@
 package pstree
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdanko/pstree/pkg/metrics"
 	"github.com/gdanko/pstree/pkg/tree"
 	"github.com/gdanko/pstree/util"
 	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/net"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
@@ -71,17 +75,38 @@ func GetProcessByPid(processes *[]tree.Process, pid int32) (proc tree.Process, e
 // Parameters:
 //   - processes: Pointer to a slice of Process structs to be sorted
 func SortProcsByAge(processes *[]tree.Process) {
-	sort.Slice(*processes, func(i, j int) bool {
+	sort.SliceStable(*processes, func(i, j int) bool {
 		return (*processes)[i].Age < (*processes)[j].Age
 	})
 }
 
+// SortProcsByCreateTime sorts the processes slice by creation time in ascending order,
+// so that children are listed in the order they started.
+//
+// The init process is kept first since it is the root of the tree. Processes created
+// in the same millisecond are ordered by PID.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to be sorted
+func SortProcsByCreateTime(processes *[]tree.Process) {
+	sort.SliceStable(*processes, func(i, j int) bool {
+		left, right := (*processes)[i], (*processes)[j]
+		if left.PID == 1 || right.PID == 1 {
+			return left.PID == 1 && right.PID != 1
+		}
+		if left.CreateTime != right.CreateTime {
+			return left.CreateTime < right.CreateTime
+		}
+		return left.PID < right.PID
+	})
+}
+
 // SortProcsByCmd sorts the processes slice by command name in ascending order.
 //
 // Parameters:
 //   - processes: Pointer to a slice of Process structs to be sorted
 func SortProcsByCmd(processes *[]tree.Process) {
-	sort.Slice(*processes, func(i, j int) bool {
+	sort.SliceStable(*processes, func(i, j int) bool {
 		return (*processes)[i].Command < (*processes)[j].Command
 	})
 }
@@ -91,7 +116,7 @@ func SortProcsByCmd(processes *[]tree.Process) {
 // Parameters:
 //   - processes: Pointer to a slice of Process structs to be sorted
 func SortProcsByCpu(processes *[]tree.Process) {
-	sort.Slice(*processes, func(i, j int) bool {
+	sort.SliceStable(*processes, func(i, j int) bool {
 		return (*processes)[i].CPUPercent < (*processes)[j].CPUPercent
 	})
 }
@@ -101,17 +126,27 @@ func SortProcsByCpu(processes *[]tree.Process) {
 // Parameters:
 //   - processes: Pointer to a slice of Process structs to be sorted
 func SortProcsByMemory(processes *[]tree.Process) {
-	sort.Slice(*processes, func(i, j int) bool {
+	sort.SliceStable(*processes, func(i, j int) bool {
 		return float64((*processes)[i].MemoryInfo.RSS) < float64((*processes)[j].MemoryInfo.RSS)
 	})
 }
 
+// SortProcsByIO sorts the processes slice by the bytes read and written in ascending order.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to be sorted
+func SortProcsByIO(processes *[]tree.Process) {
+	sort.SliceStable(*processes, func(i, j int) bool {
+		return (*processes)[i].IOBytes() < (*processes)[j].IOBytes()
+	})
+}
+
 // SortProcsByUsername sorts the processes slice by username in ascending alphabetical order.
 //
 // Parameters:
 //   - processes: Pointer to a slice of Process structs to be sorted
 func SortProcsByUsername(processes *[]tree.Process) {
-	sort.Slice(*processes, func(i, j int) bool {
+	sort.SliceStable(*processes, func(i, j int) bool {
 		return (*processes)[i].Username < (*processes)[j].Username
 	})
 }
@@ -121,7 +156,7 @@ func SortProcsByUsername(processes *[]tree.Process) {
 // Parameters:
 //   - processes: Pointer to a slice of Process structs to be sorted
 func SortProcsByPid(processes *[]tree.Process) {
-	sort.Slice(*processes, func(i, j int) bool {
+	sort.SliceStable(*processes, func(i, j int) bool {
 		return (*processes)[i].PID < (*processes)[j].PID
 	})
 }
@@ -131,7 +166,7 @@ func SortProcsByPid(processes *[]tree.Process) {
 // Parameters:
 //   - processes: Pointer to a slice of Process structs to be sorted
 func SortProcsByNumThreads(processes *[]tree.Process) {
-	sort.Slice(*processes, func(i, j int) bool {
+	sort.SliceStable(*processes, func(i, j int) bool {
 		return (*processes)[i].NumThreads < (*processes)[j].NumThreads
 	})
 }
@@ -141,159 +176,279 @@ func SortProcsByNumThreads(processes *[]tree.Process) {
 //------------------------------------------------------------------------------
 // Functions in this section handle gathering detailed process information.
 
-// generateProcess creates a Process struct from a process.Process pointer.
+// CollectOptions selects what is collected for each process and how.
+type CollectOptions struct {
+	Cache           *ProcessCache // The process cache, or nil to read every process
+	GenerateThreads bool          // Whether to simulate thread data on Darwin for testing
+	Metrics         MetricSet     // The optional metrics to collect
+	Workers         int           // Number of processes to generate concurrently; 0 or less uses the number of CPUs
+}
+
+// GenerateProcess creates a Process struct from a process.Process pointer, collecting every
+// optional metric.
+//
+// Parameters:
+//   - proc: Pointer to a process.Process struct from which to generate the Process
+//
+// Returns:
+//   - A new Process struct populated with information from the input process
+func GenerateProcess(proc *process.Process) tree.Process {
+	return GenerateProcessWith(proc, CollectOptions{Metrics: MetricsAll})
+}
+
+// GenerateProcessWith creates a Process struct from a process.Process pointer.
 // It collects various process attributes using goroutines and channels for concurrent execution
 // to improve performance when gathering process information.
 //
+// Only the optional metrics in options.Metrics are collected. Metrics that were not collected
+// are recorded as unavailable, so that they are never mistaken for a measurement of zero.
+// The command, arguments, and owner are taken from options.Cache when it has the process.
+//
 // Parameters:
 //   - proc: Pointer to a process.Process struct from which to generate the Process
+//   - options: What to collect for the process, and the process cache
 //
 // Returns:
 //   - A new Process struct populated with information from the input process
-func GenerateProcess(proc *process.Process) tree.Process {
+func GenerateProcessWith(proc *process.Process, options CollectOptions) tree.Process {
 	var (
-		args          []string
-		command       string
-		cpuPercent    float64
-		cpuTimes      *cpu.TimesStat
-		createTime    int64
-		environment   []string
-		err           error
-		gids          []uint32
-		groupName     string = "unknown"
-		groupsMap     map[uint32]string
-		pgid          int
-		pid           int32
-		ppid          int32
-		memoryInfo    *process.MemoryInfoStat
-		memoryPercent float32
-		numFDs        int32
-		numThreads    int32
-		openFiles     []process.OpenFilesStat
-		threads       map[int32]*cpu.TimesStat
-		uids          []uint32
-		username      string
+		args              []string
+		cgroup            string
+		command           string
+		cpuPercent        float64
+		cpuTimes          *cpu.TimesStat
+		createTime        int64
+		details           *tree.ProcessDetails
+		err               error
+		gids              []uint32
+		groupName         string = "unknown"
+		groupsMap         map[uint32]string
+		ioCounters        *process.IOCountersStat
+		pgid              int
+		pid               int32
+		ppid              int32
+		priority          *tree.Priority
+		memoryInfo        *process.MemoryInfoStat
+		memoryPercent     float32
+		namespaces        map[string]uint64
+		numFDs            int32
+		numThreads        int32
+		schedLatency      float64
+		status            []string
+		supplementaryGIDs []uint32
+		threadNames       map[int32]string
+		threads           map[int32]*cpu.TimesStat
+		uids              []uint32
+		unavailable       tree.Field
+		unit              string
+		username          string
 	)
 
 	pid = proc.Pid
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	argsChannel := make(chan func(ctx context.Context, proc *process.Process) (args []string, err error))
-	go metrics.ProcessArgs(argsChannel)
-	argsOut, err := (<-argsChannel)(ctx, proc)
+	createTimeChannel := make(chan func(ctx context.Context, proc *process.Process) (createTime int64, err error))
+	go metrics.ProcessCreateTime(createTimeChannel)
+	createTimeOut, err := (<-createTimeChannel)(ctx, proc)
 	if err != nil {
-		args = []string{}
+		createTime = 0
+		unavailable |= tree.FieldAge
 	} else {
-		args = argsOut
+		createTime = createTimeOut
 	}
 
-	commandNameChannel := make(chan func(ctx context.Context, proc *process.Process) (string, error))
-	go metrics.ProcessCommandName(commandNameChannel)
-	commandOut, err := (<-commandNameChannel)(ctx, proc)
-	if err != nil {
-		command = "?"
+	// The facts that never change during the life of a process are read from the cache when it has them
+	cached, cacheHit := options.Cache.Lookup(pid, createTime)
+	if cacheHit {
+		args, command, uids, username = cached.Args, cached.Command, cached.UIDs, cached.Username
 	} else {
-		command = commandOut
+		argsChannel := make(chan func(ctx context.Context, proc *process.Process) (args []string, err error))
+		go metrics.ProcessArgs(argsChannel)
+		argsOut, err := (<-argsChannel)(ctx, proc)
+		if err != nil {
+			args = []string{}
+		} else {
+			args = argsOut
+		}
+
+		commandNameChannel := make(chan func(ctx context.Context, proc *process.Process) (string, error))
+		go metrics.ProcessCommandName(commandNameChannel)
+		commandOut, err := (<-commandNameChannel)(ctx, proc)
+		if err != nil {
+			command = "?"
+		} else {
+			command = commandOut
+		}
 	}
 
-	cpuPercentChannel := make(chan func(ctx context.Context, proc *process.Process) (cpuPercent float64, err error))
-	go metrics.ProcessCpuPercent(cpuPercentChannel)
-	cpuPercentOut, err := (<-cpuPercentChannel)(ctx, proc)
-	if err != nil {
-		cpuPercent = -1
+	if options.Metrics.Has(MetricCPU) {
+		cpuPercentChannel := make(chan func(ctx context.Context, proc *process.Process) (cpuPercent float64, err error))
+		go metrics.ProcessCpuPercent(cpuPercentChannel)
+		cpuPercentOut, err := (<-cpuPercentChannel)(ctx, proc)
+		if err != nil {
+			cpuPercent = 0
+			unavailable |= tree.FieldCPUPercent
+		} else {
+			cpuPercent = cpuPercentOut
+		}
+
+		cpuTimesChannel := make(chan func(ctx context.Context, proc *process.Process) (cpuTimes *cpu.TimesStat, err error))
+		go metrics.ProcessCpuTimes(cpuTimesChannel)
+		cpuTimesOut, err := (<-cpuTimesChannel)(ctx, proc)
+		if err != nil {
+			cpuTimes = &cpu.TimesStat{}
+		} else {
+			cpuTimes = cpuTimesOut
+		}
 	} else {
-		cpuPercent = cpuPercentOut
+		cpuTimes = &cpu.TimesStat{}
+		unavailable |= tree.FieldCPUPercent
 	}
 
-	cpuTimesChannel := make(chan func(ctx context.Context, proc *process.Process) (cpuTimes *cpu.TimesStat, err error))
-	go metrics.ProcessCpuTimes(cpuTimesChannel)
-	cpuTimesOut, err := (<-cpuTimesChannel)(ctx, proc)
-	if err != nil {
-		cpuTimes = &cpu.TimesStat{}
+	if options.Metrics.Has(MetricCgroup) {
+		cgroupOut, err := readCgroup(pid)
+		if err != nil {
+			unavailable |= tree.FieldCgroup | tree.FieldUnit
+		} else {
+			cgroup = cgroupOut
+			unit = unitFromCgroup(cgroup)
+		}
 	} else {
-		cpuTimes = cpuTimesOut
+		unavailable |= tree.FieldCgroup | tree.FieldUnit
 	}
 
-	createTimeChannel := make(chan func(ctx context.Context, proc *process.Process) (createTime int64, err error))
-	go metrics.ProcessCreateTime(createTimeChannel)
-	createTimeOut, err := (<-createTimeChannel)(ctx, proc)
-	if err != nil {
-		createTime = -1
+	if options.Metrics.Has(MetricNamespaces) {
+		namespacesOut, err := readNamespaces(pid)
+		if err != nil {
+			unavailable |= tree.FieldNamespaces
+		} else {
+			namespaces = namespacesOut
+		}
 	} else {
-		createTime = createTimeOut
+		unavailable |= tree.FieldNamespaces
 	}
 
-	environmentChannel := make(chan func(ctx context.Context, proc *process.Process) (environment []string, err error))
-	go metrics.ProcessEnvironment(environmentChannel)
-	environmentOut, err := (<-environmentChannel)(ctx, proc)
-	if err != nil {
-		environment = []string{}
-	} else {
-		environment = environmentOut
+	// The large optional data is only allocated for the processes of options that need it
+	if options.Metrics.Has(MetricEnvironment) || options.Metrics.Has(MetricOpenFiles) {
+		details = &tree.ProcessDetails{Environment: []string{}, OpenFiles: []process.OpenFilesStat{}}
 	}
 
-	gidsChannel := make(chan func(ctx context.Context, proc *process.Process) (gids []uint32, groups map[uint32]string, err error))
-	go metrics.ProcessGIDs(gidsChannel)
-	gidsOut, groupsMap, err := (<-gidsChannel)(ctx, proc)
-	if err != nil {
-		gids = []uint32{}
+	if options.Metrics.Has(MetricEnvironment) {
+		environmentChannel := make(chan func(ctx context.Context, proc *process.Process) (environment []string, err error))
+		go metrics.ProcessEnvironment(environmentChannel)
+		environmentOut, err := (<-environmentChannel)(ctx, proc)
+		if err == nil {
+			details.Environment = environmentOut
+		}
+	}
+
+	if options.Metrics.Has(MetricGroup) {
+		gidsChannel := make(chan func(ctx context.Context, proc *process.Process) (gids []uint32, groups map[uint32]string, err error))
+		go metrics.ProcessGIDs(gidsChannel)
+		gidsOut, groupsOut, err := (<-gidsChannel)(ctx, proc)
+		if err != nil {
+			gids = []uint32{}
+		} else {
+			gids = gidsOut
+		}
+		groupsMap = groupsOut
 	} else {
-		gids = gidsOut
+		gids = []uint32{}
 	}
 
-	// groupsChannel := make(chan func(ctx context.Context, proc *process.Process) (groups []uint32, err error))
-	// go metrics.ProcessGroups(groupsChannel)
-	// groupsOut, err := (<-groupsChannel)(ctx, proc)
-	// if err != nil {
-	// 	groups = []uint32{}
-	// } else {
-	// 	groups = groupsOut
-	// }
+	if options.Metrics.Has(MetricGroup) {
+		groupsChannel := make(chan func(ctx context.Context, proc *process.Process) (groups []uint32, err error))
+		go metrics.ProcessGroups(groupsChannel)
+		groupsOut, err := (<-groupsChannel)(ctx, proc)
+		if err == nil {
+			supplementaryGIDs = groupsOut
+			if groupsMap == nil {
+				groupsMap = make(map[uint32]string, len(groupsOut))
+			}
+			for _, gid := range groupsOut {
+				if name, ok := metrics.LookupGroupName(gid); ok {
+					groupsMap[gid] = name
+				}
+			}
+		}
+	}
 
-	memoryInfoChannel := make(chan func(ctx context.Context, proc *process.Process) (memoryInfo *process.MemoryInfoStat, err error))
-	go metrics.ProcessMemoryInfo(memoryInfoChannel)
-	memoryInfoOut, err := (<-memoryInfoChannel)(ctx, proc)
-	if err != nil {
-		memoryInfo = &process.MemoryInfoStat{}
+	if options.Metrics.Has(MetricIO) {
+		ioCountersChannel := make(chan func(ctx context.Context, proc *process.Process) (ioCounters *process.IOCountersStat, err error))
+		go metrics.ProcessIOCounters(ioCountersChannel)
+		ioCountersOut, err := (<-ioCountersChannel)(ctx, proc)
+		if err != nil {
+			ioCounters = &process.IOCountersStat{}
+			unavailable |= tree.FieldIO
+		} else {
+			ioCounters = ioCountersOut
+		}
 	} else {
-		memoryInfo = memoryInfoOut
+		ioCounters = &process.IOCountersStat{}
+		unavailable |= tree.FieldIO
 	}
 
-	memoryPercentChannel := make(chan func(ctx context.Context, proc *process.Process) (memoryPercent float32, err error))
-	go metrics.ProcessMemoryPercent(memoryPercentChannel)
-	memoryPercentOut, err := (<-memoryPercentChannel)(ctx, proc)
-	if err != nil {
-		memoryPercent = -1.0
+	if options.Metrics.Has(MetricMemory) {
+		memoryInfoChannel := make(chan func(ctx context.Context, proc *process.Process) (memoryInfo *process.MemoryInfoStat, err error))
+		go metrics.ProcessMemoryInfo(memoryInfoChannel)
+		memoryInfoOut, err := (<-memoryInfoChannel)(ctx, proc)
+		if err != nil {
+			memoryInfo = &process.MemoryInfoStat{}
+			unavailable |= tree.FieldMemory
+		} else {
+			memoryInfo = memoryInfoOut
+		}
+
+		memoryPercentChannel := make(chan func(ctx context.Context, proc *process.Process) (memoryPercent float32, err error))
+		go metrics.ProcessMemoryPercent(memoryPercentChannel)
+		memoryPercentOut, err := (<-memoryPercentChannel)(ctx, proc)
+		if err != nil {
+			memoryPercent = 0
+			unavailable |= tree.FieldMemory
+		} else {
+			memoryPercent = memoryPercentOut
+		}
 	} else {
-		memoryPercent = memoryPercentOut
+		memoryInfo = &process.MemoryInfoStat{}
+		unavailable |= tree.FieldMemory
 	}
 
-	numFDsChannel := make(chan func(ctx context.Context, proc *process.Process) (numFDs int32, err error))
-	go metrics.ProcessNumFDs(numFDsChannel)
-	numFDsOut, err := (<-numFDsChannel)(ctx, proc)
-	if err != nil {
-		numFDs = -1
+	if options.Metrics.Has(MetricNumFDs) {
+		numFDsChannel := make(chan func(ctx context.Context, proc *process.Process) (numFDs int32, err error))
+		go metrics.ProcessNumFDs(numFDsChannel)
+		numFDsOut, err := (<-numFDsChannel)(ctx, proc)
+		if err != nil {
+			numFDs = 0
+			unavailable |= tree.FieldNumFDs
+		} else {
+			numFDs = numFDsOut
+		}
 	} else {
-		numFDs = numFDsOut
+		unavailable |= tree.FieldNumFDs
 	}
 
-	openFilesChannel := make(chan func(ctx context.Context, proc *process.Process) ([]process.OpenFilesStat, error))
-	go metrics.ProcessOpenFiles(openFilesChannel)
-	openFilesOut, err := (<-openFilesChannel)(ctx, proc)
-	if err != nil {
-		openFiles = []process.OpenFilesStat{}
-	} else {
-		openFiles = openFilesOut
+	if options.Metrics.Has(MetricOpenFiles) {
+		openFilesChannel := make(chan func(ctx context.Context, proc *process.Process) ([]process.OpenFilesStat, error))
+		go metrics.ProcessOpenFiles(openFilesChannel)
+		openFilesOut, err := (<-openFilesChannel)(ctx, proc)
+		if err == nil {
+			details.OpenFiles = openFilesOut
+		}
 	}
 
-	numThreadsChannel := make(chan func(ctx context.Context, proc *process.Process) (numThreads int32, err error))
-	go metrics.ProcessNumThreads(numThreadsChannel)
-	numThreadsOut, err := (<-numThreadsChannel)(ctx, proc)
-	if err != nil {
-		numThreads = -1
+	if options.Metrics.Has(MetricNumThreads) {
+		numThreadsChannel := make(chan func(ctx context.Context, proc *process.Process) (numThreads int32, err error))
+		go metrics.ProcessNumThreads(numThreadsChannel)
+		numThreadsOut, err := (<-numThreadsChannel)(ctx, proc)
+		if err != nil {
+			numThreads = 0
+			unavailable |= tree.FieldNumThreads
+		} else {
+			numThreads = numThreadsOut
+		}
 	} else {
-		numThreads = numThreadsOut
+		unavailable |= tree.FieldNumThreads
 	}
 
 	pgidChannel := make(chan func(proc *process.Process) (pgid int, err error))
@@ -314,31 +469,111 @@ func GenerateProcess(proc *process.Process) tree.Process {
 		ppid = ppidOut
 	}
 
-	threadsChannel := make(chan func(ctx context.Context, proc *process.Process) (threads map[int32]*cpu.TimesStat, err error))
-	go metrics.ProcessThreads(threadsChannel)
-	threadsOut, err := (<-threadsChannel)(ctx, proc)
-	if err != nil {
-		threads = map[int32]*cpu.TimesStat{}
+	if options.Metrics.Has(MetricPriority) {
+		priority = &tree.Priority{}
+		niceChannel := make(chan func(ctx context.Context, proc *process.Process) (nice int32, err error))
+		go metrics.ProcessNice(niceChannel)
+		niceOut, err := (<-niceChannel)(ctx, proc)
+		if err != nil {
+			unavailable |= tree.FieldPriority
+		} else {
+			priority.Nice = niceOut
+		}
+
+		// The scheduling classes are only known on Linux, and are left empty elsewhere
+		schedPolicyChannel := make(chan func(ctx context.Context, proc *process.Process) (policy string, rtPriority int32, err error))
+		go metrics.ProcessSchedPolicy(schedPolicyChannel)
+		if policy, rtPriority, err := (<-schedPolicyChannel)(ctx, proc); err == nil {
+			priority.Policy = policy
+			priority.RTPriority = rtPriority
+		}
+		ioPriorityChannel := make(chan func(ctx context.Context, proc *process.Process) (class string, level int32, err error))
+		go metrics.ProcessIOPriority(ioPriorityChannel)
+		if class, level, err := (<-ioPriorityChannel)(ctx, proc); err == nil {
+			priority.IOClass = class
+			priority.IOLevel = level
+		}
 	} else {
-		threads = threadsOut
+		priority = &tree.Priority{}
+		unavailable |= tree.FieldPriority
 	}
 
-	usernameChannel := make(chan func(ctx context.Context, proc *process.Process) (username string, err error))
-	go metrics.ProcessUsername(usernameChannel)
-	usernameOut, err := (<-usernameChannel)(ctx, proc)
-	if err != nil {
-		username = "?"
+	if options.Metrics.Has(MetricSchedLatency) {
+		schedLatencyChannel := make(chan func(ctx context.Context, proc *process.Process) (schedLatency float64, err error))
+		go metrics.ProcessSchedLatency(schedLatencyChannel)
+		schedLatencyOut, err := (<-schedLatencyChannel)(ctx, proc)
+		if err != nil {
+			schedLatency = 0
+			unavailable |= tree.FieldSchedLatency
+		} else {
+			schedLatency = schedLatencyOut
+		}
 	} else {
-		username = usernameOut
+		unavailable |= tree.FieldSchedLatency
 	}
 
-	uidsChannel := make(chan func(ctx context.Context, proc *process.Process) (uids []uint32, err error))
-	go metrics.ProcessUIDs(uidsChannel)
-	uidsOut, err := (<-uidsChannel)(ctx, proc)
-	if err != nil {
-		uids = []uint32{}
+	if options.Metrics.Has(MetricState) {
+		statusChannel := make(chan func(ctx context.Context, proc *process.Process) (status []string, err error))
+		go metrics.ProcessStatus(statusChannel)
+		statusOut, err := (<-statusChannel)(ctx, proc)
+		if err != nil {
+			status = []string{}
+			unavailable |= tree.FieldState
+		} else {
+			status = statusOut
+		}
 	} else {
-		uids = uidsOut
+		status = []string{}
+		unavailable |= tree.FieldState
+	}
+
+	if options.Metrics.Has(MetricThreads) {
+		threadNamesChannel := make(chan func(ctx context.Context, proc *process.Process) (names map[int32]string, err error))
+		go metrics.ProcessThreadNames(threadNamesChannel)
+		threadNamesOut, err := (<-threadNamesChannel)(ctx, proc)
+		if err != nil {
+			threadNames = map[int32]string{}
+		} else {
+			threadNames = threadNamesOut
+		}
+
+		threadsChannel := make(chan func(ctx context.Context, proc *process.Process) (threads map[int32]*cpu.TimesStat, err error))
+		go metrics.ProcessThreads(threadsChannel)
+		threadsOut, err := (<-threadsChannel)(ctx, proc)
+		if err != nil {
+			threads = map[int32]*cpu.TimesStat{}
+		} else {
+			threads = threadsOut
+		}
+	} else {
+		threadNames = map[int32]string{}
+		threads = map[int32]*cpu.TimesStat{}
+	}
+
+	if !cacheHit {
+		usernameChannel := make(chan func(ctx context.Context, proc *process.Process) (username string, err error))
+		go metrics.ProcessUsername(usernameChannel)
+		usernameOut, err := (<-usernameChannel)(ctx, proc)
+		if err != nil {
+			username = "?"
+		} else {
+			username = usernameOut
+		}
+
+		uidsChannel := make(chan func(ctx context.Context, proc *process.Process) (uids []uint32, err error))
+		go metrics.ProcessUIDs(uidsChannel)
+		uidsOut, err := (<-uidsChannel)(ctx, proc)
+		if err != nil {
+			uids = []uint32{}
+		} else {
+			uids = uidsOut
+		}
+
+		// Facts that could not be read are read again next time, e.g., for a process that was still starting.
+		// Windows has no numeric user IDs, so only the username is required there.
+		if command != "?" && username != "?" && (len(uids) > 0 || runtime.GOOS == "windows") {
+			options.Cache.Store(pid, createTime, CachedProcess{Args: args, Command: command, UIDs: uids, Username: username})
+		}
 	}
 
 	if len(args) > 0 {
@@ -358,6 +593,7 @@ func GenerateProcess(proc *process.Process) tree.Process {
 				Args:     args,
 				Command:  filepath.Base(command),
 				CPUTimes: thread,
+				Name:     threadNames[threadID],
 				PGID:     int32(pgid),
 				PID:      pid,
 				PPID:     ppid,
@@ -367,53 +603,156 @@ func GenerateProcess(proc *process.Process) tree.Process {
 	}
 
 	// Try to determine the group name from the groups map if available
-	// and if the first UID is present in the map. This is to ensure
+	// and if the first GID is present in the map. This is to ensure
 	// we have a valid group name for the process.
 	if len(gids) > 0 {
-		for _, groupName = range groupsMap {
-			break
+		if name, ok := groupsMap[gids[0]]; ok {
+			groupName = name
 		}
 	}
 
+	// Without a creation time the age would be measured from the epoch
+	var age int64
+	if unavailable&tree.FieldAge == 0 {
+		age = util.GetUnixTimestamp() - createTime
+	}
+
 	return tree.Process{
-		Age:           util.GetUnixTimestamp() - createTime,
-		Args:          args,
-		Child:         -1,
-		Children:      &[]tree.Process{},
-		Command:       command,
-		Connections:   []net.ConnectionStat{},
-		CPUPercent:    util.RoundFloat(cpuPercent, 2),
-		CPUTimes:      cpuTimes,
-		CreateTime:    createTime,
-		Environment:   environment,
-		GIDs:          gids,
-		Group:         groupName,
-		Groups:        groupsMap,
-		MemoryInfo:    memoryInfo,
-		MemoryPercent: memoryPercent,
-		NumFDs:        numFDs,
-		NumThreads:    numThreads,
-		OpenFiles:     openFiles,
-		Parent:        -1,
-		PGID:          int32(pgid),
-		PID:           pid,
-		PPID:          ppid,
-		Sister:        -1,
-		Threads:       processThreads,
-		UIDs:          uids,
-		Username:      username,
+		Age:               age,
+		Args:              args,
+		Cgroup:            cgroup,
+		Child:             -1,
+		Children:          &[]tree.Process{},
+		Command:           command,
+		CPUPercent:        util.RoundFloat(cpuPercent, 2),
+		CPUTimes:          cpuTimes,
+		CreateTime:        createTime,
+		GIDs:              gids,
+		Group:             groupName,
+		Groups:            groupsMap,
+		IOCounters:        ioCounters,
+		MemoryInfo:        memoryInfo,
+		MemoryPercent:     memoryPercent,
+		Namespaces:        namespaces,
+		NumFDs:            numFDs,
+		NumThreads:        numThreads,
+		Parent:            -1,
+		PGID:              int32(pgid),
+		ProcessDetails:    details,
+		PID:               pid,
+		PPID:              ppid,
+		Priority:          priority,
+		SchedLatency:      schedLatency,
+		Sister:            -1,
+		Status:            status,
+		SupplementaryGIDs: supplementaryGIDs,
+		Threads:           processThreads,
+		UIDs:              uids,
+		Unavailable:       unavailable,
+		Unit:              unit,
+		Username:          username,
+	}
+}
+
+// StabilizeProcesses removes run-to-run variance from the processes slice so that
+// rendering it produces byte-stable output suitable for snapshot and golden-file tests.
+//
+// Volatile fields (age, CPU, memory, thread count, descriptor count, IO, scheduling latency) are
+// zeroed, threads, which come and go with the load, and pstree itself, which is a new process on
+// every run, are left out, and processes are ordered by command, arguments, and PID, so that
+// siblings keep their order when a restarted process gets a new PID.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to stabilize
+func StabilizeProcesses(processes *[]tree.Process) {
+	myPid := int32(os.Getpid())
+	*processes = slices.DeleteFunc(*processes, func(proc tree.Process) bool {
+		return proc.PID == myPid
+	})
+	for i := range *processes {
+		proc := &(*processes)[i]
+		proc.Age = 0
+		proc.CPUPercent = 0
+		proc.CPUTimes = &cpu.TimesStat{}
+		proc.CreateTime = 0
+		proc.IOCounters = &process.IOCountersStat{}
+		proc.MemoryInfo = &process.MemoryInfoStat{}
+		proc.MemoryPercent = 0
+		proc.NumFDs = 0
+		proc.NumThreads = 0
+		proc.SchedLatency = 0
+		proc.Threads = []tree.Thread{}
+		proc.Unavailable = 0
+	}
+	slices.SortStableFunc(*processes, func(a, b tree.Process) int {
+		if compared := strings.Compare(a.Command, b.Command); compared != 0 {
+			return compared
+		}
+		if compared := slices.Compare(a.Args, b.Args); compared != 0 {
+			return compared
+		}
+		return cmp.Compare(a.PID, b.PID)
+	})
+}
+
+// collectConcurrently generates a Process for each input with a bounded pool of workers.
+//
+// Parameters:
+//   - procs: The processes to generate, in the desired output order
+//   - workers: Number of workers; 0 or less uses the number of CPUs, and it is capped at len(procs)
+//   - generate: Function generating a Process; it must be safe for concurrent use
+//
+// Returns:
+//   - []tree.Process: The generated processes, in the order of procs
+func collectConcurrently(procs []*process.Process, workers int, generate func(*process.Process) tree.Process) []tree.Process {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
 	}
+	workers = min(workers, len(procs))
+
+	generated := make([]tree.Process, len(procs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each worker writes only to the slots of the indices it receives
+			for i := range jobs {
+				generated[i] = generate(procs[i])
+			}
+		}()
+	}
+	for i := range procs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return generated
 }
 
-// GetProcesses retrieves all system processes and populates the provided processes slice.
+// GetProcesses retrieves all system processes and populates the provided processes slice,
+// collecting every optional metric.
+//
+// Parameters:
+//   - processes: A pointer to a slice that will be populated with Process structs
+//   - generateThreads: Whether to simulate thread data on Darwin for testing
+func GetProcesses(processes *[]tree.Process, generateThreads bool) {
+	GetProcessesWith(processes, CollectOptions{GenerateThreads: generateThreads, Metrics: MetricsAll})
+}
+
+// GetProcessesWith retrieves all system processes and populates the provided processes slice.
 //
 // This function uses the gopsutil library to get a list of all processes running on the system,
 // sorts them by PID, and then generates detailed Process structs for each one using the
-// generateProcess function.
+// GenerateProcessWith function. Processes are generated concurrently by a bounded pool of
+// workers, and are appended in PID order regardless of which worker finishes first.
+// On Windows, which has no process groups, they are emulated once all processes are known.
 //
 // Parameters:
 //   - processes: A pointer to a slice that will be populated with Process structs
-func GetProcesses(processes *[]tree.Process, generateThreads bool) {
+//   - options: What to collect for each process, how many processes to generate concurrently, and the process cache
+func GetProcessesWith(processes *[]tree.Process, options CollectOptions) {
 	var (
 		err      error
 		sorted   []*process.Process
@@ -426,14 +765,17 @@ func GetProcesses(processes *[]tree.Process, generateThreads bool) {
 
 	sorted = SortByPid(unsorted)
 
-	for _, p := range sorted {
-		newProcess := GenerateProcess(p)
+	generated := collectConcurrently(sorted, options.Workers, func(p *process.Process) tree.Process {
+		return GenerateProcessWith(p, options)
+	})
+
+	for _, newProcess := range generated {
 
 		// Only if OS is Darwin and --generate-threads is enabled
 		// This is for testing purposes to simulate thread data on Darwin
 		// because Darwin does not provide thread IDs and therefore cannot
 		// return a list of threads for a process.
-		if generateThreads && runtime.GOOS == "darwin" {
+		if options.GenerateThreads && runtime.GOOS == "darwin" {
 			if newProcess.NumThreads > 0 {
 				for i := 1; i <= int(newProcess.NumThreads); i++ {
 					newProcess.Threads = append(newProcess.Threads, tree.Thread{
@@ -449,4 +791,6 @@ func GetProcesses(processes *[]tree.Process, generateThreads bool) {
 		}
 		*processes = append(*processes, newProcess)
 	}
+
+	assignProcessGroups(processes)
 }
@@ -0,0 +1,266 @@
+package pstree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+//------------------------------------------------------------------------------
+// REMOTE COLLECTION
+//------------------------------------------------------------------------------
+// Functions in this section collect the processes of a remote host, so that
+// --remote can render them locally with any display options. A pstree installed
+// on the remote host collects them as a snapshot; otherwise the output of ps is
+// parsed, which provides fewer metrics.
+
+// Transport runs commands on the host whose processes are collected.
+type Transport interface {
+	// Run runs a shell command and returns its standard output
+	Run(command string) ([]byte, error)
+}
+
+// SSHTransport runs commands on a remote host with the ssh client, so that the keys,
+// agents, and host aliases of the user's SSH configuration apply.
+type SSHTransport struct {
+	Destination string // The remote host, e.g., alice@db1 or an alias from ~/.ssh/config
+}
+
+// NewSSHTransport creates a transport running commands on a remote host over SSH.
+//
+// Parameters:
+//   - destination: The remote host, as accepted by ssh, e.g., user@host
+//
+// Returns:
+//   - *SSHTransport: The transport
+//   - error: Error if the destination is empty or could be mistaken for an ssh option
+func NewSSHTransport(destination string) (*SSHTransport, error) {
+	if destination == "" || strings.HasPrefix(destination, "-") || strings.ContainsAny(destination, " \t\n") {
+		return nil, fmt.Errorf("invalid remote host '%s'", destination)
+	}
+	return &SSHTransport{Destination: destination}, nil
+}
+
+// Run runs a shell command on the remote host.
+//
+// Parameters:
+//   - command: The command, run by the login shell of the remote user
+//
+// Returns:
+//   - []byte: The standard output of the command
+//   - error: Error if ssh could not connect or the command failed, with its standard error
+func (transport *SSHTransport) Run(command string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	ssh := exec.Command("ssh", "--", transport.Destination, command)
+	ssh.Stdout = &stdout
+	ssh.Stderr = &stderr
+	if err := ssh.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("ssh %s failed: %w: %s", transport.Destination, err, message)
+		}
+		return nil, fmt.Errorf("ssh %s failed: %w", transport.Destination, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// remotePsMarker starts the output of ps, followed by the operating system and host name.
+const remotePsMarker = "#pstree-ps"
+
+// remotePsColumns are the ps columns parsed by parsePsOutput. They are supported by the ps
+// of Linux, macOS, and the BSDs; args must come last since it contains spaces.
+const remotePsColumns = "pid=,ppid=,pgid=,uid=,user=,pcpu=,pmem=,rss=,etime=,stat=,args="
+
+// remoteCollectScript collects the processes as a snapshot with pstree, falling back to ps
+// when pstree is not installed or is another pstree, e.g., the one of psmisc. It is run
+// with sh, whatever the login shell of the remote user.
+var remoteCollectScript = fmt.Sprintf(
+	`sh -c 'pstree --snapshot /dev/stdout 2>/dev/null && exit 0; echo "%s $(uname -s) $(uname -n)"; ps -A -o %s'`,
+	remotePsMarker, remotePsColumns,
+)
+
+// psStates maps the first letter of the ps state to the process states reported by gopsutil.
+var psStates = map[byte]string{
+	'D': "blocked",
+	'I': "idle",
+	'L': "lock",
+	'R': "running",
+	'S': "sleep",
+	'T': "stop",
+	'U': "blocked",
+	'W': "wait",
+	'Z': "zombie",
+	't': "stop",
+}
+
+// parseElapsed parses the elapsed time of a process as shown by ps, e.g., 05:12, 03:05:12,
+// or 2-03:05:12.
+//
+// Parameters:
+//   - value: The elapsed time, [[dd-]hh:]mm:ss
+//
+// Returns:
+//   - int64: The elapsed time in seconds
+//   - error: Error if the value is malformed
+func parseElapsed(value string) (int64, error) {
+	var days int64
+	if dayPart, clock, found := strings.Cut(value, "-"); found {
+		parsed, err := strconv.ParseInt(dayPart, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid elapsed time '%s'", value)
+		}
+		days, value = parsed, clock
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid elapsed time '%s'", value)
+	}
+	var seconds int64
+	for _, part := range parts {
+		parsed, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || parsed < 0 {
+			return 0, fmt.Errorf("invalid elapsed time '%s'", value)
+		}
+		seconds = seconds*60 + parsed
+	}
+	return days*86400 + seconds, nil
+}
+
+// parsePsOutput parses the output of ps with the columns of remotePsColumns.
+//
+// Arguments containing spaces cannot be told apart from separate arguments in the output
+// of ps, so arguments are split at every space. Metrics ps does not show, e.g., threads
+// or control groups, are marked unavailable.
+//
+// Parameters:
+//   - output: The output of ps, one process per line
+//   - now: The local time, from which the creation time of each process is derived
+//
+// Returns:
+//   - []tree.Process: The processes, in the order ps listed them
+//   - error: Error if a line is malformed
+func parsePsOutput(output string, now time.Time) ([]tree.Process, error) {
+	processes := []tree.Process{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 11 {
+			return nil, fmt.Errorf("malformed ps output on line %d: expected 11 columns, found %d", line, len(fields))
+		}
+		numbers := make([]int64, 4)
+		for i, name := range []string{"PID", "PPID", "PGID", "UID"} {
+			number, err := strconv.ParseInt(fields[i], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed ps output on line %d: invalid %s '%s'", line, name, fields[i])
+			}
+			numbers[i] = number
+		}
+		cpuPercent, cpuErr := strconv.ParseFloat(fields[5], 64)
+		memoryPercent, memoryErr := strconv.ParseFloat(fields[6], 32)
+		rss, rssErr := strconv.ParseUint(fields[7], 10, 64)
+		elapsed, elapsedErr := parseElapsed(fields[8])
+
+		var unavailable tree.Field = tree.FieldCgroup | tree.FieldIO | tree.FieldNamespaces | tree.FieldNumFDs | tree.FieldNumThreads | tree.FieldPriority | tree.FieldSchedLatency | tree.FieldSessionID | tree.FieldUnit
+		var age, createTime int64
+		if elapsedErr != nil {
+			unavailable |= tree.FieldAge
+		} else {
+			age, createTime = elapsed, now.Unix()-elapsed
+		}
+		if cpuErr != nil {
+			unavailable |= tree.FieldCPUPercent
+		}
+		memoryInfo := &process.MemoryInfoStat{RSS: rss * 1024}
+		if memoryErr != nil || rssErr != nil {
+			unavailable |= tree.FieldMemory
+		}
+		status := []string{}
+		if state, known := psStates[fields[9][0]]; known {
+			status = append(status, state)
+		} else {
+			unavailable |= tree.FieldState
+		}
+
+		processes = append(processes, tree.Process{
+			Age:           age,
+			Args:          fields[11:],
+			Child:         -1,
+			Children:      &[]tree.Process{},
+			Command:       fields[10],
+			CPUPercent:    cpuPercent,
+			CPUTimes:      &cpu.TimesStat{},
+			CreateTime:    createTime,
+			Group:         "unknown",
+			MemoryInfo:    memoryInfo,
+			MemoryPercent: float32(memoryPercent),
+			Parent:        -1,
+			PGID:          int32(numbers[2]),
+			PID:           int32(numbers[0]),
+			PPID:          int32(numbers[1]),
+			Sister:        -1,
+			Status:        status,
+			Threads:       []tree.Thread{},
+			UIDs:          []uint32{uint32(numbers[3])},
+			Unavailable:   unavailable,
+			Username:      fields[4],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ps output: %w", err)
+	}
+	return processes, nil
+}
+
+// CollectRemote collects the processes of a remote host.
+//
+// When pstree is installed on the remote host, it collects the processes as a snapshot
+// with every metric. Otherwise the output of ps is parsed, which provides the owner, CPU
+// and memory usage, age, and state of each process, but not its threads, control group,
+// or namespaces.
+//
+// Parameters:
+//   - transport: The transport running commands on the remote host
+//
+// Returns:
+//   - Snapshot: The processes of the remote host, in PID order, with its host name and operating system
+//   - error: Error if the processes could not be collected
+func CollectRemote(transport Transport) (Snapshot, error) {
+	output, err := transport.Run(remoteCollectScript)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(output), []byte("{")) {
+		return ReadSnapshot(bytes.NewReader(output))
+	}
+
+	header, psOutput, _ := strings.Cut(string(output), "\n")
+	fields := strings.Fields(header)
+	if len(fields) != 3 || fields[0] != remotePsMarker {
+		return Snapshot{}, errors.New("unexpected output from the remote host; expected a pstree snapshot or ps output")
+	}
+	now := time.Now()
+	processes, err := parsePsOutput(psOutput, now)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	SortProcsByPid(&processes)
+	return Snapshot{
+		Version:   SnapshotVersion,
+		Hostname:  fields[2],
+		OS:        strings.ToLower(fields[1]),
+		Taken:     now.UTC(),
+		Processes: processes,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package pstree
+
+import (
+	"debug/buildinfo"
+	"regexp"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// RUNTIME DETECTION
+//------------------------------------------------------------------------------
+// Functions in this section detect the language runtime of each process from
+// its executable and the libraries it has mapped, e.g., to show every JVM on a
+// host together with its memory usage.
+
+// Runtimes detected by AnnotateRuntimes.
+const (
+	RuntimeDotNet = "dotnet" // .NET (CoreCLR)
+	RuntimeGo     = "go"     // Go executable
+	RuntimeJVM    = "jvm"    // Java virtual machine
+	RuntimeNode   = "node"   // Node.js
+	RuntimePython = "python" // CPython or PyPy
+)
+
+// Runtimes returns the names of all detected runtimes in sorted order.
+//
+// Returns:
+//   - A sorted slice of runtime names, e.g., ["dotnet", "go", "jvm", "node", "python"]
+func Runtimes() []string {
+	return []string{RuntimeDotNet, RuntimeGo, RuntimeJVM, RuntimeNode, RuntimePython}
+}
+
+// runtimeExecutables maps executable names to the runtime they start.
+var runtimeExecutables = []struct {
+	pattern *regexp.Regexp
+	runtime string
+}{
+	{regexp.MustCompile(`^(java|javaw)(\.exe)?$`), RuntimeJVM},
+	{regexp.MustCompile(`^(python|pypy)[0-9.]*w?(\.exe)?$`), RuntimePython},
+	{regexp.MustCompile(`^(node|nodejs)(\.exe)?$`), RuntimeNode},
+	{regexp.MustCompile(`^dotnet(\.exe)?$`), RuntimeDotNet},
+}
+
+// runtimeLibraries maps shared libraries to the runtime that loads them, for runtimes
+// embedded in executables with other names, e.g., a JVM started by a launcher.
+var runtimeLibraries = []struct {
+	pattern *regexp.Regexp
+	runtime string
+}{
+	{regexp.MustCompile(`(^|[/\\])(libjvm\.(so|dylib)|jvm\.dll)$`), RuntimeJVM},
+	{regexp.MustCompile(`(^|[/\\])(libpython[0-9.]+\.(so|dylib)[0-9.]*|python[0-9]+\.dll)$`), RuntimePython},
+	{regexp.MustCompile(`(^|[/\\])(libnode\.(so|dylib)[0-9.]*|libnode\.dll)$`), RuntimeNode},
+	{regexp.MustCompile(`(^|[/\\])(libcoreclr\.(so|dylib)|coreclr\.dll)$`), RuntimeDotNet},
+}
+
+// runtimeFromExecutable returns the runtime started by an executable, judged by its name.
+//
+// Parameters:
+//   - command: Path of the executable
+//
+// Returns:
+//   - The runtime, or an empty string if the name is not recognized
+func runtimeFromExecutable(command string) string {
+	// Windows paths are split on backslashes on every platform
+	name := strings.ToLower(command[strings.LastIndexAny(command, `/\`)+1:])
+	for _, executable := range runtimeExecutables {
+		if executable.pattern.MatchString(name) {
+			return executable.runtime
+		}
+	}
+	return ""
+}
+
+// runtimeFromLibraries returns the runtime loaded by a process, judged by its mapped libraries.
+//
+// Parameters:
+//   - libraries: Paths of the libraries mapped by the process
+//
+// Returns:
+//   - The runtime, or an empty string if no runtime library is mapped
+func runtimeFromLibraries(libraries []string) string {
+	for _, library := range libraries {
+		for _, candidate := range runtimeLibraries {
+			if candidate.pattern.MatchString(library) {
+				return candidate.runtime
+			}
+		}
+	}
+	return ""
+}
+
+// AnnotateRuntimes records the language runtime of each process.
+//
+// The runtime is detected from the executable name, then from the libraries mapped by
+// the process, and finally from the Go build information embedded in the executable.
+// Go executables are only read once per path.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+func AnnotateRuntimes(processes *[]tree.Process) {
+	goExecutables := map[string]bool{}
+	for i := range *processes {
+		proc := &(*processes)[i]
+		proc.Runtime = runtimeFromExecutable(proc.Command)
+		if proc.Runtime == "" {
+			proc.Runtime = runtimeFromLibraries(mappedLibraries(proc.PID))
+		}
+		if proc.Runtime == "" && proc.Command != "" {
+			isGo, seen := goExecutables[proc.Command]
+			if !seen {
+				_, err := buildinfo.ReadFile(executablePath(proc))
+				isGo = err == nil
+				goExecutables[proc.Command] = isGo
+			}
+			if isGo {
+				proc.Runtime = RuntimeGo
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// mappedLibraries returns the paths of the shared libraries mapped by a process.
+//
+// Parameters:
+//   - pid: Process ID
+//
+// Returns:
+//   - []string: The paths of the mapped .so files, or nil if /proc/<pid>/maps could not be read
+func mappedLibraries(pid int32) []string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/maps", pid))
+	if err != nil {
+		return nil
+	}
+	libraries := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(data), "\n") {
+		// The sixth field is the path of the mapped file
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.Contains(fields[5], ".so") || seen[fields[5]] {
+			continue
+		}
+		seen[fields[5]] = true
+		libraries = append(libraries, fields[5])
+	}
+	return libraries
+}
+
+// executablePath returns a path from which the executable of a process can be read.
+//
+// /proc/<pid>/exe is used so that executables in other mount namespaces, or deleted
+// since they were started, can still be read.
+//
+// Parameters:
+//   - proc: The process
+//
+// Returns:
+//   - The path of the executable
+func executablePath(proc *tree.Process) string {
+	return fmt.Sprintf("/proc/%d/exe", proc.PID)
+}
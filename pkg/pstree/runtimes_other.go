@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// mappedLibraries returns the paths of the shared libraries mapped by a process.
+//
+// Mapped libraries are read from /proc/<pid>/maps, which only exists on Linux, so
+// runtimes are detected from the executable alone on other platforms.
+//
+// Parameters:
+//   - pid: Process ID
+//
+// Returns:
+//   - []string: Always nil
+func mappedLibraries(pid int32) []string {
+	return nil
+}
+
+// executablePath returns a path from which the executable of a process can be read.
+//
+// Parameters:
+//   - proc: The process
+//
+// Returns:
+//   - The command of the process, which is the path of its executable
+func executablePath(proc *tree.Process) string {
+	return proc.Command
+}
@@ -0,0 +1,412 @@
+package pstree
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// WORKER SATURATION
+//------------------------------------------------------------------------------
+// Functions in this section compare the number of workers running under the
+// master process of a pre-fork server with the maximum it is configured for,
+// so that --show-saturation shows how close each server is to refusing work.
+
+// preforkServer describes how to recognize a pre-fork server and find its worker limit.
+type preforkServer struct {
+	name       string                                              // Name of the server, e.g., nginx
+	isMaster   func(proc *tree.Process, parent *tree.Process) bool // Recognizes the master process
+	isWorker   func(proc *tree.Process) bool                       // Recognizes a worker of the master
+	maxWorkers func(proc *tree.Process) int                        // Returns the configured maximum, or 0 if unknown
+}
+
+// preforkServers lists the recognized pre-fork servers.
+var preforkServers = []preforkServer{
+	{name: "nginx", isMaster: isNginxMaster, isWorker: isNginxWorker, maxWorkers: nginxMaxWorkers},
+	{name: "apache", isMaster: isApacheMaster, isWorker: isApacheWorker, maxWorkers: apacheMaxWorkers},
+	{name: "gunicorn", isMaster: isGunicornMaster, isWorker: isGunicornWorker, maxWorkers: gunicornMaxWorkers},
+	{name: "php-fpm", isMaster: isPHPFPMMaster, isWorker: isPHPFPMWorker, maxWorkers: phpFPMMaxWorkers},
+}
+
+var (
+	apacheMaxWorkersDirective = regexp.MustCompile(`(?mi)^\s*(MaxRequestWorkers|MaxClients)\s+(\d+)`)
+	apacheIncludeDirective    = regexp.MustCompile(`(?mi)^\s*Include(Optional)?\s+"?([^"\s]+)"?`)
+	apacheServerRootDirective = regexp.MustCompile(`(?mi)^\s*ServerRoot\s+"?([^"\s]+)"?`)
+	apacheThreadsDirective    = regexp.MustCompile(`(?mi)^\s*ThreadsPerChild\s+(\d+)`)
+	gunicornWorkersSetting    = regexp.MustCompile(`(?m)^\s*workers\s*=\s*(\d+)`)
+	nginxWorkersDirective     = regexp.MustCompile(`(?m)^\s*worker_processes\s+(\w+)\s*;`)
+	phpFPMIncludeDirective    = regexp.MustCompile(`(?m)^\s*include\s*=\s*(\S+)`)
+	phpFPMMaxChildrenSetting  = regexp.MustCompile(`(?m)^\s*pm\.max_children\s*=\s*(\d+)`)
+)
+
+// maxIncludeDepth limits how deeply configuration files may include each other.
+const maxIncludeDepth = 4
+
+// commandLine returns the command line of a process, which pre-fork servers rewrite with
+// their process title, e.g., "nginx: worker process".
+//
+// Parameters:
+//   - proc: The process
+//
+// Returns:
+//   - The arguments joined with spaces, or the command if there are none
+func commandLine(proc *tree.Process) string {
+	if len(proc.Args) == 0 {
+		return proc.Command
+	}
+	return strings.Join(proc.Args, " ")
+}
+
+// optionValue returns the value of a command line option given as -o value, --option value,
+// or --option=value. When the option is given more than once, the last one wins.
+//
+// Parameters:
+//   - args: The arguments to search
+//   - names: The names of the option, e.g., -w and --workers
+//
+// Returns:
+//   - The value, or an empty string if the option is not given
+func optionValue(args []string, names ...string) string {
+	value := ""
+	for i, arg := range args {
+		for _, name := range names {
+			if arg == name && i+1 < len(args) {
+				value = args[i+1]
+			} else if after, found := strings.CutPrefix(arg, name+"="); found {
+				value = after
+			}
+		}
+	}
+	return value
+}
+
+// readConfig reads a configuration file as seen by a process, which may run in another
+// mount namespace, e.g., in a container.
+//
+// Parameters:
+//   - pid: Process ID
+//   - path: Path of the file inside the process's root
+//
+// Returns:
+//   - The contents of the file, or an empty string if it could not be read
+func readConfig(pid int32, path string) string {
+	data, err := os.ReadFile(processRootPath(pid, path))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// globConfig expands a configuration include pattern as seen by a process.
+//
+// Parameters:
+//   - pid: Process ID
+//   - pattern: Glob pattern inside the process's root
+//
+// Returns:
+//   - The matching paths inside the process's root
+func globConfig(pid int32, pattern string) []string {
+	root := processRootPath(pid, "/")
+	matches, _ := filepath.Glob(processRootPath(pid, pattern))
+	paths := make([]string, 0, len(matches))
+	for _, match := range matches {
+		paths = append(paths, "/"+strings.TrimPrefix(strings.TrimPrefix(match, root), "/"))
+	}
+	return paths
+}
+
+//------------------------------------------------------------------------------
+// NGINX
+//------------------------------------------------------------------------------
+
+// isNginxMaster recognizes the nginx master process by its title.
+func isNginxMaster(proc *tree.Process, parent *tree.Process) bool {
+	return strings.HasPrefix(commandLine(proc), "nginx: master process")
+}
+
+// isNginxWorker recognizes the nginx worker processes, excluding the cache helpers.
+func isNginxWorker(proc *tree.Process) bool {
+	return strings.HasPrefix(commandLine(proc), "nginx: worker process")
+}
+
+// nginxMaxWorkers returns the worker_processes directive of the configuration given
+// with -c, or of /etc/nginx/nginx.conf. A value of auto starts one worker per CPU.
+func nginxMaxWorkers(proc *tree.Process) int {
+	configPath := optionValue(strings.Fields(commandLine(proc)), "-c")
+	if configPath == "" {
+		configPath = "/etc/nginx/nginx.conf"
+	}
+	return parseNginxWorkers(readConfig(proc.PID, configPath))
+}
+
+// parseNginxWorkers returns the number of workers configured by an nginx configuration.
+//
+// Parameters:
+//   - config: Contents of the configuration file
+//
+// Returns:
+//   - The number of workers, which is 1 when the directive is missing
+func parseNginxWorkers(config string) int {
+	match := nginxWorkersDirective.FindStringSubmatch(config)
+	if match == nil {
+		return 1
+	}
+	if match[1] == "auto" {
+		return runtime.NumCPU()
+	}
+	workers, _ := strconv.Atoi(match[1])
+	return workers
+}
+
+//------------------------------------------------------------------------------
+// APACHE
+//------------------------------------------------------------------------------
+
+// apacheExecutables are the names of the Apache HTTP Server executable.
+var apacheExecutables = []string{"apache2", "httpd"}
+
+// isApache returns true if the process runs the Apache HTTP Server.
+func isApache(proc *tree.Process) bool {
+	name := filepath.Base(proc.Command)
+	for _, executable := range apacheExecutables {
+		if name == executable {
+			return true
+		}
+	}
+	return false
+}
+
+// isApacheMaster recognizes the Apache parent process, the only one not started by Apache.
+func isApacheMaster(proc *tree.Process, parent *tree.Process) bool {
+	return isApache(proc) && (parent == nil || !isApache(parent))
+}
+
+// isApacheWorker recognizes the Apache child processes.
+func isApacheWorker(proc *tree.Process) bool {
+	return isApache(proc)
+}
+
+// apacheLimits are the worker limits set by an Apache configuration.
+type apacheLimits struct {
+	maxRequestWorkers int // Maximum number of simultaneous requests
+	threadsPerChild   int // Threads per child process with the threaded worker and event MPMs
+}
+
+// apacheMaxWorkers returns the maximum number of child processes allowed by the configuration
+// given with -f, or by the default configuration, following Include directives.
+//
+// With the threaded MPMs, each child serves ThreadsPerChild requests, so the number of
+// children is MaxRequestWorkers divided by ThreadsPerChild.
+func apacheMaxWorkers(proc *tree.Process) int {
+	configPath := optionValue(proc.Args, "-f")
+	if configPath == "" {
+		configPath = "/etc/apache2/apache2.conf"
+		if filepath.Base(proc.Command) == "httpd" {
+			configPath = "/etc/httpd/conf/httpd.conf"
+		}
+	}
+	config := readConfig(proc.PID, configPath)
+	serverRoot := filepath.Dir(configPath)
+	if match := apacheServerRootDirective.FindStringSubmatch(config); match != nil {
+		serverRoot = match[1]
+	}
+	limits := apacheLimits{}
+	parseApacheLimits(proc.PID, config, serverRoot, 0, &limits)
+	if limits.threadsPerChild > 1 {
+		return (limits.maxRequestWorkers + limits.threadsPerChild - 1) / limits.threadsPerChild
+	}
+	return limits.maxRequestWorkers
+}
+
+// parseApacheLimits reads the worker limits set by an Apache configuration and the files
+// it includes. Directives are applied in file order, so the last one wins, as in Apache.
+//
+// Parameters:
+//   - pid: Process ID, whose root included files are read from
+//   - config: Contents of the configuration file
+//   - serverRoot: Directory relative include paths are resolved against
+//   - depth: Include depth of the configuration file
+//   - limits: The limits to update
+func parseApacheLimits(pid int32, config string, serverRoot string, depth int, limits *apacheLimits) {
+	for _, line := range strings.Split(config, "\n") {
+		if match := apacheMaxWorkersDirective.FindStringSubmatch(line); match != nil {
+			limits.maxRequestWorkers, _ = strconv.Atoi(match[2])
+		} else if match := apacheThreadsDirective.FindStringSubmatch(line); match != nil {
+			limits.threadsPerChild, _ = strconv.Atoi(match[1])
+		} else if match := apacheIncludeDirective.FindStringSubmatch(line); match != nil && depth < maxIncludeDepth {
+			pattern := match[2]
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(serverRoot, pattern)
+			}
+			for _, path := range globConfig(pid, pattern) {
+				parseApacheLimits(pid, readConfig(pid, path), serverRoot, depth+1, limits)
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+// GUNICORN
+//------------------------------------------------------------------------------
+
+// isGunicorn returns true if the process runs gunicorn, either titled by setproctitle,
+// e.g., "gunicorn: master [app:app]", or started by a Python interpreter.
+func isGunicorn(proc *tree.Process) bool {
+	line := commandLine(proc)
+	if strings.HasPrefix(line, "gunicorn: ") {
+		return true
+	}
+	for _, field := range strings.Fields(line) {
+		if filepath.Base(field) == "gunicorn" {
+			return true
+		}
+	}
+	return false
+}
+
+// isGunicornMaster recognizes the gunicorn arbiter, the only gunicorn process not started by gunicorn.
+func isGunicornMaster(proc *tree.Process, parent *tree.Process) bool {
+	return isGunicorn(proc) && (parent == nil || !isGunicorn(parent))
+}
+
+// isGunicornWorker recognizes the gunicorn workers.
+func isGunicornWorker(proc *tree.Process) bool {
+	return isGunicorn(proc)
+}
+
+// gunicornMaxWorkers returns the number of workers set by --workers, by GUNICORN_CMD_ARGS,
+// by the configuration file given with --config, or by WEB_CONCURRENCY, in order of precedence.
+func gunicornMaxWorkers(proc *tree.Process) int {
+	args := strings.Fields(commandLine(proc))
+	if workers, err := strconv.Atoi(optionValue(args, "-w", "--workers")); err == nil {
+		return workers
+	}
+	environment := map[string]string{}
+	for _, variable := range proc.EnvironmentVariables() {
+		key, value, _ := strings.Cut(variable, "=")
+		environment[key] = value
+	}
+	if workers, err := strconv.Atoi(optionValue(strings.Fields(environment["GUNICORN_CMD_ARGS"]), "-w", "--workers")); err == nil {
+		return workers
+	}
+	if configPath := optionValue(args, "-c", "--config"); configPath != "" {
+		configPath = strings.TrimPrefix(configPath, "python:")
+		if match := gunicornWorkersSetting.FindStringSubmatch(readConfig(proc.PID, configPath)); match != nil {
+			workers, _ := strconv.Atoi(match[1])
+			return workers
+		}
+	}
+	if workers, err := strconv.Atoi(environment["WEB_CONCURRENCY"]); err == nil {
+		return workers
+	}
+	return 1
+}
+
+//------------------------------------------------------------------------------
+// PHP-FPM
+//------------------------------------------------------------------------------
+
+// isPHPFPMMaster recognizes the PHP-FPM master process by its title, e.g.,
+// "php-fpm: master process (/etc/php/8.2/fpm/php-fpm.conf)".
+func isPHPFPMMaster(proc *tree.Process, parent *tree.Process) bool {
+	return strings.HasPrefix(commandLine(proc), "php-fpm: master process")
+}
+
+// isPHPFPMWorker recognizes the PHP-FPM pool workers, e.g., "php-fpm: pool www".
+func isPHPFPMWorker(proc *tree.Process) bool {
+	return strings.HasPrefix(commandLine(proc), "php-fpm: pool ")
+}
+
+// phpFPMMaxWorkers returns the total pm.max_children of the pools configured by the
+// configuration named in the master's title.
+func phpFPMMaxWorkers(proc *tree.Process) int {
+	line := commandLine(proc)
+	start, end := strings.Index(line, "("), strings.LastIndex(line, ")")
+	if start == -1 || end < start {
+		return 0
+	}
+	configPath := line[start+1 : end]
+	return parsePHPFPMMaxChildren(proc.PID, readConfig(proc.PID, configPath), filepath.Dir(configPath), 0)
+}
+
+// parsePHPFPMMaxChildren returns the total maximum number of children of the pools
+// configured by a PHP-FPM configuration and the files it includes.
+//
+// Parameters:
+//   - pid: Process ID, whose root included files are read from
+//   - config: Contents of the configuration file
+//   - configDir: Directory relative include paths are resolved against
+//   - depth: Include depth of the configuration file
+//
+// Returns:
+//   - The total maximum number of children
+func parsePHPFPMMaxChildren(pid int32, config string, configDir string, depth int) int {
+	total := 0
+	for _, match := range phpFPMMaxChildrenSetting.FindAllStringSubmatch(config, -1) {
+		children, _ := strconv.Atoi(match[1])
+		total += children
+	}
+	if depth >= maxIncludeDepth {
+		return total
+	}
+	for _, match := range phpFPMIncludeDirective.FindAllStringSubmatch(config, -1) {
+		pattern := match[1]
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(configDir, pattern)
+		}
+		for _, path := range globConfig(pid, pattern) {
+			total += parsePHPFPMMaxChildren(pid, readConfig(pid, path), configDir, depth+1)
+		}
+	}
+	return total
+}
+
+//------------------------------------------------------------------------------
+// ANNOTATION
+//------------------------------------------------------------------------------
+
+// AnnotateSaturation records on the master process of each recognized pre-fork server
+// the number of its running workers and the maximum it is configured for.
+//
+// Configuration files are read through the root of the master process, so the limits of
+// servers running in containers are read from their own configuration. When a file cannot
+// be read, e.g., without permission, the maximum is left unknown.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+func AnnotateSaturation(processes *[]tree.Process) {
+	pidToIndex := make(map[int32]int, len(*processes))
+	for i := range *processes {
+		pidToIndex[(*processes)[i].PID] = i
+	}
+
+	masters := map[int32]preforkServer{}
+	for i := range *processes {
+		proc := &(*processes)[i]
+		var parent *tree.Process
+		if parentIndex, exists := pidToIndex[proc.PPID]; exists {
+			parent = &(*processes)[parentIndex]
+		}
+		for _, server := range preforkServers {
+			if server.isMaster(proc, parent) {
+				masters[proc.PID] = server
+				proc.PreforkServer = server.name
+				proc.MaxWorkers = server.maxWorkers(proc)
+				break
+			}
+		}
+	}
+
+	for i := range *processes {
+		proc := &(*processes)[i]
+		if server, exists := masters[proc.PPID]; exists && server.isWorker(proc) {
+			(*processes)[pidToIndex[proc.PPID]].Workers++
+		}
+	}
+}
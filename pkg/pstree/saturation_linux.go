@@ -0,0 +1,28 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// processRootPath returns the path of a file as seen by a process.
+//
+// Absolute paths are resolved in the root directory of the process through /proc/<pid>/root,
+// so that the configuration of servers running in containers is read from the container.
+// Relative paths are resolved in its working directory through /proc/<pid>/cwd.
+//
+// Parameters:
+//   - pid: Process ID
+//   - path: Path of the file as given to the process
+//
+// Returns:
+//   - The path of the file
+func processRootPath(pid int32, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Join(fmt.Sprintf("/proc/%d/root", pid), path)
+	}
+	return filepath.Join(fmt.Sprintf("/proc/%d/cwd", pid), path)
+}
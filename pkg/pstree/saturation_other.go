@@ -0,0 +1,20 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+// processRootPath returns the path of a file as seen by a process.
+//
+// Processes share the root directory of pstree on other platforms, so absolute paths are
+// returned unchanged. The working directory of another process is not known, so relative
+// paths are resolved against the working directory of pstree.
+//
+// Parameters:
+//   - pid: Process ID
+//   - path: Path of the file as given to the process
+//
+// Returns:
+//   - The path of the file
+func processRootPath(pid int32, path string) string {
+	return path
+}
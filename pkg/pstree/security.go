@@ -0,0 +1,26 @@
+package pstree
+
+import (
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// SECURITY CONTEXTS
+//------------------------------------------------------------------------------
+// Functions in this section read the SELinux or AppArmor label of each process,
+// as shown by ps -Z, so that confined and unconfined processes can be audited
+// with --show-security-context and --context-contains.
+
+// parseSecurityContext extracts the label of a process from the contents of
+// /proc/<pid>/attr/current, e.g., system_u:system_r:sshd_t:s0-s0:c0.c1023 with SELinux
+// or /usr/sbin/cupsd (enforce) with AppArmor.
+//
+// Parameters:
+//   - data: Contents of /proc/<pid>/attr/current
+//
+// Returns:
+//   - The label, or empty if no security module labels the process
+func parseSecurityContext(data string) string {
+	// SELinux terminates the label with a NUL byte and AppArmor with a newline
+	return strings.TrimSpace(strings.TrimRight(data, "\x00\n"))
+}
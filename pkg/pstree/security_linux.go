@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateSecurityContext reads the SELinux or AppArmor label of each process from
+// /proc/<pid>/attr/current. The label is left empty when no security module is enabled or
+// it cannot be read.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always nil on Linux
+func AnnotateSecurityContext(processes *[]tree.Process) error {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/attr/current", proc.PID)); err == nil {
+			proc.SecurityContext = parseSecurityContext(string(data))
+		}
+	}
+	return nil
+}
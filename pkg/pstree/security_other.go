@@ -0,0 +1,24 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import (
+	"errors"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateSecurityContext reads the SELinux or AppArmor label of each process.
+//
+// Security labels are only inspected on Linux, so this always returns an error on other
+// platforms.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Always an error explaining that security contexts are not supported
+func AnnotateSecurityContext(processes *[]tree.Process) error {
+	return errors.New("--show-security-context and --context-contains are only supported on Linux")
+}
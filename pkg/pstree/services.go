@@ -0,0 +1,60 @@
+package pstree
+
+import (
+	"sort"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// WINDOWS SERVICES AND SESSIONS
+//------------------------------------------------------------------------------
+// Functions in this section map processes to the Windows services they host
+// and the logon session they run in.
+
+// serviceEntry is a running service as reported by the service control manager.
+type serviceEntry struct {
+	Name string // Short service name, e.g., "Dnscache"
+	PID  int32  // PID of the hosting process, or 0 if the service is stopped
+}
+
+// groupServicesByPID groups running services by the process hosting them.
+// Service names are sorted so that shared hosts such as svchost.exe list them consistently.
+//
+// Parameters:
+//   - entries: Services reported by the service control manager
+//
+// Returns:
+//   - map[int32][]string: Sorted service names keyed by PID
+func groupServicesByPID(entries []serviceEntry) map[int32][]string {
+	services := map[int32][]string{}
+	for _, entry := range entries {
+		if entry.PID > 0 {
+			services[entry.PID] = append(services[entry.PID], entry.Name)
+		}
+	}
+	for pid := range services {
+		sort.Strings(services[pid])
+	}
+	return services
+}
+
+// applyServices attaches hosted services and session IDs to each process.
+// Processes whose session could not be determined are marked as such.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//   - services: Service names keyed by PID, as returned by groupServicesByPID
+//   - session: Function returning the session ID of a PID
+func applyServices(processes *[]tree.Process, services map[int32][]string, session func(pid int32) (uint32, error)) {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		proc.Services = services[proc.PID]
+		sessionID, err := session(proc.PID)
+		if err != nil {
+			proc.Unavailable |= tree.FieldSessionID
+			continue
+		}
+		proc.SessionID = sessionID
+	}
+}
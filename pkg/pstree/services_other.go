@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package pstree
+
+import (
+	"errors"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateServices maps every process to the Windows services it hosts and its session ID.
+//
+// The service control manager only exists on Windows, so this function always returns
+// an error on other platforms.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Error indicating that the operation is not supported
+func AnnotateServices(processes *[]tree.Process) error {
+	return errors.New("--show-service is only supported on Windows")
+}
@@ -0,0 +1,83 @@
+//go:build windows
+// +build windows
+
+package pstree
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/gdanko/pstree/pkg/tree"
+	"golang.org/x/sys/windows"
+)
+
+// AnnotateServices maps every process to the Windows services it hosts and its session ID.
+//
+// Running services are enumerated through the service control manager, which reports
+// the PID of the process hosting each one, e.g., the svchost.exe instance for Dnscache.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Error if the service control manager could not be queried
+func AnnotateServices(processes *[]tree.Process) error {
+	entries, err := enumServices()
+	if err != nil {
+		return err
+	}
+
+	applyServices(processes, groupServicesByPID(entries), sessionID)
+	return nil
+}
+
+// enumServices returns all running Win32 services and the PIDs hosting them.
+//
+// Returns:
+//   - []serviceEntry: The running services
+//   - error: Error if the service control manager could not be queried
+func enumServices() ([]serviceEntry, error) {
+	manager, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_ENUMERATE_SERVICE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the service control manager: %w", err)
+	}
+	defer windows.CloseServiceHandle(manager)
+
+	var (
+		buffer      []byte
+		bytesNeeded uint32
+		entries     []serviceEntry
+		resume      uint32
+		returned    uint32
+	)
+
+	for {
+		var bufferPtr *byte
+		if len(buffer) > 0 {
+			bufferPtr = &buffer[0]
+		}
+		err = windows.EnumServicesStatusEx(manager, windows.SC_ENUM_PROCESS_INFO, windows.SERVICE_WIN32, windows.SERVICE_ACTIVE, bufferPtr, uint32(len(buffer)), &bytesNeeded, &returned, &resume, nil)
+		if err != nil && !errors.Is(err, windows.ERROR_MORE_DATA) {
+			return nil, fmt.Errorf("failed to enumerate services: %w", err)
+		}
+
+		if returned > 0 {
+			statuses := unsafe.Slice((*windows.ENUM_SERVICE_STATUS_PROCESS)(unsafe.Pointer(&buffer[0])), returned)
+			for _, status := range statuses {
+				entries = append(entries, serviceEntry{
+					Name: windows.UTF16PtrToString(status.ServiceName),
+					PID:  int32(status.ServiceStatusProcess.ProcessId),
+				})
+			}
+		}
+
+		if err == nil {
+			return entries, nil
+		}
+		// More data is available; grow the buffer and continue from the resume handle
+		if int(bytesNeeded) > len(buffer) {
+			buffer = make([]byte, bytesNeeded)
+		}
+	}
+}
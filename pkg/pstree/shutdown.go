@@ -0,0 +1,201 @@
+package pstree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// SHUTDOWN PLANS
+//------------------------------------------------------------------------------
+// Functions in this section compute the order in which the processes of a
+// subtree should be terminated, leaves first, and how each process reacts to
+// termination signals, so that operators can script safe service teardowns.
+
+// shutdownSignals are the signals whose disposition is reported, by their number on Linux,
+// which numbers the bits of the signal masks in /proc/<pid>/status.
+var shutdownSignals = []struct {
+	Name   string
+	Number int
+}{
+	{"HUP", 1},
+	{"INT", 2},
+	{"QUIT", 3},
+	{"USR1", 10},
+	{"USR2", 12},
+	{"TERM", 15},
+}
+
+// SignalDisposition is how a process reacts to signals, as bit masks where bit n-1 is signal n.
+type SignalDisposition struct {
+	Blocked uint64 // Signals held pending until the process unblocks them
+	Ignored uint64 // Signals discarded by the process
+	Caught  uint64 // Signals handled by the process, e.g., to shut down gracefully
+}
+
+// signalInMask reports whether signal number is in a mask.
+func signalInMask(mask uint64, number int) bool {
+	return mask&(1<<(number-1)) != 0
+}
+
+// ShutdownStep is a process of a shutdown plan.
+type ShutdownStep struct {
+	Wave        int                // Processes of the same wave can be terminated together, starting at 1
+	Process     tree.Process       // The process to terminate
+	Disposition *SignalDisposition // How the process reacts to signals, or nil if unknown
+}
+
+// parseSignalDisposition extracts the blocked, ignored, and caught signals from the contents
+// of /proc/<pid>/status.
+//
+// Parameters:
+//   - data: Contents of /proc/<pid>/status
+//
+// Returns:
+//   - *SignalDisposition: The signal masks
+//   - error: Error if a mask is missing or malformed
+func parseSignalDisposition(data string) (*SignalDisposition, error) {
+	disposition := &SignalDisposition{}
+	masks := map[string]*uint64{
+		"SigBlk": &disposition.Blocked,
+		"SigIgn": &disposition.Ignored,
+		"SigCgt": &disposition.Caught,
+	}
+	found := 0
+	for _, line := range strings.Split(data, "\n") {
+		key, value, _ := strings.Cut(line, ":")
+		mask, wanted := masks[key]
+		if !wanted {
+			continue
+		}
+		parsed, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed %s line '%s'", key, line)
+		}
+		*mask = parsed
+		found++
+	}
+	if found < len(masks) {
+		return nil, errors.New("no SigBlk, SigIgn, and SigCgt lines found")
+	}
+	return disposition, nil
+}
+
+// ShutdownPlan returns the processes of a subtree in the order they should be terminated.
+// Leaves are in the first wave, and every other process is in the wave after the last of
+// its children, so that no process outlives its parent. The pstree process itself is never
+// included.
+//
+// Parameters:
+//   - logger: Logger for the temporary process tree
+//   - processes: All collected processes
+//   - pid: PID of the root of the subtree
+//
+// Returns:
+//   - []ShutdownStep: The processes by wave, then in the order they are signaled by --kill
+//   - error: Error if the PID does not exist or is PID 1
+func ShutdownPlan(logger *slog.Logger, processes []tree.Process, pid int32) ([]ShutdownStep, error) {
+	if pid <= 1 {
+		return nil, errors.New("refusing to plan the shutdown of PID 1 and all of its descendants")
+	}
+	processTree := tree.NewProcessTree(0, logger, processes, tree.DisplayOptions{})
+	start, exists := processTree.PidToIndexMap[pid]
+	if !exists {
+		return nil, fmt.Errorf("no process with PID %d", pid)
+	}
+
+	// Children are visited before their parents, so a wave is known once its children are
+	myPid := int32(os.Getpid())
+	waves := map[int]int{}
+	steps := []ShutdownStep{}
+	processTree.WalkWithOptions(tree.WalkOptions{Order: tree.PostOrder, Start: start, IgnoreMark: true}, func(node tree.Node) tree.WalkDecision {
+		wave := max(waves[node.Index], 1)
+		if node.ParentIndex != -1 {
+			waves[node.ParentIndex] = max(waves[node.ParentIndex], wave+1)
+		}
+		if node.Process.PID != myPid {
+			// The disposition of other users' processes may not be readable
+			disposition, _ := readSignalDisposition(node.Process.PID)
+			steps = append(steps, ShutdownStep{Wave: wave, Process: *node.Process, Disposition: disposition})
+		}
+		return tree.WalkContinue
+	})
+
+	ordered := make([]ShutdownStep, 0, len(steps))
+	for wave := 1; len(ordered) < len(steps); wave++ {
+		for _, step := range steps {
+			if step.Wave == wave {
+				ordered = append(ordered, step)
+			}
+		}
+	}
+	return ordered, nil
+}
+
+// Hints returns how a process reacts to SIGTERM, and which other shutdown signals it handles
+// or ignores, e.g., handles SIGTERM (graceful shutdown) or ignores SIGTERM (needs SIGKILL).
+//
+// Returns:
+//   - The hints, or a single hint saying the disposition is unknown
+func (step ShutdownStep) Hints() []string {
+	disposition := step.Disposition
+	if disposition == nil {
+		return []string{"signal disposition unknown"}
+	}
+	const term = 15
+	hints := []string{}
+	switch {
+	case signalInMask(disposition.Ignored, term):
+		hints = append(hints, "ignores SIGTERM (needs SIGKILL)")
+	case signalInMask(disposition.Blocked, term):
+		hints = append(hints, "blocks SIGTERM (delivered when unblocked)")
+	case signalInMask(disposition.Caught, term):
+		hints = append(hints, "handles SIGTERM (graceful shutdown)")
+	default:
+		hints = append(hints, "exits on SIGTERM")
+	}
+	handled, ignored := []string{}, []string{}
+	for _, signal := range shutdownSignals {
+		if signal.Number == term {
+			continue
+		}
+		if signalInMask(disposition.Caught, signal.Number) {
+			handled = append(handled, "SIG"+signal.Name)
+		} else if signalInMask(disposition.Ignored, signal.Number) {
+			ignored = append(ignored, "SIG"+signal.Name)
+		}
+	}
+	if len(handled) > 0 {
+		hints = append(hints, "handles "+strings.Join(handled, ", "))
+	}
+	if len(ignored) > 0 {
+		hints = append(hints, "ignores "+strings.Join(ignored, ", "))
+	}
+	return hints
+}
+
+// WriteShutdownPlan writes a shutdown plan, one process per line with its wave and hints, e.g.:
+//
+//	wave 1  4243 (php-fpm)  exits on SIGTERM
+//	wave 2  4242 (php-fpm)  handles SIGTERM (graceful shutdown); handles SIGUSR1, SIGUSR2
+//
+// Parameters:
+//   - output: Writer to write the plan to
+//   - steps: The plan, as returned by ShutdownPlan
+func WriteShutdownPlan(output io.Writer, steps []ShutdownStep) {
+	width := 0
+	for _, step := range steps {
+		width = max(width, len(strconv.Itoa(int(step.Process.PID))))
+	}
+	for _, step := range steps {
+		fmt.Fprintf(output, "wave %d  %*d (%s)  %s\n", step.Wave, width, step.Process.PID, filepath.Base(step.Process.Command), strings.Join(step.Hints(), "; "))
+	}
+}
@@ -0,0 +1,26 @@
+//go:build linux
+// +build linux
+
+package pstree
+
+import (
+	"fmt"
+	"os"
+)
+
+// readSignalDisposition reads the blocked, ignored, and caught signals of a process from
+// /proc/<pid>/status.
+//
+// Parameters:
+//   - pid: Process ID
+//
+// Returns:
+//   - *SignalDisposition: The signal masks, or nil on error
+//   - error: Error if the status could not be read or parsed
+func readSignalDisposition(pid int32) (*SignalDisposition, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, err
+	}
+	return parseSignalDisposition(string(data))
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package pstree
+
+import "errors"
+
+// readSignalDisposition reads the blocked, ignored, and caught signals of a process.
+//
+// Signal dispositions are only inspected on Linux, so this always returns an error on
+// other platforms, and shutdown plans list the processes without hints.
+//
+// Parameters:
+//   - pid: Process ID
+//
+// Returns:
+//   - *SignalDisposition: Always nil
+//   - error: Always an error explaining that signal dispositions are not supported
+func readSignalDisposition(pid int32) (*SignalDisposition, error) {
+	return nil, errors.New("signal dispositions are only inspected on Linux")
+}
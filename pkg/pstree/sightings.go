@@ -0,0 +1,185 @@
+package pstree
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// SIGHTINGS
+//------------------------------------------------------------------------------
+// Functions in this section remember when pstree serve first and last saw each
+// process, so that uptimes and restarts can be analyzed from its API without an
+// external monitoring stack.
+
+// Sighting is when a process was first and last seen in the samples of pstree serve.
+type Sighting struct {
+	ID        string    `json:"id"`         // Stable node ID, <pid>-<creation time>, which differs when a PID is reused
+	PID       int32     `json:"pid"`        // Process ID
+	PPID      int32     `json:"ppid"`       // Parent process ID
+	Command   string    `json:"command"`    // Command name
+	FirstSeen time.Time `json:"first_seen"` // Time of the first sample the process was in
+	LastSeen  time.Time `json:"last_seen"`  // Time of the latest sample the process was in
+	Exited    bool      `json:"exited"`     // Whether the process was missing from the latest sample
+}
+
+// SightingEvent reports that a process appeared or exited.
+type SightingEvent struct {
+	Event string    `json:"event"` // appeared or exited
+	Time  time.Time `json:"time"`  // Time of the sample that revealed the change
+	Sighting
+}
+
+// SightingTracker records the sightings of the displayed processes across samples and
+// streams their changes to listeners.
+type SightingTracker struct {
+	mutex     sync.Mutex
+	sightings map[watchKey]*Sighting      // Sightings of the running and recently exited processes
+	retention time.Duration               // How long exited processes are remembered
+	sampled   time.Time                   // Time of the latest sample
+	listeners map[chan SightingEvent]bool // Channels receiving the changes
+}
+
+// NewSightingTracker creates a tracker that has seen no process yet.
+//
+// Parameters:
+//   - retention: How long an exited process is remembered after it was last seen
+//
+// Returns:
+//   - *SightingTracker: The tracker
+func NewSightingTracker(retention time.Duration) *SightingTracker {
+	return &SightingTracker{
+		sightings: map[watchKey]*Sighting{},
+		retention: retention,
+		listeners: map[chan SightingEvent]bool{},
+	}
+}
+
+// NodeID returns the stable ID of a process, which tells apart processes that reused the
+// same PID.
+//
+// Parameters:
+//   - proc: The process
+//
+// Returns:
+//   - string: The ID, <pid>-<creation time in milliseconds>
+func NodeID(proc tree.Process) string {
+	return fmt.Sprintf("%d-%d", proc.PID, proc.CreateTime)
+}
+
+// Observe records a sample: processes marked for printing are seen at the sample time,
+// and previously seen processes missing from it have exited. Exited processes last seen
+// more than the retention ago are forgotten. The changes are sent to the listeners.
+//
+// Parameters:
+//   - processes: The marked processes of the sample
+//   - sampled: Time the sample was collected
+func (tracker *SightingTracker) Observe(processes []tree.Process, sampled time.Time) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.sampled = sampled
+
+	events := []SightingEvent{}
+	current := map[watchKey]bool{}
+	for _, proc := range processes {
+		if !proc.Print {
+			continue
+		}
+		key := watchKey{PID: proc.PID, CreateTime: proc.CreateTime}
+		current[key] = true
+		sighting, found := tracker.sightings[key]
+		if !found {
+			sighting = &Sighting{ID: NodeID(proc), PID: proc.PID, FirstSeen: sampled}
+			tracker.sightings[key] = sighting
+		}
+		sighting.PPID = proc.PPID
+		sighting.Command = proc.Command
+		sighting.LastSeen = sampled
+		sighting.Exited = false
+		if !found {
+			events = append(events, SightingEvent{Event: "appeared", Time: sampled, Sighting: *sighting})
+		}
+	}
+
+	for key, sighting := range tracker.sightings {
+		switch {
+		case current[key]:
+		case !sighting.Exited:
+			sighting.Exited = true
+			events = append(events, SightingEvent{Event: "exited", Time: sampled, Sighting: *sighting})
+		case sampled.Sub(sighting.LastSeen) > tracker.retention:
+			delete(tracker.sightings, key)
+		}
+	}
+
+	slices.SortFunc(events, func(a, b SightingEvent) int {
+		if a.Event != b.Event {
+			// Exits are reported first, so that a restarted process is seen to stop, then start
+			if a.Event == "exited" {
+				return -1
+			}
+			return 1
+		}
+		return int(a.PID - b.PID)
+	})
+	for _, event := range events {
+		for listener := range tracker.listeners {
+			// A listener that falls behind misses events rather than holding up the collector
+			select {
+			case listener <- event:
+			default:
+			}
+		}
+	}
+}
+
+// Sightings returns the sightings of the running and recently exited processes.
+//
+// Returns:
+//   - []Sighting: The sightings, ordered by the time they were first seen and PID
+//   - time.Time: Time of the latest sample, or zero if none was observed
+func (tracker *SightingTracker) Sightings() ([]Sighting, time.Time) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	sightings := make([]Sighting, 0, len(tracker.sightings))
+	for _, sighting := range tracker.sightings {
+		sightings = append(sightings, *sighting)
+	}
+	slices.SortFunc(sightings, func(a, b Sighting) int {
+		if compared := a.FirstSeen.Compare(b.FirstSeen); compared != 0 {
+			return compared
+		}
+		return int(a.PID - b.PID)
+	})
+	return sightings, tracker.sampled
+}
+
+// Listen subscribes to the processes that appear and exit from now on.
+//
+// Parameters:
+//   - buffer: Number of events kept for the listener while it is busy
+//
+// Returns:
+//   - <-chan SightingEvent: The channel receiving the events
+//   - func(): Unsubscribes and closes the channel
+func (tracker *SightingTracker) Listen(buffer int) (<-chan SightingEvent, func()) {
+	listener := make(chan SightingEvent, buffer)
+	tracker.mutex.Lock()
+	tracker.listeners[listener] = true
+	tracker.mutex.Unlock()
+
+	var once sync.Once
+	return listener, func() {
+		once.Do(func() {
+			tracker.mutex.Lock()
+			delete(tracker.listeners, listener)
+			tracker.mutex.Unlock()
+			close(listener)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package pstree
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// MACOS CODE SIGNING
+//------------------------------------------------------------------------------
+// Functions in this section resolve the .app bundle that owns a process and
+// classify how its executable is signed.
+
+// Signing classifications reported by --show-signing
+const (
+	SigningAdHoc      = "ad-hoc"      // Signed without an identity, e.g., by the linker
+	SigningApple      = "apple"       // Apple platform binary
+	SigningThirdParty = "third-party" // Signed with a Developer ID or App Store identity
+	SigningUnknown    = "?"           // Signature could not be inspected
+	SigningUnsigned   = "unsigned"    // No signature at all
+)
+
+// findAppBundle returns the name of the outermost .app bundle containing an executable.
+//
+// Parameters:
+//   - path: Full path of the executable
+//
+// Returns:
+//   - The bundle name without the .app suffix, e.g., "Safari", or "" if the executable is not in a bundle
+func findAppBundle(path string) string {
+	for _, component := range strings.Split(filepath.ToSlash(path), "/") {
+		if strings.HasSuffix(component, ".app") && len(component) > len(".app") {
+			return strings.TrimSuffix(component, ".app")
+		}
+	}
+	return ""
+}
+
+// parseCodesign classifies an executable based on the output of `codesign -dv --verbose=2`.
+//
+// Parameters:
+//   - output: Combined output of codesign, which writes its details to stderr
+//
+// Returns:
+//   - One of the Signing* constants
+func parseCodesign(output string) string {
+	if strings.Contains(output, "not signed at all") {
+		return SigningUnsigned
+	}
+
+	signed := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "Signature=adhoc", strings.HasPrefix(line, "CodeDirectory") && strings.Contains(line, "(adhoc)"):
+			return SigningAdHoc
+		case line == "Authority=Software Signing":
+			// Only Apple's own binaries are signed by the "Software Signing" authority
+			return SigningApple
+		case strings.HasPrefix(line, "Authority="), strings.HasPrefix(line, "TeamIdentifier="):
+			signed = true
+		}
+	}
+
+	if signed {
+		return SigningThirdParty
+	}
+	return SigningUnknown
+}
+
+// annotateSigning sets the bundle and signing classification of every process,
+// inspecting each distinct executable only once.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//   - inspect: Function returning the signing classification of an executable
+func annotateSigning(processes *[]tree.Process, inspect func(path string) string) {
+	cache := map[string]string{}
+	for i := range *processes {
+		proc := &(*processes)[i]
+		if !filepath.IsAbs(proc.Command) {
+			proc.Signing = SigningUnknown
+			continue
+		}
+		signing, ok := cache[proc.Command]
+		if !ok {
+			signing = inspect(proc.Command)
+			cache[proc.Command] = signing
+		}
+		proc.Bundle = findAppBundle(proc.Command)
+		proc.Signing = signing
+	}
+}
@@ -0,0 +1,34 @@
+//go:build darwin
+// +build darwin
+
+package pstree
+
+import (
+	"os/exec"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateSigning resolves the owning .app bundle and code signing status of every process.
+//
+// Signatures are inspected with codesign(1), which uses the Security framework to
+// validate the executable's code directory and certificate chain.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Error if codesign is not available
+func AnnotateSigning(processes *[]tree.Process) error {
+	codesign, err := exec.LookPath("codesign")
+	if err != nil {
+		return err
+	}
+
+	annotateSigning(processes, func(path string) string {
+		// codesign exits non-zero for unsigned binaries, so the output is always parsed
+		output, _ := exec.Command(codesign, "-dv", "--verbose=2", path).CombinedOutput()
+		return parseCodesign(string(output))
+	})
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !darwin
+// +build !darwin
+
+package pstree
+
+import (
+	"errors"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+// AnnotateSigning resolves the owning .app bundle and code signing status of every process.
+//
+// App bundles and codesign(1) only exist on macOS, so this function always returns
+// an error on other platforms.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to annotate
+//
+// Returns:
+//   - error: Error indicating that the operation is not supported
+func AnnotateSigning(processes *[]tree.Process) error {
+	return errors.New("--show-signing is only supported on macOS")
+}
@@ -0,0 +1,120 @@
+package pstree
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// SNAPSHOTS
+//------------------------------------------------------------------------------
+// Functions in this section save the collected processes to a file and load them
+// back, so that a tree can be rendered offline with any display flags, e.g., for
+// postmortem analysis or tests that do not depend on the live system.
+
+// SnapshotVersion is the version of the snapshot file format written by WriteSnapshot.
+const SnapshotVersion = 1
+
+// Snapshot is the contents of a snapshot file.
+type Snapshot struct {
+	Version   int            `json:"version"`   // Version of the file format
+	Hostname  string         `json:"hostname"`  // Host the processes were collected on
+	OS        string         `json:"os"`        // Operating system of the host, e.g., linux
+	Taken     time.Time      `json:"taken"`     // Time the processes were collected
+	Processes []tree.Process `json:"processes"` // The collected processes, in PID order
+}
+
+// WriteSnapshot writes the collected processes as a snapshot.
+//
+// Parameters:
+//   - output: Writer to write the snapshot to
+//   - processes: The collected processes
+//
+// Returns:
+//   - error: Error if the snapshot could not be encoded
+func WriteSnapshot(output io.Writer, processes []tree.Process) error {
+	hostname, _ := os.Hostname()
+	snapshot := Snapshot{
+		Version:   SnapshotVersion,
+		Hostname:  hostname,
+		OS:        runtime.GOOS,
+		Taken:     time.Now().UTC(),
+		Processes: processes,
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// SaveSnapshot writes the collected processes to a snapshot file.
+//
+// Parameters:
+//   - path: Path of the snapshot file to create
+//   - processes: The collected processes
+//
+// Returns:
+//   - error: Error if the file could not be written
+func SaveSnapshot(path string, processes []tree.Process) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	if err := WriteSnapshot(file, processes); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadSnapshot reads a snapshot.
+//
+// The tree links of each process are reset, so that a snapshot can be built into
+// a tree exactly like freshly collected processes.
+//
+// Parameters:
+//   - input: Reader to read the snapshot from
+//
+// Returns:
+//   - Snapshot: The snapshot
+//   - error: Error if the snapshot is malformed or has an unsupported version
+func ReadSnapshot(input io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(input).Decode(&snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("malformed snapshot: %w", err)
+	}
+	if snapshot.Version != SnapshotVersion {
+		return Snapshot{}, fmt.Errorf("unsupported snapshot version %d; expected %d", snapshot.Version, SnapshotVersion)
+	}
+	for i := range snapshot.Processes {
+		proc := &snapshot.Processes[i]
+		proc.Child = -1
+		proc.Parent = -1
+		proc.Sister = -1
+		proc.Children = &[]tree.Process{}
+		proc.Print = false
+	}
+	return snapshot, nil
+}
+
+// LoadSnapshot reads a snapshot file.
+//
+// Parameters:
+//   - path: Path of the snapshot file
+//
+// Returns:
+//   - Snapshot: The snapshot
+//   - error: Error if the file could not be read or is not a valid snapshot
+func LoadSnapshot(path string) (Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	defer file.Close()
+	return ReadSnapshot(file)
+}
@@ -0,0 +1,118 @@
+package pstree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gdanko/pstree/pkg/tree"
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// PROCESS TAGGING
+//------------------------------------------------------------------------------
+// Functions in this section load user-defined tags from a mapping file and
+// attach them to processes, enabling organizational views of shared hosts.
+
+// TagRule maps either a PID or a command pattern to a label.
+type TagRule struct {
+	Label   string         // Label to attach to matching processes
+	PID     int32          // PID to match, or 0 to match by pattern
+	Pattern *regexp.Regexp // Regular expression matched against the command
+}
+
+// LoadTagRules reads a tags file and returns the rules it contains.
+//
+// Parameters:
+//   - path: Path of the tags file
+//
+// Returns:
+//   - []TagRule: The parsed rules, in file order
+//   - error: Error if the file could not be read or contains an invalid rule
+func LoadTagRules(path string) ([]TagRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags file: %w", err)
+	}
+	return parseTagRules(string(data))
+}
+
+// parseTagRules parses the contents of a tags file.
+//
+// Each non-empty line that does not start with '#' maps a selector to a label:
+//
+//	nginx           → web-team
+//	pid:1234        -> db-team
+//	^/usr/sbin/sshd   infra
+//
+// A selector of the form pid:<n> matches a single process; anything else is a
+// regular expression matched against the command path and its basename.
+//
+// Parameters:
+//   - data: Contents of the tags file
+//
+// Returns:
+//   - []TagRule: The parsed rules, in file order
+//   - error: Error if a line is malformed or a pattern does not compile
+func parseTagRules(data string) ([]TagRule, error) {
+	rules := []TagRule{}
+	for lineNumber, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := []string{}
+		for _, field := range strings.Fields(line) {
+			if field != "→" && field != "->" {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("tags file line %d: expected '<pattern> → <label>'", lineNumber+1)
+		}
+
+		rule := TagRule{Label: fields[1]}
+		if strings.HasPrefix(fields[0], "pid:") {
+			rule.PID = util.StrToInt32(strings.TrimPrefix(fields[0], "pid:"))
+			if rule.PID < 1 {
+				return nil, fmt.Errorf("tags file line %d: invalid pid selector '%s'", lineNumber+1, fields[0])
+			}
+		} else {
+			pattern, err := regexp.Compile(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("tags file line %d: %w", lineNumber+1, err)
+			}
+			rule.Pattern = pattern
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ApplyTags attaches the labels of all matching rules to each process.
+// A label is only attached once per process, even if several rules match.
+//
+// Parameters:
+//   - processes: Pointer to a slice of Process structs to tag
+//   - rules: Rules returned by LoadTagRules
+func ApplyTags(processes *[]tree.Process, rules []TagRule) {
+	for i := range *processes {
+		proc := &(*processes)[i]
+		for _, rule := range rules {
+			if rule.PID > 0 {
+				if proc.PID != rule.PID {
+					continue
+				}
+			} else if !rule.Pattern.MatchString(proc.Command) && !rule.Pattern.MatchString(filepath.Base(proc.Command)) {
+				continue
+			}
+			if !util.Contains(proc.Tags, rule.Label) {
+				proc.Tags = append(proc.Tags, rule.Label)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package pstree
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gdanko/pstree/pkg/tree"
+)
+
+//------------------------------------------------------------------------------
+// WATCH MODE
+//------------------------------------------------------------------------------
+// Functions in this section track processes across --watch samples so that
+// processes which appeared or exited between refreshes can be highlighted, and
+// optionally record their lifetimes for a Gantt chart.
+
+// watchKey identifies a process across samples. The creation time guards
+// against a PID being reused by an unrelated process between samples.
+type watchKey struct {
+	PID        int32
+	CreateTime int64
+}
+
+// WatchState remembers the displayed processes of the previous --watch sample.
+type WatchState struct {
+	previous  map[watchKey]tree.Process // Processes displayed in the previous sample
+	primed    bool                      // Whether a previous sample exists
+	recording bool                      // Whether lifetimes are recorded
+	lifetimes map[watchKey]*Lifetime    // Recorded lifetimes of every process displayed in any sample
+	started   time.Time                 // Time of the first sample
+	sampled   time.Time                 // Time of the latest sample
+	now       func() time.Time          // Clock used to timestamp samples
+}
+
+// Lifetime is the observed lifetime of a process during a recording.
+type Lifetime struct {
+	PID     int32     // Process ID
+	PPID    int32     // Parent process ID
+	Command string    // Command name
+	Start   time.Time // Creation time, or when the process was first seen if it is unavailable
+	End     time.Time // Time of the first sample the process was missing from, or zero if it is still running
+}
+
+// Recording is the result of recording lifetimes across --watch samples.
+type Recording struct {
+	Start     time.Time  // Time of the first sample
+	End       time.Time  // Time of the latest sample
+	Lifetimes []Lifetime // Lifetimes ordered by start time and PID
+}
+
+// NewWatchState creates an empty WatchState.
+//
+// Returns:
+//   - *WatchState: A state with no previous sample
+func NewWatchState() *WatchState {
+	return &WatchState{
+		previous:  map[watchKey]tree.Process{},
+		lifetimes: map[watchKey]*Lifetime{},
+		now:       time.Now,
+	}
+}
+
+// Record enables recording the lifetimes of the displayed processes in subsequent samples.
+func (state *WatchState) Record() {
+	state.recording = true
+}
+
+// Compare marks the processes that appeared since the previous sample and returns the
+// processes that exited, then remembers the current sample for the next comparison.
+//
+// Only processes marked for printing are considered, so that filters such as --user
+// also apply to the reported changes. Nothing is marked as appeared on the first sample.
+//
+// Parameters:
+//   - processes: Pointer to the marked processes of the current sample
+//
+// Returns:
+//   - []tree.Process: Processes displayed in the previous sample that are no longer displayed, in PID order
+func (state *WatchState) Compare(processes *[]tree.Process) []tree.Process {
+	now := state.now()
+	if !state.primed {
+		state.started = now
+	}
+	state.sampled = now
+
+	current := map[watchKey]tree.Process{}
+	for i := range *processes {
+		proc := &(*processes)[i]
+		if !proc.Print {
+			continue
+		}
+		key := watchKey{PID: proc.PID, CreateTime: proc.CreateTime}
+		current[key] = *proc
+		if _, exists := state.previous[key]; state.primed && !exists {
+			proc.Appeared = true
+		}
+		if _, exists := state.lifetimes[key]; state.recording && !exists {
+			start := now
+			if proc.Available(tree.FieldAge) && proc.CreateTime > 0 {
+				start = time.UnixMilli(proc.CreateTime)
+			}
+			state.lifetimes[key] = &Lifetime{PID: proc.PID, PPID: proc.PPID, Command: proc.Command, Start: start}
+		}
+	}
+
+	exited := []tree.Process{}
+	for key, proc := range state.previous {
+		if _, exists := current[key]; !exists {
+			exited = append(exited, proc)
+			if lifetime, recorded := state.lifetimes[key]; recorded {
+				lifetime.End = now
+			}
+		}
+	}
+	SortProcsByPid(&exited)
+
+	state.previous = current
+	state.primed = true
+	return exited
+}
+
+// Recording returns the lifetimes recorded so far.
+//
+// Returns:
+//   - Recording: The recorded lifetimes, ordered by start time and PID
+func (state *WatchState) Recording() Recording {
+	recording := Recording{Start: state.started, End: state.sampled, Lifetimes: []Lifetime{}}
+	for _, lifetime := range state.lifetimes {
+		recording.Lifetimes = append(recording.Lifetimes, *lifetime)
+	}
+	sort.Slice(recording.Lifetimes, func(i, j int) bool {
+		left, right := recording.Lifetimes[i], recording.Lifetimes[j]
+		if !left.Start.Equal(right.Start) {
+			return left.Start.Before(right.Start)
+		}
+		return left.PID < right.PID
+	})
+	return recording
+}
@@ -0,0 +1,187 @@
+// Package terminal detects the capabilities of the terminal pstree writes to, e.g., its number
+// of colors, its width, and whether it can display Unicode, from the environment and the
+// terminfo database, without running external commands.
+package terminal
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	dimensions "github.com/wayneashleyberry/terminal-dimensions"
+	"golang.org/x/term"
+)
+
+// DefaultWidth is the width assumed when the width of the terminal cannot be determined.
+const DefaultWidth = 132
+
+// DefaultHeight is the height assumed when the height of the terminal cannot be determined.
+const DefaultHeight = 24
+
+// Capabilities describes what the terminal can display.
+type Capabilities struct {
+	// Number of colors, or 0 if the terminal cannot display at least 8 colors
+	Colors int
+	// Whether the standard output is a terminal
+	Interactive bool
+	// Whether the terminal can display Unicode characters
+	Unicode bool
+	// Width in characters
+	Width int
+}
+
+// detector detects capabilities from the environment and the terminfo database of a host whose
+// filesystem is mounted at root, so that detection can be tested against a fake host.
+type detector struct {
+	getenv func(key string) string
+	goos   string
+	root   string
+}
+
+// newDetector returns a detector for this host.
+func newDetector() *detector {
+	return &detector{getenv: os.Getenv, goos: runtime.GOOS, root: "/"}
+}
+
+// Detect detects every capability of the terminal.
+//
+// Returns:
+//   - Capabilities: The capabilities of the terminal
+func Detect() Capabilities {
+	return Capabilities{Colors: Colors(), Interactive: Interactive(), Unicode: Unicode(), Width: Width()}
+}
+
+// Colors returns the number of colors the terminal can display.
+//
+// Windows consoles are assumed to display 256 colors. Elsewhere, the number is read from the
+// max_colors capability of the terminfo entry for TERM, as tput colors does, and raised to 256
+// when COLORTERM announces true color. Without a terminfo entry, it is guessed from the name
+// of the terminal, e.g., xterm-256color.
+//
+// Returns:
+//   - int: The number of colors, or 0 if the terminal cannot display at least 8 colors
+func Colors() int {
+	return newDetector().colors()
+}
+
+// Unicode returns true if the terminal can display Unicode characters: on Windows, if the
+// console uses the UTF-8 code page, and elsewhere, if the locale selected by LC_ALL,
+// LC_CTYPE, or LANG uses UTF-8.
+//
+// Returns:
+//   - bool: true if the terminal uses UTF-8
+func Unicode() bool {
+	return newDetector().unicode()
+}
+
+// Interactive returns true if the standard output is a terminal rather than, e.g., a pipe
+// or a file, so that output can be adapted to it, e.g., with Unicode line drawing characters.
+//
+// Returns:
+//   - bool: true if the standard output is a terminal
+func Interactive() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Width returns the width of the terminal, from the terminal itself or the COLUMNS
+// environment variable, e.g., when the output is piped.
+//
+// Returns:
+//   - int: The width in characters, or DefaultWidth if it is unknown
+func Width() int {
+	if width, err := dimensions.Width(); err == nil && width > 0 {
+		return int(width)
+	}
+	return newDetector().columns()
+}
+
+// Height returns the height of the terminal, from the terminal itself or the LINES
+// environment variable.
+//
+// Returns:
+//   - int: The height in lines, or DefaultHeight if it is unknown
+func Height() int {
+	if height, err := dimensions.Height(); err == nil && height > 0 {
+		return int(height)
+	}
+	return newDetector().lines()
+}
+
+// colors implements Colors.
+func (detector *detector) colors() int {
+	switch detector.goos {
+	case "windows":
+		return 256
+	case "darwin", "linux", "freebsd", "netbsd", "openbsd":
+	default:
+		return 0
+	}
+
+	name := detector.getenv("TERM")
+	if name == "" || name == "dumb" {
+		return 0
+	}
+
+	colors, found := -1, false
+	if path := FindTerminfo(detector.root, name, detector.getenv); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			colors, found = parseMaxColors(data)
+		}
+	}
+	if !found {
+		colors = guessColors(name)
+	}
+
+	switch strings.ToLower(detector.getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		colors = max(colors, 256)
+	}
+	if colors < 8 {
+		return 0
+	}
+	return colors
+}
+
+// guessColors guesses the number of colors of a terminal without a terminfo entry from
+// its name.
+//
+// Parameters:
+//   - term: The name of the terminal, e.g., xterm-256color
+//
+// Returns:
+//   - int: The number of colors, or 0 if the terminal is not known to display colors
+func guessColors(term string) int {
+	switch {
+	case strings.Contains(term, "256color"):
+		return 256
+	case strings.Contains(term, "mono"):
+		return 0
+	case strings.Contains(term, "color"), strings.HasPrefix(term, "xterm"), strings.HasPrefix(term, "screen"),
+		strings.HasPrefix(term, "tmux"), strings.HasPrefix(term, "rxvt"), term == "linux", term == "cygwin", term == "ansi":
+		return 8
+	}
+	return 0
+}
+
+// columns returns the width from the COLUMNS environment variable.
+//
+// Returns:
+//   - int: The width in characters, or DefaultWidth if COLUMNS is not a positive number
+func (detector *detector) columns() int {
+	if columns, err := strconv.Atoi(detector.getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return DefaultWidth
+}
+
+// lines returns the height from the LINES environment variable.
+//
+// Returns:
+//   - int: The height in lines, or DefaultHeight if LINES is not a positive number
+func (detector *detector) lines() int {
+	if lines, err := strconv.Atoi(detector.getenv("LINES")); err == nil && lines > 0 {
+		return lines
+	}
+	return DefaultHeight
+}
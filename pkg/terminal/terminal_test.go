@@ -0,0 +1,124 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compileTerminfo returns a compiled terminfo entry with the given max_colors, or without
+// it if colors is nil
+func compileTerminfo(names string, colors *int, extended bool) []byte {
+	magic := terminfoMagic
+	if extended {
+		magic = terminfoMagicExtended
+	}
+	numberCount := 0
+	if colors != nil {
+		numberCount = terminfoMaxColors + 1
+	}
+	boolCount := 3
+	data := []byte{}
+	for _, value := range []int{magic, len(names) + 1, boolCount, numberCount, 0, 0} {
+		data = binary.LittleEndian.AppendUint16(data, uint16(value))
+	}
+	data = append(data, names...)
+	data = append(data, 0)
+	data = append(data, make([]byte, boolCount)...)
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+	for i := 0; i < numberCount; i++ {
+		value := -1
+		if i == terminfoMaxColors {
+			value = *colors
+		}
+		if extended {
+			data = binary.LittleEndian.AppendUint32(data, uint32(int32(value)))
+		} else {
+			data = binary.LittleEndian.AppendUint16(data, uint16(int16(value)))
+		}
+	}
+	return data
+}
+
+func TestParseMaxColors(t *testing.T) {
+	eight, many := 8, 256
+	colors, ok := parseMaxColors(compileTerminfo("xterm|xterm terminal emulator", &eight, false))
+	assert.True(t, ok)
+	assert.Equal(t, 8, colors)
+
+	colors, ok = parseMaxColors(compileTerminfo("xterm-256color|xterm with 256 colors", &many, true))
+	assert.True(t, ok)
+	assert.Equal(t, 256, colors)
+
+	colors, ok = parseMaxColors(compileTerminfo("vt100|dec vt100", nil, false))
+	assert.True(t, ok)
+	assert.Equal(t, -1, colors)
+
+	_, ok = parseMaxColors([]byte("not a terminfo entry"))
+	assert.False(t, ok)
+	_, ok = parseMaxColors(compileTerminfo("xterm", &eight, false)[:20])
+	assert.False(t, ok)
+}
+
+func TestColors(t *testing.T) {
+	root := t.TempDir()
+	eight, many := 8, 256
+	entries := map[string][]byte{
+		"/usr/share/terminfo/x/xterm":          compileTerminfo("xterm", &eight, false),
+		"/usr/share/terminfo/x/xterm-256color": compileTerminfo("xterm-256color", &many, true),
+		"/usr/share/terminfo/76/vt100":         compileTerminfo("vt100", nil, false),
+		"/home/alice/.terminfo/x/xterm":        compileTerminfo("xterm", &many, false),
+	}
+	for path, data := range entries {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), data, 0644))
+	}
+
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want int
+	}{
+		{"terminfo", "linux", map[string]string{"TERM": "xterm"}, 8},
+		{"extended terminfo", "linux", map[string]string{"TERM": "xterm-256color"}, 256},
+		{"hexadecimal directory", "darwin", map[string]string{"TERM": "vt100"}, 0},
+		{"home terminfo first", "linux", map[string]string{"TERM": "xterm", "HOME": "/home/alice"}, 256},
+		{"true color", "linux", map[string]string{"TERM": "xterm", "COLORTERM": "truecolor"}, 256},
+		{"guessed from name", "linux", map[string]string{"TERM": "screen-256color"}, 256},
+		{"unknown terminal", "linux", map[string]string{"TERM": "unknown"}, 0},
+		{"dumb", "linux", map[string]string{"TERM": "dumb", "COLORTERM": "truecolor"}, 0},
+		{"no terminal", "linux", map[string]string{}, 0},
+		{"windows", "windows", map[string]string{}, 256},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			detector := &detector{getenv: func(key string) string { return test.env[key] }, goos: test.goos, root: root}
+			assert.Equal(t, test.want, detector.colors())
+		})
+	}
+}
+
+func TestWidth(t *testing.T) {
+	assert.GreaterOrEqual(t, Width(), 40)
+
+	detector := &detector{getenv: func(key string) string { return map[string]string{"COLUMNS": "87"}[key] }}
+	assert.Equal(t, 87, detector.columns())
+	detector.getenv = func(string) string { return "wide" }
+	assert.Equal(t, DefaultWidth, detector.columns())
+}
+
+func TestHeight(t *testing.T) {
+	assert.Greater(t, Height(), 0)
+
+	detector := &detector{getenv: func(key string) string { return map[string]string{"LINES": "50"}[key] }}
+	assert.Equal(t, 50, detector.lines())
+	detector.getenv = func(string) string { return "tall" }
+	assert.Equal(t, DefaultHeight, detector.lines())
+}
@@ -0,0 +1,133 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// TERMINFO
+//------------------------------------------------------------------------------
+// Functions in this section read the compiled terminfo database, see term(5),
+// so that the number of colors is known without running tput.
+
+const (
+	// terminfoMagic marks compiled entries with 16-bit numbers
+	terminfoMagic = 0o432
+	// terminfoMagicExtended marks compiled entries with 32-bit numbers, written by ncurses 6.1 and later
+	terminfoMagicExtended = 0o1036
+	// terminfoMaxColors is the index of the max_colors capability among the numbers
+	terminfoMaxColors = 13
+)
+
+// terminfoDirs lists the directories searched after those given by the environment, as ncurses does.
+var terminfoDirs = []string{
+	"/etc/terminfo",
+	"/lib/terminfo",
+	"/usr/share/terminfo",
+	"/usr/lib/terminfo",
+	"/usr/local/share/terminfo",
+}
+
+// FindTerminfo returns the path of the compiled terminfo entry for a terminal, searching
+// $TERMINFO, ~/.terminfo, $TERMINFO_DIRS, and the system directories in that order.
+//
+// Parameters:
+//   - root: Directory the searched directories are relative to, / for this host
+//   - term: The name of the terminal, e.g., xterm-256color
+//   - getenv: Returns the value of an environment variable
+//
+// Returns:
+//   - string: The path of the entry, or an empty string if there is none
+func FindTerminfo(root string, term string, getenv func(key string) string) string {
+	if term == "" || strings.ContainsAny(term, `/\`) {
+		return ""
+	}
+
+	dirs := []string{}
+	if dir := getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home := getenv("HOME"); home != "" {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	if value := getenv("TERMINFO_DIRS"); value != "" {
+		for _, dir := range strings.Split(value, ":") {
+			// An empty entry stands for the system directories
+			if dir == "" {
+				dirs = append(dirs, terminfoDirs...)
+			} else {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	dirs = append(dirs, terminfoDirs...)
+
+	for _, dir := range dirs {
+		// Entries are filed under their first letter, or its hexadecimal code, e.g., on macOS
+		for _, letter := range []string{term[:1], fmt.Sprintf("%02x", term[0])} {
+			path := filepath.Join(root, dir, letter, term)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// parseMaxColors reads the max_colors capability of a compiled terminfo entry.
+//
+// Parameters:
+//   - data: The contents of the entry
+//
+// Returns:
+//   - int: The number of colors, or -1 if the entry does not define it
+//   - bool: true if data is a valid compiled entry
+func parseMaxColors(data []byte) (int, bool) {
+	if len(data) < 12 {
+		return -1, false
+	}
+	header := make([]int, 6)
+	for i := range header {
+		header[i] = int(int16(binary.LittleEndian.Uint16(data[i*2:])))
+	}
+	magic, nameSize, boolCount, numberCount := header[0], header[1], header[2], header[3]
+
+	numberSize := 2
+	switch magic {
+	case terminfoMagic:
+	case terminfoMagicExtended:
+		numberSize = 4
+	default:
+		return -1, false
+	}
+	if nameSize < 0 || boolCount < 0 || numberCount < 0 {
+		return -1, false
+	}
+	if numberCount <= terminfoMaxColors {
+		return -1, true
+	}
+
+	// The numbers start on an even byte after the names and booleans
+	offset := 12 + nameSize + boolCount
+	offset += offset % 2
+	offset += terminfoMaxColors * numberSize
+	if offset+numberSize > len(data) {
+		return -1, false
+	}
+
+	var colors int
+	if numberSize == 4 {
+		colors = int(int32(binary.LittleEndian.Uint32(data[offset:])))
+	} else {
+		colors = int(int16(binary.LittleEndian.Uint16(data[offset:])))
+	}
+	// Negative values mark absent or cancelled capabilities
+	if colors < 0 {
+		return -1, true
+	}
+	return colors, true
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package terminal
+
+import (
+	"strings"
+)
+
+// unicode returns true if the locale of the environment uses UTF-8. As in the C library,
+// LC_ALL overrides LC_CTYPE, which overrides LANG, and the first one set decides, e.g.,
+// LC_ALL=C disables Unicode whatever LANG is.
+func (detector *detector) unicode() bool {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := detector.getenv(key); locale != "" {
+			return isUTF8Locale(locale)
+		}
+	}
+	return false
+}
+
+// isUTF8Locale returns true if the codeset of a locale is UTF-8, e.g., en_US.UTF-8,
+// C.utf8, or de_DE.UTF-8@euro.
+//
+// Parameters:
+//   - locale: The locale, as language[_territory][.codeset][@modifier]
+//
+// Returns:
+//   - bool: true if the codeset is UTF-8
+func isUTF8Locale(locale string) bool {
+	_, codeset, found := strings.Cut(locale, ".")
+	if !found {
+		return false
+	}
+	codeset, _, _ = strings.Cut(codeset, "@")
+	codeset = strings.ToLower(codeset)
+	return codeset == "utf-8" || codeset == "utf8"
+}
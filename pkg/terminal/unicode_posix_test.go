@@ -0,0 +1,43 @@
+//go:build !windows
+// +build !windows
+
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnicode(t *testing.T) {
+	for locale, want := range map[string]bool{
+		"en_US.UTF-8":       true,
+		"C.utf8":            true,
+		"de_DE.UTF-8@euro":  true,
+		"en_US.ISO-8859-1":  false,
+		"C":                 false,
+		"POSIX":             false,
+		"fr_FR@euro":        false,
+		"ja_JP.eucJP":       false,
+		"en_US.UTF-8-extra": false,
+	} {
+		assert.Equal(t, want, isUTF8Locale(locale), locale)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"lang", map[string]string{"LANG": "en_US.UTF-8"}, true},
+		{"lc_ctype overrides lang", map[string]string{"LANG": "C", "LC_CTYPE": "en_US.UTF-8"}, true},
+		{"lc_all overrides everything", map[string]string{"LANG": "en_US.UTF-8", "LC_CTYPE": "en_US.UTF-8", "LC_ALL": "C"}, false},
+		{"no locale", map[string]string{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			detector := &detector{getenv: func(key string) string { return test.env[key] }}
+			assert.Equal(t, test.want, detector.unicode())
+		})
+	}
+}
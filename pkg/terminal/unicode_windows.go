@@ -1,13 +1,14 @@
 //go:build windows
 // +build windows
 
-package util
+package terminal
 
 import (
 	"golang.org/x/sys/windows"
 )
 
-func hasUnicodeSupport() bool {
+// unicode returns true if the output code page of the console is UTF-8.
+func (detector *detector) unicode() bool {
 	const CP_UTF8 = 65001
 	outCP, err := windows.GetConsoleOutputCP()
 	return err == nil && outCP == CP_UTF8
@@ -0,0 +1,114 @@
+package tree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// CPU AFFINITY AND NUMA PLACEMENT
+//------------------------------------------------------------------------------
+// Functions in this section display the CPUs each process may run on and the
+// NUMA nodes holding its memory, and highlight heavy processes held back by
+// their pinning or paying for remote memory accesses.
+
+const (
+	// affinityBoundPercent is the usage, in percent of the capacity of its allowed CPUs,
+	// from which a pinned process is considered held back by its pinning
+	affinityBoundPercent = 80
+	// affinityHeavyCPU is the CPU usage percentage from which a process split across NUMA nodes is highlighted
+	affinityHeavyCPU = 50
+	// affinityHeavyRSS is the resident memory from which a process split across NUMA nodes is highlighted
+	affinityHeavyRSS = 1 << 30
+)
+
+// FormatCPUList formats CPU or NUMA node numbers as a list of ranges, e.g., 0-3,8,10-11,
+// as used by taskset and /proc/<pid>/status.
+//
+// Parameters:
+//   - cpus: The numbers, in ascending order
+//
+// Returns:
+//   - The formatted list
+func FormatCPUList(cpus []int) string {
+	ranges := []string{}
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if j == i {
+			ranges = append(ranges, strconv.Itoa(cpus[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", cpus[i], cpus[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ",")
+}
+
+// Pinned reports whether the process may only run on some of the online CPUs.
+//
+// Parameters:
+//   - onlineCPUs: The number of online CPUs, or 0 if unknown
+//
+// Returns:
+//   - true if the affinity of the process is known and excludes some online CPUs
+func (process *Process) Pinned(onlineCPUs int) bool {
+	return process.CPUAffinity != nil && onlineCPUs > 0 && len(process.CPUAffinity) < onlineCPUs
+}
+
+// affinityAlert returns true if a process is pinned and uses nearly all of its allowed CPUs,
+// or is heavy and has its memory split across NUMA nodes.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the placement of the process is likely to hurt its performance
+func (processTree *ProcessTree) affinityAlert(pidIndex int) bool {
+	process := &processTree.Nodes[pidIndex]
+	cpuPercent := 0.0
+	if process.Available(FieldCPUPercent) {
+		cpuPercent = process.CPUPercent
+	}
+	if process.Pinned(processTree.DisplayOptions.OnlineCPUs) && cpuPercent >= float64(affinityBoundPercent*len(process.CPUAffinity)) {
+		return true
+	}
+	if len(process.NUMANodes) > 1 {
+		heavy := cpuPercent >= affinityHeavyCPU
+		if process.Available(FieldMemory) && process.MemoryInfo != nil && process.MemoryInfo.RSS >= affinityHeavyRSS {
+			heavy = true
+		}
+		return heavy
+	}
+	return false
+}
+
+// formatAffinity returns the CPUs a process may run on and the NUMA nodes holding its memory,
+// e.g., [cpus:0-3 numa:0], or [cpus:all numa:0,1] when it may run on every online CPU.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted placement, or an empty string if neither is known
+func (processTree *ProcessTree) formatAffinity(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	parts := []string{}
+	if process.CPUAffinity != nil {
+		if process.Pinned(processTree.DisplayOptions.OnlineCPUs) || processTree.DisplayOptions.OnlineCPUs == 0 {
+			parts = append(parts, "cpus:"+FormatCPUList(process.CPUAffinity))
+		} else {
+			parts = append(parts, "cpus:all")
+		}
+	}
+	if len(process.NUMANodes) > 0 {
+		parts = append(parts, "numa:"+FormatCPUList(process.NUMANodes))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, " "))
+}
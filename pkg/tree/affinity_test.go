@@ -0,0 +1,35 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShowAffinity verifies CPU and NUMA placement is shown and poorly placed heavy processes are highlighted
+func TestShowAffinity(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", CPUAffinity: []int{0, 1, 2, 3, 4, 5, 6, 7}, NUMANodes: []int{0}},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", CPUAffinity: []int{0, 1, 4}, CPUPercent: 250},
+		{PID: 20, PPID: 1, Command: "/usr/bin/postgres", CPUAffinity: []int{0, 1, 2, 3, 4, 5, 6, 7}, NUMANodes: []int{0, 1}, CPUPercent: 75},
+		{PID: 30, PPID: 1, Command: "/usr/bin/redis", CPUAffinity: []int{2}, CPUPercent: 10, NUMANodes: []int{0, 1}},
+		{PID: 40, PPID: 1, Command: "/usr/bin/vim"},
+	}
+	options := DisplayOptions{ColorCount: 256, ColorSupport: true, MaxDepth: 999, OnlineCPUs: 8, ScreenWidth: 132, ShowAffinity: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	assert.Equal(t, "[cpus:all numa:0]", processTree.formatAffinity(0))
+	assert.Equal(t, "[cpus:0-1,4]", processTree.formatAffinity(1))
+	assert.Equal(t, "[cpus:all numa:0-1]", processTree.formatAffinity(2))
+	assert.Equal(t, "", processTree.formatAffinity(4))
+
+	// nginx uses more than 80% of its 3 CPUs, postgres is busy and split across nodes,
+	// and redis is pinned and split but light
+	assert.Equal(t, []bool{false, true, true, false, false}, []bool{processTree.affinityAlert(0), processTree.affinityAlert(1), processTree.affinityAlert(2), processTree.affinityAlert(3), processTree.affinityAlert(4)})
+
+	alert := "[cpus:0-1,4]"
+	processTree.Colorizer.Crit(processTree.ColorScheme, &alert)
+	assert.Contains(t, processTree.buildLineItem(" ", 1), alert)
+}
@@ -14,8 +14,10 @@ package tree
 import (
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"runtime"
+	"slices"
 
 	"github.com/gdanko/pstree/pkg/color"
 )
@@ -26,6 +28,59 @@ import (
 // Functions in this section handle the creation of the process tree structure
 // and establishing the hierarchical relationships between processes.
 
+// EffectiveDisplayOptions derives the options that rendering actually uses from the requested ones.
+//
+// Options that imply other options are resolved here, once, before the first line is rendered,
+// so that every line of output has the same columns. For example, coloring by an attribute
+// requires that attribute to be shown. The returned value shares no slices, maps, or pointers
+// with the input, so callers may keep modifying their copy without affecting a tree that is
+// being rendered.
+//
+// Parameters:
+//   - displayOptions: The options requested by the caller
+//
+// Returns:
+//   - A copy of displayOptions with all implied options applied
+func EffectiveDisplayOptions(displayOptions DisplayOptions) DisplayOptions {
+	effective := displayOptions
+	effective.EnvContains = slices.Clone(displayOptions.EnvContains)
+	effective.ExcludePatterns = slices.Clone(displayOptions.ExcludePatterns)
+	effective.ExcludeUsers = slices.Clone(displayOptions.ExcludeUsers)
+	effective.InGroups = slices.Clone(displayOptions.InGroups)
+	effective.NamespaceFilters = maps.Clone(displayOptions.NamespaceFilters)
+	if displayOptions.NiceAbove != nil {
+		niceAbove := *displayOptions.NiceAbove
+		effective.NiceAbove = &niceAbove
+	}
+	if displayOptions.NiceBelow != nil {
+		niceBelow := *displayOptions.NiceBelow
+		effective.NiceBelow = &niceBelow
+	}
+	effective.RequireEnv = slices.Clone(displayOptions.RequireEnv)
+	effective.Runtimes = slices.Clone(displayOptions.Runtimes)
+	effective.SelectedPIDs = slices.Clone(displayOptions.SelectedPIDs)
+	effective.ShowEnv = slices.Clone(displayOptions.ShowEnv)
+	effective.Tags = slices.Clone(displayOptions.Tags)
+	effective.Thresholds = maps.Clone(displayOptions.Thresholds)
+	effective.TTYs = slices.Clone(displayOptions.TTYs)
+	effective.Units = slices.Clone(displayOptions.Units)
+	effective.Usernames = slices.Clone(displayOptions.Usernames)
+
+	// Ensure the attribute being colored by is shown
+	switch effective.ColorAttr {
+	case "age":
+		effective.ShowProcessAge = true
+	case "cpu":
+		effective.ShowCpuPercent = true
+	case "latency":
+		effective.ShowSchedLatency = true
+	case "mem":
+		effective.ShowMemoryUsage = true
+	}
+
+	return effective
+}
+
 // NewProcessTree creates a new process tree from a slice of processes.
 //
 // This function initializes a ProcessTree structure, populates it with ProcessNode objects
@@ -40,36 +95,14 @@ import (
 // Returns:
 //   - A pointer to the newly created ProcessTree
 func NewProcessTree(debugLevel int, logger *slog.Logger, processes []Process, displayOptions DisplayOptions) (processTree *ProcessTree) {
-	var (
-		idx  int
-		proc Process
-	)
-
 	processTree = &ProcessTree{
 		AtDepth:        0,
 		DebugLevel:     debugLevel,
-		DisplayOptions: displayOptions,
-		IndexToPidMap:  make(map[int]int32, len(processes)),
+		DisplayOptions: EffectiveDisplayOptions(displayOptions),
 		Logger:         logger,
-		Nodes:          make([]Process, 0, len(processes)),
-		PidToIndexMap:  make(map[int32]int, len(processes)),
-		ProcessGroups:  make(map[int32]map[string]map[string]ProcessGroup),
 		RootPID:        displayOptions.RootPID,
-		SkipProcesses:  make(map[int]bool),
 	}
 
-	// Create nodes
-	for _, proc = range processes {
-		// Add to tree
-		idx = len(processTree.Nodes)
-		processTree.Nodes = append(processTree.Nodes, proc)
-		processTree.PidToIndexMap[proc.PID] = idx
-		processTree.IndexToPidMap[idx] = proc.PID
-	}
-
-	// If PID is not set via --pid, we want to look for PID 1...
-	// https://github.com/FredHucht/pstree/blob/main/pstree.c#L558-L587
-
 	// Define the tree characters
 	if processTree.DisplayOptions.IBM850Graphics {
 		processTree.TreeChars = TreeStyles["pc850"]
@@ -82,9 +115,10 @@ func NewProcessTree(debugLevel int, logger *slog.Logger, processes []Process, di
 	}
 
 	// Initialize the color scheme
-	// if 8 bit color (8-16) is detected, we will use the ansi8 color scheme
+	// if 8 bit color (8-16) is detected, we will use the ansi8 color scheme, keeping the semantic
+	// slots of a selected color-blind safe scheme
 	if processTree.DisplayOptions.ColorCount >= 8 && processTree.DisplayOptions.ColorCount <= 16 {
-		processTree.ColorScheme = color.ColorSchemes["ansi8"]
+		processTree.ColorScheme = color.ANSI8Scheme(processTree.DisplayOptions.ColorScheme)
 	} else if processTree.DisplayOptions.ColorCount >= 256 {
 		if processTree.DisplayOptions.ColorScheme != "" {
 			processTree.ColorScheme = color.ColorSchemes[processTree.DisplayOptions.ColorScheme]
@@ -106,8 +140,8 @@ func NewProcessTree(debugLevel int, logger *slog.Logger, processes []Process, di
 		}
 	}
 
-	// Initialize colorizer
-	if processTree.DisplayOptions.ColorizeOutput || processTree.DisplayOptions.ColorAttr != "" {
+	// Initialize colorizer; it is also needed without a color mode to highlight --watch changes
+	if processTree.DisplayOptions.ColorSupport || processTree.DisplayOptions.ColorizeOutput || processTree.DisplayOptions.ColorAttr != "" {
 		if processTree.DisplayOptions.ColorCount >= 8 && processTree.DisplayOptions.ColorCount <= 16 {
 			processTree.Colorizer = color.Colorizers["8color"]
 		} else if processTree.DisplayOptions.ColorCount >= 256 {
@@ -116,12 +150,59 @@ func NewProcessTree(debugLevel int, logger *slog.Logger, processes []Process, di
 	}
 
 	// Build the tree
+	processTree.Build(processes)
+
+	return processTree
+}
+
+// Build replaces the processes of the tree, connects each one to its parent, and annotates
+// them with what every display needs, e.g., UID transitions and zombie counts.
+//
+// Parameters:
+//   - processes: Slice of Process objects containing the process information
+func (processTree *ProcessTree) Build(processes []Process) {
+	var (
+		idx  int
+		proc Process
+	)
+
+	processTree.IndexToPidMap = make(map[int]int32, len(processes))
+	processTree.Nodes = make([]Process, 0, len(processes))
+	processTree.PidToIndexMap = make(map[int32]int, len(processes))
+	processTree.ProcessGroups = make(map[int32]map[string]map[string]ProcessGroup)
+	processTree.SkipProcesses = make(map[int]bool)
+
+	// Create nodes
+	for _, proc = range processes {
+		// Add to tree
+		idx = len(processTree.Nodes)
+		processTree.Nodes = append(processTree.Nodes, proc)
+		processTree.PidToIndexMap[proc.PID] = idx
+		processTree.IndexToPidMap[idx] = proc.PID
+	}
+
+	// If PID is not set via --pid, we want to look for PID 1...
+	// https://github.com/FredHucht/pstree/blob/main/pstree.c#L558-L587
+
+	// Connect the processes
 	processTree.BuildTree()
 
 	// Mark UID transitions
 	processTree.MarkUIDTransitions()
 
-	return processTree
+	// Mark the highlighted process and its ancestors
+	processTree.MarkCurrentAndAncestors(processTree.DisplayOptions.HighlightPID)
+
+	// Mark the processes matching --highlight
+	processTree.MarkHighlighted(processTree.DisplayOptions.Highlight)
+
+	// Count the zombie children of each process
+	processTree.CountZombies()
+
+	// Total the usage of each subtree
+	if processTree.DisplayOptions.Cumulative {
+		processTree.AggregateSubtrees()
+	}
 }
 
 // BuildTree constructs the hierarchical relationships between processes in the tree.
@@ -181,6 +262,22 @@ func (processTree *ProcessTree) BuildTree() {
 	}
 }
 
+// Roots returns the processes whose parent is not in the tree, e.g., PID 1 and kthreadd on
+// Linux, or the processes orphaned on Windows, which does not re-parent them. Each one is
+// shown as a separate top-level tree.
+//
+// Returns:
+//   - The indexes of the root processes, in the order of the nodes
+func (processTree *ProcessTree) Roots() []int {
+	roots := []int{}
+	for pidIndex := range processTree.Nodes {
+		if processTree.Nodes[pidIndex].Parent == -1 {
+			roots = append(roots, pidIndex)
+		}
+	}
+	return roots
+}
+
 //------------------------------------------------------------------------------
 // DEBUGGING UTILITIES
 //------------------------------------------------------------------------------
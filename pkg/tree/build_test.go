@@ -1,11 +1,18 @@
 package tree
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/shirou/gopsutil/v4/process"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // setupTestLogger creates a logger for testing
@@ -118,3 +125,429 @@ func TestMarkUIDTransitions(t *testing.T) {
 	// This is a placeholder test that can be implemented when needed
 	t.Skip("Skipping TestMarkUIDTransitions as it needs to be implemented properly")
 }
+
+// TestEffectiveDisplayOptions tests that implied options are derived before rendering
+func TestEffectiveDisplayOptions(t *testing.T) {
+	niceAbove, niceBelow := int32(-5), int32(10)
+	requested := DisplayOptions{ColorAttr: "cpu", Usernames: []string{"root"}, Runtimes: []string{"go"}, SelectedPIDs: []int32{10}, NamespaceFilters: map[string]uint64{"net": 4026532204}, NiceAbove: &niceAbove, NiceBelow: &niceBelow, Units: []string{"nginx.service"}, Thresholds: map[string]Threshold{"cpu": {Medium: 10, High: 50}}, TTYs: []string{"pts/0"}}
+	effective := EffectiveDisplayOptions(requested)
+	assert.True(t, effective.ShowCpuPercent)
+	assert.False(t, requested.ShowCpuPercent)
+
+	// The derived options do not share slices, maps, or pointers with the requested ones
+	requested.Usernames[0] = "nobody"
+	assert.Equal(t, []string{"root"}, effective.Usernames)
+	requested.Runtimes[0] = "jvm"
+	assert.Equal(t, []string{"go"}, effective.Runtimes)
+	requested.SelectedPIDs[0] = 20
+	assert.Equal(t, []int32{10}, effective.SelectedPIDs)
+	requested.NamespaceFilters["net"] = 4026531840
+	assert.Equal(t, map[string]uint64{"net": 4026532204}, effective.NamespaceFilters)
+	*requested.NiceAbove = 5
+	assert.Equal(t, int32(-5), *effective.NiceAbove)
+	*requested.NiceBelow = 15
+	assert.Equal(t, int32(10), *effective.NiceBelow)
+	requested.Units[0] = "ssh.service"
+	assert.Equal(t, []string{"nginx.service"}, effective.Units)
+	requested.Thresholds["cpu"] = Threshold{Medium: 20, High: 80}
+	assert.Equal(t, map[string]Threshold{"cpu": {Medium: 10, High: 50}}, effective.Thresholds)
+	requested.TTYs[0] = "pts/1"
+	assert.Equal(t, []string{"pts/0"}, effective.TTYs)
+
+	// Every line has the same columns, including the first one rendered
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "init", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 100, PPID: 1, Command: "proc2", MemoryInfo: &process.MemoryInfoStat{}},
+	}
+	options := DisplayOptions{ColorAttr: "mem", ColorCount: 256, ColorSupport: true, InstalledMemory: 1024, MaxDepth: 999, ScreenWidth: 132}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	assert.Contains(t, processTree.stripANSI(processTree.buildLineItem("", 0)), "(m:0.00 B)")
+	assert.Contains(t, processTree.stripANSI(processTree.buildLineItem("", 1)), "(m:0.00 B)")
+	assert.False(t, options.ShowMemoryUsage)
+}
+
+// TestMarkThreads tests filtering threads by name
+func TestMarkThreads(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "init"},
+		{PID: 100, PPID: 1, Command: "java", Threads: []Thread{
+			{TID: 101, Name: "GC Thread#0", Command: "java"},
+			{TID: 102, Name: "C2 CompilerThre", Command: "java"},
+			{TID: 103, Name: "GC Thread#1", Command: "java"},
+		}},
+		{PID: 200, PPID: 1, Command: "nginx", Threads: []Thread{{TID: 201, Command: "nginx"}}},
+	}
+
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{ThreadContains: "GC", MaxDepth: 999})
+	processTree.MarkProcesses()
+
+	// The process with matching threads and its parent are marked
+	assert.True(t, processTree.Nodes[0].Print)
+	assert.True(t, processTree.Nodes[1].Print)
+	assert.False(t, processTree.Nodes[2].Print)
+
+	// Only the matching threads are visible
+	visible := processTree.visibleThreads(1)
+	assert.Equal(t, 2, len(visible))
+	assert.Equal(t, int32(101), visible[0].TID)
+	assert.Equal(t, int32(103), visible[1].TID)
+
+	// Without a filter all threads are visible, and unnamed threads use the command
+	processTree = NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{MaxDepth: 999})
+	assert.Equal(t, 3, len(processTree.visibleThreads(1)))
+	assert.Equal(t, "nginx", processTree.Nodes[2].Threads[0].DisplayName())
+}
+
+// TestMarkElevated tests showing only elevated processes and their ancestors
+func TestMarkElevated(t *testing.T) {
+	processes := []Process{
+		{PID: 4, PPID: 0, Command: "System", Elevation: "system"},
+		{PID: 100, PPID: 4, Command: "explorer.exe"},
+		{PID: 200, PPID: 100, Command: "cmd.exe", Elevation: "admin"},
+		{PID: 300, PPID: 100, Command: "notepad.exe"},
+	}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{ElevatedOnly: true, MaxDepth: 999})
+	processTree.MarkProcesses()
+
+	assert.True(t, processTree.Nodes[0].Print)
+	assert.True(t, processTree.Nodes[1].Print)
+	assert.True(t, processTree.Nodes[2].Print)
+	assert.False(t, processTree.Nodes[3].Print)
+}
+
+// TestTreeModels tests that both tree models filter and format processes the same way
+func TestTreeModels(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init", Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 10, PPID: 1, PGID: 10, Command: "/usr/sbin/sshd", Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 11, PPID: 10, PGID: 11, Command: "/bin/bash", Username: "alice", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 20, PPID: 1, PGID: 20, Command: "/usr/sbin/cron", Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+	}
+	displayOptions := DisplayOptions{MaxDepth: 999, ScreenWidth: 200, ShowOwner: true, ShowPIDs: true, Usernames: []string{"alice"}}
+
+	var buffers [2]bytes.Buffer
+	models := []TreeModel{
+		NewProcessTree(0, setupTestLogger(), processes, displayOptions),
+		NewProcessMap(setupTestLogger(), processes, displayOptions),
+	}
+	for i, model := range models {
+		model.Mark()
+		model.Drop()
+		require.NoError(t, model.Render(&buffers[i]))
+	}
+
+	assert.Contains(t, buffers[0].String(), "(root) (10) /usr/sbin/sshd")
+	assert.Contains(t, buffers[0].String(), "(alice) (11) /bin/bash")
+	assert.NotContains(t, buffers[0].String(), "cron")
+	assert.Equal(t, buffers[0].String(), buffers[1].String())
+
+	// Building again replaces the processes
+	models[1].Build(processes[:2])
+	models[1].Mark()
+	buffers[1].Reset()
+	require.NoError(t, models[1].Render(&buffers[1]))
+	assert.NotContains(t, buffers[1].String(), "bash")
+
+	// Steps following the filters, e.g., anonymization, change the lines through the shared ProcessTree
+	processMap := models[1].(*ProcessMap)
+	processMap.Build(processes)
+	processMap.Mark()
+	processMap.ProcessTree().Nodes[processMap.ProcessTree().PidToIndexMap[10]].Command = "/usr/sbin/daemon-1a2b3c"
+	buffers[1].Reset()
+	require.NoError(t, processMap.Render(&buffers[1]))
+	assert.Contains(t, buffers[1].String(), "(10) /usr/sbin/daemon-1a2b3c")
+
+	// The deprecated FindPrintable marks the same processes as Mark
+	processMap.Build(processes)
+	processMap.FindPrintable()
+	processMap.Drop()
+	buffers[1].Reset()
+	require.NoError(t, processMap.Render(&buffers[1]))
+	assert.Equal(t, buffers[0].String(), buffers[1].String())
+}
+
+// TestMultipleRoots tests that every process whose parent is missing is shown as a separate tree by both tree models
+func TestMultipleRoots(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init"},
+		{PID: 2, PPID: 0, PGID: 0, Command: "kthreadd"},
+		{PID: 3, PPID: 2, PGID: 0, Command: "kworker/0:0"},
+		{PID: 10, PPID: 1, PGID: 10, Command: "/usr/sbin/sshd"},
+		// The parent exited and the process was not re-parented, as on Windows
+		{PID: 500, PPID: 400, PGID: 500, Command: "orphan.exe"},
+	}
+	displayOptions := DisplayOptions{MaxDepth: 999, ScreenWidth: 200, ShowPIDs: true}
+
+	processTree := NewProcessTree(0, setupTestLogger(), processes, displayOptions)
+	assert.Equal(t, []int{0, 1, 4}, processTree.Roots())
+
+	var buffers [2]bytes.Buffer
+	models := []TreeModel{
+		processTree,
+		NewProcessMap(setupTestLogger(), processes, displayOptions),
+	}
+	for i, model := range models {
+		model.Mark()
+		model.Drop()
+		require.NoError(t, model.Render(&buffers[i]))
+	}
+	assert.Equal(t, "-+- (1) /sbin/init \n \\--- (10) /usr/sbin/sshd \n-+- (2) kthreadd \n \\--- (3) kworker/0:0 \n-+- (500) orphan.exe \n", buffers[0].String())
+	assert.Equal(t, buffers[0].String(), buffers[1].String())
+
+	// The exports list each root, and keep a single document for a single root
+	roots := processTree.BuildJSONRoots()
+	require.Len(t, roots, 3)
+	assert.Equal(t, []int32{1, 2, 500}, []int32{roots[0].PID, roots[1].PID, roots[2].PID})
+	assert.Equal(t, int32(1), processTree.BuildJSONTree().PID)
+	var output bytes.Buffer
+	require.NoError(t, (&JSONRenderer{}).Render(&output, processTree))
+	assert.True(t, strings.HasPrefix(output.String(), "["), output.String())
+
+	displayOptions.RootPID = 2
+	processTree = NewProcessTree(0, setupTestLogger(), processes, displayOptions)
+	processTree.MarkProcesses()
+	output.Reset()
+	require.NoError(t, (&JSONRenderer{}).Render(&output, processTree))
+	assert.True(t, strings.HasPrefix(output.String(), "{"), output.String())
+	output.Reset()
+	require.NoError(t, (&TextRenderer{}).Render(&output, processTree))
+	assert.Equal(t, "-+- (2) kthreadd \n \\--- (3) kworker/0:0 \n", output.String())
+}
+
+// TestTreeModelsCompact verifies that both tree models group identical processes alike
+func TestTreeModelsCompact(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 10, PPID: 1, PGID: 10, Command: "/usr/sbin/nginx", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 11, PPID: 10, PGID: 10, Command: "/usr/sbin/nginx", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 12, PPID: 10, PGID: 10, Command: "/usr/sbin/nginx", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 13, PPID: 10, PGID: 10, Command: "/usr/sbin/nginx", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 20, PPID: 1, PGID: 20, Command: "/usr/sbin/cron", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 21, PPID: 1, PGID: 20, Command: "/usr/sbin/cron", MemoryInfo: &process.MemoryInfoStat{}},
+	}
+	for _, compact := range []bool{true, false} {
+		t.Run(fmt.Sprintf("compact %t", compact), func(t *testing.T) {
+			displayOptions := DisplayOptions{CompactMode: compact, ScreenWidth: 200, ShowPIDs: true}
+
+			var buffers [2]bytes.Buffer
+			models := []TreeModel{
+				NewProcessTree(0, setupTestLogger(), processes, displayOptions),
+				NewProcessMap(setupTestLogger(), processes, displayOptions),
+			}
+			for i, model := range models {
+				model.Mark()
+				model.Drop()
+				require.NoError(t, model.Render(&buffers[i]))
+			}
+
+			assert.Equal(t, compact, strings.Contains(buffers[0].String(), "3*[nginx] (11,12,13)"), buffers[0].String())
+			assert.Equal(t, compact, strings.Contains(buffers[0].String(), "2*[cron] (20,21)"), buffers[0].String())
+			assert.Equal(t, buffers[0].String(), buffers[1].String())
+		})
+	}
+}
+
+// TestTreeModelsFilters verifies that both tree models display the same processes for each filter
+func TestTreeModelsFilters(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init", Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 10, PPID: 1, PGID: 10, Command: "/usr/sbin/sshd", Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 11, PPID: 10, PGID: 11, Command: "/bin/bash", Username: "alice", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 12, PPID: 11, PGID: 12, Command: "/usr/bin/vim", Username: "alice", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 20, PPID: 1, PGID: 20, Command: "/usr/sbin/cron", Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 21, PPID: 20, PGID: 20, Command: "/bin/sh", Username: "bob", MemoryInfo: &process.MemoryInfoStat{}},
+	}
+	tests := []struct {
+		name           string
+		displayOptions DisplayOptions
+		want           []string
+	}{
+		{"pid", DisplayOptions{RootPID: 11}, []string{"init", "sshd", "bash", "vim"}},
+		{"user", DisplayOptions{Usernames: []string{"bob"}}, []string{"init", "cron", "sh"}},
+		{"contains", DisplayOptions{Contains: "vim"}, []string{"init", "sshd", "bash", "vim"}},
+		{"exclude root", DisplayOptions{ExcludeRoot: true}, []string{"init", "sshd", "bash", "vim", "cron", "sh"}},
+		{"exclude pattern", DisplayOptions{ExcludePatterns: []string{"bash"}}, []string{"init", "sshd", "cron", "sh"}},
+		{"exclude user", DisplayOptions{ExcludeUsers: []string{"bob"}}, []string{"init", "sshd", "bash", "vim", "cron"}},
+		{"pid and level", DisplayOptions{RootPID: 10, MaxDepth: 1}, []string{"init", "sshd", "bash"}},
+		{"level counts from the selected root", DisplayOptions{RootPID: 11, MaxDepth: 1}, []string{"init", "sshd", "bash", "vim"}},
+		{"level from the top of the tree", DisplayOptions{RootPID: 11, MaxDepth: 2, LevelFromRoot: true}, []string{"init", "sshd", "bash"}},
+		{"root command and level", DisplayOptions{RootCommand: "cron", MaxDepth: 1}, []string{"init", "cron", "sh"}},
+		{"user and level", DisplayOptions{Usernames: []string{"alice"}, MaxDepth: 2}, []string{"init", "sshd", "bash"}},
+		{"no match", DisplayOptions{RootPID: 99}, []string{}},
+		{"root command", DisplayOptions{RootCommand: "(ba)?sh"}, []string{"init", "sshd", "bash", "vim"}},
+		{"all root commands", DisplayOptions{RootCommand: "(ba)?sh", RootCommandAll: true}, []string{"init", "sshd", "bash", "vim", "cron", "sh"}},
+		{"root command path", DisplayOptions{RootCommand: "/usr/sbin/c.*"}, []string{"init", "cron", "sh"}},
+		{"root command matches entirely", DisplayOptions{RootCommand: "ssh"}, []string{}},
+		{"ancestors of pid", DisplayOptions{Ancestors: "11"}, []string{"init", "sshd", "bash"}},
+		{"ancestors of command", DisplayOptions{Ancestors: "(ba)?sh"}, []string{"init", "sshd", "bash", "cron", "sh"}},
+		{"ancestors of missing pid", DisplayOptions{Ancestors: "99"}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.displayOptions.ScreenWidth = 200
+			test.displayOptions.ShowPIDs = true
+
+			var buffers [2]bytes.Buffer
+			models := []TreeModel{
+				NewProcessTree(0, setupTestLogger(), processes, test.displayOptions),
+				NewProcessMap(setupTestLogger(), processes, test.displayOptions),
+			}
+			for i, model := range models {
+				model.Mark()
+				model.Drop()
+				require.NoError(t, model.Render(&buffers[i]))
+			}
+
+			lines := strings.Split(strings.TrimSpace(buffers[0].String()), "\n")
+			shown := []string{}
+			for _, line := range lines {
+				if fields := strings.Fields(line); len(fields) > 0 {
+					shown = append(shown, filepath.Base(fields[len(fields)-1]))
+				}
+			}
+			assert.Equal(t, test.want, shown, buffers[0].String())
+			assert.Equal(t, buffers[0].String(), buffers[1].String())
+		})
+	}
+}
+
+// TestWalk verifies pre-order and post-order walks, skip-subtree and stop decisions, and marking
+func TestWalk(t *testing.T) {
+	// init -> a -> (a1, a2), init -> b -> b1
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "init"},
+		{PID: 10, PPID: 1, Command: "a"},
+		{PID: 11, PPID: 10, Command: "a1"},
+		{PID: 12, PPID: 10, Command: "a2"},
+		{PID: 20, PPID: 1, Command: "b"},
+		{PID: 21, PPID: 20, Command: "b1"},
+	}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{MaxDepth: 999})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	collect := func(options WalkOptions, decide func(Node) WalkDecision) []string {
+		visited := []string{}
+		processTree.WalkWithOptions(options, func(node Node) WalkDecision {
+			visited = append(visited, node.Process.Command)
+			return decide(node)
+		})
+		return visited
+	}
+	always := func(Node) WalkDecision { return WalkContinue }
+
+	assert.Equal(t, []string{"init", "a", "a1", "a2", "b", "b1"}, collect(WalkOptions{Order: PreOrder}, always))
+	assert.Equal(t, []string{"a1", "a2", "a", "b1", "b", "init"}, collect(WalkOptions{Order: PostOrder}, always))
+
+	skipA := func(node Node) WalkDecision {
+		if node.Process.Command == "a" {
+			return WalkSkipSubtree
+		}
+		return WalkContinue
+	}
+	assert.Equal(t, []string{"init", "a", "b", "b1"}, collect(WalkOptions{Order: PreOrder}, skipA))
+
+	stopAtA2 := func(node Node) WalkDecision {
+		if node.Process.Command == "a2" {
+			return WalkStop
+		}
+		return WalkContinue
+	}
+	assert.Equal(t, []string{"init", "a", "a1", "a2"}, collect(WalkOptions{Order: PreOrder}, stopAtA2))
+	assert.Equal(t, []string{"a1", "a2"}, collect(WalkOptions{Order: PostOrder}, stopAtA2))
+
+	// Depth and parent are reported relative to the starting process
+	depths := map[string][2]int{}
+	processTree.WalkWithOptions(WalkOptions{Start: processTree.PidToIndexMap[10]}, func(node Node) WalkDecision {
+		depths[node.Process.Command] = [2]int{node.Depth, node.ParentIndex}
+		return WalkContinue
+	})
+	assert.Equal(t, map[string][2]int{"a": {0, -1}, "a1": {1, processTree.PidToIndexMap[10]}, "a2": {1, processTree.PidToIndexMap[10]}}, depths)
+
+	// Unmarked processes and the depth limit are honored
+	processTree.Nodes[processTree.PidToIndexMap[20]].Print = false
+	processTree.DisplayOptions.MaxDepth = 1
+	assert.Equal(t, []string{"init", "a"}, collect(WalkOptions{}, always))
+	assert.Equal(t, []string{"init", "a", "b"}, collect(WalkOptions{IgnoreMark: true}, always))
+}
+
+// countingMetadata is a MetadataSource that counts how often each attribute is fetched
+type countingMetadata struct {
+	calls map[string]int
+}
+
+func (source *countingMetadata) CPUPercent(pid int32) (float64, error) {
+	source.calls["cpu"]++
+	return float64(pid) / 10, nil
+}
+
+func (source *countingMetadata) RSS(pid int32) (uint64, error) {
+	source.calls["rss"]++
+	if pid == 20 {
+		return 0, errors.New("permission denied")
+	}
+	return uint64(pid) * 1024, nil
+}
+
+func (source *countingMetadata) Cgroup(pid int32) (string, error) {
+	source.calls["cgroup"]++
+	return "/system.slice/app.service", nil
+}
+
+func (source *countingMetadata) Unit(pid int32) (string, error) {
+	source.calls["unit"]++
+	return "app.service", nil
+}
+
+// TestNodeMetadata verifies that node attributes are fetched lazily, cached, and fall back to collected values
+func TestNodeMetadata(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "init", CPUPercent: 1.5, MemoryInfo: &process.MemoryInfoStat{RSS: 4096}},
+		{PID: 10, PPID: 1, Command: "a", Unavailable: FieldCPUPercent | FieldMemory},
+		{PID: 20, PPID: 1, Command: "b", Unavailable: FieldCPUPercent | FieldMemory},
+	}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{MaxDepth: 999})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	nodes := map[int32]Node{}
+	processTree.Walk(func(node Node) WalkDecision {
+		nodes[node.Process.PID] = node
+		return WalkContinue
+	})
+
+	// Without a source, collected values are returned and on-demand attributes are unavailable
+	cpuPercent, err := nodes[1].CPU()
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, cpuPercent)
+	rss, err := nodes[1].RSS()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4096), rss)
+	_, err = nodes[10].CPU()
+	assert.ErrorIs(t, err, ErrUnavailable)
+	_, err = nodes[10].Unit()
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	// With a source, each attribute is fetched once per node
+	source := &countingMetadata{calls: map[string]int{}}
+	processTree.Metadata = source
+	for i := 0; i < 3; i++ {
+		cpuPercent, err = nodes[10].CPU()
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, cpuPercent)
+		rss, err = nodes[10].RSS()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10240), rss)
+		_, err = nodes[20].RSS()
+		assert.ErrorIs(t, err, ErrUnavailable)
+		unit, err := nodes[10].Unit()
+		require.NoError(t, err)
+		assert.Equal(t, "app.service", unit)
+	}
+	assert.Equal(t, map[string]int{"cpu": 1, "rss": 2, "unit": 1}, source.calls)
+
+	// Fetched values are stored on the process for renderers
+	assert.True(t, processTree.Nodes[processTree.PidToIndexMap[10]].Available(FieldCPUPercent))
+	assert.Equal(t, "app.service", processTree.Nodes[processTree.PidToIndexMap[10]].Unit)
+	assert.False(t, processTree.Nodes[processTree.PidToIndexMap[20]].Available(FieldMemory))
+}
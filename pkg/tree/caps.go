@@ -0,0 +1,108 @@
+package tree
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// PROCESS CAPABILITIES
+//------------------------------------------------------------------------------
+// Functions in this section decode and format the effective capabilities set by
+// --show-caps, flagging processes that hold dangerous ones.
+
+// CapabilityNames are the names of the Linux capabilities, indexed by their number.
+var CapabilityNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner", "cap_fsetid", "cap_kill",
+	"cap_setgid", "cap_setuid", "cap_setpcap", "cap_linux_immutable", "cap_net_bind_service",
+	"cap_net_broadcast", "cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner",
+	"cap_sys_module", "cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace", "cap_sys_pacct",
+	"cap_sys_admin", "cap_sys_boot", "cap_sys_nice", "cap_sys_resource", "cap_sys_time",
+	"cap_sys_tty_config", "cap_mknod", "cap_lease", "cap_audit_write", "cap_audit_control",
+	"cap_setfcap", "cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm",
+	"cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf", "cap_checkpoint_restore",
+}
+
+// dangerousCapabilities are the capabilities that amount to root, e.g., by loading kernel
+// modules, tracing other processes, or bypassing file permissions.
+var dangerousCapabilities = []string{
+	"cap_bpf", "cap_dac_override", "cap_dac_read_search", "cap_net_admin", "cap_setfcap", "cap_setgid",
+	"cap_setuid", "cap_sys_admin", "cap_sys_module", "cap_sys_ptrace", "cap_sys_rawio",
+}
+
+// Capabilities decodes the effective capabilities of a process.
+//
+// Returns:
+//   - The names of the capabilities in ascending order, e.g., cap_net_raw, or cap_<n> for
+//     capabilities newer than this list
+func (process *Process) Capabilities() []string {
+	names := []string{}
+	for bit := 0; bit < 64; bit++ {
+		if process.CapEff&(1<<bit) == 0 {
+			continue
+		}
+		if bit < len(CapabilityNames) {
+			names = append(names, CapabilityNames[bit])
+		} else {
+			names = append(names, fmt.Sprintf("cap_%d", bit))
+		}
+	}
+	return names
+}
+
+// fullCapabilities returns true if a process holds every capability known to the kernel.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the number of capabilities is known and the process holds all of them
+func (processTree *ProcessTree) fullCapabilities(pidIndex int) bool {
+	count := processTree.DisplayOptions.Capabilities
+	if count < 1 || count > 63 {
+		return false
+	}
+	full := uint64(1)<<count - 1
+	return processTree.Nodes[pidIndex].CapEff&full == full
+}
+
+// capsAlert returns true if a process holds a dangerous capability, unless it is an
+// ordinary root process holding every capability.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the capabilities of the process deserve a look
+func (processTree *ProcessTree) capsAlert(pidIndex int) bool {
+	process := &processTree.Nodes[pidIndex]
+	if processTree.fullCapabilities(pidIndex) {
+		// The effective UID is the second one
+		return len(process.UIDs) > 1 && process.UIDs[1] != 0
+	}
+	for _, name := range process.Capabilities() {
+		if slices.Contains(dangerousCapabilities, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatCapabilities formats the effective capabilities of a process, e.g.,
+// [caps:cap_net_bind_service,cap_net_raw], or [caps:all] when it holds every capability.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted capabilities, or "" if the process holds none
+func (processTree *ProcessTree) formatCapabilities(pidIndex int) string {
+	if processTree.Nodes[pidIndex].CapEff == 0 {
+		return ""
+	}
+	if processTree.fullCapabilities(pidIndex) {
+		return "[caps:all]"
+	}
+	return fmt.Sprintf("[caps:%s]", strings.Join(processTree.Nodes[pidIndex].Capabilities(), ","))
+}
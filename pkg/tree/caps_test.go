@@ -0,0 +1,45 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowCaps verifies effective capabilities are shown by name and dangerous ones held by non-root users are flagged
+func TestShowCaps(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", UIDs: []uint32{0, 0, 0, 0}, CapEff: 0x1ffffffffff},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", UIDs: []uint32{33, 33, 33, 33}, CapEff: 1 << 10},
+		{PID: 20, PPID: 1, Command: "/usr/bin/agent", UIDs: []uint32{1000, 1000, 1000, 1000}, CapEff: 1<<21 | 1<<13},
+		{PID: 30, PPID: 1, Command: "/usr/bin/escalated", UIDs: []uint32{1000, 1000, 1000, 1000}, CapEff: 0x1ffffffffff},
+		{PID: 40, PPID: 1, Command: "/usr/bin/plain", UIDs: []uint32{1000, 1000, 1000, 1000}},
+	}
+	options := DisplayOptions{Capabilities: 41, MaxDepth: 999, ScreenWidth: 200, ShowCaps: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/sbin/init [caps:all]")
+	assert.Contains(t, output, "/usr/sbin/nginx [caps:cap_net_bind_service]")
+	assert.Contains(t, output, "/usr/bin/agent [caps:cap_net_raw,cap_sys_admin]")
+	assert.Contains(t, output, "/usr/bin/escalated [caps:all]")
+	assert.NotContains(t, output, "/usr/bin/plain [caps")
+
+	// Ordinary root processes are not flagged, unlike dangerous capabilities held by other users
+	assert.False(t, processTree.capsAlert(0))
+	assert.False(t, processTree.capsAlert(1))
+	assert.True(t, processTree.capsAlert(2))
+	assert.True(t, processTree.capsAlert(3))
+
+	// Capabilities newer than the known names are numbered
+	assert.Equal(t, []string{"cap_chown", "cap_45"}, (&Process{CapEff: 1 | 1<<45}).Capabilities())
+
+	root := processTree.BuildJSONTree()
+	require.NotNil(t, root)
+	assert.Len(t, root.Capabilities, 41)
+	assert.Equal(t, []string{"cap_net_bind_service"}, root.Children[0].Capabilities)
+	assert.Nil(t, root.Children[3].Capabilities)
+}
@@ -0,0 +1,135 @@
+package tree
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// CONTROL GROUPS
+//------------------------------------------------------------------------------
+// Functions in this section show the control group and systemd unit of each
+// process, and the control groups standing in for processes when --group-by-cgroup
+// roots the tree at the cgroup hierarchy instead of PID 1.
+
+// systemdCgroupSuffixes are the systemd unit types that name control groups.
+var systemdCgroupSuffixes = []string{".mount", ".scope", ".service", ".slice", ".socket", ".swap"}
+
+// CgroupName returns the short name of a control group: the systemd slice, scope, or
+// service it is named after, e.g., nginx.service, or the full path for control groups
+// not managed by systemd, e.g., /docker/3f2a.
+//
+// Parameters:
+//   - cgroup: The control group path
+//
+// Returns:
+//   - The short name of the control group
+func CgroupName(cgroup string) string {
+	name := path.Base(cgroup)
+	for _, suffix := range systemdCgroupSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return name
+		}
+	}
+	return cgroup
+}
+
+// formatCgroup returns the control group of a process, e.g., [cg:nginx.service].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted control group, or an empty string if it was not collected
+func (processTree *ProcessTree) formatCgroup(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	if process.CgroupNode || process.Cgroup == "" || !process.Available(FieldCgroup) {
+		return ""
+	}
+	return fmt.Sprintf("[cg:%s]", CgroupName(process.Cgroup))
+}
+
+// formatUnit returns the systemd unit of a process if it is not the unit of its parent,
+// e.g., [unit:nginx.service], marking where each unit begins in the tree.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted unit, or an empty string if the process is in the unit of its parent or in none
+func (processTree *ProcessTree) formatUnit(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	if !process.Available(FieldUnit) || !processTree.isUnitStart(pidIndex) {
+		return ""
+	}
+	return fmt.Sprintf("[unit:%s]", process.Unit)
+}
+
+// isUnitStart reports whether a process is the topmost process of its systemd unit, i.e.,
+// where the unit begins in the tree.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the process is in a unit that its parent is not in
+func (processTree *ProcessTree) isUnitStart(pidIndex int) bool {
+	process := &processTree.Nodes[pidIndex]
+	if process.CgroupNode || process.Unit == "" {
+		return false
+	}
+	return process.Parent == -1 || processTree.Nodes[process.Parent].Unit != process.Unit
+}
+
+// formatDependencies returns the dependencies of the systemd unit of a process on the other
+// units in the tree, e.g., [after:postgresql.service requires:php-fpm.service], on the topmost
+// process of the unit.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted dependencies, or an empty string if the unit has none or the process is not its topmost process
+func (processTree *ProcessTree) formatDependencies(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	if !processTree.isUnitStart(pidIndex) {
+		return ""
+	}
+	parts := []string{}
+	if len(process.UnitAfter) > 0 {
+		parts = append(parts, "after:"+strings.Join(process.UnitAfter, ","))
+	}
+	if len(process.UnitRequires) > 0 {
+		parts = append(parts, "requires:"+strings.Join(process.UnitRequires, ","))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, " "))
+}
+
+// buildCgroupFields returns the fields of a control group node, which only shows the last
+// element of the control group path, and the totals of its processes with --cumulative.
+//
+// Parameters:
+//   - pidIndex: Index of the control group node
+//
+// Returns:
+//   - The formatted fields
+func (processTree *ProcessTree) buildCgroupFields(pidIndex int) string {
+	var builder strings.Builder
+
+	name := path.Base(processTree.Nodes[pidIndex].Cgroup)
+	processTree.colorizeField("tag", &name, pidIndex)
+	builder.WriteString(name)
+	builder.WriteString(" ")
+
+	if processTree.DisplayOptions.Cumulative {
+		cumulative := processTree.formatCumulative(pidIndex)
+		processTree.colorizeField("cpu", &cumulative, pidIndex)
+		builder.WriteString(cumulative)
+		builder.WriteString(" ")
+	}
+	return builder.String()
+}
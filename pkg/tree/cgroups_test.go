@@ -0,0 +1,112 @@
+package tree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowCgroup verifies control groups are shown inline and control group nodes only show their name
+func TestShowCgroup(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Cgroup: "/init.scope"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", Cgroup: "/system.slice/nginx.service"},
+		{PID: 20, PPID: 1, Command: "/usr/bin/containerd-shim", Cgroup: "/docker/3f2a"},
+		{PID: 30, PPID: 1, Command: "/usr/bin/app", Unavailable: FieldCgroup | FieldUnit},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowCgroup: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/sbin/init [cg:init.scope]")
+	assert.Contains(t, output, "/usr/sbin/nginx [cg:nginx.service]")
+	assert.Contains(t, output, "/usr/bin/containerd-shim [cg:/docker/3f2a]")
+	assert.NotContains(t, output, "/usr/bin/app [cg:")
+
+	// Control group nodes only show their name, and do not count as a user transition
+	processes = []Process{
+		{PID: -1, PPID: -1, PGID: -1, Command: "/", Cgroup: "/", CgroupNode: true, Unavailable: ^Field(0)},
+		{PID: -2, PPID: -1, PGID: -1, Command: "/system.slice", Cgroup: "/system.slice", CgroupNode: true, Unavailable: ^Field(0)},
+		{PID: 10, PPID: -2, PGID: 10, Command: "/usr/sbin/nginx", Cgroup: "/system.slice", UIDs: []uint32{0}, Username: "root", CPUPercent: 1.5},
+		{PID: 11, PPID: 10, PGID: 10, Command: "/usr/sbin/nginx", Cgroup: "/system.slice", UIDs: []uint32{33}, Username: "www-data", CPUPercent: 0.5},
+	}
+	options = DisplayOptions{Cumulative: true, MaxDepth: 999, ScreenWidth: 132, ShowCpuPercent: true, ShowUserTransitions: true}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "-+- / (Σc:2.00% / Σm:0.00 B)", strings.TrimSpace(lines[0]))
+	assert.Equal(t, "\\-+- system.slice (Σc:2.00% / Σm:0.00 B)", strings.TrimSpace(lines[1]))
+	assert.NotContains(t, lines[2], "→")
+	assert.False(t, processTree.Nodes[2].HasUIDTransition)
+	assert.Contains(t, lines[3], "(root→www-data)")
+}
+
+// TestShowUnit verifies systemd units are shown where they change and --unit filters processes
+func TestShowUnit(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Cgroup: "/init.scope", Unit: "init.scope"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", Cgroup: "/system.slice/nginx.service", Unit: "nginx.service"},
+		{PID: 11, PPID: 10, Command: "/usr/sbin/nginx-worker", Cgroup: "/system.slice/nginx.service", Unit: "nginx.service"},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/sshd", Cgroup: "/system.slice/ssh.service", Unit: "ssh.service"},
+		{PID: 21, PPID: 20, Command: "/bin/bash", Cgroup: "/user.slice/user-1000.slice/session-3.scope", Unit: "session-3.scope"},
+		{PID: 30, PPID: 1, Command: "/usr/bin/app", Unavailable: FieldCgroup | FieldUnit},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowUnit: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/sbin/init [unit:init.scope]")
+	assert.Contains(t, output, "/usr/sbin/nginx [unit:nginx.service]")
+	assert.Contains(t, output, "/bin/bash [unit:session-3.scope]")
+	assert.NotContains(t, output, "/usr/sbin/nginx-worker [unit:")
+	assert.NotContains(t, output, "/usr/bin/app [unit:")
+
+	// Only the processes in the given units and their ancestors are shown
+	options = DisplayOptions{MaxDepth: 999, ScreenWidth: 132, Units: []string{"nginx.service"}}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/sbin/init")
+	assert.Contains(t, output, "/usr/sbin/nginx-worker")
+	assert.NotContains(t, output, "/usr/sbin/sshd")
+	assert.NotContains(t, output, "/usr/bin/app")
+}
+
+// TestShowDeps verifies unit dependencies are shown inline and drawn as dashed DOT edges
+func TestShowDeps(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Unit: "init.scope"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", Unit: "nginx.service", UnitAfter: []string{"php-fpm.service"}, UnitRequires: []string{"php-fpm.service"}},
+		{PID: 11, PPID: 10, Command: "/usr/sbin/nginx-worker", Unit: "nginx.service", UnitAfter: []string{"php-fpm.service"}, UnitRequires: []string{"php-fpm.service"}},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/php-fpm", Unit: "php-fpm.service"},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowDeps: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	var output bytes.Buffer
+	require.NoError(t, (&TextRenderer{}).Render(&output, processTree))
+	assert.Contains(t, output.String(), "/usr/sbin/nginx [after:php-fpm.service requires:php-fpm.service]")
+	assert.NotContains(t, output.String(), "/usr/sbin/nginx-worker [")
+	assert.NotContains(t, output.String(), "/usr/sbin/php-fpm [")
+
+	// Dependencies are drawn as dashed edges between the topmost processes of the units
+	output.Reset()
+	require.NoError(t, (&DOTRenderer{}).Render(&output, processTree))
+	assert.Contains(t, output.String(), `p10 -> p20 [style=dashed, color=gray, label="after"];`)
+	assert.Contains(t, output.String(), `p10 -> p20 [style=dashed, color=gray, label="requires"];`)
+	assert.NotContains(t, output.String(), "p11 -> p20")
+}
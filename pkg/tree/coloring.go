@@ -1,10 +1,12 @@
 package tree
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/gdanko/pstree/pkg/color"
 	"github.com/mattn/go-runewidth"
 )
 
@@ -66,6 +68,9 @@ import (
 //   - value: Pointer to the string value to be colored (modified in-place)
 //   - pidIndex: Index of the process to be colored
 //
+// Fields implied by the coloring attribute are resolved up front by EffectiveDisplayOptions,
+// so this method never modifies the display options while a tree is being rendered.
+//
 // Refactoring opportunity: This function could be split into:
 // - applyStandardColors: Apply standard color scheme
 // - applyAttributeBasedColors: Apply colors based on attribute thresholds
@@ -75,6 +80,26 @@ func (processTree *ProcessTree) colorizeField(fieldName string, value *string, p
 	)
 	// Only apply colors if the terminal supports them
 	if processTree.DisplayOptions.ColorSupport {
+		// Changes between --watch samples, unreaped zombies, zombies and blocked processes flagged by --show-state,
+		// heaps over budget, saturated pre-fork servers, poorly placed processes flagged by --show-affinity, dangerous
+		// capabilities flagged by --show-caps, and the --highlight-pid chain are highlighted in every color mode
+		switch fieldName {
+		case "appeared":
+			if processTree.Colorizer.Tag != nil {
+				processTree.Colorizer.Tag(processTree.ColorScheme, value)
+			}
+			return
+		case "affinityAlert", "capsAlert", "exited", "overBudget", "saturated", "stateAlert", "zombies":
+			if processTree.Colorizer.Crit != nil {
+				processTree.Colorizer.Crit(processTree.ColorScheme, value)
+			}
+			return
+		case "highlight":
+			// Like the original pstree's -h, the highlighted chain is shown in bold reverse video
+			*value = color.AnsiBold + color.AnsiReverse + *value + color.AnsiReset
+			return
+		}
+
 		// Standard colorization mode (--colorize flag)
 		if processTree.DisplayOptions.ColorizeOutput {
 			// Apply specific colors based on the field type
@@ -91,6 +116,31 @@ func (processTree *ProcessTree) colorizeField(fieldName string, value *string, p
 				processTree.Colorizer.CompactStr(processTree.ColorScheme, value)
 			case "cpu":
 				processTree.Colorizer.CPU(processTree.ColorScheme, value)
+			case "daemon":
+				// Session leaders that detached from their terminal without being reparented to init did not fully daemonize
+				if processTree.isPartialDaemon(pidIndex) {
+					processTree.Colorizer.Crit(processTree.ColorScheme, value)
+				} else {
+					processTree.Colorizer.Owner(processTree.ColorScheme, value)
+				}
+			case "elevation":
+				// Elevated processes can modify the system, so they stand out more than system ones
+				if processTree.Nodes[pidIndex].Elevation == "admin" {
+					processTree.Colorizer.Crit(processTree.ColorScheme, value)
+				} else {
+					processTree.Colorizer.Owner(processTree.ColorScheme, value)
+				}
+			case "latency":
+				// Scheduling latency is time spent waiting for a CPU, so it is colored like CPU usage
+				processTree.Colorizer.CPU(processTree.ColorScheme, value)
+			case "fds":
+				// Descriptor counts are always shown as a heat value so that leaking processes stand out
+				processTree.colorizeFDs(processTree.Nodes[pidIndex].NumFDs, value)
+			case "io":
+				// IO is always shown as a heat value so that disk-heavy processes stand out
+				processTree.colorizeIO(processTree.Nodes[pidIndex].IOBytes(), value)
+			case "lock":
+				processTree.Colorizer.Crit(processTree.ColorScheme, value)
 			case "memory":
 				processTree.Colorizer.Memory(processTree.ColorScheme, value)
 			case "owner":
@@ -101,66 +151,67 @@ func (processTree *ProcessTree) colorizeField(fieldName string, value *string, p
 				processTree.Colorizer.PIDPGID(processTree.ColorScheme, value)
 			// case "prefix":
 			// 	processTree.Colorizer.Prefix(processTree.ColorScheme, value)
+			case "signing":
+				// Unsigned and ad-hoc signed executables are flagged
+				if processTree.Nodes[pidIndex].Signing == "unsigned" || processTree.Nodes[pidIndex].Signing == "ad-hoc" {
+					processTree.Colorizer.Crit(processTree.ColorScheme, value)
+				} else {
+					processTree.Colorizer.Owner(processTree.ColorScheme, value)
+				}
+			case "tag":
+				processTree.Colorizer.Tag(processTree.ColorScheme, value)
 			case "threads":
 				processTree.Colorizer.NumThreads(processTree.ColorScheme, value)
+			case "unavailable":
+				processTree.Colorizer.Unavailable(processTree.ColorScheme, value)
 			}
 		} else if processTree.DisplayOptions.ColorAttr != "" {
 			// Attribute-based colorization mode (--color flag)
 			// Don't apply attribute-based coloring to the tree prefix
-			if fieldName != "prefix" {
+			if fieldName == "unavailable" {
+				// Missing metrics have no value to threshold, so they are always dimmed
+				processTree.Colorizer.Unavailable(processTree.ColorScheme, value)
+			} else if fieldName != "prefix" {
 				process = &processTree.Nodes[pidIndex]
 				switch processTree.DisplayOptions.ColorAttr {
 				case "age":
-					// Ensure process age is shown when coloring by age
-					processTree.DisplayOptions.ShowProcessAge = true
-
 					// Apply color based on process age thresholds in seconds
 					if process.Age < 60 {
 						// Low age (< 1 minute)
-						processTree.Colorizer.ProcessAgeLow(processTree.ColorScheme, value)
+						processTree.Colorizer.Crit(processTree.ColorScheme, value)
 					} else if process.Age >= 60 && process.Age < 3600 {
 						// Medium age (< 1 hour)
-						processTree.Colorizer.ProcessAgeMedium(processTree.ColorScheme, value)
+						processTree.Colorizer.Warn(processTree.ColorScheme, value)
 					} else if process.Age >= 3600 && process.Age < 86400 {
 						// High age (> 1 hour and < 1 day)
-						processTree.Colorizer.ProcessAgeHigh(processTree.ColorScheme, value)
+						processTree.Colorizer.Info(processTree.ColorScheme, value)
 					} else if process.Age >= 86400 {
 						// Very high age (> 1 day)
-						processTree.Colorizer.ProcessAgeVeryHigh(processTree.ColorScheme, value)
+						processTree.Colorizer.OK(processTree.ColorScheme, value)
 					}
 				case "cpu":
-					// Ensure CPU percentage is shown when coloring by CPU
-					processTree.DisplayOptions.ShowCpuPercent = true
+					// Compact groups are colored by their combined usage, matching what is displayed
+					cpuPercent := processTree.GetGroupMetrics(pidIndex).CPUPercent
 
 					// Apply color based on CPU usage thresholds in percentage
-					if process.CPUPercent < 5 {
-						// Low CPU usage (< 5%)
-						processTree.Colorizer.CPULow(processTree.ColorScheme, value)
-					} else if process.CPUPercent >= 5 && process.CPUPercent < 15 {
-						// Medium CPU usage (5-15%)
-						processTree.Colorizer.CPUMedium(processTree.ColorScheme, value)
-					} else if process.CPUPercent >= 15 {
-						// High CPU usage (> 15%)
-						processTree.Colorizer.CPUHigh(processTree.ColorScheme, value)
-					}
+					processTree.colorizeThreshold("cpu", cpuPercent, value)
+				case "fds":
+					processTree.colorizeFDs(process.NumFDs, value)
+				case "io":
+					processTree.colorizeIO(process.IOBytes(), value)
+				case "latency":
+					processTree.colorizeLatency(process.SchedLatency, value)
 				case "mem":
-					// Ensure memory usage is shown when coloring by memory
-					processTree.DisplayOptions.ShowMemoryUsage = true
-
 					// Calculate memory usage as percentage of total system memory
-					percent := (process.MemoryInfo.RSS / processTree.DisplayOptions.InstalledMemory) * 100
+					var percent float64
+					if processTree.DisplayOptions.InstalledMemory > 0 {
+						percent = float64(processTree.GetGroupMetrics(pidIndex).RSS) / float64(processTree.DisplayOptions.InstalledMemory) * 100
+					}
 
 					// Apply color based on memory usage thresholds in percentage
-					if percent < 10 {
-						// Low memory usage (< 10%)
-						processTree.Colorizer.MemoryLow(processTree.ColorScheme, value)
-					} else if percent >= 10 && percent < 20 {
-						// Medium memory usage (10-20%)
-						processTree.Colorizer.MemoryMedium(processTree.ColorScheme, value)
-					} else if percent >= 20 {
-						// High memory usage (> 20%)
-						processTree.Colorizer.MemoryHigh(processTree.ColorScheme, value)
-					}
+					processTree.colorizeThreshold("mem", percent, value)
+				case "share":
+					processTree.colorizeShare(process.Username, value)
 				}
 				// } else {
 				// 	processTree.Colorizer.Default(processTree.ColorScheme, value)
@@ -169,6 +220,73 @@ func (processTree *ProcessTree) colorizeField(fieldName string, value *string, p
 	}
 }
 
+// colorizeLatency applies a heat color to a value based on the average scheduling delay.
+//
+// By default, processes waiting less than 1ms per timeslice are colored as low, 1-10ms as
+// medium, and anything above 10ms as high, which usually indicates CPU starvation.
+//
+// Parameters:
+//   - latency: Average scheduling delay in nanoseconds
+//   - value: Pointer to the string value to be colored (modified in-place)
+func (processTree *ProcessTree) colorizeLatency(latency float64, value *string) {
+	processTree.colorizeThreshold("latency", latency/1e6, value)
+}
+
+// Threshold holds the values from which a color attribute colors a process as medium and high.
+type Threshold struct {
+	Medium float64 `yaml:"medium"` // Value from which a process is colored as medium
+	High   float64 `yaml:"high"`   // Value from which a process is colored as high
+}
+
+// DefaultThresholds are the thresholds of the color attributes that can be configured, in the
+// unit each attribute is measured in: percent for cpu and mem, descriptors for fds, MiB read
+// and written for io, and milliseconds for latency.
+var DefaultThresholds = map[string]Threshold{
+	"cpu":     {Medium: 5, High: 15},
+	"fds":     {Medium: FDsMedium, High: FDsHigh},
+	"io":      {Medium: IOMedium, High: IOHigh},
+	"latency": {Medium: 1, High: 10},
+	"mem":     {Medium: 10, High: 20},
+}
+
+// colorizeThreshold applies a heat color to a value based on the threshold of a color attribute,
+// as configured in DisplayOptions.Thresholds or by default.
+//
+// Parameters:
+//   - attribute: The color attribute, e.g., cpu
+//   - measured: The measurement of the process, in the unit of the attribute
+//   - value: Pointer to the string value to be colored (modified in-place)
+func (processTree *ProcessTree) colorizeThreshold(attribute string, measured float64, value *string) {
+	threshold, ok := processTree.DisplayOptions.Thresholds[attribute]
+	if !ok {
+		threshold = DefaultThresholds[attribute]
+	}
+	if measured < threshold.Medium {
+		processTree.Colorizer.OK(processTree.ColorScheme, value)
+	} else if measured < threshold.High {
+		processTree.Colorizer.Warn(processTree.ColorScheme, value)
+	} else {
+		processTree.Colorizer.Crit(processTree.ColorScheme, value)
+	}
+}
+
+// unavailableField renders a placeholder for a metric that could not be collected.
+//
+// A dimmed dash is shown instead of a zero value so that "unavailable" (permission denied,
+// unsupported platform) is not mistaken for a real measurement of zero.
+//
+// Parameters:
+//   - label: The field label including its separator, e.g., "c:", or "" for unlabeled fields
+//   - pidIndex: Index of the process being rendered
+//
+// Returns:
+//   - The formatted and, if enabled, colorized placeholder
+func (processTree *ProcessTree) unavailableField(label string, pidIndex int) string {
+	value := fmt.Sprintf("(%s–)", label)
+	processTree.colorizeField("unavailable", &value, pidIndex)
+	return value
+}
+
 // TruncateANSI truncates a string containing ANSI escape sequences to fit within a specified screen width.
 // It preserves ANSI color and formatting codes while only counting visible characters toward the width limit.
 //
@@ -0,0 +1,37 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestColorizeLatency tests that scheduling latency follows --colorize and its own thresholds with --color-attr
+func TestColorizeLatency(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", SchedLatency: 3e6},
+	}
+	options := DisplayOptions{ColorCount: 256, ColorSupport: true, ColorizeOutput: true, MaxDepth: 999, ScreenWidth: 132, ShowSchedLatency: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+
+	// --colorize gives the field the color of the scheme, whatever its value
+	value, expected := "3.00ms", "3.00ms"
+	processTree.colorizeField("latency", &value, 0)
+	processTree.Colorizer.CPU(processTree.ColorScheme, &expected)
+	assert.Equal(t, expected, value)
+
+	// --color-attr latency colors by the latency thresholds rather than those of cpu
+	processTree.DisplayOptions.ColorizeOutput = false
+	processTree.DisplayOptions.ColorAttr = "latency"
+	processTree.DisplayOptions.Thresholds = map[string]Threshold{"cpu": {Medium: 10, High: 20}, "latency": {Medium: 1, High: 2}}
+	value, expected = "3.00ms", "3.00ms"
+	processTree.colorizeField("latency", &value, 0)
+	processTree.Colorizer.Crit(processTree.ColorScheme, &expected)
+	assert.Equal(t, expected, value)
+
+	delete(processTree.DisplayOptions.Thresholds, "latency")
+	value, expected = "3.00ms", "3.00ms"
+	processTree.colorizeField("latency", &value, 0)
+	processTree.Colorizer.Warn(processTree.ColorScheme, &expected)
+	assert.Equal(t, expected, value)
+}
@@ -6,6 +6,9 @@ import (
 	"strings"
 )
 
+// CompactPIDsMax is the number of member PIDs listed for a compact group before the list is truncated.
+const CompactPIDsMax = 5
+
 //------------------------------------------------------------------------------
 // INITIALIZATION
 //------------------------------------------------------------------------------
@@ -21,6 +24,12 @@ import (
 // If any process in a potential group has threads and thread display is enabled
 // (HideThreads is false), that group of processes will not be compacted.
 //
+// Grouping operates after marking, so only processes that will be printed are grouped.
+// This keeps compact mode meaningful alongside filters such as --contains and --user.
+//
+// Once all groups are known, the member that represents each group is chosen according
+// to DisplayOptions.CompactRepresentative; see selectRepresentative.
+//
 // This function should be called before printing the tree when compact mode is enabled.
 //
 // Returns:
@@ -28,7 +37,6 @@ import (
 func (processTree *ProcessTree) InitCompactMode() error {
 	processTree.Logger.Debug("Entering processTree.InitCompactMode()")
 	var (
-		args         []string
 		cmd          string
 		exists       bool
 		group        ProcessGroup
@@ -37,10 +45,15 @@ func (processTree *ProcessTree) InitCompactMode() error {
 		processOwner string
 	)
 
+	// Groups are found again from scratch, so that initializing compact mode again, e.g., for
+	// each root of the tree, does not count their members twice
+	processTree.ProcessGroups = make(map[int32]map[string]map[string]ProcessGroup)
+	processTree.SkipProcesses = make(map[int]bool)
+
 	// Group processes with identical commands under the same parent
 	for pidIndex = range processTree.Nodes {
-		// Skip processes that are already part of a group
-		if processTree.SkipProcesses[pidIndex] {
+		// Skip processes that are already part of a group or were filtered out
+		if processTree.SkipProcesses[pidIndex] || !processTree.Nodes[pidIndex].Print {
 			continue
 		}
 
@@ -53,13 +66,7 @@ func (processTree *ProcessTree) InitCompactMode() error {
 		// Get the command and arguments to create a composite key
 		// This ensures processes are only grouped if both command AND arguments match exactly
 		cmd = processTree.Nodes[pidIndex].Command
-		args = processTree.Nodes[pidIndex].Args
-
-		// Create a composite key with both command and arguments
-		compositeKey := cmd
-		if len(args) > 0 {
-			compositeKey = fmt.Sprintf("%s %s", cmd, strings.Join(args, " "))
-		}
+		compositeKey := processTree.compactKey(pidIndex)
 
 		// Initialize map for this parent if needed
 		if _, exists := processTree.ProcessGroups[parentPID]; !exists {
@@ -99,7 +106,8 @@ func (processTree *ProcessTree) InitCompactMode() error {
 			// Only add to group if either:
 			// 1. No threads in the group and current process, or
 			// 2. Threads are hidden
-			if !hasThreads || processTree.DisplayOptions.HideThreads {
+			// Client backends are always grouped, to be summarized on their database server
+			if !hasThreads || processTree.DisplayOptions.HideThreads || processTree.Nodes[pidIndex].DBBackend {
 				// Add to existing group
 				group.Count++
 				group.Indices = append(group.Indices, pidIndex)
@@ -112,9 +120,119 @@ func (processTree *ProcessTree) InitCompactMode() error {
 		// Update the group in the map
 		processTree.ProcessGroups[parentPID][compositeKey][processOwner] = group
 	}
+
+	// Choose the member that represents each group; all other members are skipped
+	for _, groupsByKey := range processTree.ProcessGroups {
+		for _, groupsByOwner := range groupsByKey {
+			for owner, group := range groupsByOwner {
+				// Client backends are counted on their database server instead
+				if processTree.Nodes[group.FirstIndex].DBBackend {
+					for _, idx := range group.Indices {
+						processTree.SkipProcesses[idx] = true
+					}
+					continue
+				}
+				if group.Count < 2 {
+					continue
+				}
+				group.FirstIndex = processTree.selectRepresentative(group.Indices)
+				for _, idx := range group.Indices {
+					processTree.SkipProcesses[idx] = idx != group.FirstIndex
+				}
+				groupsByOwner[owner] = group
+			}
+		}
+	}
 	return nil
 }
 
+// selectRepresentative returns the member of a compact group that is displayed in its place.
+//
+// The policy is set by DisplayOptions.CompactRepresentative:
+//   - "pid" (default): the member with the lowest PID
+//   - "oldest": the member that has been running the longest
+//   - "cpu": the member with the highest CPU usage
+//
+// Members whose metric is unavailable never win over members that report it, and
+// ties are broken by the lowest PID so that the choice is always deterministic.
+//
+// Parameters:
+//   - indices: Indices of all processes in the group
+//
+// Returns:
+//   - Index of the representative process
+func (processTree *ProcessTree) selectRepresentative(indices []int) int {
+	better := func(candidate, current *Process) bool {
+		switch processTree.DisplayOptions.CompactRepresentative {
+		case "oldest":
+			if candidate.Available(FieldAge) != current.Available(FieldAge) {
+				return candidate.Available(FieldAge)
+			}
+			if candidate.Age != current.Age {
+				return candidate.Age > current.Age
+			}
+		case "cpu":
+			if candidate.Available(FieldCPUPercent) != current.Available(FieldCPUPercent) {
+				return candidate.Available(FieldCPUPercent)
+			}
+			if candidate.CPUPercent != current.CPUPercent {
+				return candidate.CPUPercent > current.CPUPercent
+			}
+		}
+		return candidate.PID < current.PID
+	}
+
+	representative := indices[0]
+	for _, idx := range indices[1:] {
+		if better(&processTree.Nodes[idx], &processTree.Nodes[representative]) {
+			representative = idx
+		}
+	}
+	return representative
+}
+
+// compactKey returns the key used to decide whether two processes under the same parent are identical.
+//
+// Processes are only grouped if both command AND arguments match exactly. Service hosts such as
+// svchost.exe are additionally only identical if they host the same services, and processes
+// are only identical if the environment variables selected with ShowEnv match. Client backends
+// of a database server recognized by --db-connections are all identical.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The composite key for the process
+func (processTree *ProcessTree) compactKey(pidIndex int) string {
+	// The process titles of client backends differ by connection, but they are all alike
+	if processTree.Nodes[pidIndex].DBBackend {
+		return "[client backend]"
+	}
+	compositeKey := processTree.Nodes[pidIndex].Command
+	if len(processTree.Nodes[pidIndex].Args) > 0 {
+		compositeKey = fmt.Sprintf("%s %s", compositeKey, strings.Join(processTree.Nodes[pidIndex].Args, " "))
+	}
+	if processTree.DisplayOptions.ShowService && len(processTree.Nodes[pidIndex].Services) > 0 {
+		compositeKey = fmt.Sprintf("%s [%s]", compositeKey, strings.Join(processTree.Nodes[pidIndex].Services, ","))
+	}
+	if processTree.DisplayOptions.ShowCgroup {
+		compositeKey = fmt.Sprintf("%s [%s]", compositeKey, processTree.Nodes[pidIndex].Cgroup)
+	}
+	if processTree.DisplayOptions.ShowUnit {
+		compositeKey = fmt.Sprintf("%s %s", compositeKey, processTree.formatUnit(pidIndex))
+	}
+	if processTree.DisplayOptions.ShowNamespaces {
+		compositeKey = fmt.Sprintf("%s %s", compositeKey, processTree.formatNamespaces(pidIndex))
+	}
+	if processTree.DisplayOptions.ShowContainer {
+		compositeKey = fmt.Sprintf("%s [%s:%s]", compositeKey, processTree.Nodes[pidIndex].ContainerRuntime, processTree.Nodes[pidIndex].ContainerID)
+	}
+	if len(processTree.DisplayOptions.ShowEnv) > 0 {
+		compositeKey = fmt.Sprintf("%s (%s)", compositeKey, strings.Join(processTree.SelectedEnv(pidIndex), ", "))
+	}
+	return compositeKey
+}
+
 //------------------------------------------------------------------------------
 // PROCESS FILTERING
 //------------------------------------------------------------------------------
@@ -153,47 +271,81 @@ func (processTree *ProcessTree) ShouldSkipProcess(pidIndex int) bool {
 //   - isThread: Whether the process group represents threads
 func (processTree *ProcessTree) GetProcessCount(pidIndex int) (int, []int32, bool) {
 	var (
-		args            []string
-		cmd             string
-		compositeKey    string
 		groupHasThreads bool
 		groupPIDs       []int32
-		parentPID       int32
-		processOwner    string
 	)
 
-	// Get parent PID and command
-	args = processTree.Nodes[pidIndex].Args
-	cmd = processTree.Nodes[pidIndex].Command
-	parentPID = processTree.Nodes[pidIndex].PPID
-	processOwner = processTree.Nodes[pidIndex].Username
-
-	// Create the same composite key used in InitCompactMode
-	compositeKey = cmd
-	if len(args) > 0 {
-		compositeKey = cmd + " " + strings.Join(args, " ")
-	}
-
-	// Check if we have a group for this process
-	if groups, exists := processTree.ProcessGroups[parentPID]; exists {
-		// Look up by composite key (command + args)
-		if group, exists := groups[compositeKey][processOwner]; exists && group.FirstIndex == pidIndex {
-			// Find PIDs for each member of the group
-			for i := range group.Indices {
-				groupProcess := processTree.Nodes[group.Indices[i]]
-				if len(groupProcess.Threads) > 0 {
-					groupHasThreads = true
-				}
-				groupPIDs = append(groupPIDs, processTree.Nodes[group.Indices[i]].PID)
+	if group, exists := processTree.getCompactGroup(pidIndex); exists {
+		// Find PIDs for each member of the group
+		for i := range group.Indices {
+			groupProcess := processTree.Nodes[group.Indices[i]]
+			if len(groupProcess.Threads) > 0 {
+				groupHasThreads = true
 			}
-			return group.Count, groupPIDs, groupHasThreads
+			groupPIDs = append(groupPIDs, processTree.Nodes[group.Indices[i]].PID)
 		}
+		return group.Count, groupPIDs, groupHasThreads
 	}
 
 	// No group or not the first process in the group
 	return 1, []int32{}, false
 }
 
+// GetGroupMetrics returns the metrics displayed for a process, aggregated over its compact group.
+//
+// When compact mode is enabled and the process represents a group of identical processes,
+// the CPU usage and resident memory of all members are summed, so that collapsing a group
+// never hides resource usage. Otherwise the process's own metrics are returned.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The CPU usage and resident memory to display
+func (processTree *ProcessTree) GetGroupMetrics(pidIndex int) GroupMetrics {
+	indices := []int{pidIndex}
+	if processTree.DisplayOptions.CompactMode {
+		if group, exists := processTree.getCompactGroup(pidIndex); exists {
+			indices = group.Indices
+		}
+	}
+
+	metrics := GroupMetrics{Unavailable: FieldCPUPercent | FieldMemory}
+	for _, idx := range indices {
+		member := &processTree.Nodes[idx]
+		if member.Available(FieldCPUPercent) {
+			metrics.CPUPercent += member.CPUPercent
+			metrics.Unavailable &^= FieldCPUPercent
+		}
+		if member.Available(FieldMemory) && member.MemoryInfo != nil {
+			metrics.RSS += member.MemoryInfo.RSS
+			metrics.Unavailable &^= FieldMemory
+		}
+	}
+	return metrics
+}
+
+// getCompactGroup returns the compact group represented by a process.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - ProcessGroup: The group, if the process is the first member of one
+//   - bool: true if the process represents a group
+func (processTree *ProcessTree) getCompactGroup(pidIndex int) (ProcessGroup, bool) {
+	groups, exists := processTree.ProcessGroups[processTree.Nodes[pidIndex].PPID]
+	if !exists {
+		return ProcessGroup{}, false
+	}
+	// Look up by composite key (command + args) and owner, using the same key as InitCompactMode
+	group, exists := groups[processTree.compactKey(pidIndex)][processTree.Nodes[pidIndex].Username]
+	if !exists || group.FirstIndex != pidIndex {
+		return ProcessGroup{}, false
+	}
+	return group, true
+}
+
 //------------------------------------------------------------------------------
 // OUTPUT FORMATTING
 //------------------------------------------------------------------------------
@@ -204,6 +356,9 @@ func (processTree *ProcessTree) GetProcessCount(pidIndex int) (int, []int32, boo
 // in the style of Linux pstree. For regular processes, the format is "N*[command]",
 // and for threads, the format is "N*[{command}]", where N is the count.
 //
+// When CompactShowPIDs is enabled, the member PIDs are appended and truncated after
+// CompactPIDsMax entries, e.g., "12*[nginx] (pids 102,103,104,105,106,…)".
+//
 // Parameters:
 //   - command: The command name to format
 //   - count: Number of identical processes/threads
@@ -216,7 +371,13 @@ func (processTree *ProcessTree) FormatCompactOutput(command string, count int, g
 	if count <= 1 {
 		return command
 	}
-	if processTree.DisplayOptions.ShowPIDs {
+	if processTree.DisplayOptions.CompactShowPIDs {
+		pidStrings := processTree.PIDsToString(groupPIDs)
+		if len(pidStrings) > CompactPIDsMax {
+			pidStrings = append(pidStrings[:CompactPIDsMax], "…")
+		}
+		return fmt.Sprintf("%d*[%s] (pids %s)", count, filepath.Base(command), strings.Join(pidStrings, ","))
+	} else if processTree.DisplayOptions.ShowPIDs {
 		return fmt.Sprintf("%d*[%s] (%s)", count, filepath.Base(command), strings.Join(processTree.PIDsToString(groupPIDs), ","))
 	} else {
 		return fmt.Sprintf("%d*[%s]", count, filepath.Base(command))
@@ -0,0 +1,129 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompactServiceHosts verifies that service hosts are only compacted when they host the same services
+func TestCompactServiceHosts(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "services.exe", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 10, PPID: 1, Command: "svchost.exe", Services: []string{"Dnscache"}, MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 11, PPID: 1, Command: "svchost.exe", Services: []string{"Spooler"}, MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 12, PPID: 1, Command: "svchost.exe", Services: []string{"Spooler"}, MemoryInfo: &process.MemoryInfoStat{}},
+	}
+	options := DisplayOptions{CompactMode: true, MaxDepth: 999, ScreenWidth: 132, ShowService: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+
+	assert.Contains(t, output, "svchost.exe [svc:Dnscache]")
+	assert.Contains(t, output, "svchost.exe───2*[svchost.exe] [svc:Spooler]")
+}
+
+// TestCompactGroupMetrics verifies compact groups show combined usage and only group printable processes
+func TestCompactGroupMetrics(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", CPUPercent: 1, MemoryInfo: &process.MemoryInfoStat{RSS: 1024}},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/nginx", Username: "www", CPUPercent: 1.5, MemoryInfo: &process.MemoryInfoStat{RSS: 1024}},
+		{PID: 21, PPID: 1, Command: "/usr/sbin/nginx", Username: "www", CPUPercent: 2.5, MemoryInfo: &process.MemoryInfoStat{RSS: 2048}},
+		{PID: 22, PPID: 1, Command: "/usr/sbin/nginx", Username: "www", Unavailable: FieldCPUPercent, MemoryInfo: &process.MemoryInfoStat{RSS: 1024}},
+	}
+	options := DisplayOptions{CompactMode: true, MaxDepth: 999, ScreenWidth: 132, ShowCpuPercent: true, ShowMemoryUsage: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(c:4.00%) (m:4.00 KiB) /usr/sbin/nginx───3*[nginx]")
+
+	// Filtered-out processes are not part of any group
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.Nodes[2].Print = false
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(c:1.50%) (m:2.00 KiB) /usr/sbin/nginx───2*[nginx]")
+	assert.NotContains(t, output, "3*[nginx]")
+}
+
+// TestCompactShowPIDs verifies compact groups list truncated PIDs in text and the full list in JSON
+func TestCompactShowPIDs(t *testing.T) {
+	processes := []Process{{PID: 1, PPID: 0, Command: "/sbin/init"}}
+	for pid := int32(100); pid < 107; pid++ {
+		processes = append(processes, Process{PID: pid, PPID: 1, Command: "/usr/sbin/nginx", Username: "www"})
+	}
+	options := DisplayOptions{CompactMode: true, CompactShowPIDs: true, MaxDepth: 999, ScreenWidth: 132}
+
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "7*[nginx] (pids 100,101,102,103,104,…)")
+
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	renderer, err := NewRenderer("json")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, renderer.Render(&buf, processTree))
+	output = buf.String()
+	var root JSONNode
+	require.NoError(t, json.Unmarshal([]byte(output), &root))
+	require.Len(t, root.Children, 1)
+	assert.Equal(t, []int32{100, 101, 102, 103, 104, 105, 106}, root.Children[0].GroupPIDs)
+}
+
+// TestCompactRepresentative verifies the representative of a compact group follows the configured policy
+func TestCompactRepresentative(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 30, PPID: 1, Command: "/usr/sbin/nginx", Age: 100, CPUPercent: 1.0},
+		{PID: 31, PPID: 1, Command: "/usr/sbin/nginx", Age: 500, CPUPercent: 0.5},
+		{PID: 32, PPID: 1, Command: "/usr/sbin/nginx", Age: 900, Unavailable: FieldCPUPercent},
+		{PID: 33, PPID: 1, Command: "/usr/sbin/nginx", Age: 50, CPUPercent: 7.0},
+	}
+	tests := []struct {
+		policy string
+		want   int32
+	}{
+		{"", 30},
+		{"pid", 30},
+		{"oldest", 32},
+		{"cpu", 33},
+	}
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			options := DisplayOptions{CompactMode: true, CompactRepresentative: test.policy, MaxDepth: 999, ScreenWidth: 132, ShowPIDs: true}
+			processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+			processTree.MarkProcesses()
+			processTree.DropUnmarked()
+			output := captureStdout(t, func() {
+				processTree.PrintTree(0, "")
+			})
+			assert.Contains(t, output, fmt.Sprintf("(%d) /usr/sbin/nginx───4*[nginx] (30,31,32,33)", test.want))
+
+			for pidIndex, node := range processTree.Nodes {
+				if node.PID >= 30 {
+					assert.Equal(t, node.PID != test.want, processTree.ShouldSkipProcess(pidIndex))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package tree
+
+import (
+	"fmt"
+)
+
+//------------------------------------------------------------------------------
+// CONTAINERS
+//------------------------------------------------------------------------------
+// Functions in this section label containerized processes with their container,
+// and collapse each container into its topmost process with --collapse-containers.
+
+// containerIDLength is the length of the abbreviated container IDs shown when a
+// container has no known name, as in docker ps.
+const containerIDLength = 12
+
+// sameContainer returns true if two processes run in the same container.
+//
+// Parameters:
+//   - a: Index of the first process
+//   - b: Index of the second process
+//
+// Returns:
+//   - true if both processes are containerized and in the same container
+func (processTree *ProcessTree) sameContainer(a int, b int) bool {
+	first, second := &processTree.Nodes[a], &processTree.Nodes[b]
+	return first.ContainerRuntime != "" && first.ContainerRuntime == second.ContainerRuntime && first.ContainerID == second.ContainerID
+}
+
+// collapseContainers unmarks the descendants of the topmost process of each container
+// that run in the same container, counting them on the topmost process.
+func (processTree *ProcessTree) collapseContainers() {
+	for pidIndex := range processTree.Nodes {
+		process := &processTree.Nodes[pidIndex]
+		if !process.Print || process.ContainerRuntime == "" {
+			continue
+		}
+		if process.Parent != -1 && processTree.sameContainer(pidIndex, process.Parent) {
+			continue
+		}
+		for childIndex := process.Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+			if processTree.sameContainer(pidIndex, childIndex) {
+				process.ContainerCollapsed += processTree.unmarkSubtree(childIndex)
+			}
+		}
+	}
+}
+
+// formatContainer returns the container of a process, e.g., [docker:web] or
+// [containerd:3f2a1b9c0d4e], with the number of processes collapsed into it,
+// e.g., [docker:web, +12 processes].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted container, or an empty string if the process is not containerized
+func (processTree *ProcessTree) formatContainer(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	if process.ContainerRuntime == "" {
+		return ""
+	}
+	container := process.ContainerRuntime
+	if process.ContainerName != "" {
+		container = fmt.Sprintf("%s:%s", container, process.ContainerName)
+	} else if process.ContainerID != "" {
+		container = fmt.Sprintf("%s:%s", container, process.ContainerID[:min(len(process.ContainerID), containerIDLength)])
+	}
+	if process.ContainerCollapsed > 0 {
+		noun := "process"
+		if process.ContainerCollapsed != 1 {
+			noun += "es"
+		}
+		container = fmt.Sprintf("%s, +%d %s", container, process.ContainerCollapsed, noun)
+	}
+	return fmt.Sprintf("[%s]", container)
+}
@@ -0,0 +1,51 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowContainer verifies containers are shown inline and collapsed into their topmost process
+func TestShowContainer(t *testing.T) {
+	web := strings.Repeat("3f2a1b9c", 8)
+	db := strings.Repeat("0d4e5f6a", 8)
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/bin/containerd-shim"},
+		{PID: 11, PPID: 10, Command: "/usr/sbin/nginx", ContainerRuntime: "docker", ContainerID: web, ContainerName: "web"},
+		{PID: 12, PPID: 11, Command: "/usr/sbin/nginx-worker", ContainerRuntime: "docker", ContainerID: web, ContainerName: "web"},
+		{PID: 13, PPID: 11, Command: "/usr/sbin/nginx-cache", ContainerRuntime: "docker", ContainerID: web, ContainerName: "web"},
+		{PID: 20, PPID: 10, Command: "/usr/bin/postgres", ContainerRuntime: "containerd", ContainerID: db},
+		{PID: 21, PPID: 20, Command: "/usr/bin/postgres-writer", ContainerRuntime: "containerd", ContainerID: db},
+		{PID: 30, PPID: 1, Command: "/usr/bin/app", ContainerRuntime: "podman"},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowContainer: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/sbin/nginx [docker:web]")
+	assert.Contains(t, output, "/usr/sbin/nginx-worker [docker:web]")
+	assert.Contains(t, output, "/usr/bin/postgres [containerd:0d4e5f6a0d4e]")
+	assert.Contains(t, output, "/usr/bin/app [podman]")
+	assert.NotContains(t, output, "/usr/bin/containerd-shim [")
+
+	// Each container is collapsed into its topmost process
+	options.CollapseContainers = true
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Contains(t, output, "/usr/sbin/nginx [docker:web, +2 processes]")
+	assert.Contains(t, output, "/usr/bin/postgres [containerd:0d4e5f6a0d4e, +1 process]")
+	assert.NotContains(t, output, "nginx-worker")
+	assert.NotContains(t, output, "postgres-writer")
+}
@@ -0,0 +1,101 @@
+package tree
+
+import (
+	"fmt"
+
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// CUMULATIVE METRICS
+//------------------------------------------------------------------------------
+// Functions in this section total the CPU usage, resident memory, and thread
+// counts of each subtree, so that --cumulative shows what a whole service costs
+// rather than only its parent process.
+
+// SubtreeMetrics is the total usage of a process and all of its descendants.
+type SubtreeMetrics struct {
+	CPUPercent float64 `json:"cpu_percent" yaml:"cpu_percent"`           // Sum of the CPU usage percentages
+	RSS        uint64  `json:"memory_rss_bytes" yaml:"memory_rss_bytes"` // Sum of the resident memory in bytes
+	Threads    int32   `json:"num_threads" yaml:"num_threads"`           // Sum of the thread counts
+}
+
+// AggregateSubtrees records on each process the totals of its subtree in a post-order pass,
+// so that every child is totaled before its parent.
+//
+// Metrics that could not be collected for a process count as zero. Descendants are included
+// regardless of the filters and depth limit applied when marking.
+func (processTree *ProcessTree) AggregateSubtrees() {
+	var aggregate func(pidIndex int) SubtreeMetrics
+	aggregate = func(pidIndex int) SubtreeMetrics {
+		process := &processTree.Nodes[pidIndex]
+		totals := SubtreeMetrics{}
+		if process.Available(FieldCPUPercent) {
+			totals.CPUPercent = process.CPUPercent
+		}
+		if process.Available(FieldMemory) && process.MemoryInfo != nil {
+			totals.RSS = process.MemoryInfo.RSS
+		}
+		if process.Available(FieldNumThreads) {
+			totals.Threads = process.NumThreads
+		}
+		for childIndex := process.Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+			child := aggregate(childIndex)
+			totals.CPUPercent += child.CPUPercent
+			totals.RSS += child.RSS
+			totals.Threads += child.Threads
+		}
+		process.Subtree = totals
+		return totals
+	}
+	for pidIndex := range processTree.Nodes {
+		if processTree.Nodes[pidIndex].Parent == -1 {
+			aggregate(pidIndex)
+		}
+	}
+}
+
+// GetGroupSubtreeMetrics returns the subtree totals displayed for a process, aggregated
+// over its compact group.
+//
+// When compact mode is enabled and the process represents a group of identical processes,
+// the subtrees of all members are summed, matching GetGroupMetrics.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The subtree totals to display
+func (processTree *ProcessTree) GetGroupSubtreeMetrics(pidIndex int) SubtreeMetrics {
+	indices := []int{pidIndex}
+	if processTree.DisplayOptions.CompactMode {
+		if group, exists := processTree.getCompactGroup(pidIndex); exists {
+			indices = group.Indices
+		}
+	}
+
+	totals := SubtreeMetrics{}
+	for _, idx := range indices {
+		member := processTree.Nodes[idx].Subtree
+		totals.CPUPercent += member.CPUPercent
+		totals.RSS += member.RSS
+		totals.Threads += member.Threads
+	}
+	return totals
+}
+
+// formatCumulative returns the subtree totals of a process, e.g., (Σc:12.50% / Σm:1.20 GiB),
+// followed by the thread total when thread counts are shown.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted subtree totals
+func (processTree *ProcessTree) formatCumulative(pidIndex int) string {
+	totals := processTree.GetGroupSubtreeMetrics(pidIndex)
+	if processTree.DisplayOptions.ShowNumThreads {
+		return fmt.Sprintf("(Σc:%.2f%% / Σm:%s / Σt:%d)", totals.CPUPercent, util.ByteConverter(totals.RSS), totals.Threads)
+	}
+	return fmt.Sprintf("(Σc:%.2f%% / Σm:%s)", totals.CPUPercent, util.ByteConverter(totals.RSS))
+}
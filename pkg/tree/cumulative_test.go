@@ -0,0 +1,41 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCumulative verifies subtree totals are aggregated and shown before the process metrics
+func TestCumulative(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", CPUPercent: 0.5, NumThreads: 1, MemoryInfo: &process.MemoryInfoStat{RSS: 1024 * 1024}},
+		{PID: 10, PPID: 1, Command: "/usr/bin/postgres", CPUPercent: 1, NumThreads: 2, MemoryInfo: &process.MemoryInfoStat{RSS: 4 * 1024 * 1024}},
+		{PID: 11, PPID: 10, Command: "/usr/bin/postgres", Args: []string{"writer"}, CPUPercent: 2.5, NumThreads: 3, MemoryInfo: &process.MemoryInfoStat{RSS: 2 * 1024 * 1024}},
+		{PID: 12, PPID: 10, Command: "/usr/bin/postgres", Args: []string{"walwriter"}, Unavailable: FieldCPUPercent | FieldMemory, NumThreads: 4},
+	}
+	options := DisplayOptions{CompactMode: true, Cumulative: true, MaxDepth: 999, ScreenWidth: 132, ShowNumThreads: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+
+	assert.Equal(t, SubtreeMetrics{CPUPercent: 4, RSS: 7 * 1024 * 1024, Threads: 10}, processTree.Nodes[0].Subtree)
+	assert.Equal(t, SubtreeMetrics{CPUPercent: 3.5, RSS: 6 * 1024 * 1024, Threads: 9}, processTree.Nodes[1].Subtree)
+
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(Σc:4.00% / Σm:7.00 MiB / Σt:10) (t:1) /sbin/init")
+	assert.Contains(t, output, "(Σc:3.50% / Σm:6.00 MiB / Σt:9) (t:2) /usr/bin/postgres")
+	assert.Contains(t, output, "(Σc:0.00% / Σm:0.00 B / Σt:4) (t:4) /usr/bin/postgres")
+
+	var buf bytes.Buffer
+	require.NoError(t, (&JSONRenderer{}).Render(&buf, processTree))
+	assert.Contains(t, buf.String(), `"subtree": {
+    "cpu_percent": 4,
+    "memory_rss_bytes": 7340032,
+    "num_threads": 10
+  }`)
+}
@@ -0,0 +1,79 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// DAEMON DETACHMENT STATUS
+//------------------------------------------------------------------------------
+// Functions in this section format the session and terminal information set by
+// --show-daemon-status, so that services which did not detach properly stand out,
+// and the controlling terminal shown and filtered by --show-tty and --tty.
+
+// IsSessionLeader returns true if the process is the leader of its session.
+//
+// Returns:
+//   - true if the session ID is known and equal to the process ID
+func (process *Process) IsSessionLeader() bool {
+	return process.SID != 0 && process.SID == process.PID
+}
+
+// isPartialDaemon returns true if a process detached from its terminal without fully daemonizing.
+//
+// A session leader without a controlling terminal whose parent is not init has
+// typically called setsid() but skipped the second fork.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the process is a detached session leader not reparented to init
+func (processTree *ProcessTree) isPartialDaemon(pidIndex int) bool {
+	process := &processTree.Nodes[pidIndex]
+	return process.DaemonStatus == "detached" && process.IsSessionLeader()
+}
+
+// formatDaemonStatus formats the daemon status of a process.
+//
+// Fully daemonized processes are shown as [daemon]. Other processes show whether
+// they lead their session, followed by their controlling terminal, e.g.,
+// [session-leader, tty:pts/0], or [detached] if they have none.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted status
+func (processTree *ProcessTree) formatDaemonStatus(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	if process.DaemonStatus == "daemon" {
+		return "[daemon]"
+	}
+
+	parts := []string{}
+	if process.IsSessionLeader() {
+		parts = append(parts, "session-leader")
+	}
+	if process.TTY != "" {
+		parts = append(parts, fmt.Sprintf("tty:%s", process.TTY))
+	} else {
+		parts = append(parts, "detached")
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, ", "))
+}
+
+// formatTTY formats the controlling terminal of a process, e.g., [tty:pts/3].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted terminal, or "" if the process has none
+func (processTree *ProcessTree) formatTTY(pidIndex int) string {
+	if processTree.Nodes[pidIndex].TTY == "" {
+		return ""
+	}
+	return fmt.Sprintf("[tty:%s]", processTree.Nodes[pidIndex].TTY)
+}
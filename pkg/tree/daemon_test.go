@@ -0,0 +1,62 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowDaemonStatus verifies daemons, session leaders, and partially detached processes are labeled
+func TestShowDaemonStatus(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", SID: 1, DaemonStatus: "daemon"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd", SID: 10, DaemonStatus: "daemon"},
+		{PID: 20, PPID: 10, Command: "/bin/bash", SID: 20, TTY: "pts/0", DaemonStatus: "attached"},
+		{PID: 30, PPID: 20, Command: "/usr/bin/half-daemon", SID: 30, DaemonStatus: "detached"},
+		{PID: 40, PPID: 20, Command: "/usr/bin/vim", SID: 20, TTY: "pts/0", DaemonStatus: "attached"},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowDaemonStatus: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/sbin/sshd [daemon]")
+	assert.Contains(t, output, "/bin/bash [session-leader, tty:pts/0]")
+	assert.Contains(t, output, "/usr/bin/half-daemon [session-leader, detached]")
+	assert.Contains(t, output, "/usr/bin/vim [tty:pts/0]")
+	assert.True(t, processTree.isPartialDaemon(3))
+	assert.False(t, processTree.isPartialDaemon(1))
+}
+
+// TestShowTTY verifies controlling terminals are shown inline and --tty filters processes
+func TestShowTTY(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd"},
+		{PID: 20, PPID: 10, Command: "/bin/bash", TTY: "pts/3"},
+		{PID: 21, PPID: 20, Command: "/usr/bin/vim", TTY: "pts/3"},
+		{PID: 30, PPID: 10, Command: "/bin/zsh", TTY: "pts/4"},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowTTY: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/bin/bash [tty:pts/3]")
+	assert.Contains(t, output, "/bin/zsh [tty:pts/4]")
+	assert.NotContains(t, output, "[tty:]")
+
+	root := processTree.BuildJSONTree()
+	require.NotNil(t, root)
+	assert.Equal(t, "", root.TTY)
+	assert.Equal(t, "pts/3", root.Children[0].Children[0].TTY)
+
+	// Only processes attached to the terminal and their ancestors are shown
+	options = DisplayOptions{MaxDepth: 999, TTYs: []string{"pts/3"}}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	assert.Equal(t, []int32{1, 10, 20, 21}, printedPIDs(processTree))
+}
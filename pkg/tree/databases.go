@@ -0,0 +1,41 @@
+package tree
+
+import "fmt"
+
+//------------------------------------------------------------------------------
+// DATABASE CONNECTIONS
+//------------------------------------------------------------------------------
+// Functions in this section summarize the client connections of database
+// servers recognized by --db-connections on the server process.
+
+// Database servers whose client connections are counted.
+const (
+	DatabaseMySQL    = "mysql"    // MySQL or MariaDB, one thread per connection
+	DatabasePostgres = "postgres" // PostgreSQL, one backend process per connection
+)
+
+// databaseConnectionNouns names the client connections of each database server.
+var databaseConnectionNouns = map[string]string{
+	DatabaseMySQL:    "client connection",
+	DatabasePostgres: "client backend",
+}
+
+// formatDBConnections returns the number of client connections of a database server,
+// e.g., [137 client backends].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted count, or an empty string if the process is not a recognized database server
+func (processTree *ProcessTree) formatDBConnections(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	noun, exists := databaseConnectionNouns[process.Database]
+	if !exists {
+		return ""
+	}
+	if process.DBConnections != 1 {
+		noun += "s"
+	}
+	return fmt.Sprintf("[%d %s]", process.DBConnections, noun)
+}
@@ -0,0 +1,40 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDBConnections verifies database client connections are counted on the server and backends are collapsed
+func TestDBConnections(t *testing.T) {
+	postgres := "/usr/lib/postgresql/16/bin/postgres"
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 100, PPID: 1, Command: postgres, Username: "postgres", Database: DatabasePostgres, DBConnections: 2},
+		{PID: 101, PPID: 100, Command: postgres, Args: []string{"postgres: checkpointer"}, Username: "postgres"},
+		{PID: 102, PPID: 100, Command: postgres, Args: []string{"postgres: alice app [local] idle"}, Username: "postgres", DBBackend: true},
+		{PID: 103, PPID: 100, Command: postgres, Args: []string{"postgres: bob app [local] idle"}, Username: "postgres", DBBackend: true},
+		{PID: 200, PPID: 1, Command: "/usr/sbin/mysqld", Database: DatabaseMySQL, DBConnections: 1},
+	}
+	options := DisplayOptions{CompactMode: true, MaxDepth: 999, ScreenWidth: 132, ShowArguments: true, ShowDBConnections: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "[2 client backends]")
+	assert.Contains(t, output, "[1 client connection]")
+	assert.Contains(t, output, "postgres: checkpointer")
+	assert.NotContains(t, output, "alice")
+	assert.NotContains(t, output, "bob")
+
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.DisplayOptions.CompactMode = false
+	processTree.MarkProcesses()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "[2 client backends]")
+	assert.Contains(t, output, "postgres: alice app [local] idle")
+}
@@ -0,0 +1,95 @@
+package tree
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// DECLUTTERING
+//------------------------------------------------------------------------------
+// Functions in this section collapse noisy helper processes recognized by
+// --declutter into a count on their nearest displayed ancestor, or hide them.
+
+// Actions taken on the processes matched by a declutter rule.
+const (
+	DeclutterCollapse = "collapse" // Hide the process and count it on its nearest displayed ancestor
+	DeclutterHide     = "hide"     // Hide the process without counting it
+)
+
+// declutter unmarks the helper processes recognized by --declutter and their descendants,
+// counting the collapsed ones on their nearest displayed ancestor by label.
+//
+// A root process is never hidden, so that there is always something to display.
+func (processTree *ProcessTree) declutter() {
+	var visit func(pidIndex int, anchor int)
+	visit = func(pidIndex int, anchor int) {
+		process := &processTree.Nodes[pidIndex]
+		if !process.Print {
+			return
+		}
+		if process.ClutterAction != "" && anchor != -1 {
+			hidden := processTree.unmarkSubtree(pidIndex)
+			if process.ClutterAction == DeclutterCollapse {
+				if processTree.Nodes[anchor].Decluttered == nil {
+					processTree.Nodes[anchor].Decluttered = map[string]int{}
+				}
+				processTree.Nodes[anchor].Decluttered[process.Clutter] += hidden
+			}
+			return
+		}
+		for childIndex := process.Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+			visit(childIndex, pidIndex)
+		}
+	}
+	for pidIndex := range processTree.Nodes {
+		if processTree.Nodes[pidIndex].Parent == -1 {
+			visit(pidIndex, -1)
+		}
+	}
+}
+
+// unmarkSubtree unmarks a process and its marked descendants.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The number of processes unmarked
+func (processTree *ProcessTree) unmarkSubtree(pidIndex int) int {
+	if !processTree.Nodes[pidIndex].Print {
+		return 0
+	}
+	processTree.Nodes[pidIndex].Print = false
+	count := 1
+	for childIndex := processTree.Nodes[pidIndex].Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+		count += processTree.unmarkSubtree(childIndex)
+	}
+	return count
+}
+
+// formatDecluttered returns the counts of the helper processes collapsed into a process,
+// e.g., [+12 browser-helper, +2 language-server].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted counts ordered by label, or an empty string if nothing was collapsed
+func (processTree *ProcessTree) formatDecluttered(pidIndex int) string {
+	decluttered := processTree.Nodes[pidIndex].Decluttered
+	if len(decluttered) == 0 {
+		return ""
+	}
+	labels := make([]string, 0, len(decluttered))
+	for label := range decluttered {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+	counts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		counts = append(counts, fmt.Sprintf("+%d %s", decluttered[label], label))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(counts, ", "))
+}
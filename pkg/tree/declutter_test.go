@@ -0,0 +1,35 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDeclutter verifies helpers are collapsed into a count on their nearest displayed ancestor or hidden
+func TestDeclutter(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/opt/google/chrome/chrome"},
+		{PID: 11, PPID: 10, Command: "/opt/google/chrome/chrome", Clutter: "browser-helper", ClutterAction: DeclutterCollapse},
+		{PID: 12, PPID: 11, Command: "/opt/google/chrome/chrome", Clutter: "browser-helper", ClutterAction: DeclutterCollapse},
+		{PID: 13, PPID: 10, Command: "/opt/google/chrome/chrome_crashpad_handler", Clutter: "crash-reporter", ClutterAction: DeclutterHide},
+		{PID: 14, PPID: 10, Command: "/home/alice/go/bin/gopls", Clutter: "language-server", ClutterAction: DeclutterCollapse},
+	}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{Declutter: true, MaxDepth: 999, ScreenWidth: 132})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/opt/google/chrome/chrome [+2 browser-helper, +1 language-server]")
+	assert.NotContains(t, output, "crashpad")
+	assert.NotContains(t, output, "gopls")
+	assert.Equal(t, map[string]int{"browser-helper": 2, "language-server": 1}, processTree.BuildJSONTree().Children[0].Decluttered)
+
+	// A root process is never hidden
+	processes[0].Clutter, processes[0].ClutterAction = "desktop-service", DeclutterCollapse
+	processTree = NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{Declutter: true, MaxDepth: 999, ScreenWidth: 132})
+	processTree.MarkProcesses()
+	assert.True(t, processTree.Nodes[0].Print)
+}
@@ -6,12 +6,15 @@
 package tree
 
 import (
+	"io"
 	"log/slog"
+	"path/filepath"
 	"regexp"
+	"sync"
+	"text/template"
 
 	"github.com/gdanko/pstree/pkg/color"
 	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/net"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
@@ -25,44 +28,94 @@ import (
 type Process struct {
 	// Process age in seconds since creation
 	Age int64
+	// Indicates if this process appeared since the previous --watch sample
+	Appeared bool
 	// Command line arguments
 	Args []string
+	// Name of the .app bundle containing the executable (macOS-only)
+	Bundle string
+	// Effective capabilities, with bit n set for capability n, or 0 if none or unknown (set by --show-caps)
+	CapEff uint64
 	// Index of the first child process in the process tree
 	Child int
+	// Control group path, e.g., /system.slice/nginx.service (Linux-only, fetched on demand)
+	Cgroup string
+	// Indicates this node stands for a control group rather than a process (set by --group-by-cgroup)
+	CgroupNode bool
 	// Pointer to a slice of child processes
 	Children *[]Process
+	// Label of the declutter rule matching this process, e.g., browser-helper (set by --declutter)
+	Clutter string
+	// Action of the declutter rule matching this process: "collapse", "hide", or empty
+	ClutterAction string
 	// Command name (executable name)
 	Command string
-	// Network connections associated with this process
-	Connections []net.ConnectionStat
+	// Number of processes of the container collapsed into this one (set by --collapse-containers)
+	ContainerCollapsed int
+	// Full ID of the container this process runs in, if known
+	ContainerID string
+	// Name of the container this process runs in, if known
+	ContainerName string
+	// Container runtime this process runs under, e.g., docker, or empty if it is not containerized
+	ContainerRuntime string
+	// CPUs the process may run on, from its affinity mask, or nil if unknown (set by --show-affinity)
+	CPUAffinity []int
+	// Change in CPU usage percentage since the baseline snapshot (set by pstree diff)
+	CPUDelta float64
 	// CPU usage percentage
 	CPUPercent float64
 	// CPU time statistics
 	CPUTimes *cpu.TimesStat
 	// Process creation time as Unix timestamp
 	CreateTime int64
-	// Environment variables
-	Environment []string
+	// Daemon detachment status: "daemon", "attached", or "detached" (set by --show-daemon-status)
+	DaemonStatus string
+	// Database server engine, "mysql" or "postgres" (set by --db-connections)
+	Database string
+	// Indicates if this process serves one client connection of its parent database server (set by --db-connections)
+	DBBackend bool
+	// Number of client connections of this database server (set by --db-connections)
+	DBConnections int
+	// Number of helper processes collapsed into this process by --declutter, keyed by label
+	Decluttered map[string]int
+	// Change since the baseline snapshot: "added", "changed", "removed", or empty (set by pstree diff)
+	Diff string
+	// Elevation tag, "admin" or "system", or empty for unprivileged processes (Windows-only)
+	Elevation string
 	// Group IDs associated with this process
 	GIDs []uint32
 	// The group name associated with the process
 	Group string
-	// A map of group ID < group name
+	// Names of the groups in GIDs and SupplementaryGIDs, keyed by group ID
 	Groups map[uint32]string
 	// Indicates if this process has a different UID from its parent
 	HasUIDTransition bool
+	// Configured maximum heap in bytes, e.g., from -Xmx, or 0 if none is configured (set by --show-heap)
+	HeapLimit uint64
+	// Indicates if the command line of this process matches the pattern given to --highlight
+	Highlighted bool
+	// Mandatory integrity level, e.g., "medium" or "system" (Windows-only)
+	IntegrityLevel string
+	// Bytes and operations read and written since the process started
+	IOCounters *process.IOCountersStat
 	// Indicates if this process is the current process or an ancestor
 	IsCurrentOrAncestor bool
+	// Locks held on the file given to --who-locks, e.g., "POSIX WRITE"
+	Locks []string
+	// Configured maximum number of workers of a pre-fork server master, or 0 if unknown (set by --show-saturation)
+	MaxWorkers int
 	// Memory usage information
 	MemoryInfo *process.MemoryInfoStat
 	// Memory usage as percentage of total system memory
 	MemoryPercent float32
+	// Inodes of the namespaces this process runs in by type, e.g., net (Linux-only)
+	Namespaces map[string]uint64
 	// Number of file descriptors
 	NumFDs int32
 	// Number of threads
 	NumThreads int32
-	// Open files
-	OpenFiles []process.OpenFilesStat
+	// NUMA nodes holding the memory of the process, or nil if unknown (set by --show-affinity)
+	NUMANodes []int
 	// Index of the parent process in the process tree
 	Parent int
 	// Pointer to the parent process
@@ -75,27 +128,129 @@ type Process struct {
 	PGID int32
 	// Process ID
 	PID int32
+	// Ports this process listens on that are also listened on in another network namespace, e.g., tcp/8080 (set by --port-conflicts)
+	PortConflicts []string
 	// Parent process ID
 	PPID int32
+	// Pre-fork server this process is the master of, e.g., nginx or php-fpm (set by --show-saturation)
+	PreforkServer string
 	// Whether or not we plan to display this process
 	Print bool
+	// Nice value and scheduling classes
+	Priority *Priority
+	// Change in resident memory in bytes since the baseline snapshot (set by pstree diff)
+	RSSDelta int64
+	// Language runtime, e.g., jvm or python (set by --show-runtime and --runtime)
+	Runtime string
+	// Average scheduling delay per timeslice in nanoseconds (Linux-only)
+	SchedLatency float64
+	// SELinux or AppArmor label, e.g., unconfined (set by --show-security-context and --context-contains)
+	SecurityContext string
+	// Names of the services hosted by this process (Windows-only)
+	Services []string
+	// Logon session the process runs in (Windows-only)
+	SessionID uint32
+	// Code signing classification, e.g., "apple" or "unsigned" (macOS-only)
+	Signing string
 	// Index of the next sibling process in the process tree
 	Sister int
 	// Process status information
 	Status []string
+	// Total CPU usage, resident memory, and thread count of this process and its descendants (set by --cumulative)
+	Subtree SubtreeMetrics
+	// Session ID of the process (set by --show-daemon-status)
+	SID int32
+	// Supplementary group IDs of the process, or of its owner where they cannot be read, e.g., on macOS
+	SupplementaryGIDs []uint32
+	// Labels attached to this process from the tags file
+	Tags []string
 	// A map of threads for the process
 	Threads []Thread
 	// Thread ID (if this is a thread)
 	TID int32
+	// Controlling terminal, e.g., pts/0, or empty if there is none (set by --show-daemon-status, --show-tty, and --tty)
+	TTY string
 	// User IDs associated with this process
 	UIDs []uint32
+	// Systemd unit the process belongs to, e.g., nginx.service (Linux-only, fetched on demand)
+	Unit string
+	// Units running other processes that the unit of the process is started after (set by --show-deps)
+	UnitAfter []string
+	// Units running other processes that the unit of the process requires (set by --show-deps)
+	UnitRequires []string
+	// Metrics that could not be collected, e.g., due to permissions or platform support
+	Unavailable Field
 	// Username of the process owner
 	Username string
+	// Number of running workers of a pre-fork server master (set by --show-saturation)
+	Workers int
+	// Number of zombie children this process has not reaped
+	Zombies int
+	// Rarely collected data, or nil when none was collected; see ProcessDetails
+	*ProcessDetails
+}
+
+// ProcessDetails holds the large per-process data that only a few options need. It is kept
+// out of Process, which only points to it, so that the processes of a busy host stay small
+// when none of it is collected. Its fields are embedded in the snapshot format as before.
+type ProcessDetails struct {
+	// Environment variables, collected only for the options that need them, e.g., --show-env
+	Environment []string
+	// Open files, collected only for --show-open-files
+	OpenFiles []process.OpenFilesStat
+}
+
+// Field identifies a per-process metric whose availability is tracked separately from its value.
+// Fields are bit flags so that a process can record several unavailable metrics at once.
+type Field uint32
+
+const (
+	// Process age, derived from the creation time
+	FieldAge Field = 1 << iota
+	// CPU usage percentage
+	FieldCPUPercent
+	// Memory usage information
+	FieldMemory
+	// Number of threads
+	FieldNumThreads
+	// Average scheduling latency
+	FieldSchedLatency
+	// Windows session ID
+	FieldSessionID
+	// Control group path (Linux-only)
+	FieldCgroup
+	// Systemd unit (Linux-only)
+	FieldUnit
+	// Number of open file descriptors
+	FieldNumFDs
+	// Process state, e.g., running or zombie
+	FieldState
+	// Namespace inodes (Linux-only)
+	FieldNamespaces
+	// Bytes and operations read and written
+	FieldIO
+	// Nice value and scheduling classes
+	FieldPriority
+)
+
+// Available reports whether the given metric was successfully collected for the process.
+//
+// Parameters:
+//   - field: The metric to check
+//
+// Returns:
+//   - true if the metric value is meaningful, false if it could not be collected
+func (process *Process) Available(field Field) bool {
+	return process.Unavailable&field == 0
 }
 
 type Thread struct {
 	// Command line arguments
 	Args []string
+	// Thread name, e.g., "GC Thread#0" (Linux-only; empty if unknown)
+	Name string
+	// Whether or not we plan to display this thread
+	Print bool
 	// Process group ID
 	PGID int32
 	// PID
@@ -110,6 +265,18 @@ type Thread struct {
 	CPUTimes *cpu.TimesStat
 }
 
+// DisplayName returns the name shown for the thread, falling back to the
+// process command when the thread name is unknown.
+//
+// Returns:
+//   - The thread name, or the base name of the process command
+func (thread *Thread) DisplayName() string {
+	if thread.Name != "" {
+		return thread.Name
+	}
+	return filepath.Base(thread.Command)
+}
+
 //------------------------------------------------------------------------------
 // DISPLAY CONFIGURATION
 //------------------------------------------------------------------------------
@@ -117,6 +284,14 @@ type Thread struct {
 // DisplayOptions controls how the process tree is displayed, including formatting,
 // coloring, and which information is shown for each process.
 type DisplayOptions struct {
+	// Format of process ages ("clock", "human", "iso8601", "long", or "seconds")
+	AgeFormat string
+	// PID, or regular expression matching the name or path, of the processes whose ancestry alone is shown
+	Ancestors string
+	// Number of capabilities known to the kernel, to recognize processes holding all of them, or 0 if unknown
+	Capabilities int
+	// Whether to collapse each container into its topmost process
+	CollapseContainers bool
 	// Attribute to color by ("age", "cpu", or "mem")
 	ColorAttr string
 	// Number of colors to use in rainbow mode
@@ -129,36 +304,136 @@ type DisplayOptions struct {
 	ColorSupport bool
 	// Whether to compact identical processes in the tree
 	CompactMode bool
+	// Which member represents a compact group ("pid", "oldest", or "cpu")
+	CompactRepresentative string
+	// Whether to list the member PIDs of each compact group
+	CompactShowPIDs bool
 	// String to search for in process names
 	Contains string
+	// Text that the SELinux or AppArmor label of processes must contain
+	ContextContains string
+	// Whether to show the total CPU usage, memory, and threads of each subtree
+	Cumulative bool
+	// Whether to collapse or hide noisy helper processes matched by a declutter rule
+	Declutter bool
+	// Whether to show only elevated processes
+	ElevatedOnly bool
+	// Environment variables, as KEY=TEXT, that processes must define with a value containing TEXT
+	EnvContains []string
+	// Regular expressions matching commands to exclude, together with their subtrees
+	ExcludePatterns []string
 	// Whether to exclude processes owned by root
 	ExcludeRoot bool
+	// Users whose processes are excluded, together with their subtrees
+	ExcludeUsers []string
+	// Whether to print the share of each user of the CPU and memory after the tree
+	FairShare bool
+	// Go template rendering each process instead of the fixed layout, or empty for the fixed layout
+	Format string
 	// Whether to hide threads in the output
 	HideThreads bool
+	// Text in the command line of the processes to highlight without pruning the tree
+	Highlight string
+	// Process to highlight together with its ancestors, or 0 for none
+	HighlightPID int32
 	// Whether to use IBM850 graphics characters for tree lines
 	IBM850Graphics bool
+	// Groups, by name or ID, whose members' processes are shown with their ancestors
+	InGroups []string
 	// Total installed system memory in bytes
 	InstalledMemory uint64
-	// Maximum depth of the tree to display (0 for unlimited)
+	// Whether MaxDepth counts from the top of the tree rather than from the process selected by RootPID or RootCommand
+	LevelFromRoot bool
+	// Maximum depth of the tree to display below the selected root, or the top of the tree (0 for unlimited)
 	MaxDepth int
+	// Namespace inodes by type to filter by, e.g., net (set by --ns)
+	NamespaceFilters map[string]uint64
+	// Nice value above which processes are shown, or nil for no limit
+	NiceAbove *int32
+	// Nice value below which processes are shown, or nil for no limit
+	NiceBelow *int32
+	// Whether to omit headers, e.g., the column names of the fair share summary
+	NoHeaders bool
+	// Number of online CPUs, to recognize processes pinned to some of them, or 0 if unknown
+	OnlineCPUs int
 	// Sort the results by a number of fields
 	OrderBy string
+	// Whether to show only processes listening on a port that is listened on in another network namespace
+	PortConflicts bool
 	// Whether to use rainbow colors for output
 	RainbowOutput bool
+	// Environment variables a process must define, as KEY or KEY=VALUE
+	RequireEnv []string
+	// Regular expression matching the name or path of the processes whose subtrees are shown
+	RootCommand string
+	// Whether to show the subtrees of all the processes matching RootCommand instead of the first
+	RootCommandAll bool
+	// Where and when the processes were collected, e.g., "web01 2025-06-01T12:00:00Z", shown next to the root process
+	RootLabel string
 	// Root process PID
 	RootPID int32
+	// Language runtimes to filter by, e.g., jvm
+	Runtimes []string
 	// Width of the terminal screen in characters
 	ScreenWidth int
+	// PIDs of the processes to show with their ancestors, e.g., read by --pids-from
+	SelectedPIDs []int32
+	// List of tags to filter by
+	Tags []string
+	// String to search for in thread names
+	ThreadContains string
+	// Values from which --color-attr colors a process as medium and high, keyed by attribute; other attributes use DefaultThresholds
+	Thresholds map[string]Threshold
+	// Whether to show start offsets from the subtree root, with children ordered by creation time
+	Timeline bool
+	// Title shown above the tree and embedded in exports, e.g., "web01 production"
+	Title string
+	// Controlling terminals to filter by, e.g., pts/3
+	TTYs []string
+	// Whether to show the CPUs and NUMA nodes each process may run on and uses
+	ShowAffinity bool
 	// Whether to show command line arguments
 	ShowArguments bool
+	// Whether to show the effective capabilities of each process
+	ShowCaps bool
+	// Whether to show the control group, or the systemd slice, scope, or service, of each process
+	ShowCgroup bool
+	// Whether to show the container of each containerized process
+	ShowContainer bool
 	// Whether to show CPU usage percentage
 	ShowCpuPercent bool
+	// Whether to show session leaders and daemon detachment status
+	ShowDaemonStatus bool
+	// Whether to summarize the client connections of database servers, collapsing postgres backends
+	ShowDBConnections bool
+	// Whether to show the After= and Requires= dependencies of each systemd unit on the other units in the tree
+	ShowDeps bool
+	// Whether to mark processes added, changed, or removed since a baseline snapshot
+	ShowDiff bool
+	// Whether to qualify owners with their domain, e.g., CORP\alice
+	ShowDomain bool
+	// Whether to show elevation tags
+	ShowElevation bool
+	// Environment variables to show with each process
+	ShowEnv []string
+	// Whether to show the number of open file descriptors
+	ShowFDs bool
 	// Whether to show the process group
 	ShowGroup bool
+	// Whether to show the supplementary groups of each process
+	ShowGroups bool
+	// Whether to show the bytes each process read and wrote
+	ShowIO bool
+	// Whether to show the configured maximum heap of JVM and Node.js processes next to their resident memory
+	ShowHeap bool
 	// Whether to show memory usage
 	ShowMemoryUsage bool
+	// Whether to show the namespaces each process does not share with its parent
+	ShowNamespaces bool
 	// Whether to show thread count
 	ShowNumThreads bool
+	// Whether to show the files opened by each process
+	ShowOpenFiles bool
 	// Whether to show process owner
 	ShowOwner bool
 	// Whether to highlight process group leaders
@@ -169,20 +444,50 @@ type DisplayOptions struct {
 	ShowPIDs bool
 	// Whether to show parent process IDs
 	ShowPPIDs bool
+	// Whether to show the nice value and scheduling classes of each process
+	ShowPriority bool
 	// Whether to show process age
 	ShowProcessAge bool
+	// Whether to show the language runtime of each process
+	ShowRuntime bool
+	// Whether to show the worker saturation of nginx, Apache, gunicorn, and PHP-FPM masters
+	ShowSaturation bool
+	// Whether to show the average scheduling latency
+	ShowSchedLatency bool
+	// Whether to show the SELinux or AppArmor label of each process
+	ShowSecurityContext bool
+	// Whether to show hosted services and session IDs
+	ShowService bool
+	// Whether to show the app bundle and code signing status
+	ShowSigning bool
+	// Whether to show the one-letter state code of each process
+	ShowState bool
+	// Whether to show the controlling terminal of each process
+	ShowTTY bool
+	// Whether to show unchanged processes when ShowDiff is enabled, not only the changed branches
+	ShowUnchanged bool
 	// Whether to show UID transitions
 	ShowUIDTransitions bool
+	// Whether to show the systemd unit of each process
+	ShowUnit bool
 	// Whether to show username transitions
 	ShowUserTransitions bool
 	// Whether to use UTF-8 graphics characters for tree lines
 	UTF8Graphics bool
+	// List of systemd units to filter by
+	Units []string
 	// List of usernames to filter by
 	Usernames []string
 	// Whether to use VT100 graphics characters for tree lines
 	VT100Graphics bool
 	// Whether to display wide output (not truncated to screen width)
 	WideDisplay bool
+	// Whether to show only processes that are failing to reap zombie children
+	ZombieParents bool
+	// Whether to show only zombie processes
+	ZombiesOnly bool
+	// Path of a file whose lock holders should be shown
+	WhoLocks string
 }
 
 //------------------------------------------------------------------------------
@@ -217,8 +522,28 @@ type ProcessTree struct {
 	ColorScheme color.ColorScheme
 	// Process groups for compact mode
 	ProcessGroups map[int32]map[string]map[string]ProcessGroup
+	// Source of attributes fetched on demand by the Node accessors, or nil to use collected values only
+	Metadata MetadataSource
+	// Attributes already fetched from Metadata, by node index
+	metadataLoaded map[int]Field
+	// Guards metadataLoaded and the fetched attributes
+	metadataMutex sync.Mutex
 	// Map to track processes that should be skipped during printing
 	SkipProcesses map[int]bool
+	// Post-processors applied to each rendered line, in order, or nil for the defaults
+	postProcessors []namedPostProcessor
+	// Writer the text tree is written to, or nil for os.Stdout
+	Output io.Writer
+	// First error returned by Output or the --format template while writing the text tree
+	writeErr error
+	// Template parsed from DisplayOptions.Format, when first used
+	formatTemplate *template.Template
+	// Larger of the CPU and memory shares of each user, computed when first colored by share
+	fairShares map[string]float64
+	// Indexes of the selected roots MaxDepth counts from, or nil to count from the top of the tree
+	levelRoots map[int]bool
+	// Whether filters were given and matched no process on the last marking
+	matchedNone bool
 }
 
 //------------------------------------------------------------------------------
@@ -310,8 +635,15 @@ var AnsiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
 // ProcessGroup represents a group of identical processes
 type ProcessGroup struct {
 	Count      int    // Number of identical processes
-	FirstIndex int    // Index of the first process in the group
+	FirstIndex int    // Index of the process representing the group
 	FullPath   string // Full path of the command
 	Indices    []int  // Indices of all processes in the group
 	Owner      string // Owner of the process group
 }
+
+// GroupMetrics holds the metrics displayed for a process or, in compact mode, its whole group
+type GroupMetrics struct {
+	CPUPercent  float64 // Combined CPU usage percentage
+	RSS         uint64  // Combined resident memory in bytes
+	Unavailable Field   // FieldCPUPercent and/or FieldMemory if no member reported them
+}
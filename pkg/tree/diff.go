@@ -0,0 +1,126 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// SNAPSHOT DIFFS
+//------------------------------------------------------------------------------
+// Functions in this section display the changes between two snapshots, marking
+// each line with +, -, or ~ like a unified diff and showing the CPU and memory
+// deltas of changed processes, e.g., for before/after comparisons of deployments.
+
+// Changes recorded in Process.Diff.
+const (
+	DiffAdded   = "added"   // The process is not in the baseline snapshot
+	DiffChanged = "changed" // The process is in both snapshots, but its usage or command line changed
+	DiffRemoved = "removed" // The process is only in the baseline snapshot
+)
+
+// diffMarker returns the marker shown at the start of the line of a process.
+//
+// Parameters:
+//   - diff: The change recorded for the process
+//
+// Returns:
+//   - The marker: +, ~, -, or a space for an unchanged process
+func diffMarker(diff string) string {
+	switch diff {
+	case DiffAdded:
+		return "+"
+	case DiffChanged:
+		return "~"
+	case DiffRemoved:
+		return "-"
+	}
+	return " "
+}
+
+// colorizeDiff colors a diff marker or delta like a unified diff.
+//
+// Parameters:
+//   - diff: The change recorded for the process
+//   - value: Pointer to the string value to be colored (modified in-place)
+func (processTree *ProcessTree) colorizeDiff(diff string, value *string) {
+	if !processTree.DisplayOptions.ColorSupport {
+		return
+	}
+	switch diff {
+	case DiffAdded:
+		processTree.Colorizer.OK(processTree.ColorScheme, value)
+	case DiffChanged:
+		processTree.Colorizer.Warn(processTree.ColorScheme, value)
+	case DiffRemoved:
+		processTree.Colorizer.Crit(processTree.ColorScheme, value)
+	}
+}
+
+// formatDiffDeltas returns the usage deltas of a changed process, e.g., (Δc:+1.20%, Δm:-3.00 MiB).
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted deltas, or an empty string if neither CPU nor memory usage changed
+func (processTree *ProcessTree) formatDiffDeltas(pidIndex int) string {
+	process := processTree.Nodes[pidIndex]
+	deltas := []string{}
+	if process.CPUDelta != 0 {
+		deltas = append(deltas, fmt.Sprintf("Δc:%+.2f%%", process.CPUDelta))
+	}
+	if process.RSSDelta != 0 {
+		sign, rss := "+", process.RSSDelta
+		if rss < 0 {
+			sign, rss = "-", -rss
+		}
+		deltas = append(deltas, fmt.Sprintf("Δm:%s%s", sign, util.ByteConverter(uint64(rss))))
+	}
+	if len(deltas) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(%s)", strings.Join(deltas, ", "))
+}
+
+// hideUnchangedBranches unmarks the processes whose subtree has no added, changed, or
+// removed process, so that only the changed branches and their ancestors are displayed.
+func (processTree *ProcessTree) hideUnchangedBranches() {
+	var changed func(pidIndex int) bool
+	changed = func(pidIndex int) bool {
+		hasChange := processTree.Nodes[pidIndex].Diff != ""
+		for childIndex := processTree.Nodes[pidIndex].Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+			// Every child is visited so that its own subtree is pruned
+			if changed(childIndex) {
+				hasChange = true
+			}
+		}
+		if !hasChange {
+			processTree.Nodes[pidIndex].Print = false
+		}
+		return hasChange
+	}
+	for pidIndex := range processTree.Nodes {
+		if processTree.Nodes[pidIndex].Parent == -1 {
+			changed(pidIndex)
+		}
+	}
+}
+
+// diffPostProcessor prefixes each line with the diff marker of its process when diffs are shown.
+//
+// Threads are marked like their process when it was added or removed.
+func diffPostProcessor(processTree *ProcessTree, line Line) string {
+	if !processTree.DisplayOptions.ShowDiff {
+		return line.Text
+	}
+	diff := processTree.Nodes[line.PIDIndex].Diff
+	if line.Thread != nil && diff == DiffChanged {
+		diff = ""
+	}
+	marker := diffMarker(diff)
+	processTree.colorizeDiff(diff, &marker)
+	return marker + " " + line.Text
+}
@@ -0,0 +1,48 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowDiff verifies snapshot diffs mark changed branches and hide unchanged processes unless requested
+func TestShowDiff(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Threads: []Thread{{TID: 2, PID: 1, Command: "init"}}},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", Diff: DiffChanged, CPUDelta: 1.5, RSSDelta: -3 * 1024 * 1024},
+		{PID: 11, PPID: 10, Command: "/usr/sbin/nginx-worker", Diff: DiffAdded},
+		{PID: 12, PPID: 10, Command: "/usr/sbin/nginx-cache", Diff: DiffRemoved},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/sshd"},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowDiff: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "  "))
+	assert.Contains(t, lines[0], "/sbin/init")
+	assert.True(t, strings.HasPrefix(lines[1], "~ "))
+	assert.Contains(t, lines[1], "/usr/sbin/nginx (Δc:+1.50%, Δm:-3.00 MiB)")
+	assert.True(t, strings.HasPrefix(lines[2], "+ "))
+	assert.Contains(t, lines[2], "/usr/sbin/nginx-worker")
+	assert.True(t, strings.HasPrefix(lines[3], "- "))
+	assert.Contains(t, lines[3], "/usr/sbin/nginx-cache")
+
+	// Unchanged processes and threads are shown on request
+	options.ShowUnchanged = true
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "{init}")
+	assert.Contains(t, output, "/usr/sbin/sshd")
+}
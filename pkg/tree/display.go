@@ -2,13 +2,10 @@ package tree
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/gdanko/pstree/util"
-	"github.com/giancarlosio/gorainbow"
-	"golang.org/x/term"
 )
 
 //------------------------------------------------------------------------------
@@ -35,7 +32,7 @@ func (processTree *ProcessTree) PrintTree(pidIndex int, head string) {
 	processTree.Logger.Debug(fmt.Sprintf("processTree.PrintTree(pidIndex=%d, head=\"%s\", atDepth=%d)", pidIndex, head, processTree.AtDepth))
 	// https://github.com/FredHucht/pstree/blob/main/pstree.c#L721-L777
 	// Skip if we've reached the maximum depth
-	if processTree.DisplayOptions.MaxDepth > 0 && processTree.AtDepth > processTree.DisplayOptions.MaxDepth {
+	if !processTree.withinDepth(processTree.level(pidIndex, processTree.AtDepth)) {
 		processTree.Logger.Debug(fmt.Sprintf("Skipping process %d at depth %d (max depth %d)", processTree.Nodes[pidIndex].PID, processTree.AtDepth, processTree.DisplayOptions.MaxDepth))
 		return
 	}
@@ -60,11 +57,6 @@ func (processTree *ProcessTree) PrintTree(pidIndex int, head string) {
 		newHead string
 	)
 
-	if processTree.AtDepth > processTree.DisplayOptions.MaxDepth {
-		processTree.Logger.Debug(fmt.Sprintf("Skipping process %d at depth %d (max depth %d)", processTree.Nodes[pidIndex].PID, processTree.AtDepth, processTree.DisplayOptions.MaxDepth))
-		return
-	}
-
 	if head == "" && !processTree.Nodes[pidIndex].Print {
 		processTree.Logger.Debug(fmt.Sprintf("Skipping process %d because head is empty and Print is false", processTree.Nodes[pidIndex].PID))
 		return
@@ -72,45 +64,20 @@ func (processTree *ProcessTree) PrintTree(pidIndex int, head string) {
 
 	line = processTree.buildLineItem(head, pidIndex)
 
-	// If output is not a terminal, strip color
-	if !term.IsTerminal(int(os.Stdout.Fd())) {
-		line = processTree.stripANSI(line)
-		if len(line) > processTree.DisplayOptions.ScreenWidth {
-			if !processTree.DisplayOptions.WideDisplay {
-				line = processTree.truncatePlain(line)
-			}
-		}
-	} else {
-		if !processTree.DisplayOptions.WideDisplay {
-			if len(line) > processTree.DisplayOptions.ScreenWidth {
-				if processTree.DisplayOptions.RainbowOutput {
-					line = processTree.truncateANSI(gorainbow.Rainbow(line))
-				} else {
-					line = processTree.truncateANSI(line)
-				}
-			} else {
-				if processTree.DisplayOptions.RainbowOutput {
-					line = gorainbow.Rainbow(line)
-				}
-			}
-		} else {
-			if processTree.DisplayOptions.RainbowOutput {
-				line = gorainbow.Rainbow(line)
-			}
-		}
-	}
-
 	newHead = processTree.buildNewHead(head, pidIndex)
 
 	processTree.Logger.Debug(fmt.Sprintf("processTree.PrintTree(): printing line for node.PID=%d, head=\"%s\"", processTree.Nodes[pidIndex].PID, head))
-	fmt.Fprintln(os.Stdout, line)
+	processTree.writeLine(Line{Text: line, PIDIndex: pidIndex})
 
-	// Print threads for this process if any exist and threads are not hidden
-	if !processTree.DisplayOptions.HideThreads && len(processTree.Nodes[pidIndex].Threads) > 0 {
+	// Print threads for this process if any exist, threads are not hidden, and they are within the depth limit
+	if len(processTree.threadsWithinDepth(pidIndex)) > 0 {
 		processTree.PrintThreads(pidIndex, newHead)
 	}
 
 	// Iterate over children and determine sibling status
+	if !processTree.childrenWithinDepth(pidIndex) {
+		return
+	}
 	childme := processTree.Nodes[pidIndex].Child
 	for childme != -1 {
 		nextChild := processTree.Nodes[childme].Sister
@@ -158,8 +125,9 @@ func (processTree *ProcessTree) buildLinePrefix(head string, pidIndex int) strin
 	builder.WriteString(processTree.TreeChars.SG)
 	builder.WriteString(head)
 
-	if processTree.Nodes[pidIndex].PID == 1 {
-		// This is a worakround
+	if head == "" && (processTree.Nodes[pidIndex].Parent == -1 || processTree.Nodes[pidIndex].CgroupNode) {
+		// This is a worakround; every root, e.g., an orphaned process on Windows, and the root control
+		// group of --group-by-cgroup are drawn like PID 1
 		builder.WriteString(processTree.TreeChars.P)
 		if processTree.DisplayOptions.ShowPGLs {
 			builder.WriteString(processTree.TreeChars.PGL)
@@ -199,8 +167,8 @@ func (processTree *ProcessTree) buildLinePrefix(head string, pidIndex int) strin
 	}
 
 	// Check if this process has children or threads
-	hasChildren := processTree.Nodes[pidIndex].Child != -1 && processTree.AtDepth < processTree.DisplayOptions.MaxDepth
-	hasThreads := !processTree.DisplayOptions.HideThreads && len(processTree.Nodes[pidIndex].Threads) > 0
+	hasChildren := processTree.childrenWithinDepth(pidIndex)
+	hasThreads := len(processTree.threadsWithinDepth(pidIndex)) > 0
 
 	// Add branch character if the process has children or threads
 	if hasChildren || hasThreads {
@@ -225,7 +193,7 @@ func (processTree *ProcessTree) buildLinePrefix(head string, pidIndex int) strin
 }
 
 // buildLineItem constructs a complete formatted line for a process in the tree display.
-// It combines the tree structure prefix with various process information based on display options.
+// It combines the tree structure prefix with the fields of the process.
 //
 // Parameters:
 //   - head: The accumulated prefix string from parent levels
@@ -235,24 +203,58 @@ func (processTree *ProcessTree) buildLinePrefix(head string, pidIndex int) strin
 //   - A fully formatted string containing the process information with appropriate formatting and coloring.
 //     The string includes elements such as tree structure, process IDs, resource usage, and command information
 //     based on the configured display options.
+func (processTree *ProcessTree) buildLineItem(head string, pidIndex int) string {
+	processTree.Logger.Debug(fmt.Sprintf("processTree.buildLineItem(head=\"%s\", pidIndex=%d, atDepth=%d)", head, pidIndex, processTree.AtDepth))
+
+	linePrefix := processTree.buildLinePrefix(head, pidIndex)
+	processTree.colorizeField("prefix", &linePrefix, pidIndex)
+	return processTree.appendRootLabel(linePrefix+" "+processTree.buildLineFields(pidIndex), pidIndex)
+}
+
+// buildLineFields constructs the fields of a process following its tree prefix, e.g., its
+// owner, PIDs, resource usage, and command, based on the display options.
+//
+// The fields are shared by every tree model, so that ProcessMap shows the same fields as
+// ProcessTree without implementing them again.
+//
+// Parameters:
+//   - pidIndex: Index of the current process in the Nodes array
+//
+// Returns:
+//   - The formatted and colored fields of the process
 //
 // Refactoring opportunity: This function is very large and could be broken down into:
 // - formatProcessIDs: Format PID, PPID, PGID information
 // - formatResourceUsage: Format CPU, memory, thread information
 // - formatCommandInfo: Format command and arguments
 // - formatOwnerInfo: Format username and UID transition information
-func (processTree *ProcessTree) buildLineItem(head string, pidIndex int) string {
-	processTree.Logger.Debug(fmt.Sprintf("processTree.buildLineItem(head=\"%s\", pidIndex=%d, atDepth=%d)", head, pidIndex, processTree.AtDepth))
+func (processTree *ProcessTree) buildLineFields(pidIndex int) string {
 	var (
+		affinityString   string
 		ageString        string
+		appearedString   string
 		args             string
 		commandStr       string
 		compactStr       string
 		connector        string
+		cgroupString     string
+		containerString  string
 		cpuPercent       string
+		daemonString     string
+		declutterString  string
+		deltaString      string
+		depsString       string
+		elevationString  string
+		envString        string
+		fdsString        string
+		filesString      string
+		dbString         string
 		group            string
-		linePrefix       string
+		heapString       string
+		ioString         string
+		lockString       string
 		memoryUsage      string
+		namespaceString  string
 		owner            string
 		ownerGroupSlice  []string
 		ownerGroupString string
@@ -261,10 +263,36 @@ func (processTree *ProcessTree) buildLineItem(head string, pidIndex int) string
 		pidPgidSlice     []string
 		pidPgidString    string
 		pidString        string
+		portString       string
+		priorityString   string
 		ppidString       string
+		runtimeString    string
+		saturationString string
+		schedLatency     string
+		serviceString    string
+		sessionString    string
+		signingString    string
+		stateString      string
+		tagString        string
+		timelineString   string
+		ttyString        string
+		contextString    string
+		capsString       string
 		threads          string
+		unitString       string
+		zombies          string
 	)
 
+	// Control groups standing in for processes with --group-by-cgroup only have a name
+	if processTree.Nodes[pidIndex].CgroupNode {
+		return processTree.buildCgroupFields(pidIndex)
+	}
+
+	// The --format template replaces the fixed layout
+	if processTree.DisplayOptions.Format != "" {
+		return processTree.buildFormatFields(pidIndex)
+	}
+
 	// Create a strings.Builder with an estimated capacity
 	// This helps avoid reallocations as the builder grows
 	var builder strings.Builder
@@ -272,19 +300,26 @@ func (processTree *ProcessTree) buildLineItem(head string, pidIndex int) string
 	// Pre-allocate capacity based on expected size
 	// This is an optimization to avoid reallocations
 	// You can adjust the capacity based on typical usage patterns
-	builder.Grow(len(head) + 260) // Estimate based on typical usage
-
-	linePrefix = processTree.buildLinePrefix(head, pidIndex)
-	processTree.colorizeField("prefix", &linePrefix, pidIndex)
+	builder.Grow(260) // Estimate based on typical usage
 
-	builder.WriteString(linePrefix)
-	builder.WriteString(" ")
+	// Show the start offset from the subtree root in timeline mode
+	if processTree.DisplayOptions.Timeline {
+		if offset, ok := processTree.StartOffset(pidIndex); ok {
+			timelineString = formatStartOffset(offset)
+			processTree.colorizeField("age", &timelineString, pidIndex)
+		} else {
+			timelineString = processTree.unavailableField("+", pidIndex)
+		}
+		builder.WriteString(timelineString)
+		builder.WriteString(" ")
+	}
 
 	// Show owner/group information if enabled
 	ownerGroupSlice = []string{} // Reset for each process
 	if processTree.DisplayOptions.ShowOwner {
 		owner = processTree.Nodes[pidIndex].Username
 		if owner != "" {
+			owner = processTree.formatOwner(owner)
 			ownerGroupSlice = append(ownerGroupSlice, owner)
 		}
 	}
@@ -333,45 +368,139 @@ func (processTree *ProcessTree) buildLineItem(head string, pidIndex int) string
 		builder.WriteString(" ")
 	}
 
+	if processTree.DisplayOptions.ShowState {
+		if processTree.Nodes[pidIndex].Available(FieldState) {
+			stateString = fmt.Sprintf("(%s)", processTree.Nodes[pidIndex].StateCode())
+			if processTree.stateAlert(pidIndex) {
+				processTree.colorizeField("stateAlert", &stateString, pidIndex)
+			} else {
+				processTree.colorizeField("pidPgid", &stateString, pidIndex)
+			}
+		} else {
+			stateString = processTree.unavailableField("", pidIndex)
+		}
+		builder.WriteString(stateString)
+		builder.WriteString(" ")
+	}
+
 	// Show process age if enabled
 	if processTree.DisplayOptions.ShowProcessAge {
-		duration := util.FindDuration(processTree.Nodes[pidIndex].Age)
-		ageSlice := []string{}
-		ageSlice = append(ageSlice, fmt.Sprintf("%02d", duration.Days))
-		ageSlice = append(ageSlice, fmt.Sprintf("%02d", duration.Hours))
-		ageSlice = append(ageSlice, fmt.Sprintf("%02d", duration.Minutes))
-		ageSlice = append(ageSlice, fmt.Sprintf("%02d", duration.Seconds))
-		ageString = fmt.Sprintf(
-			"(%s)",
-			strings.Join(ageSlice, ":"),
-		)
-		processTree.colorizeField("age", &ageString, pidIndex)
+		if processTree.Nodes[pidIndex].Available(FieldAge) {
+			ageString = fmt.Sprintf("(%s)", util.FormatDuration(processTree.Nodes[pidIndex].Age, processTree.DisplayOptions.AgeFormat))
+			processTree.colorizeField("age", &ageString, pidIndex)
+		} else {
+			ageString = processTree.unavailableField("", pidIndex)
+		}
 		builder.WriteString(ageString)
 		builder.WriteString(" ")
 	}
 
+	// In compact mode, groups show the combined usage of all their members
+	groupMetrics := processTree.GetGroupMetrics(pidIndex)
+
 	if processTree.DisplayOptions.ShowCpuPercent {
-		cpuPercent = fmt.Sprintf("(c:%.2f%%)", processTree.Nodes[pidIndex].CPUPercent)
-		processTree.colorizeField("cpu", &cpuPercent, pidIndex)
+		if groupMetrics.Unavailable&FieldCPUPercent == 0 {
+			cpuPercent = fmt.Sprintf("(c:%.2f%%)", groupMetrics.CPUPercent)
+			processTree.colorizeField("cpu", &cpuPercent, pidIndex)
+		} else {
+			cpuPercent = processTree.unavailableField("c:", pidIndex)
+		}
 		builder.WriteString(cpuPercent)
 		builder.WriteString(" ")
 	}
 
 	if processTree.DisplayOptions.ShowMemoryUsage {
-		memoryUsage = fmt.Sprintf("(m:%s)", util.ByteConverter(processTree.Nodes[pidIndex].MemoryInfo.RSS))
-		processTree.colorizeField("memory", &memoryUsage, pidIndex)
+		if groupMetrics.Unavailable&FieldMemory == 0 {
+			memoryUsage = fmt.Sprintf("(m:%s)", util.ByteConverter(groupMetrics.RSS))
+			processTree.colorizeField("memory", &memoryUsage, pidIndex)
+		} else {
+			memoryUsage = processTree.unavailableField("m:", pidIndex)
+		}
 		builder.WriteString(memoryUsage)
 		builder.WriteString(" ")
 	}
 
+	if processTree.DisplayOptions.Cumulative {
+		cumulative := processTree.formatCumulative(pidIndex)
+		processTree.colorizeField("cpu", &cumulative, pidIndex)
+		builder.WriteString(cumulative)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowSchedLatency {
+		if processTree.Nodes[pidIndex].Available(FieldSchedLatency) {
+			schedLatency = fmt.Sprintf("(lat:%s)", util.FormatNanoseconds(processTree.Nodes[pidIndex].SchedLatency))
+			processTree.colorizeField("latency", &schedLatency, pidIndex)
+		} else {
+			schedLatency = processTree.unavailableField("lat:", pidIndex)
+		}
+		builder.WriteString(schedLatency)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowService {
+		if processTree.Nodes[pidIndex].Available(FieldSessionID) {
+			sessionString = fmt.Sprintf("(s:%d)", processTree.Nodes[pidIndex].SessionID)
+			processTree.colorizeField("pidPgid", &sessionString, pidIndex)
+		} else {
+			sessionString = processTree.unavailableField("s:", pidIndex)
+		}
+		builder.WriteString(sessionString)
+		builder.WriteString(" ")
+	}
+
 	if processTree.DisplayOptions.ShowNumThreads {
 		// Always show thread count, even when showing compact format
-		threads = fmt.Sprintf("(t:%d)", processTree.Nodes[pidIndex].NumThreads)
-		processTree.colorizeField("threads", &threads, pidIndex)
+		if processTree.Nodes[pidIndex].Available(FieldNumThreads) {
+			threads = fmt.Sprintf("(t:%d)", processTree.Nodes[pidIndex].NumThreads)
+			processTree.colorizeField("threads", &threads, pidIndex)
+		} else {
+			threads = processTree.unavailableField("t:", pidIndex)
+		}
 		builder.WriteString(threads)
 		builder.WriteString(" ")
 	}
 
+	if processTree.DisplayOptions.ShowFDs {
+		if processTree.Nodes[pidIndex].Available(FieldNumFDs) {
+			fdsString = fmt.Sprintf("(fd:%d)", processTree.Nodes[pidIndex].NumFDs)
+			processTree.colorizeField("fds", &fdsString, pidIndex)
+		} else {
+			fdsString = processTree.unavailableField("fd:", pidIndex)
+		}
+		builder.WriteString(fdsString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowIO {
+		if processTree.Nodes[pidIndex].HasIO() {
+			ioString = processTree.formatIO(pidIndex)
+			processTree.colorizeField("io", &ioString, pidIndex)
+		} else {
+			ioString = processTree.unavailableField("io:", pidIndex)
+		}
+		builder.WriteString(ioString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowPriority {
+		if processTree.Nodes[pidIndex].HasPriority() {
+			priorityString = processTree.formatPriority(pidIndex)
+		} else {
+			priorityString = processTree.unavailableField("ni:", pidIndex)
+		}
+		builder.WriteString(priorityString)
+		builder.WriteString(" ")
+	}
+
+	// Unreaped zombie children are shown on their parent
+	if processTree.showZombies() && processTree.Nodes[pidIndex].Zombies > 0 {
+		zombies = fmt.Sprintf("(z:%d)", processTree.Nodes[pidIndex].Zombies)
+		processTree.colorizeField("zombies", &zombies, pidIndex)
+		builder.WriteString(zombies)
+		builder.WriteString(" ")
+	}
+
 	if processTree.DisplayOptions.ShowUIDTransitions && processTree.Nodes[pidIndex].HasUIDTransition {
 		// Add UID transition notation {parentUID→currentUID}
 		if len(processTree.Nodes[pidIndex].UIDs) > 0 {
@@ -380,7 +509,7 @@ func (processTree *ProcessTree) buildLineItem(head string, pidIndex int) string
 	} else if processTree.DisplayOptions.ShowUserTransitions && processTree.Nodes[pidIndex].HasUIDTransition {
 		// Add user transition notation {parentUser→currentUser}
 		if processTree.Nodes[pidIndex].ParentUsername != "" {
-			ownerTransition = fmt.Sprintf("(%s→%s)", processTree.Nodes[pidIndex].ParentUsername, processTree.Nodes[pidIndex].Username)
+			ownerTransition = fmt.Sprintf("(%s→%s)", processTree.formatOwner(processTree.Nodes[pidIndex].ParentUsername), processTree.formatOwner(processTree.Nodes[pidIndex].Username))
 		}
 	}
 
@@ -425,10 +554,235 @@ func (processTree *ProcessTree) buildLineItem(head string, pidIndex int) string
 		}
 	}
 
-	processTree.colorizeField("command", &commandStr, pidIndex)
+	// Zombies and processes stuck in uninterruptible sleep are shown in red with --show-state
+	if processTree.stateAlert(pidIndex) {
+		processTree.colorizeField("stateAlert", &commandStr, pidIndex)
+	} else {
+		processTree.colorizeField("command", &commandStr, pidIndex)
+	}
+	if processTree.Nodes[pidIndex].IsCurrentOrAncestor || processTree.Nodes[pidIndex].Highlighted {
+		processTree.colorizeField("highlight", &commandStr, pidIndex)
+	}
 	builder.WriteString(commandStr)
 	builder.WriteString(" ")
 
+	if processTree.Nodes[pidIndex].Appeared {
+		appearedString = "[new]"
+		processTree.colorizeField("appeared", &appearedString, pidIndex)
+		builder.WriteString(appearedString)
+		builder.WriteString(" ")
+	}
+
+	if declutterString = processTree.formatDecluttered(pidIndex); declutterString != "" {
+		processTree.colorizeField("compactStr", &declutterString, pidIndex)
+		builder.WriteString(declutterString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowDiff && processTree.Nodes[pidIndex].Diff == DiffChanged {
+		if deltaString = processTree.formatDiffDeltas(pidIndex); deltaString != "" {
+			processTree.colorizeDiff(DiffChanged, &deltaString)
+			builder.WriteString(deltaString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if len(processTree.Nodes[pidIndex].Tags) > 0 {
+		tagString = fmt.Sprintf("<%s>", strings.Join(processTree.Nodes[pidIndex].Tags, ","))
+		processTree.colorizeField("tag", &tagString, pidIndex)
+		builder.WriteString(tagString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowElevation && processTree.Nodes[pidIndex].Elevation != "" {
+		elevationString = fmt.Sprintf("[%s]", processTree.Nodes[pidIndex].Elevation)
+		processTree.colorizeField("elevation", &elevationString, pidIndex)
+		builder.WriteString(elevationString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowService && len(processTree.Nodes[pidIndex].Services) > 0 {
+		serviceString = fmt.Sprintf("[svc:%s]", strings.Join(processTree.Nodes[pidIndex].Services, ","))
+		processTree.colorizeField("tag", &serviceString, pidIndex)
+		builder.WriteString(serviceString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowRuntime && processTree.Nodes[pidIndex].Runtime != "" {
+		runtimeString = fmt.Sprintf("[rt:%s]", processTree.Nodes[pidIndex].Runtime)
+		processTree.colorizeField("tag", &runtimeString, pidIndex)
+		builder.WriteString(runtimeString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowGroups {
+		if groupsString := processTree.formatGroups(pidIndex); groupsString != "" {
+			processTree.colorizeField("tag", &groupsString, pidIndex)
+			builder.WriteString(groupsString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowCgroup {
+		if cgroupString = processTree.formatCgroup(pidIndex); cgroupString != "" {
+			processTree.colorizeField("tag", &cgroupString, pidIndex)
+			builder.WriteString(cgroupString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowUnit {
+		if unitString = processTree.formatUnit(pidIndex); unitString != "" {
+			processTree.colorizeField("tag", &unitString, pidIndex)
+			builder.WriteString(unitString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowDeps {
+		if depsString = processTree.formatDependencies(pidIndex); depsString != "" {
+			processTree.colorizeField("tag", &depsString, pidIndex)
+			builder.WriteString(depsString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowNamespaces {
+		if namespaceString = processTree.formatNamespaces(pidIndex); namespaceString != "" {
+			processTree.colorizeField("tag", &namespaceString, pidIndex)
+			builder.WriteString(namespaceString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowContainer {
+		if containerString = processTree.formatContainer(pidIndex); containerString != "" {
+			processTree.colorizeField("tag", &containerString, pidIndex)
+			builder.WriteString(containerString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowDBConnections {
+		if dbString = processTree.formatDBConnections(pidIndex); dbString != "" {
+			processTree.colorizeField("tag", &dbString, pidIndex)
+			builder.WriteString(dbString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowHeap {
+		if heapString = processTree.formatHeap(pidIndex); heapString != "" {
+			if processTree.Nodes[pidIndex].ExceedsHeap() {
+				processTree.colorizeField("overBudget", &heapString, pidIndex)
+			} else {
+				processTree.colorizeField("tag", &heapString, pidIndex)
+			}
+			builder.WriteString(heapString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowSaturation {
+		if saturationString = processTree.formatSaturation(pidIndex); saturationString != "" {
+			if processTree.Nodes[pidIndex].saturated() {
+				processTree.colorizeField("saturated", &saturationString, pidIndex)
+			} else {
+				processTree.colorizeField("tag", &saturationString, pidIndex)
+			}
+			builder.WriteString(saturationString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowAffinity {
+		if affinityString = processTree.formatAffinity(pidIndex); affinityString != "" {
+			if processTree.affinityAlert(pidIndex) {
+				processTree.colorizeField("affinityAlert", &affinityString, pidIndex)
+			} else {
+				processTree.colorizeField("tag", &affinityString, pidIndex)
+			}
+			builder.WriteString(affinityString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowSigning && processTree.Nodes[pidIndex].Signing != "" {
+		if processTree.Nodes[pidIndex].Bundle != "" {
+			signingString = fmt.Sprintf("[%s.app, %s]", processTree.Nodes[pidIndex].Bundle, processTree.Nodes[pidIndex].Signing)
+		} else {
+			signingString = fmt.Sprintf("[%s]", processTree.Nodes[pidIndex].Signing)
+		}
+		processTree.colorizeField("signing", &signingString, pidIndex)
+		builder.WriteString(signingString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowDaemonStatus && processTree.Nodes[pidIndex].DaemonStatus != "" {
+		daemonString = processTree.formatDaemonStatus(pidIndex)
+		processTree.colorizeField("daemon", &daemonString, pidIndex)
+		builder.WriteString(daemonString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowTTY {
+		if ttyString = processTree.formatTTY(pidIndex); ttyString != "" {
+			processTree.colorizeField("tag", &ttyString, pidIndex)
+			builder.WriteString(ttyString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowSecurityContext {
+		if contextString = processTree.formatSecurityContext(pidIndex); contextString != "" {
+			processTree.colorizeField("tag", &contextString, pidIndex)
+			builder.WriteString(contextString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if processTree.DisplayOptions.ShowCaps {
+		if capsString = processTree.formatCapabilities(pidIndex); capsString != "" {
+			if processTree.capsAlert(pidIndex) {
+				processTree.colorizeField("capsAlert", &capsString, pidIndex)
+			} else {
+				processTree.colorizeField("tag", &capsString, pidIndex)
+			}
+			builder.WriteString(capsString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if len(processTree.Nodes[pidIndex].Locks) > 0 {
+		lockString = fmt.Sprintf("[lock:%s]", strings.Join(processTree.Nodes[pidIndex].Locks, ","))
+		processTree.colorizeField("lock", &lockString, pidIndex)
+		builder.WriteString(lockString)
+		builder.WriteString(" ")
+	}
+
+	if len(processTree.Nodes[pidIndex].PortConflicts) > 0 {
+		portString = fmt.Sprintf("[port:%s]", strings.Join(processTree.Nodes[pidIndex].PortConflicts, ","))
+		processTree.colorizeField("lock", &portString, pidIndex)
+		builder.WriteString(portString)
+		builder.WriteString(" ")
+	}
+
+	if processTree.DisplayOptions.ShowOpenFiles {
+		if filesString = processTree.formatOpenFiles(pidIndex); filesString != "" {
+			processTree.colorizeField("args", &filesString, pidIndex)
+			builder.WriteString(filesString)
+			builder.WriteString(" ")
+		}
+	}
+
+	if len(processTree.DisplayOptions.ShowEnv) > 0 {
+		if envString = processTree.formatEnv(pidIndex); envString != "" {
+			processTree.colorizeField("args", &envString, pidIndex)
+			builder.WriteString(envString)
+			builder.WriteString(" ")
+		}
+	}
+
 	if processTree.DisplayOptions.ShowArguments {
 		if len(processTree.Nodes[pidIndex].Args) > 0 {
 			// psutil.Process sometimes prepends the first argument with the name of the binary,
@@ -496,131 +850,75 @@ func (processTree *ProcessTree) buildNewHead(head string, pidIndex int) string {
 	return newHead
 }
 
-// PrintThreads displays the threads of a process in a tree-like structure.
-// It formats each thread with its thread ID and PGID. This only works on
-// Linux because macOS does not provide thread IDs.
+//------------------------------------------------------------------------------
+// DEPTH LIMITS
+//------------------------------------------------------------------------------
+// Functions in this section define how --level applies to processes, threads,
+// and compact groups. The root process, or the process selected with --pid or
+// --root-cmd unless --level-from-root is given, is at depth 0 and children are
+// one level below their parent. Threads count as one level below their process, and a
+// compact group is displayed at the depth of its representative, so a group
+// and its members are either shown or hidden together.
+
+// withinDepth returns true if something at the given depth should be displayed.
 //
 // Parameters:
-//   - pidIndex: Index of the parent process whose threads to display
-//   - head: The accumulated prefix string from parent levels
-func (processTree *ProcessTree) PrintThreads(pidIndex int, head string) {
-	if len(processTree.Nodes[pidIndex].Threads) == 0 {
-		return
-	}
-
-	var threadHead string
-
-	processTree.Logger.Debug(fmt.Sprintf("Printing %d threads for process %d", len(processTree.Nodes[pidIndex].Threads), processTree.Nodes[pidIndex].PID))
-
-	// Get the thread head with proper spacing
-	if processTree.Nodes[pidIndex].PID == 1 {
-		// Special case for PID 1 (init/systemd)
-		threadHead = " "
-	} else {
-		threadHead = processTree.buildThreadHead(head)
-	}
-
-	processTree.Logger.Debug(fmt.Sprintf("Thread head for PID %d: \"%s\"", processTree.Nodes[pidIndex].PID, threadHead))
-
-	for i, thread := range processTree.Nodes[pidIndex].Threads {
-		var (
-			line          string
-			pgidString    string
-			prefix        string
-			threadLine    strings.Builder
-			tidPgidSlice  = []string{}
-			tidPgidString string
-			tidString     string
-		)
-
-		// Always use T-connector (├) for threads except for the last thread when there are no child processes
-		// This ensures that when a thread is followed by a process, the thread uses the correct connector
-		isLastThread := i == len(processTree.Nodes[pidIndex].Threads)-1
-		hasChildProcess := processTree.Nodes[pidIndex].Child != -1
-
-		// Create thread line prefix with appropriate branch characters
-		if isLastThread && !hasChildProcess {
-			// Last thread with no child processes uses └──── style connector (L shape)
-			prefix = threadHead + processTree.TreeChars.BarL + processTree.TreeChars.EG + strings.Repeat(processTree.TreeChars.S2, 1) + processTree.TreeChars.NPGL
-		} else {
-			// Other threads or last thread with child processes use ├──── style connector (T shape)
-			prefix = threadHead + processTree.TreeChars.BarC + processTree.TreeChars.EG + strings.Repeat(processTree.TreeChars.S2, 1) + processTree.TreeChars.NPGL
-		}
-
-		// Add the prefix to the thread line
-		threadLine.WriteString(prefix)
-		threadLine.WriteString(" ")
-
-		// Format thread name with curly braces like {processname}
-		threadName := fmt.Sprintf("{%s}", filepath.Base(thread.Command))
-		processTree.colorizeField("command", &threadName, pidIndex)
-		threadLine.WriteString(threadName)
-		threadLine.WriteString(" ")
-
-		// Format TID, PGID for threads
-		tidPgidSlice = []string{} // Reset for each thread
-		if processTree.DisplayOptions.ShowPIDs {
-			if thread.TID >= 0 {
-				tidString = util.Int32toStr(thread.TID)
-				tidPgidSlice = append(tidPgidSlice, tidString)
-			}
-		}
-
-		if processTree.DisplayOptions.ShowPGIDs {
-			if thread.PGID >= 0 {
-				pgidString = util.Int32toStr(thread.PGID)
-				tidPgidSlice = append(tidPgidSlice, pgidString)
-			}
-		}
-
-		if len(tidPgidSlice) > 0 {
-			tidPgidString = fmt.Sprintf("(%s)", strings.Join(tidPgidSlice, ","))
-			processTree.colorizeField("pidPgid", &tidPgidString, pidIndex)
-			threadLine.WriteString(tidPgidString)
-			threadLine.WriteString(" ")
-		}
-
-		line = threadLine.String()
+//   - depth: Depth in the tree, with the root process at depth 0
+//
+// Returns:
+//   - true if MaxDepth is unlimited (0 or less) or depth does not exceed it
+func (processTree *ProcessTree) withinDepth(depth int) bool {
+	return processTree.DisplayOptions.MaxDepth <= 0 || depth <= processTree.DisplayOptions.MaxDepth
+}
 
-		// Handle terminal width and coloring
-		if !term.IsTerminal(int(os.Stdout.Fd())) {
-			line = processTree.stripANSI(line)
-			if len(line) > processTree.DisplayOptions.ScreenWidth && !processTree.DisplayOptions.WideDisplay {
-				line = processTree.truncatePlain(line)
-			}
-		} else if !processTree.DisplayOptions.WideDisplay && len(line) > processTree.DisplayOptions.ScreenWidth {
-			if processTree.DisplayOptions.RainbowOutput {
-				line = processTree.truncateANSI(gorainbow.Rainbow(line))
-			} else {
-				line = processTree.truncateANSI(line)
-			}
-		} else if processTree.DisplayOptions.RainbowOutput {
-			line = gorainbow.Rainbow(line)
+// level returns the depth --level applies to for a process. It is the given depth when
+// counting from the top of the tree, and otherwise the number of generations below the
+// nearest selected root, so that --level limits the subtree selected with --pid or
+// --root-cmd. The ancestors of a selected root are at level 0, so that the path to it is
+// always displayed.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//   - depth: Depth of the process below the top of the tree
+//
+// Returns:
+//   - The level of the process
+func (processTree *ProcessTree) level(pidIndex int, depth int) int {
+	if len(processTree.levelRoots) == 0 {
+		return depth
+	}
+	generations := 0
+	for index := pidIndex; index != -1; index = processTree.Nodes[index].Parent {
+		if processTree.levelRoots[index] {
+			return generations
 		}
-
-		// Print the thread line
-		fmt.Fprintln(os.Stdout, line)
+		generations++
 	}
+	return 0
 }
 
-// buildThreadHead constructs a head string specifically for thread display.
-// It ensures the correct spacing and vertical bars for thread hierarchy.
+// childrenWithinDepth returns true if the process at the current depth has children that will be displayed.
 //
 // Parameters:
-//   - head: The accumulated prefix string from parent levels
+//   - pidIndex: Index of the process, located at processTree.AtDepth
 //
 // Returns:
-//   - A string to be used as the head for thread display
-func (processTree *ProcessTree) buildThreadHead(head string) string {
-	// Remove the trailing space from the head if it exists
-	head = strings.TrimSuffix(head, " ")
+//   - true if the process has children and the level below it is within the depth limit
+func (processTree *ProcessTree) childrenWithinDepth(pidIndex int) bool {
+	return processTree.Nodes[pidIndex].Child != -1 && processTree.withinDepth(processTree.level(pidIndex, processTree.AtDepth)+1)
+}
 
-	// For thread display, we need to ensure the correct spacing
-	// The format should be "│ " (vertical bar followed by space)
-	if len(head) > 0 {
-		// Replace the last space with a vertical bar + space
-		return head + " "
+// threadsWithinDepth returns the visible threads of the process at the current depth,
+// or nil if the level below the process exceeds the depth limit.
+//
+// Parameters:
+//   - pidIndex: Index of the process, located at processTree.AtDepth
+//
+// Returns:
+//   - The threads to display
+func (processTree *ProcessTree) threadsWithinDepth(pidIndex int) []Thread {
+	if !processTree.withinDepth(processTree.level(pidIndex, processTree.AtDepth) + 1) {
+		return nil
 	}
-
-	return head
+	return processTree.visibleThreads(pidIndex)
 }
@@ -0,0 +1,201 @@
+package tree
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// captureStdout runs fn and returns everything it wrote to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	writer.Close()
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, reader)
+	require.NoError(t, err)
+	return buf.String()
+}
+
+// goldenProcesses returns a fixed set of processes with zeroed volatile fields
+func goldenProcesses() []Process {
+	return []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init", Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 10, PPID: 1, PGID: 10, Command: "/usr/sbin/sshd", Args: []string{"-D"}, Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 11, PPID: 10, PGID: 11, Command: "/bin/bash", Username: "alice", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 20, PPID: 1, PGID: 20, Command: "/usr/sbin/nginx", Username: "root", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 21, PPID: 20, PGID: 20, Command: "/usr/sbin/nginx", Username: "www-data", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 22, PPID: 20, PGID: 20, Command: "/usr/sbin/nginx", Username: "www-data", MemoryInfo: &process.MemoryInfoStat{}},
+	}
+}
+
+// printedPIDs returns the PIDs of the processes marked for printing, in node order
+func printedPIDs(processTree *ProcessTree) []int32 {
+	pids := []int32{}
+	for _, node := range processTree.Nodes {
+		if node.Print {
+			pids = append(pids, node.PID)
+		}
+	}
+	return pids
+}
+
+// TestPrintTreeGolden renders a fixed tree and compares it to the golden file
+func TestPrintTreeGolden(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options DisplayOptions
+	}{
+		{
+			name:    "compact",
+			options: DisplayOptions{CompactMode: true, MaxDepth: 999, ScreenWidth: 132},
+		},
+		{
+			name:    "all",
+			options: DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowArguments: true, ShowCpuPercent: true, ShowMemoryUsage: true, ShowOwner: true, ShowPIDs: true, ShowProcessAge: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			processTree := NewProcessTree(0, setupTestLogger(), goldenProcesses(), tc.options)
+			processTree.MarkProcesses()
+			processTree.DropUnmarked()
+			output := captureStdout(t, func() {
+				processTree.PrintTree(0, "")
+			})
+
+			golden := filepath.Join("testdata", tc.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(output), 0644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), output)
+		})
+	}
+}
+
+// TestUnavailableMetrics verifies that missing metrics render as a dash rather than zero
+func TestUnavailableMetrics(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 2, PPID: 1, Command: "/usr/bin/locked", MemoryInfo: &process.MemoryInfoStat{}, Unavailable: FieldAge | FieldCPUPercent | FieldMemory | FieldNumThreads},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowCpuPercent: true, ShowMemoryUsage: true, ShowNumThreads: true, ShowProcessAge: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+
+	assert.True(t, processTree.Nodes[0].Available(FieldCPUPercent))
+	assert.False(t, processTree.Nodes[1].Available(FieldCPUPercent))
+	assert.True(t, processTree.Nodes[1].Available(FieldSchedLatency))
+
+	line := processTree.buildLineItem("", 0)
+	assert.Contains(t, line, "(c:0.00%) (m:0.00 B) (t:0)")
+
+	line = processTree.buildLineItem("", 1)
+	assert.Contains(t, line, "(–) (c:–) (m:–) (t:–)")
+}
+
+// TestMaxDepthBoundaries verifies that processes, threads, and compact groups respect --level uniformly
+func TestMaxDepthBoundaries(t *testing.T) {
+	// init (0) -> app (1) with threads (2) -> 2 identical workers (2) -> helper (3)
+	processes := []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, PGID: 10, Command: "/usr/bin/app", Threads: []Thread{{TID: 11, PGID: 10}}},
+		{PID: 20, PPID: 10, PGID: 10, Command: "/usr/bin/worker"},
+		{PID: 21, PPID: 10, PGID: 10, Command: "/usr/bin/worker"},
+		{PID: 30, PPID: 20, PGID: 10, Command: "/usr/bin/helper"},
+	}
+	tests := []struct {
+		maxDepth     int
+		wantLines    int
+		wantThread   bool
+		wantWorkers  bool
+		wantHelper   bool
+		appHasBranch bool
+	}{
+		{maxDepth: 1, wantLines: 2, appHasBranch: false},
+		{maxDepth: 2, wantLines: 4, wantThread: true, wantWorkers: true, appHasBranch: true},
+		{maxDepth: 3, wantLines: 5, wantThread: true, wantWorkers: true, wantHelper: true, appHasBranch: true},
+		{maxDepth: 0, wantLines: 5, wantThread: true, wantWorkers: true, wantHelper: true, appHasBranch: true},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("level %d", test.maxDepth), func(t *testing.T) {
+			options := DisplayOptions{CompactMode: true, HideThreads: false, MaxDepth: test.maxDepth, ScreenWidth: 132, ShowPIDs: true}
+			processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+			processTree.MarkProcesses()
+			processTree.DropUnmarked()
+			output := captureStdout(t, func() {
+				processTree.PrintTree(0, "")
+			})
+			lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+			assert.Len(t, lines, test.wantLines, output)
+			assert.Equal(t, test.wantThread, strings.Contains(output, "(11)"), output)
+			assert.Equal(t, test.wantWorkers, strings.Contains(output, "2*[worker]"), output)
+			assert.Equal(t, test.wantHelper, strings.Contains(output, "/usr/bin/helper"), output)
+			assert.Equal(t, test.appHasBranch, strings.Contains(lines[1], "-+-"), lines[1])
+
+			// The JSON and DOT renderers apply the same limit to processes
+			processTree.AtDepth = 0
+			root := processTree.BuildJSONTree()
+			require.NotNil(t, root)
+			assert.Equal(t, test.wantWorkers, len(root.Children[0].Children) > 0)
+		})
+	}
+}
+
+// TestMaxDepthFromSelectedRoot verifies that --level counts from the process selected with --pid
+func TestMaxDepthFromSelectedRoot(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, PGID: 10, Command: "/usr/bin/app", Threads: []Thread{{TID: 11, PGID: 10}}},
+		{PID: 20, PPID: 10, PGID: 10, Command: "/usr/bin/worker"},
+		{PID: 30, PPID: 20, PGID: 10, Command: "/usr/bin/helper"},
+	}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{MaxDepth: 1, RootPID: 10, ScreenWidth: 132, ShowPIDs: true})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Len(t, strings.Split(strings.TrimRight(output, "\n"), "\n"), 4, output)
+	assert.Contains(t, output, "(11)")
+	assert.Contains(t, output, "/usr/bin/worker")
+	assert.NotContains(t, output, "/usr/bin/helper")
+
+	processTree.AtDepth = 0
+	root := processTree.BuildJSONTree()
+	require.NotNil(t, root)
+	app := root.Children[0]
+	require.Len(t, app.Children, 1)
+	assert.Len(t, app.Threads, 1)
+	assert.Empty(t, app.Children[0].Children)
+
+	// --level-from-root counts from the top of the tree, as in earlier versions
+	processTree = NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{LevelFromRoot: true, MaxDepth: 1, RootPID: 10, ScreenWidth: 132, ShowPIDs: true})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Len(t, strings.Split(strings.TrimRight(output, "\n"), "\n"), 2, output)
+}
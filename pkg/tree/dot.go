@@ -0,0 +1,120 @@
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// GRAPHVIZ DOT OUTPUT
+//------------------------------------------------------------------------------
+// Functions in this section render the process tree as a Graphviz digraph,
+// e.g., `pstree --output dot | dot -Tsvg > tree.svg`, with the dependencies of
+// systemd units as dashed edges with --show-deps and the title as the graph label.
+
+// DOTRenderer writes the tree as a Graphviz DOT digraph.
+type DOTRenderer struct{}
+
+// Render writes a node for every printable process and an edge from each parent to its children.
+//
+// Parameters:
+//   - output: Writer to write the digraph to
+//   - processTree: The marked and pruned process tree
+//
+// Returns:
+//   - error: Error if the output could not be written
+func (renderer *DOTRenderer) Render(output io.Writer, processTree *ProcessTree) error {
+	writer := bufio.NewWriter(output)
+	fmt.Fprintln(writer, "digraph pstree {")
+	fmt.Fprintln(writer, "  rankdir=LR;")
+	if processTree.DisplayOptions.Title != "" {
+		fmt.Fprintf(writer, "  label=\"%s\";\n", dotEscape(processTree.DisplayOptions.Title))
+		fmt.Fprintln(writer, "  labelloc=t;")
+	}
+	fmt.Fprintln(writer, `  node [shape=box, fontname="monospace"];`)
+	unitStarts := []int{}
+	processTree.Walk(func(node Node) WalkDecision {
+		fmt.Fprintf(writer, "  p%d [label=\"%s\"];\n", node.Process.PID, dotEscape(processTree.dotLabel(node.Index)))
+		if node.ParentIndex != -1 {
+			fmt.Fprintf(writer, "  p%d -> p%d;\n", processTree.Nodes[node.ParentIndex].PID, node.Process.PID)
+		}
+		if processTree.isUnitStart(node.Index) {
+			unitStarts = append(unitStarts, node.Index)
+		}
+		return WalkContinue
+	})
+	if processTree.DisplayOptions.ShowDeps {
+		processTree.writeDependencyEdges(writer, unitStarts)
+	}
+	fmt.Fprintln(writer, "}")
+	return writer.Flush()
+}
+
+// writeDependencyEdges writes a dashed edge from the topmost process of each systemd unit to
+// the topmost process of every unit it is started after or requires, as a layer over the
+// process hierarchy, e.g., p812 -> p640 [label="after"].
+//
+// Parameters:
+//   - writer: Writer to write the edges to
+//   - unitStarts: Indexes of the topmost displayed process of each unit, in display order
+func (processTree *ProcessTree) writeDependencyEdges(writer io.Writer, unitStarts []int) {
+	unitPIDs := map[string]int32{}
+	for _, pidIndex := range unitStarts {
+		if _, exists := unitPIDs[processTree.Nodes[pidIndex].Unit]; !exists {
+			unitPIDs[processTree.Nodes[pidIndex].Unit] = processTree.Nodes[pidIndex].PID
+		}
+	}
+	for _, pidIndex := range unitStarts {
+		process := &processTree.Nodes[pidIndex]
+		if unitPIDs[process.Unit] != process.PID {
+			continue
+		}
+		for _, edge := range []struct {
+			Label string
+			Units []string
+		}{
+			{"after", process.UnitAfter},
+			{"requires", process.UnitRequires},
+		} {
+			for _, unit := range edge.Units {
+				if pid, exists := unitPIDs[unit]; exists {
+					fmt.Fprintf(writer, "  p%d -> p%d [style=dashed, color=gray, label=\"%s\"];\n", process.PID, pid, edge.Label)
+				}
+			}
+		}
+	}
+}
+
+// dotLabel returns the label of a process node: its command name, PID, owner if enabled,
+// and the root label for the root process.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The unescaped, newline-separated label
+func (processTree *ProcessTree) dotLabel(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	lines := []string{filepath.Base(process.Command), fmt.Sprintf("PID %d", process.PID)}
+	if processTree.DisplayOptions.ShowOwner && process.Username != "" {
+		lines = append(lines, processTree.formatOwner(process.Username))
+	}
+	if label := processTree.rootLabel(pidIndex); label != "" {
+		lines = append(lines, label)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dotEscape escapes a string for use inside a double-quoted DOT label.
+//
+// Parameters:
+//   - label: The raw label
+//
+// Returns:
+//   - The label with backslashes, quotes, and newlines escaped
+func dotEscape(label string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(label)
+}
@@ -0,0 +1,33 @@
+package tree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDOTRenderer verifies the DOT output contains filtered nodes and parent/child edges
+func TestDOTRenderer(t *testing.T) {
+	processes := goldenProcesses()
+	processes[5].Command = `/usr/sbin/"nginx"`
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{Contains: "nginx", MaxDepth: 999, ShowOwner: true})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	renderer, err := NewRenderer("dot")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, renderer.Render(&buf, processTree))
+	output := buf.String()
+
+	assert.True(t, strings.HasPrefix(output, "digraph pstree {\n"))
+	assert.True(t, strings.HasSuffix(output, "}\n"))
+	assert.Contains(t, output, `p1 [label="init\nPID 1\nroot"];`)
+	assert.Contains(t, output, "p1 -> p20;")
+	assert.Contains(t, output, "p20 -> p22;")
+	assert.Contains(t, output, `p22 [label="\"nginx\"\nPID 22\nwww-data"];`)
+	assert.NotContains(t, output, "p10")
+}
@@ -0,0 +1,115 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// ENVIRONMENT VARIABLES
+//------------------------------------------------------------------------------
+// Functions in this section display selected environment variables inline and
+// filter processes by the variables they define.
+
+// EnvValueMaxWidth is the number of characters of an environment variable value shown before it is truncated.
+const EnvValueMaxWidth = 32
+
+// EnvironmentVariables returns the environment variables of the process.
+//
+// Returns:
+//   - The variables as KEY=VALUE, or nil if they were not collected
+func (process *Process) EnvironmentVariables() []string {
+	if process.ProcessDetails == nil {
+		return nil
+	}
+	return process.Environment
+}
+
+// Getenv returns the value of an environment variable of the process.
+//
+// Parameters:
+//   - key: Name of the variable
+//
+// Returns:
+//   - string: The value of the variable
+//   - bool: true if the process defines the variable
+func (process *Process) Getenv(key string) (string, bool) {
+	prefix := key + "="
+	for _, variable := range process.EnvironmentVariables() {
+		if strings.HasPrefix(variable, prefix) {
+			return variable[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+// SelectedEnv returns the variables named by DisplayOptions.ShowEnv that the process defines.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The selected variables as KEY=VALUE, in the order they were requested
+func (processTree *ProcessTree) SelectedEnv(pidIndex int) []string {
+	selected := []string{}
+	for _, key := range processTree.DisplayOptions.ShowEnv {
+		if value, exists := processTree.Nodes[pidIndex].Getenv(key); exists {
+			selected = append(selected, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	return selected
+}
+
+// formatEnv formats the selected variables of a process for display, e.g., (PATH=/usr/bin, LANG=C).
+// Long values are truncated with "…" unless wide output is enabled.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted variables, or empty if the process defines none of them
+func (processTree *ProcessTree) formatEnv(pidIndex int) string {
+	selected := processTree.SelectedEnv(pidIndex)
+	if len(selected) == 0 {
+		return ""
+	}
+	if !processTree.DisplayOptions.WideDisplay {
+		for i, variable := range selected {
+			key, value, _ := strings.Cut(variable, "=")
+			if runes := []rune(value); len(runes) > EnvValueMaxWidth {
+				selected[i] = fmt.Sprintf("%s=%s…", key, string(runes[:EnvValueMaxWidth-1]))
+			}
+		}
+	}
+	return fmt.Sprintf("(%s)", strings.Join(selected, ", "))
+}
+
+// hasRequiredEnv returns true if the process defines every variable in DisplayOptions.RequireEnv
+// and DisplayOptions.EnvContains.
+//
+// A requirement of RequireEnv may be a variable name, which must be defined, or KEY=VALUE, in
+// which case the variable must also have exactly that value. A requirement of EnvContains is
+// KEY=TEXT, and the value of the variable must contain TEXT, e.g., JAVA_OPTS=-Xmx.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if all requirements are met
+func (processTree *ProcessTree) hasRequiredEnv(pidIndex int) bool {
+	for _, requirement := range processTree.DisplayOptions.RequireEnv {
+		key, want, hasValue := strings.Cut(requirement, "=")
+		value, exists := processTree.Nodes[pidIndex].Getenv(key)
+		if !exists || (hasValue && value != want) {
+			return false
+		}
+	}
+	for _, requirement := range processTree.DisplayOptions.EnvContains {
+		key, text, _ := strings.Cut(requirement, "=")
+		value, exists := processTree.Nodes[pidIndex].Getenv(key)
+		if !exists || !strings.Contains(value, text) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShowEnv verifies selected environment variables are shown inline and --require-env filters processes
+func TestShowEnv(t *testing.T) {
+	longPath := "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", ProcessDetails: &ProcessDetails{Environment: []string{"PATH=/bin"}}},
+		{PID: 10, PPID: 1, Command: "/usr/bin/java", ProcessDetails: &ProcessDetails{Environment: []string{"PATH=" + longPath, "JAVA_HOME=/opt/jdk", "TOKEN=****"}}},
+		{PID: 20, PPID: 1, Command: "/usr/bin/python3", ProcessDetails: &ProcessDetails{Environment: []string{"PATH=/usr/bin"}}},
+		{PID: 21, PPID: 20, Command: "/usr/bin/java", ProcessDetails: &ProcessDetails{Environment: []string{"JAVA_HOME=/opt/jdk17"}}},
+	}
+
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 200, ShowEnv: []string{"JAVA_HOME", "PATH"}}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/sbin/init (PATH=/bin)")
+	assert.Contains(t, output, "/usr/bin/java (JAVA_HOME=/opt/jdk, PATH="+longPath[:EnvValueMaxWidth-1]+"…)")
+	assert.Contains(t, output, "/usr/bin/java (JAVA_HOME=/opt/jdk17)")
+	assert.NotContains(t, output, "TOKEN")
+
+	options.RequireEnv = []string{"JAVA_HOME=/opt/jdk17"}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/bin/python3")
+	assert.Contains(t, output, "JAVA_HOME=/opt/jdk17")
+	assert.NotContains(t, output, "JAVA_HOME=/opt/jdk,")
+
+	options.RequireEnv = []string{"JAVA_HOME"}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	assert.Equal(t, []bool{true, true, true, true}, []bool{processTree.Nodes[0].Print, processTree.Nodes[1].Print, processTree.Nodes[2].Print, processTree.Nodes[3].Print})
+	assert.Equal(t, map[string]string{"JAVA_HOME": "/opt/jdk", "PATH": longPath}, processTree.buildJSONNode(1, 1).Env)
+
+	// --env-contains matches part of the value, and is combined with --require-env
+	options.RequireEnv = nil
+	options.EnvContains = []string{"JAVA_HOME=jdk1"}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	assert.Equal(t, []bool{true, false, true, true}, []bool{processTree.Nodes[0].Print, processTree.Nodes[1].Print, processTree.Nodes[2].Print, processTree.Nodes[3].Print})
+
+	options.RequireEnv = []string{"PATH"}
+	options.EnvContains = []string{"JAVA_HOME=/opt/"}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	assert.Equal(t, []bool{true, true, false, false}, []bool{processTree.Nodes[0].Print, processTree.Nodes[1].Print, processTree.Nodes[2].Print, processTree.Nodes[3].Print})
+}
@@ -0,0 +1,159 @@
+package tree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// FAIR SHARE
+//------------------------------------------------------------------------------
+// Functions in this section compute each user's share of the CPU usage and
+// resident memory of the displayed processes, so that --fair-share shows who is
+// using a shared login or build server. Processes are colored by the share of
+// their owner compared to an equal split between the users.
+
+// fairShareBarWidth is the number of characters of the share bars.
+const fairShareBarWidth = 20
+
+// UserShare is the usage of the displayed processes of one user.
+type UserShare struct {
+	Username    string  // Owner of the processes
+	Processes   int     // Number of displayed processes
+	CPUPercent  float64 // Sum of the CPU usage percentages
+	RSS         uint64  // Sum of the resident memory in bytes
+	CPUShare    float64 // Percentage of the CPU usage of all displayed processes
+	MemoryShare float64 // Percentage of the resident memory of all displayed processes
+}
+
+// share returns the larger of the CPU and memory shares of a user.
+func (userShare UserShare) share() float64 {
+	return max(userShare.CPUShare, userShare.MemoryShare)
+}
+
+// FairShares returns the usage of each user owning displayed processes, by largest share
+// of CPU or memory, then by username. Metrics that could not be collected count as zero.
+//
+// Returns:
+//   - []UserShare: The usage of each user
+func (processTree *ProcessTree) FairShares() []UserShare {
+	byUser := map[string]*UserShare{}
+	var totalCPU float64
+	var totalRSS uint64
+	for pidIndex := range processTree.Nodes {
+		process := &processTree.Nodes[pidIndex]
+		if !process.Print {
+			continue
+		}
+		userShare, ok := byUser[process.Username]
+		if !ok {
+			userShare = &UserShare{Username: process.Username}
+			byUser[process.Username] = userShare
+		}
+		userShare.Processes++
+		if process.Available(FieldCPUPercent) {
+			userShare.CPUPercent += process.CPUPercent
+			totalCPU += process.CPUPercent
+		}
+		if process.Available(FieldMemory) && process.MemoryInfo != nil {
+			userShare.RSS += process.MemoryInfo.RSS
+			totalRSS += process.MemoryInfo.RSS
+		}
+	}
+
+	shares := make([]UserShare, 0, len(byUser))
+	for _, userShare := range byUser {
+		if totalCPU > 0 {
+			userShare.CPUShare = userShare.CPUPercent / totalCPU * 100
+		}
+		if totalRSS > 0 {
+			userShare.MemoryShare = float64(userShare.RSS) / float64(totalRSS) * 100
+		}
+		shares = append(shares, *userShare)
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].share() != shares[j].share() {
+			return shares[i].share() > shares[j].share()
+		}
+		return shares[i].Username < shares[j].Username
+	})
+	return shares
+}
+
+// colorizeShare applies a heat color to a value based on the share of the owner of the process.
+//
+// Owners using less than an equal split of the CPU or memory between the users are colored
+// as low, up to twice an equal split as medium, and more as high.
+//
+// Parameters:
+//   - username: Owner of the process
+//   - value: Pointer to the string value to be colored (modified in-place)
+func (processTree *ProcessTree) colorizeShare(username string, value *string) {
+	if processTree.fairShares == nil {
+		processTree.fairShares = map[string]float64{}
+		for _, userShare := range processTree.FairShares() {
+			processTree.fairShares[userShare.Username] = userShare.share()
+		}
+	}
+	fair := 100 / float64(len(processTree.fairShares))
+	share := processTree.fairShares[username]
+	if share >= 2*fair && len(processTree.fairShares) > 1 {
+		processTree.Colorizer.Crit(processTree.ColorScheme, value)
+	} else if share >= fair && len(processTree.fairShares) > 1 {
+		processTree.Colorizer.Warn(processTree.ColorScheme, value)
+	} else {
+		processTree.Colorizer.OK(processTree.ColorScheme, value)
+	}
+}
+
+// formatShareBar returns a percentage with a bar, e.g., ██████░░░░ 60.0%.
+//
+// Parameters:
+//   - percent: The percentage, from 0 to 100
+//
+// Returns:
+//   - The formatted bar
+func (processTree *ProcessTree) formatShareBar(percent float64) string {
+	full, empty := "#", "."
+	if processTree.DisplayOptions.UTF8Graphics {
+		full, empty = "█", "░"
+	}
+	filled := min(int(percent*fairShareBarWidth/100+0.5), fairShareBarWidth)
+	return fmt.Sprintf("%s%s %5.1f%%", strings.Repeat(full, filled), strings.Repeat(empty, fairShareBarWidth-filled), percent)
+}
+
+// PrintFairShare prints a summary of the share of each user of the CPU usage and resident
+// memory of the displayed processes, after the tree.
+func (processTree *ProcessTree) PrintFairShare() {
+	shares := processTree.FairShares()
+	if len(shares) == 0 {
+		return
+	}
+	width := len("USER")
+	for _, userShare := range shares {
+		width = max(width, len(userShare.Username))
+	}
+
+	fmt.Fprintln(processTree.output())
+	if !processTree.DisplayOptions.NoHeaders {
+		fmt.Fprintf(processTree.output(), "%-*s %5s  %-*s  %s\n", width, "USER", "PROCS", fairShareBarWidth+7, "CPU", "MEMORY")
+	}
+	for _, userShare := range shares {
+		username := fmt.Sprintf("%-*s", width, userShare.Username)
+		if processTree.DisplayOptions.ColorSupport && processTree.DisplayOptions.ColorAttr == "share" {
+			processTree.colorizeShare(userShare.Username, &username)
+		}
+		fmt.Fprintf(
+			processTree.output(),
+			"%s %5d  %s  %s (%s)\n",
+			username,
+			userShare.Processes,
+			processTree.formatShareBar(userShare.CPUShare),
+			processTree.formatShareBar(userShare.MemoryShare),
+			util.ByteConverter(userShare.RSS),
+		)
+	}
+}
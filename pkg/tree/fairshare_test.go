@@ -0,0 +1,47 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFairShare verifies each user's share of the displayed processes and the coloring by share
+func TestFairShare(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Username: "root", CPUPercent: 1, MemoryInfo: &process.MemoryInfoStat{RSS: 2 * 1024 * 1024}},
+		{PID: 10, PPID: 1, Command: "/usr/bin/make", Username: "alice", CPUPercent: 60, MemoryInfo: &process.MemoryInfoStat{RSS: 8 * 1024 * 1024}},
+		{PID: 11, PPID: 10, Command: "/usr/bin/cc", Username: "alice", CPUPercent: 20, MemoryInfo: &process.MemoryInfoStat{RSS: 2 * 1024 * 1024}},
+		{PID: 20, PPID: 1, Command: "/usr/bin/vim", Username: "bob", Unavailable: FieldCPUPercent, MemoryInfo: &process.MemoryInfoStat{RSS: 4 * 1024 * 1024}},
+		{PID: 30, PPID: 1, Command: "/usr/bin/top", Username: "carol", CPUPercent: 19},
+	}
+	options := DisplayOptions{ColorAttr: "share", ColorCount: 256, ColorSupport: true, FairShare: true, MaxDepth: 999, ScreenWidth: 132, Usernames: []string{"alice", "bob", "carol"}}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	assert.Equal(t, []UserShare{
+		{Username: "alice", Processes: 2, CPUPercent: 80, RSS: 10 * 1024 * 1024, CPUShare: 80, MemoryShare: 62.5},
+		{Username: "bob", Processes: 1, RSS: 4 * 1024 * 1024, MemoryShare: 25},
+		{Username: "carol", Processes: 1, CPUPercent: 19, CPUShare: 19},
+		{Username: "root", Processes: 1, CPUPercent: 1, RSS: 2 * 1024 * 1024, CPUShare: 1, MemoryShare: 12.5},
+	}, processTree.FairShares())
+
+	// Alice uses more than twice an equal split between the 4 users, bob an equal split
+	crit, warn, ok := "/usr/bin/cc", "/usr/bin/vim", "/usr/bin/top"
+	processTree.Colorizer.Crit(processTree.ColorScheme, &crit)
+	processTree.Colorizer.Warn(processTree.ColorScheme, &warn)
+	processTree.Colorizer.OK(processTree.ColorScheme, &ok)
+	assert.Contains(t, processTree.buildLineItem(" ", 2), crit)
+	assert.Contains(t, processTree.buildLineItem(" ", 3), warn)
+	assert.Contains(t, processTree.buildLineItem(" ", 4), ok)
+
+	processTree.DisplayOptions.ColorSupport = false
+	output := captureStdout(t, func() {
+		processTree.PrintFairShare()
+	})
+	assert.Contains(t, output, "USER  PROCS  CPU                          MEMORY\n")
+	assert.Contains(t, output, "alice     2  ################....  80.0%  #############.......  62.5% (10.00 MiB)\n")
+	assert.Contains(t, output, "carol     1  ####................  19.0%  ....................   0.0% (0.00 B)\n")
+}
@@ -0,0 +1,70 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// FILE DESCRIPTORS
+//------------------------------------------------------------------------------
+// Functions in this section format the descriptor count and open files of each
+// process, so that processes leaking descriptors stand out.
+
+const (
+	// FDsMedium is the descriptor count from which a process is colored as medium
+	FDsMedium = 256
+	// FDsHigh is the descriptor count from which a process is colored as high,
+	// matching the common default soft limit
+	FDsHigh = 1024
+	// OpenFilesMax is the number of open files shown before the list is truncated
+	OpenFilesMax = 5
+)
+
+// OpenFilePaths returns the paths of the files opened by a process.
+//
+// Returns:
+//   - The paths in descriptor order
+func (process *Process) OpenFilePaths() []string {
+	if process.ProcessDetails == nil {
+		return []string{}
+	}
+	paths := make([]string, 0, len(process.OpenFiles))
+	for _, openFile := range process.OpenFiles {
+		paths = append(paths, openFile.Path)
+	}
+	return paths
+}
+
+// formatOpenFiles formats the files opened by a process, e.g., [files:/var/log/app.log,/tmp/x].
+//
+// Unless wide output is enabled, the list is truncated after OpenFilesMax paths and
+// the number of remaining files is appended, e.g., [files:/a,/b,/c,/d,/e,+12].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted list, or an empty string if the process has no open files
+func (processTree *ProcessTree) formatOpenFiles(pidIndex int) string {
+	paths := processTree.Nodes[pidIndex].OpenFilePaths()
+	if len(paths) == 0 {
+		return ""
+	}
+	if !processTree.DisplayOptions.WideDisplay && len(paths) > OpenFilesMax {
+		paths = append(paths[:OpenFilesMax:OpenFilesMax], fmt.Sprintf("+%d", len(paths)-OpenFilesMax))
+	}
+	return fmt.Sprintf("[files:%s]", strings.Join(paths, ","))
+}
+
+// colorizeFDs applies a heat color to a value based on the number of open descriptors.
+//
+// By default, processes holding fewer than FDsMedium descriptors are colored as low, fewer
+// than FDsHigh as medium, and anything above as high, which usually indicates a leak.
+//
+// Parameters:
+//   - numFDs: Number of open file descriptors
+//   - value: Pointer to the string value to be colored (modified in-place)
+func (processTree *ProcessTree) colorizeFDs(numFDs int32, value *string) {
+	processTree.colorizeThreshold("fds", float64(numFDs), value)
+}
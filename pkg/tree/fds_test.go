@@ -0,0 +1,34 @@
+package tree
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShowFDs verifies open file descriptor counts and the truncated list of open files
+func TestShowFDs(t *testing.T) {
+	openFiles := []process.OpenFilesStat{}
+	for i := 0; i < 7; i++ {
+		openFiles = append(openFiles, process.OpenFilesStat{Fd: uint64(i + 3), Path: fmt.Sprintf("/var/log/app%d.log", i)})
+	}
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", NumFDs: 64},
+		{PID: 10, PPID: 1, Command: "/usr/bin/leaky", NumFDs: 2048, ProcessDetails: &ProcessDetails{OpenFiles: openFiles}},
+		{PID: 20, PPID: 1, Command: "/usr/bin/hidden", Unavailable: FieldNumFDs},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowFDs: true, ShowOpenFiles: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(fd:64) /sbin/init")
+	assert.Contains(t, output, "(fd:2048) /usr/bin/leaky [files:/var/log/app0.log,/var/log/app1.log,/var/log/app2.log,/var/log/app3.log,/var/log/app4.log,+2]")
+	assert.Contains(t, output, "(fd:–) /usr/bin/hidden")
+
+	processTree.DisplayOptions.WideDisplay = true
+	assert.Contains(t, processTree.formatOpenFiles(1), "/var/log/app6.log]")
+}
@@ -0,0 +1,141 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gdanko/pstree/util"
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+//------------------------------------------------------------------------------
+// FORMAT TEMPLATES
+//------------------------------------------------------------------------------
+// Functions in this section render each process with the Go template given by
+// --format, e.g., "{{.PID}} {{.User}} {{.Command}}", instead of the fixed layout
+// of buildLineFields, so that users choose which fields appear and in what order.
+
+// FormatContext is the data a --format template is executed with. All the fields of
+// the process are available, e.g., {{.PID}} or {{.CPUPercent}}, plus shorthands.
+type FormatContext struct {
+	Process
+	Depth int    // Depth of the process in the displayed tree, starting at 0
+	Name  string // Base name of the command, e.g., nginx for /usr/sbin/nginx
+	RSS   uint64 // Resident memory in bytes, or 0 if not collected
+	User  string // Owner of the process, same as Username
+}
+
+// formatFuncs are the functions available to --format templates.
+var formatFuncs = template.FuncMap{
+	"base":  filepath.Base,       // {{base .Command}}: last element of a path
+	"bytes": util.ByteConverter,  // {{bytes .RSS}}: size in bytes in human-readable units
+	"join":  strings.Join,        // {{join .Args " "}}: elements of a list separated by a string
+	"lower": strings.ToLower,     // {{lower .Name}}: string in lower case
+	"trunc": truncateFormatField, // {{trunc 20 .Command}}: string cut to at most n characters
+	"upper": strings.ToUpper,     // {{upper .User}}: string in upper case
+}
+
+// truncateFormatField returns at most width runes of a string.
+//
+// Parameters:
+//   - width: Maximum number of runes
+//   - value: The string to truncate
+//
+// Returns:
+//   - The truncated string
+func truncateFormatField(width int, value string) string {
+	runes := []rune(value)
+	if width < 0 || len(runes) <= width {
+		return value
+	}
+	return string(runes[:width])
+}
+
+// newFormatContext returns the template data of a process. Metrics that were not collected
+// are zero rather than nil, so that templates such as {{.MemoryInfo.VMS}} never fail.
+//
+// Parameters:
+//   - proc: The process
+//   - depth: Depth of the process in the displayed tree
+//
+// Returns:
+//   - FormatContext: The template data
+func newFormatContext(proc Process, depth int) FormatContext {
+	context := FormatContext{
+		Process: proc,
+		Depth:   depth,
+		Name:    filepath.Base(proc.Command),
+		User:    proc.Username,
+	}
+	if proc.MemoryInfo != nil && proc.Available(FieldMemory) {
+		context.RSS = proc.MemoryInfo.RSS
+	}
+	if context.CPUTimes == nil {
+		context.CPUTimes = &cpu.TimesStat{}
+	}
+	if context.IOCounters == nil {
+		context.IOCounters = &process.IOCountersStat{}
+	}
+	if context.MemoryInfo == nil {
+		context.MemoryInfo = &process.MemoryInfoStat{}
+	}
+	if context.ProcessDetails == nil {
+		context.ProcessDetails = &ProcessDetails{}
+	}
+	if context.Priority == nil {
+		context.Priority = &Priority{}
+	}
+	return context
+}
+
+// ParseFormat parses a --format template and checks it against a sample process, so that
+// unknown fields and functions are reported before any process is collected.
+//
+// Parameters:
+//   - format: The template, e.g., "{{.PID}} {{.User}} {{.Command}}"
+//
+// Returns:
+//   - *template.Template: The parsed template
+//   - error: Error if the template is malformed or refers to an unknown field
+func ParseFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(formatFuncs).Parse(format)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, newFormatContext(Process{}, 0)); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// buildFormatFields constructs the fields of a process from the --format template, which
+// follow the tree prefix. A template error is recorded and returned by the renderer.
+//
+// Parameters:
+//   - pidIndex: Index of the current process in the Nodes array
+//
+// Returns:
+//   - The formatted fields
+func (processTree *ProcessTree) buildFormatFields(pidIndex int) string {
+	if processTree.formatTemplate == nil {
+		tmpl, err := ParseFormat(processTree.DisplayOptions.Format)
+		if err != nil {
+			processTree.setWriteErr(fmt.Errorf("invalid --format: %w", err))
+			tmpl = template.Must(template.New("format").Parse(""))
+		}
+		processTree.formatTemplate = tmpl
+	}
+
+	var fields strings.Builder
+	if err := processTree.formatTemplate.Execute(&fields, newFormatContext(processTree.Nodes[pidIndex], processTree.AtDepth)); err != nil {
+		processTree.setWriteErr(fmt.Errorf("failed to format PID %d: %w", processTree.Nodes[pidIndex].PID, err))
+	}
+	// Lines are written one per process
+	line := strings.ReplaceAll(fields.String(), "\n", " ")
+	processTree.colorizeField("command", &line, pidIndex)
+	return line
+}
@@ -0,0 +1,32 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFormat verifies the --format template replaces the fixed layout after the tree prefix
+func TestFormat(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Username: "root", MemoryInfo: &process.MemoryInfoStat{RSS: 2 * 1024 * 1024}},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", Args: []string{"-g", "daemon off;"}, Username: "www", Unavailable: FieldMemory},
+	}
+	options := DisplayOptions{Format: `{{.PID}} {{.User}} {{.Name}} [{{join .Args " "}}] {{bytes .RSS}} d{{.Depth}}`, MaxDepth: 999, ScreenWidth: 132}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	var buf bytes.Buffer
+	require.NoError(t, (&TextRenderer{}).Render(&buf, processTree))
+	assert.Equal(t, "-+- 1 root init [] 2.00 MiB d0\n \\--- 10 www nginx [-g daemon off;] 0.00 B d1\n", buf.String())
+
+	// Unknown fields are reported before any process is collected, and uncollected metrics are zero
+	_, err := ParseFormat("{{.Nope}}")
+	assert.Error(t, err)
+	_, err = ParseFormat("{{.MemoryInfo.VMS}} {{.CPUTimes.User}} {{.Environment}}")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,79 @@
+package tree
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// GROUP MEMBERSHIPS
+//------------------------------------------------------------------------------
+// Functions in this section show the supplementary groups of each process and
+// select processes by group membership, e.g., every process whose owner is in
+// the docker group and so can control the Docker daemon.
+
+// groupName returns the name of a group of a process, or its ID if the name is unknown.
+//
+// Parameters:
+//   - gid: The group ID
+//
+// Returns:
+//   - The name of the group, e.g., docker, or its ID, e.g., 998
+func (process *Process) groupName(gid uint32) string {
+	if name, ok := process.Groups[gid]; ok && name != "" {
+		return name
+	}
+	return strconv.FormatUint(uint64(gid), 10)
+}
+
+// SupplementaryGroupNames returns the names of the supplementary groups of a process, sorted,
+// with the IDs of groups whose name is unknown.
+//
+// Returns:
+//   - []string: The names of the groups, e.g., ["adm", "docker", "sudo"]
+func (process *Process) SupplementaryGroupNames() []string {
+	names := make([]string, 0, len(process.SupplementaryGIDs))
+	for _, gid := range process.SupplementaryGIDs {
+		names = append(names, process.groupName(gid))
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// InGroup reports whether a process runs with one of the given groups, as its real, effective,
+// or supplementary group.
+//
+// Parameters:
+//   - groups: Names or IDs of the groups, e.g., docker or 998
+//
+// Returns:
+//   - true if the process is in any of the groups
+func (process *Process) InGroup(groups ...string) bool {
+	for _, gid := range slices.Concat(process.GIDs, process.SupplementaryGIDs) {
+		name := process.groupName(gid)
+		id := strconv.FormatUint(uint64(gid), 10)
+		for _, group := range groups {
+			if group == name || group == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatGroups returns the supplementary groups of a process, e.g., [groups:adm,docker,sudo].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted groups, or an empty string if the process has none or they were not collected
+func (processTree *ProcessTree) formatGroups(pidIndex int) string {
+	names := processTree.Nodes[pidIndex].SupplementaryGroupNames()
+	if len(names) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[groups:%s]", strings.Join(names, ","))
+}
@@ -0,0 +1,32 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGroups verifies supplementary groups are shown by name and processes are selected by group
+func TestGroups(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Username: "root", GIDs: []uint32{0}, Groups: map[uint32]string{0: "root"}, MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 10, PPID: 1, Command: "/bin/bash", Username: "alice", GIDs: []uint32{1000}, SupplementaryGIDs: []uint32{998, 27, 4242}, Groups: map[uint32]string{27: "sudo", 998: "docker", 1000: "alice"}, MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 11, PPID: 10, Command: "/usr/bin/vim", Username: "alice", GIDs: []uint32{1000}, Groups: map[uint32]string{1000: "alice"}, MemoryInfo: &process.MemoryInfoStat{}},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/cron", Username: "root", GIDs: []uint32{0}, Groups: map[uint32]string{0: "root"}, MemoryInfo: &process.MemoryInfoStat{}},
+	}
+	assert.Equal(t, []string{"4242", "docker", "sudo"}, processes[1].SupplementaryGroupNames())
+	assert.True(t, processes[1].InGroup("docker"))
+	assert.True(t, processes[1].InGroup("nobody", "998"))
+	assert.True(t, processes[2].InGroup("alice"))
+	assert.False(t, processes[2].InGroup("docker"))
+
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowGroups: true})
+	assert.Contains(t, processTree.buildLineItem(" ", processTree.PidToIndexMap[10]), "[groups:4242,docker,sudo] ")
+	assert.NotContains(t, processTree.buildLineItem(" ", processTree.PidToIndexMap[11]), "[groups:")
+
+	// Only members of the group and their ancestors are shown
+	processTree = NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{InGroups: []string{"docker"}, MaxDepth: 999, ScreenWidth: 132})
+	processTree.MarkProcesses()
+	assert.Equal(t, []int32{1, 10}, printedPIDs(processTree))
+}
@@ -0,0 +1,47 @@
+package tree
+
+import (
+	"fmt"
+
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// HEAP LIMITS
+//------------------------------------------------------------------------------
+// Functions in this section compare the maximum heap configured for JVM and
+// Node.js processes with the resident memory they actually use.
+
+// ExceedsHeap returns true if the process uses more resident memory than its configured heap.
+//
+// Resident memory also includes the code, stacks, and off-heap buffers of the runtime,
+// so a process slightly over its heap is not necessarily leaking, but one well over is
+// likely to be killed or to thrash.
+//
+// Returns:
+//   - true if a heap is configured and the resident memory exceeds it
+func (process *Process) ExceedsHeap() bool {
+	return process.HeapLimit > 0 && process.Available(FieldMemory) && process.MemoryInfo != nil && process.MemoryInfo.RSS > process.HeapLimit
+}
+
+// formatHeap returns the configured heap of a process next to its resident memory,
+// e.g., [heap 4.00 GiB cfg / 5.10 GiB rss, over budget].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted heap, or an empty string if no heap is configured
+func (processTree *ProcessTree) formatHeap(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	if process.HeapLimit == 0 {
+		return ""
+	}
+	if !process.Available(FieldMemory) || process.MemoryInfo == nil {
+		return fmt.Sprintf("[heap %s cfg]", util.ByteConverter(process.HeapLimit))
+	}
+	if process.ExceedsHeap() {
+		return fmt.Sprintf("[heap %s cfg / %s rss, over budget]", util.ByteConverter(process.HeapLimit), util.ByteConverter(process.MemoryInfo.RSS))
+	}
+	return fmt.Sprintf("[heap %s cfg / %s rss]", util.ByteConverter(process.HeapLimit), util.ByteConverter(process.MemoryInfo.RSS))
+}
@@ -0,0 +1,30 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShowHeap verifies configured heap limits are shown against the resident memory
+func TestShowHeap(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/bin/java", HeapLimit: 4 << 30, MemoryInfo: &process.MemoryInfoStat{RSS: 5 << 30}},
+		{PID: 11, PPID: 1, Command: "/usr/bin/node", HeapLimit: 2 << 30, MemoryInfo: &process.MemoryInfoStat{RSS: 1 << 30}},
+		{PID: 12, PPID: 1, Command: "/usr/bin/python3", HeapLimit: 1 << 30, Unavailable: FieldMemory},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowHeap: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/bin/java [heap 4.00 GiB cfg / 5.00 GiB rss, over budget]")
+	assert.Contains(t, output, "/usr/bin/node [heap 2.00 GiB cfg / 1.00 GiB rss]")
+	assert.Contains(t, output, "/usr/bin/python3 [heap 1.00 GiB cfg]")
+	assert.NotContains(t, output, "/sbin/init [heap")
+	assert.True(t, processTree.Nodes[1].ExceedsHeap())
+	assert.False(t, processTree.Nodes[3].ExceedsHeap())
+}
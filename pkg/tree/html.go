@@ -0,0 +1,138 @@
+package tree
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// HTML OUTPUT
+//------------------------------------------------------------------------------
+// Functions in this section render the process tree as a standalone HTML page
+// with collapsible subtrees and a tooltip per process, so that process state can
+// be shared with people who do not have shell access to the host.
+
+// htmlNode is the HTML representation of a process and its descendants.
+type htmlNode struct {
+	Label    string      // Text shown for the process, e.g., nginx (22)
+	Tooltip  string      // Details shown when hovering over the process
+	Children []*htmlNode // Printable child processes
+}
+
+// htmlPage is the data of the standalone page.
+type htmlPage struct {
+	Title string      // Title of the page and heading above the tree, or "" for none
+	Roots []*htmlNode // Printable root processes, each shown as a separate tree
+}
+
+// htmlTemplate is the standalone page; subtrees use <details> so that they can be
+// collapsed without any JavaScript.
+var htmlTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{if .Title}}{{.Title}}{{else}}pstree{{end}}</title>
+<style>
+body { font-family: monospace; font-size: 14px; }
+h1 { font-size: 16px; }
+ul { list-style: none; margin: 0; padding-left: 1.5em; border-left: 1px dotted #999; }
+summary, .leaf { cursor: default; padding: 1px 0; }
+summary:hover, .leaf:hover { background: #eef; }
+.leaf { padding-left: 1.1em; }
+</style>
+</head>
+<body>
+{{- if .Title}}
+<h1>{{.Title}}</h1>
+{{- end}}
+{{- range .Roots}}
+<ul>{{template "node" .}}</ul>
+{{- end}}
+</body>
+</html>
+{{define "node"}}
+<li>
+{{- if .Children}}<details open><summary title="{{.Tooltip}}">{{.Label}}</summary><ul>{{range .Children}}{{template "node" .}}{{end}}</ul></details>
+{{- else}}<div class="leaf" title="{{.Tooltip}}">{{.Label}}</div>
+{{- end}}</li>
+{{- end}}`))
+
+// HTMLRenderer writes the tree as a standalone HTML page.
+type HTMLRenderer struct{}
+
+// Render writes the page with one collapsible node per printable process, under the title
+// if one is set.
+//
+// Parameters:
+//   - output: Writer to write the page to
+//   - processTree: The marked and pruned process tree
+//
+// Returns:
+//   - error: Error if the page could not be written
+func (renderer *HTMLRenderer) Render(output io.Writer, processTree *ProcessTree) error {
+	page := htmlPage{Title: processTree.DisplayOptions.Title}
+	for _, rootIndex := range processTree.Roots() {
+		if processTree.Nodes[rootIndex].Print {
+			page.Roots = append(page.Roots, processTree.buildHTMLNode(rootIndex, 0))
+		}
+	}
+	return htmlTemplate.Execute(output, page)
+}
+
+// buildHTMLNode converts a process and its printable descendants into an htmlNode,
+// honoring the same filters and depth limit as PrintTree.
+//
+// Parameters:
+//   - pidIndex: Index of the process to convert
+//   - depth: Depth of the process in the tree, with the root at depth 0
+//
+// Returns:
+//   - The HTML representation of the process
+func (processTree *ProcessTree) buildHTMLNode(pidIndex int, depth int) *htmlNode {
+	process := &processTree.Nodes[pidIndex]
+	node := &htmlNode{
+		Label:   processTree.appendRootLabel(fmt.Sprintf("%s (%d)", filepath.Base(process.Command), process.PID), pidIndex),
+		Tooltip: processTree.htmlTooltip(pidIndex),
+	}
+	if processTree.withinDepth(processTree.level(pidIndex, depth) + 1) {
+		for childIndex := process.Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+			if processTree.Nodes[childIndex].Print {
+				node.Children = append(node.Children, processTree.buildHTMLNode(childIndex, depth+1))
+			}
+		}
+	}
+	return node
+}
+
+// htmlTooltip returns the details shown when hovering over a process: its command line,
+// owner, number of environment variables, and memory usage.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The unescaped, newline-separated tooltip
+func (processTree *ProcessTree) htmlTooltip(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	lines := []string{
+		fmt.Sprintf("PID %d, PPID %d", process.PID, process.PPID),
+		strings.TrimSpace(fmt.Sprintf("%s %s", process.Command, strings.Join(process.Args, " "))),
+	}
+	if process.Username != "" {
+		lines = append(lines, fmt.Sprintf("owner: %s", process.Username))
+	}
+	if count := len(process.EnvironmentVariables()); count == 1 {
+		lines = append(lines, "env: 1 variable")
+	} else if count > 1 {
+		lines = append(lines, fmt.Sprintf("env: %d variables", count))
+	}
+	if process.Available(FieldMemory) && process.MemoryInfo != nil {
+		lines = append(lines, fmt.Sprintf("memory: %s (%.2f%%)", util.ByteConverter(process.MemoryInfo.RSS), process.MemoryPercent))
+	}
+	return strings.Join(lines, "\n")
+}
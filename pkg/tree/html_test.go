@@ -0,0 +1,35 @@
+package tree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTMLRenderer verifies the HTML output nests filtered processes and escapes their tooltips
+func TestHTMLRenderer(t *testing.T) {
+	processes := goldenProcesses()
+	processes[5].Args = []string{"-g", "daemon <off>;"}
+	processes[5].ProcessDetails = &ProcessDetails{Environment: []string{"HOME=/", "PATH=/usr/bin"}}
+	processes[5].MemoryInfo = &process.MemoryInfoStat{RSS: 2 * 1024 * 1024}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{Contains: "nginx", MaxDepth: 999})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	renderer, err := NewRenderer("html")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, renderer.Render(&buf, processTree))
+	output := buf.String()
+
+	assert.True(t, strings.HasPrefix(output, "<!DOCTYPE html>"))
+	assert.Contains(t, output, "<summary title=\"PID 1, PPID 0\n/sbin/init\nowner: root\nmemory: 0.00 B (0.00%)\">init (1)</summary>")
+	assert.Contains(t, output, "daemon &lt;off&gt;;")
+	assert.Contains(t, output, "env: 2 variables\nmemory: 2.00 MiB (0.00%)")
+	assert.Contains(t, output, `<div class="leaf"`)
+	assert.NotContains(t, output, "sshd")
+}
@@ -0,0 +1,63 @@
+package tree
+
+import (
+	"fmt"
+
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// IO STATISTICS
+//------------------------------------------------------------------------------
+// Functions in this section format the bytes each process read and wrote since
+// it started, so that disk-heavy subtrees stand out.
+
+const (
+	// IOMedium is the number of MiB read and written from which a process is colored as medium
+	IOMedium = 100
+	// IOHigh is the number of MiB read and written from which a process is colored as high
+	IOHigh = 1024
+)
+
+// HasIO reports whether the IO counters of the process were collected.
+//
+// Returns:
+//   - true if the counters are meaningful
+func (process *Process) HasIO() bool {
+	return process.IOCounters != nil && process.Available(FieldIO)
+}
+
+// IOBytes returns the number of bytes the process read and wrote since it started.
+//
+// Returns:
+//   - The bytes read and written, or 0 if the counters were not collected
+func (process *Process) IOBytes() uint64 {
+	if !process.HasIO() {
+		return 0
+	}
+	return process.IOCounters.ReadBytes + process.IOCounters.WriteBytes
+}
+
+// formatIO formats the bytes a process read and wrote, e.g., (io:1.20 MiB/340.00 KiB).
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The bytes read and written, separated by a slash
+func (processTree *ProcessTree) formatIO(pidIndex int) string {
+	counters := processTree.Nodes[pidIndex].IOCounters
+	return fmt.Sprintf("(io:%s/%s)", util.ByteConverter(counters.ReadBytes), util.ByteConverter(counters.WriteBytes))
+}
+
+// colorizeIO applies a heat color to a value based on the bytes a process read and wrote.
+//
+// By default, processes that moved less than IOMedium MiB are colored as low, less than
+// IOHigh MiB as medium, and anything above as high.
+//
+// Parameters:
+//   - ioBytes: Number of bytes read and written
+//   - value: Pointer to the string value to be colored (modified in-place)
+func (processTree *ProcessTree) colorizeIO(ioBytes uint64, value *string) {
+	processTree.colorizeThreshold("io", float64(ioBytes)/(1024*1024), value)
+}
@@ -0,0 +1,36 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowIO verifies I/O counters are shown inline and in the JSON output
+func TestShowIO(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", IOCounters: &process.IOCountersStat{ReadBytes: 1024, WriteBytes: 0}},
+		{PID: 10, PPID: 1, Command: "/usr/bin/backup", IOCounters: &process.IOCountersStat{ReadBytes: 2 << 30, WriteBytes: 512 << 20, ReadCount: 7, WriteCount: 3}},
+		{PID: 20, PPID: 1, Command: "/usr/bin/hidden", IOCounters: &process.IOCountersStat{}, Unavailable: FieldIO},
+		{PID: 30, PPID: 1, Command: "/usr/bin/old-snapshot"},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowIO: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(io:1.00 KiB/0.00 B) /sbin/init")
+	assert.Contains(t, output, "(io:2.00 GiB/512.00 MiB) /usr/bin/backup")
+	assert.Contains(t, output, "(io:–) /usr/bin/hidden")
+	assert.Contains(t, output, "(io:–) /usr/bin/old-snapshot")
+	assert.Equal(t, uint64(2<<30+512<<20), processTree.Nodes[1].IOBytes())
+	assert.Zero(t, processTree.Nodes[2].IOBytes())
+
+	root := processTree.BuildJSONTree()
+	require.NotNil(t, root)
+	assert.Equal(t, &JSONIO{ReadBytes: 2 << 30, WriteBytes: 512 << 20, ReadCount: 7, WriteCount: 3}, root.Children[0].IO)
+	assert.Nil(t, root.Children[1].IO)
+}
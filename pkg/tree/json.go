@@ -0,0 +1,322 @@
+package tree
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// JSON OUTPUT
+//------------------------------------------------------------------------------
+// Functions in this section serialize the process tree as nested JSON so that
+// it can be consumed by jq and other tooling.
+
+// JSONNode is the JSON representation of a process and its descendants, also used for
+// the YAML output. Metrics that could not be collected are null rather than zero.
+//
+// When compact mode is enabled with CompactShowPIDs, identical processes are collapsed
+// into their first member as in the text output, and GroupPIDs holds the complete
+// list of member PIDs.
+type JSONNode struct {
+	Title         string            `json:"title,omitempty" yaml:"title,omitempty"`
+	Label         string            `json:"label,omitempty" yaml:"label,omitempty"`
+	PID           int32             `json:"pid" yaml:"pid"`
+	PPID          int32             `json:"ppid" yaml:"ppid"`
+	PGID          int32             `json:"pgid" yaml:"pgid"`
+	State         string            `json:"state,omitempty" yaml:"state,omitempty"`
+	TTY           string            `json:"tty,omitempty" yaml:"tty,omitempty"`
+	Context       string            `json:"security_context,omitempty" yaml:"security_context,omitempty"`
+	Capabilities  []string          `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
+	Name          string            `json:"name" yaml:"name"`
+	Command       string            `json:"command" yaml:"command"`
+	Args          []string          `json:"args" yaml:"args"`
+	Owner         string            `json:"owner" yaml:"owner"`
+	Group         string            `json:"group" yaml:"group"`
+	Age           *int64            `json:"age_seconds" yaml:"age_seconds"`
+	CPUPercent    *float64          `json:"cpu_percent" yaml:"cpu_percent"`
+	MemoryRSS     *uint64           `json:"memory_rss_bytes" yaml:"memory_rss_bytes"`
+	MemoryPercent *float32          `json:"memory_percent" yaml:"memory_percent"`
+	NumThreads    *int32            `json:"num_threads" yaml:"num_threads"`
+	Subtree       *SubtreeMetrics   `json:"subtree,omitempty" yaml:"subtree,omitempty"`
+	NumFDs        *int32            `json:"num_fds,omitempty" yaml:"num_fds,omitempty"`
+	IO            *JSONIO           `json:"io,omitempty" yaml:"io,omitempty"`
+	Priority      *JSONPriority     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	OpenFiles     []string          `json:"open_files,omitempty" yaml:"open_files,omitempty"`
+	Tags          []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	PortConflicts []string          `json:"port_conflicts,omitempty" yaml:"port_conflicts,omitempty"`
+	Decluttered   map[string]int    `json:"decluttered,omitempty" yaml:"decluttered,omitempty"`
+	Runtime       string            `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+	Cgroup        string            `json:"cgroup,omitempty" yaml:"cgroup,omitempty"`
+	Unit          string            `json:"unit,omitempty" yaml:"unit,omitempty"`
+	UnitAfter     []string          `json:"unit_after,omitempty" yaml:"unit_after,omitempty"`
+	UnitRequires  []string          `json:"unit_requires,omitempty" yaml:"unit_requires,omitempty"`
+	CgroupNode    bool              `json:"cgroup_node,omitempty" yaml:"cgroup_node,omitempty"`
+	ContainerID   string            `json:"container_id,omitempty" yaml:"container_id,omitempty"`
+	ContainerName string            `json:"container_name,omitempty" yaml:"container_name,omitempty"`
+	Container     string            `json:"container_runtime,omitempty" yaml:"container_runtime,omitempty"`
+	Collapsed     int               `json:"container_collapsed,omitempty" yaml:"container_collapsed,omitempty"`
+	Namespaces    map[string]uint64 `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	HeapLimit     uint64            `json:"heap_limit_bytes,omitempty" yaml:"heap_limit_bytes,omitempty"`
+	DBConnections *int              `json:"db_connections,omitempty" yaml:"db_connections,omitempty"`
+	Workers       *int              `json:"workers,omitempty" yaml:"workers,omitempty"`
+	MaxWorkers    int               `json:"max_workers,omitempty" yaml:"max_workers,omitempty"`
+	Env           map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	GroupPIDs     []int32           `json:"group_pids,omitempty" yaml:"group_pids,omitempty"`
+	Zombies       int               `json:"zombies,omitempty" yaml:"zombies,omitempty"`
+	Diff          string            `json:"diff,omitempty" yaml:"diff,omitempty"`
+	Threads       []*JSONThread     `json:"threads,omitempty" yaml:"threads,omitempty"`
+	Children      []*JSONNode       `json:"children" yaml:"children"`
+}
+
+// JSONThread is the JSON representation of a displayed thread of a process.
+//
+// When compact mode is enabled with CompactShowPIDs, the threads sharing a name are
+// collapsed into their first member as in the text output, and GroupTIDs holds the
+// complete list of member thread IDs.
+type JSONThread struct {
+	TID       int32   `json:"tid" yaml:"tid"`
+	PGID      int32   `json:"pgid" yaml:"pgid"`
+	Name      string  `json:"name" yaml:"name"`
+	GroupTIDs []int32 `json:"group_tids,omitempty" yaml:"group_tids,omitempty"`
+}
+
+// JSONIO is the JSON representation of the bytes and operations a process read and wrote
+// since it started.
+type JSONIO struct {
+	ReadBytes  uint64 `json:"read_bytes" yaml:"read_bytes"`
+	WriteBytes uint64 `json:"write_bytes" yaml:"write_bytes"`
+	ReadCount  uint64 `json:"read_count" yaml:"read_count"`
+	WriteCount uint64 `json:"write_count" yaml:"write_count"`
+}
+
+// JSONPriority is the JSON representation of the nice value and scheduling classes of a
+// process. The classes are omitted where they are unknown, and the priorities where they
+// do not apply to the class.
+type JSONPriority struct {
+	Nice       int32  `json:"nice" yaml:"nice"`
+	Policy     string `json:"policy,omitempty" yaml:"policy,omitempty"`
+	RTPriority *int32 `json:"rt_priority,omitempty" yaml:"rt_priority,omitempty"`
+	IOClass    string `json:"io_class,omitempty" yaml:"io_class,omitempty"`
+	IOLevel    *int32 `json:"io_level,omitempty" yaml:"io_level,omitempty"`
+}
+
+// JSONRenderer writes the tree as indented, nested JSON.
+type JSONRenderer struct{}
+
+// Render writes the tree starting at its root process as a single JSON document. A tree
+// with several printable roots, e.g., with orphaned processes on Windows, is written as an
+// array with one object per root.
+//
+// Parameters:
+//   - output: Writer to write the document to
+//   - processTree: The marked and pruned process tree
+//
+// Returns:
+//   - error: Error if the tree could not be encoded or written
+func (renderer *JSONRenderer) Render(output io.Writer, processTree *ProcessTree) error {
+	if processTree.compactJSON() {
+		processTree.InitCompactMode()
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(processTree.jsonDocument())
+}
+
+// jsonDocument returns what the JSON and YAML renderers encode.
+//
+// Returns:
+//   - The root node if there is a single printable root, the root nodes if there are several, or nil if there are none
+func (processTree *ProcessTree) jsonDocument() any {
+	roots := processTree.BuildJSONRoots()
+	switch len(roots) {
+	case 0:
+		return nil
+	case 1:
+		return roots[0]
+	}
+	return roots
+}
+
+// BuildJSONTree converts the printable part of the tree into nested JSONNodes,
+// honoring the same filters and depth limit as PrintTree.
+//
+// The root node carries the title and root label, if set.
+//
+// Returns:
+//   - The first printable root node, or nil if no root process is printable
+func (processTree *ProcessTree) BuildJSONTree() *JSONNode {
+	roots := processTree.BuildJSONRoots()
+	if len(roots) == 0 {
+		return nil
+	}
+	return roots[0]
+}
+
+// BuildJSONRoots converts each printable root process and its printable descendants into
+// nested JSONNodes, honoring the same filters and depth limit as PrintTree.
+//
+// The first root node carries the title and root label, if set.
+//
+// Returns:
+//   - The root nodes, in the order of the tree
+func (processTree *ProcessTree) BuildJSONRoots() []*JSONNode {
+	roots := []*JSONNode{}
+	for _, rootIndex := range processTree.Roots() {
+		if !processTree.Nodes[rootIndex].Print {
+			continue
+		}
+		root := processTree.buildJSONNode(rootIndex, 0)
+		root.Label = processTree.rootLabel(rootIndex)
+		if len(roots) == 0 {
+			root.Title = processTree.DisplayOptions.Title
+		}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// buildJSONNode converts a process and its printable descendants into a JSONNode.
+//
+// Parameters:
+//   - pidIndex: Index of the process to convert
+//   - depth: Depth of the process in the tree, with the root at depth 0
+//
+// Returns:
+//   - The JSON representation of the process
+func (processTree *ProcessTree) buildJSONNode(pidIndex int, depth int) *JSONNode {
+	process := &processTree.Nodes[pidIndex]
+	node := &JSONNode{
+		PID:           process.PID,
+		PPID:          process.PPID,
+		PGID:          process.PGID,
+		Name:          filepath.Base(process.Command),
+		Command:       process.Command,
+		Args:          process.Args,
+		Owner:         process.Username,
+		Group:         process.Group,
+		Tags:          process.Tags,
+		PortConflicts: process.PortConflicts,
+		Decluttered:   process.Decluttered,
+		Runtime:       process.Runtime,
+		Cgroup:        process.Cgroup,
+		Unit:          process.Unit,
+		UnitAfter:     process.UnitAfter,
+		UnitRequires:  process.UnitRequires,
+		CgroupNode:    process.CgroupNode,
+		ContainerID:   process.ContainerID,
+		ContainerName: process.ContainerName,
+		Container:     process.ContainerRuntime,
+		Collapsed:     process.ContainerCollapsed,
+		Namespaces:    process.Namespaces,
+		HeapLimit:     process.HeapLimit,
+		MaxWorkers:    process.MaxWorkers,
+		Zombies:       process.Zombies,
+		Diff:          process.Diff,
+		Children:      []*JSONNode{},
+	}
+	if node.Args == nil {
+		node.Args = []string{}
+	}
+
+	if process.Available(FieldAge) {
+		node.Age = &process.Age
+	}
+	if process.Available(FieldState) {
+		node.State = process.StateCode()
+	}
+	if process.Available(FieldCPUPercent) {
+		node.CPUPercent = &process.CPUPercent
+	}
+	if process.Available(FieldMemory) && process.MemoryInfo != nil {
+		node.MemoryRSS = &process.MemoryInfo.RSS
+		node.MemoryPercent = &process.MemoryPercent
+	}
+	if process.Available(FieldNumThreads) {
+		node.NumThreads = &process.NumThreads
+	}
+	if processTree.DisplayOptions.Cumulative {
+		node.Subtree = &process.Subtree
+	}
+	if process.Database != "" {
+		node.DBConnections = &process.DBConnections
+	}
+	if process.PreforkServer != "" {
+		node.Workers = &process.Workers
+	}
+	if processTree.DisplayOptions.ShowFDs && process.Available(FieldNumFDs) {
+		node.NumFDs = &process.NumFDs
+	}
+	if processTree.DisplayOptions.ShowIO && process.HasIO() {
+		node.IO = &JSONIO{ReadBytes: process.IOCounters.ReadBytes, WriteBytes: process.IOCounters.WriteBytes, ReadCount: process.IOCounters.ReadCount, WriteCount: process.IOCounters.WriteCount}
+	}
+	if processTree.DisplayOptions.ShowTTY {
+		node.TTY = process.TTY
+	}
+	if processTree.DisplayOptions.ShowSecurityContext {
+		node.Context = process.SecurityContext
+	}
+	if processTree.DisplayOptions.ShowCaps && process.CapEff != 0 {
+		node.Capabilities = process.Capabilities()
+	}
+	if processTree.DisplayOptions.ShowPriority && process.HasPriority() {
+		node.Priority = process.Priority.jsonPriority()
+	}
+	if processTree.DisplayOptions.ShowOpenFiles {
+		node.OpenFiles = process.OpenFilePaths()
+	}
+
+	if selected := processTree.SelectedEnv(pidIndex); len(selected) > 0 {
+		node.Env = map[string]string{}
+		for _, variable := range selected {
+			key, value, _ := strings.Cut(variable, "=")
+			node.Env[key] = value
+		}
+	}
+
+	if processTree.compactJSON() {
+		if count, groupPIDs, _ := processTree.GetProcessCount(pidIndex); count > 1 {
+			node.GroupPIDs = groupPIDs
+		}
+	}
+
+	if processTree.withinDepth(processTree.level(pidIndex, depth) + 1) {
+		node.Threads = processTree.buildJSONThreads(pidIndex)
+		for childIndex := process.Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+			if processTree.compactJSON() && processTree.ShouldSkipProcess(childIndex) {
+				continue
+			}
+			node.Children = append(node.Children, processTree.buildJSONNode(childIndex, depth+1))
+		}
+	}
+	return node
+}
+
+// buildJSONThreads converts the displayed threads of a process into JSONThreads.
+//
+// Parameters:
+//   - pidIndex: Index of the process whose threads to convert
+//
+// Returns:
+//   - The JSON representation of the threads, or nil if none are displayed
+func (processTree *ProcessTree) buildJSONThreads(pidIndex int) []*JSONThread {
+	var threads []*JSONThread
+	// Threads are only collapsed when the members can be listed
+	for _, group := range processTree.groupThreads(processTree.visibleThreads(pidIndex), processTree.compactJSON()) {
+		thread := &JSONThread{TID: group.Thread.TID, PGID: group.Thread.PGID, Name: group.Name}
+		if group.Count > 1 {
+			thread.GroupTIDs = group.TIDs
+		}
+		threads = append(threads, thread)
+	}
+	return threads
+}
+
+// compactJSON returns true if compact groups should be collapsed in the JSON output.
+//
+// Returns:
+//   - true if both compact mode and CompactShowPIDs are enabled
+func (processTree *ProcessTree) compactJSON() bool {
+	return processTree.DisplayOptions.CompactMode && processTree.DisplayOptions.CompactShowPIDs
+}
@@ -0,0 +1,40 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJSONRenderer verifies the nested JSON output honors filters and unavailable metrics
+func TestJSONRenderer(t *testing.T) {
+	processes := goldenProcesses()
+	processes[2].Unavailable = FieldCPUPercent
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{Contains: "sshd", MaxDepth: 999})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	renderer, err := NewRenderer("json")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, renderer.Render(&buf, processTree))
+	output := buf.String()
+
+	var root JSONNode
+	require.NoError(t, json.Unmarshal([]byte(output), &root))
+	assert.Equal(t, int32(1), root.PID)
+	require.Equal(t, 1, len(root.Children))
+	sshd := root.Children[0]
+	assert.Equal(t, "sshd", sshd.Name)
+	assert.Equal(t, []string{"-D"}, sshd.Args)
+	require.Equal(t, 1, len(sshd.Children))
+	assert.Equal(t, "alice", sshd.Children[0].Owner)
+	assert.Nil(t, sshd.Children[0].CPUPercent)
+	assert.NotNil(t, sshd.CPUPercent)
+
+	_, err = NewRenderer("xml")
+	assert.Error(t, err)
+}
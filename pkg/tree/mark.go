@@ -2,6 +2,11 @@ package tree
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -12,64 +17,192 @@ import (
 // that should be included in the display, based on various filtering criteria.
 
 // MarkProcesses marks processes that should be displayed based on filtering criteria.
-// It applies various filters such as process name pattern matching, username filtering,
-// root process exclusion, and PID filtering to determine which processes should be displayed.
-//
-// Refactoring opportunity: This function could be broken down into smaller functions:
-// - applyUsernameFilter: Mark processes matching username criteria
-// - applyRootPIDFilter: Mark processes based on root PID
-// - applyCommandFilter: Mark processes matching command pattern
-// - applyRootExclusionFilter: Apply root user exclusion filter
+// The subtrees selected by username filtering, root process exclusion, PID, or process name
+// pattern matching are displayed, unless filters selecting processes by an attribute, e.g.,
+// --tty, are set. The processes of these subtrees satisfying every such filter are then
+// displayed with their ancestry.
 func (processTree *ProcessTree) MarkProcesses() {
 	// https://github.com/FredHucht/pstree/blob/main/pstree.c#L662-L684
 	processTree.Logger.Debug("Entering processTree.MarkProcesses()")
 	var (
-		myPid    int32
-		process  Process
-		pidIndex int
-		showAll  bool
-		username string
+		base      map[int]bool
+		matched   []int
+		pidIndex  int
+		roots     map[int]bool
+		scope     map[int]bool
+		selectors []selector
+		showAll   bool
 	)
 
-	if processTree.DisplayOptions.Contains == "" && len(processTree.DisplayOptions.Usernames) == 0 && !processTree.DisplayOptions.ExcludeRoot && processTree.DisplayOptions.RootPID < 1 {
-		showAll = true
+	// --level counts from the selected roots, found again on each marking
+	processTree.levelRoots = nil
+	if !processTree.DisplayOptions.LevelFromRoot && (processTree.DisplayOptions.RootPID > 0 || processTree.DisplayOptions.RootCommand != "") {
+		processTree.levelRoots = map[int]bool{}
+	}
+
+	if processTree.DisplayOptions.RootCommand != "" {
+		roots = processTree.matchCommand(processTree.DisplayOptions.RootCommand, processTree.DisplayOptions.RootCommandAll)
+	}
+	// Selectors narrow the subtrees selected by the other filters rather than replacing them
+	base = processTree.matchBase(roots)
+	selectors = processTree.selectors()
+	showAll = base == nil && len(selectors) == 0
+	if base != nil && len(selectors) > 0 {
+		scope = processTree.subtrees(base)
 	}
 
 	for pidIndex = range processTree.Nodes {
-		if showAll {
+		switch {
+		case showAll:
 			processTree.Nodes[pidIndex].Print = true
-		} else {
-			process = processTree.Nodes[pidIndex]
-			if len(processTree.DisplayOptions.Usernames) > 0 {
-				for _, username = range processTree.DisplayOptions.Usernames {
-					if process.Username == username {
-						processTree.markParents(pidIndex)
-						processTree.markChildren(pidIndex)
-					}
-				}
-			} else if processTree.Nodes[pidIndex].PID == processTree.DisplayOptions.RootPID {
-				// processTree.Logger.Debug("--pid == processTree.DisplayOptions.RootPID")
-				if (processTree.DisplayOptions.ExcludeRoot && processTree.Nodes[pidIndex].Username != "root") || (!processTree.DisplayOptions.ExcludeRoot) {
-					// processTree.Logger.Debug("(processTree.DisplayOptions.ExcludeRoot && processTree.Nodes[pidIndex].Username != root) || !processTree.DisplayOptions.ExcludeRoot")
-					processTree.markParents(pidIndex)
-					processTree.markChildren(pidIndex)
+		case len(selectors) > 0:
+			if (scope == nil || scope[pidIndex]) && processTree.selected(pidIndex, selectors) {
+				matched = append(matched, pidIndex)
+				processTree.markSelected(pidIndex, selectors)
+			}
+		case base[pidIndex]:
+			matched = append(matched, pidIndex)
+			processTree.markParents(pidIndex)
+			processTree.markChildren(pidIndex)
+		}
+	}
+
+	processTree.matchedNone = !showAll && len(matched) == 0
+
+	if len(processTree.DisplayOptions.ExcludePatterns) > 0 || len(processTree.DisplayOptions.ExcludeUsers) > 0 {
+		processTree.applyExclusions(matched, showAll)
+	}
+	// Snapshot diffs show only the changed branches unless unchanged processes are requested
+	if processTree.DisplayOptions.ShowDiff && !processTree.DisplayOptions.ShowUnchanged {
+		processTree.hideUnchangedBranches()
+	}
+	if processTree.DisplayOptions.Declutter {
+		processTree.declutter()
+	}
+	if processTree.DisplayOptions.CollapseContainers {
+		processTree.collapseContainers()
+	}
+}
+
+// matchCommand finds the processes whose name, or full path, matches a regular expression
+// entirely, e.g., sshd or /usr/sbin/sshd, as selected by --root-cmd and --ancestors. Unless
+// all is set, only the first one, with the lowest PID, is kept, which is usually the oldest,
+// e.g., the master of a daemon.
+//
+// Parameters:
+//   - expr: The regular expression
+//   - all: Whether to keep every matching process instead of the first
+//
+// Returns:
+//   - The indices of the matching processes, or an empty map if none matches
+func (processTree *ProcessTree) matchCommand(expr string, all bool) map[int]bool {
+	matches := map[int]bool{}
+	pattern, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		processTree.Logger.Warn(fmt.Sprintf("ignoring invalid command pattern '%s': %v", expr, err))
+		return matches
+	}
+
+	first := -1
+	for pidIndex := range processTree.Nodes {
+		process := &processTree.Nodes[pidIndex]
+		if !pattern.MatchString(filepath.Base(process.Command)) && !pattern.MatchString(process.Command) {
+			continue
+		}
+		if all {
+			matches[pidIndex] = true
+		} else if first == -1 || process.PID < processTree.Nodes[first].PID {
+			first = pidIndex
+		}
+	}
+	if first != -1 {
+		matches[first] = true
+	}
+	processTree.Logger.Debug(fmt.Sprintf("Command pattern '%s' matched %d processes", expr, len(matches)))
+	return matches
+}
+
+// resolveAncestors finds the processes whose ancestry --ancestors shows: the process with
+// the given PID, or every process whose name or path matches the given regular expression.
+//
+// Returns:
+//   - The indices of the target processes, or an empty map if there is none
+func (processTree *ProcessTree) resolveAncestors() map[int]bool {
+	if pid, err := strconv.ParseInt(processTree.DisplayOptions.Ancestors, 10, 32); err == nil {
+		targets := map[int]bool{}
+		if pidIndex, ok := processTree.PidToIndexMap[int32(pid)]; ok {
+			targets[pidIndex] = true
+		}
+		return targets
+	}
+	return processTree.matchCommand(processTree.DisplayOptions.Ancestors, true)
+}
+
+// matchBase finds the processes selected by --user, --pid, --root-cmd, --contains, and
+// --exclude-root, whose subtrees are shown. The first of these filters that is set
+// decides, as in the original pstree. The processes selected with --pid or --root-cmd are
+// recorded as the roots --level counts from.
+//
+// Parameters:
+//   - roots: The indices of the processes selected with --root-cmd
+//
+// Returns:
+//   - The indices of the selected processes, or nil if none of these filters is set
+func (processTree *ProcessTree) matchBase(roots map[int]bool) map[int]bool {
+	options := processTree.DisplayOptions
+	if len(options.Usernames) == 0 && options.RootPID < 1 && options.RootCommand == "" && options.Contains == "" && !options.ExcludeRoot {
+		return nil
+	}
+
+	matches := map[int]bool{}
+	for pidIndex := range processTree.Nodes {
+		process := &processTree.Nodes[pidIndex]
+		allowed := !options.ExcludeRoot || process.Username != "root"
+		switch {
+		case len(options.Usernames) > 0:
+			for _, username := range options.Usernames {
+				if ParseOwner(process.Username).Matches(username) {
+					matches[pidIndex] = true
 				}
-			} else if processTree.DisplayOptions.Contains != "" && strings.Contains(process.Command, processTree.DisplayOptions.Contains) && (process.PID != myPid) {
-				// processTree.Logger.Debug("processTree.DisplayOptions.Contains is set && process.Command contains processTree.DisplayOptions.Contains && process.PID != myPid")
-				if (processTree.DisplayOptions.ExcludeRoot && process.Username != "root") || (!processTree.DisplayOptions.ExcludeRoot) {
-					// processTree.Logger.Debug("(processTree.DisplayOptions.ExcludeRoot && process.Username != root) || !processTree.DisplayOptions.ExcludeRoot")
-					processTree.markParents(pidIndex)
-					processTree.markChildren(pidIndex)
+			}
+		case roots[pidIndex] || process.PID == options.RootPID:
+			if allowed {
+				matches[pidIndex] = true
+				if processTree.levelRoots != nil {
+					processTree.levelRoots[pidIndex] = true
 				}
-			} else if processTree.DisplayOptions.Contains != "" && !strings.Contains(process.Command, processTree.DisplayOptions.Contains) && (process.PID != myPid) {
-				// processTree.Logger.Debug("processTree.DisplayOptions.Contains is set && process.Command does not contain processTree.DisplayOptions.Contains && process.PID != myPid")
-			} else if processTree.DisplayOptions.ExcludeRoot && process.Username != "root" {
-				// processTree.Logger.Debug("processTree.DisplayOptions.ExcludeRoot && process.Username != root")
-				processTree.markParents(pidIndex)
-				processTree.markChildren(pidIndex)
+			}
+		case options.Contains != "":
+			if allowed && strings.Contains(process.Command, options.Contains) {
+				matches[pidIndex] = true
+			}
+		case options.ExcludeRoot:
+			if allowed {
+				matches[pidIndex] = true
 			}
 		}
 	}
+	return matches
+}
+
+// subtrees finds the processes in the subtrees of the given processes.
+//
+// Parameters:
+//   - roots: The indices of the processes at the top of the subtrees
+//
+// Returns:
+//   - The indices of the given processes and all their descendants
+func (processTree *ProcessTree) subtrees(roots map[int]bool) map[int]bool {
+	scope := map[int]bool{}
+	for pidIndex := range processTree.Nodes {
+		for ancestor := pidIndex; ancestor != -1; ancestor = processTree.Nodes[ancestor].Parent {
+			if roots[ancestor] {
+				scope[pidIndex] = true
+				break
+			}
+		}
+	}
+	return scope
 }
 
 // DropUnmarked removes processes that are not marked for display from the process tree.
@@ -129,8 +262,8 @@ func (processTree *ProcessTree) MarkUIDTransitions() {
 	processTree.Logger.Debug("Marking UID transitions between processes - START")
 
 	for pidIndex = range processTree.Nodes {
-		// Skip the root process (which has no parent)
-		if processTree.Nodes[pidIndex].Parent == -1 {
+		// Skip the root process (which has no parent) and the processes grouped under a control group
+		if processTree.Nodes[pidIndex].Parent == -1 || processTree.Nodes[processTree.Nodes[pidIndex].Parent].CgroupNode {
 			continue
 		}
 
@@ -203,6 +336,245 @@ func (processTree *ProcessTree) MarkCurrentAndAncestors(currentPid int32) {
 	}
 }
 
+//------------------------------------------------------------------------------
+// PROCESS EXCLUSION
+//------------------------------------------------------------------------------
+
+// applyExclusions unmarks processes matching --exclude-pattern or --exclude-user, together
+// with their subtrees.
+//
+// A process matched by another filter is kept even when it lies inside an excluded
+// subtree, along with its ancestry, so that exclusions never hide what was asked for.
+// Ancestors that were only marked to lead to excluded matches are unmarked.
+//
+// Parameters:
+//   - matched: Indices of the processes matched directly by the other filters
+//   - showAll: Whether no other filter is active, so every process was marked
+func (processTree *ProcessTree) applyExclusions(matched []int, showAll bool) {
+	patterns := []*regexp.Regexp{}
+	for _, expr := range processTree.DisplayOptions.ExcludePatterns {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			processTree.Logger.Warn(fmt.Sprintf("ignoring invalid exclude pattern '%s': %v", expr, err))
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	excluded := func(pidIndex int) bool {
+		process := &processTree.Nodes[pidIndex]
+		for _, username := range processTree.DisplayOptions.ExcludeUsers {
+			if ParseOwner(process.Username).Matches(username) {
+				return true
+			}
+		}
+		commandLine := strings.Join(append([]string{process.Command}, process.Args...), " ")
+		for _, pattern := range patterns {
+			if pattern.MatchString(process.Command) || pattern.MatchString(filepath.Base(process.Command)) || pattern.MatchString(commandLine) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Keep the processes matched by other filters, and their ancestors, unless they are excluded themselves
+	keep := map[int]bool{}
+	keptMatches := map[int]bool{}
+	for _, pidIndex := range matched {
+		if excluded(pidIndex) {
+			continue
+		}
+		keptMatches[pidIndex] = true
+		for ancestor := pidIndex; ancestor != -1 && !keep[ancestor]; ancestor = processTree.Nodes[ancestor].Parent {
+			keep[ancestor] = true
+		}
+	}
+
+	// Any other process is kept if it is a descendant of a kept match, or if no other
+	// filter is active, unless an excluded process is found on the way up
+	for pidIndex := range processTree.Nodes {
+		if !processTree.Nodes[pidIndex].Print || keep[pidIndex] {
+			continue
+		}
+		visible := showAll
+		for ancestor := pidIndex; ancestor != -1; ancestor = processTree.Nodes[ancestor].Parent {
+			if excluded(ancestor) {
+				visible = false
+				break
+			}
+			if keptMatches[ancestor] {
+				visible = true
+				break
+			}
+		}
+		if !visible {
+			processTree.Logger.Debug(fmt.Sprintf("Excluding PID %d", processTree.Nodes[pidIndex].PID))
+			processTree.Nodes[pidIndex].Print = false
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+// PROCESS SELECTORS
+//------------------------------------------------------------------------------
+// Functions in this section handle the filters selecting processes by one of their
+// attributes, e.g., --who-locks. A process is shown with its ancestry when it
+// satisfies every active selector, within the subtrees selected by --user, --pid,
+// --root-cmd, --contains, and --exclude-root.
+
+// selector is a filter selecting processes by one of their attributes.
+type selector struct {
+	matches func(pidIndex int) bool // Whether the process satisfies the filter
+	mark    func(pidIndex int)      // Marks what is shown along with a selected process besides its ancestry, or nil
+}
+
+// selectors returns the attribute filters that are set.
+//
+// Returns:
+//   - []selector: The active selectors, or an empty slice if there is none
+func (processTree *ProcessTree) selectors() []selector {
+	selectors := []selector{}
+	if processTree.DisplayOptions.WhoLocks != "" {
+		// Lock holders
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			return len(processTree.Nodes[pidIndex].Locks) > 0
+		}})
+	}
+	if len(processTree.DisplayOptions.Tags) > 0 {
+		// Processes with any of the tags, shown with their descendants
+		selectors = append(selectors, selector{
+			matches: func(pidIndex int) bool {
+				for _, tag := range processTree.DisplayOptions.Tags {
+					if slices.Contains(processTree.Nodes[pidIndex].Tags, tag) {
+						return true
+					}
+				}
+				return false
+			},
+			mark: processTree.markChildren,
+		})
+	}
+	if processTree.DisplayOptions.ThreadContains != "" {
+		// Processes with matching threads, shown with only those threads
+		selectors = append(selectors, selector{matches: processTree.hasMatchingThread, mark: processTree.markThreads})
+	}
+	if processTree.DisplayOptions.ElevatedOnly {
+		// Elevated processes
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			return processTree.Nodes[pidIndex].Elevation != ""
+		}})
+	}
+	if len(processTree.DisplayOptions.RequireEnv) > 0 || len(processTree.DisplayOptions.EnvContains) > 0 {
+		// Processes defining the required variables
+		selectors = append(selectors, selector{matches: processTree.hasRequiredEnv})
+	}
+	if processTree.DisplayOptions.ZombieParents {
+		// Parents failing to reap zombie children, shown with their zombies
+		selectors = append(selectors, selector{
+			matches: func(pidIndex int) bool {
+				return processTree.Nodes[pidIndex].Zombies > 0
+			},
+			mark: processTree.markZombies,
+		})
+	}
+	if processTree.DisplayOptions.PortConflicts {
+		// Processes listening on a port also listened on in another network namespace
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			return len(processTree.Nodes[pidIndex].PortConflicts) > 0
+		}})
+	}
+	if len(processTree.DisplayOptions.Runtimes) > 0 {
+		// Processes of the given language runtimes
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			return slices.Contains(processTree.DisplayOptions.Runtimes, processTree.Nodes[pidIndex].Runtime)
+		}})
+	}
+	if processTree.DisplayOptions.ZombiesOnly {
+		// Zombies
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			return processTree.Nodes[pidIndex].IsZombie()
+		}})
+	}
+	if len(processTree.DisplayOptions.SelectedPIDs) > 0 {
+		// Processes whose PIDs were read with --pids-from
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			return slices.Contains(processTree.DisplayOptions.SelectedPIDs, processTree.Nodes[pidIndex].PID)
+		}})
+	}
+	if len(processTree.DisplayOptions.NamespaceFilters) > 0 {
+		// Processes in all the given namespaces
+		selectors = append(selectors, selector{matches: processTree.inNamespaces})
+	}
+	if len(processTree.DisplayOptions.Units) > 0 {
+		// Processes in the given systemd units
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			unit := processTree.Nodes[pidIndex].Unit
+			return unit != "" && slices.Contains(processTree.DisplayOptions.Units, unit)
+		}})
+	}
+	if len(processTree.DisplayOptions.InGroups) > 0 {
+		// Processes of members of the given groups
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			return processTree.Nodes[pidIndex].InGroup(processTree.DisplayOptions.InGroups...)
+		}})
+	}
+	if processTree.DisplayOptions.Ancestors != "" {
+		// The targets of --ancestors, shown without their siblings or descendants
+		targets := processTree.resolveAncestors()
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			return targets[pidIndex]
+		}})
+	}
+	if processTree.DisplayOptions.NiceBelow != nil || processTree.DisplayOptions.NiceAbove != nil {
+		// Processes whose nice value is within the limits
+		selectors = append(selectors, selector{matches: processTree.niceMatches})
+	}
+	if len(processTree.DisplayOptions.TTYs) > 0 {
+		// Processes attached to the given terminals
+		selectors = append(selectors, selector{matches: func(pidIndex int) bool {
+			tty := processTree.Nodes[pidIndex].TTY
+			return tty != "" && slices.Contains(processTree.DisplayOptions.TTYs, tty)
+		}})
+	}
+	if processTree.DisplayOptions.ContextContains != "" {
+		// Processes whose security label contains the text
+		selectors = append(selectors, selector{matches: processTree.contextMatches})
+	}
+	return selectors
+}
+
+// selected returns true if a process satisfies every selector.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//   - selectors: The active selectors
+//
+// Returns:
+//   - true if the process is selected
+func (processTree *ProcessTree) selected(pidIndex int, selectors []selector) bool {
+	for _, selector := range selectors {
+		if !selector.matches(pidIndex) {
+			return false
+		}
+	}
+	return true
+}
+
+// markSelected marks a selected process, its ancestry, and what its selectors show along with it.
+//
+// Parameters:
+//   - pidIndex: Index of the selected process
+//   - selectors: The active selectors
+func (processTree *ProcessTree) markSelected(pidIndex int, selectors []selector) {
+	processTree.markParents(pidIndex)
+	processTree.Nodes[pidIndex].Print = true
+	for _, selector := range selectors {
+		if selector.mark != nil {
+			selector.mark(pidIndex)
+		}
+	}
+}
+
 //------------------------------------------------------------------------------
 // TREE TRAVERSAL HELPERS
 //------------------------------------------------------------------------------
@@ -249,3 +621,67 @@ func (processTree *ProcessTree) markChildren(pidIndex int) {
 		childPidIndex = processTree.Nodes[childPidIndex].Sister
 	}
 }
+
+// hasMatchingThread returns true if a process has a thread whose name contains the
+// --thread-contains string.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if at least one thread matches
+func (processTree *ProcessTree) hasMatchingThread(pidIndex int) bool {
+	for i := range processTree.Nodes[pidIndex].Threads {
+		if strings.Contains(processTree.Nodes[pidIndex].Threads[i].DisplayName(), processTree.DisplayOptions.ThreadContains) {
+			return true
+		}
+	}
+	return false
+}
+
+// markThreads marks the threads of a process whose names contain the --thread-contains string.
+//
+// Parameters:
+//   - pidIndex: Index of the process whose threads should be marked
+func (processTree *ProcessTree) markThreads(pidIndex int) {
+	for i := range processTree.Nodes[pidIndex].Threads {
+		thread := &processTree.Nodes[pidIndex].Threads[i]
+		if strings.Contains(thread.DisplayName(), processTree.DisplayOptions.ThreadContains) {
+			processTree.Logger.Debug(fmt.Sprintf("Marking thread %d of PID %d", thread.TID, processTree.IndexToPidMap[pidIndex]))
+			thread.Print = true
+		}
+	}
+}
+
+// MatchedNone returns true if filters were given, e.g., --contains, and matched no process
+// when the tree was last marked.
+//
+// Returns:
+//   - true if nothing was found
+func (processTree *ProcessTree) MatchedNone() bool {
+	return processTree.matchedNone
+}
+
+// MarkHighlighted marks the processes whose command line contains a pattern with
+// Highlighted=true, so that they stand out in the full tree rather than pruning it as
+// --contains does. pstree itself, whose arguments contain the pattern, is not marked.
+//
+// Parameters:
+//   - pattern: The text to look for in the command and arguments, or "" for none
+func (processTree *ProcessTree) MarkHighlighted(pattern string) {
+	if pattern == "" {
+		return
+	}
+
+	myPid := int32(os.Getpid())
+	for pidIndex := range processTree.Nodes {
+		process := &processTree.Nodes[pidIndex]
+		if process.PID == myPid {
+			continue
+		}
+		commandLine := strings.Join(append([]string{process.Command}, process.Args...), " ")
+		if strings.Contains(commandLine, pattern) {
+			process.Highlighted = true
+		}
+	}
+}
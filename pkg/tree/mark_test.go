@@ -0,0 +1,191 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExcludeFilters verifies excluded processes are pruned with their subtrees unless a descendant matches another filter
+func TestExcludeFilters(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Username: "root"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd", Username: "root"},
+		{PID: 11, PPID: 10, Command: "/bin/bash", Username: "alice"},
+		{PID: 12, PPID: 11, Command: "/usr/bin/vim", Username: "alice"},
+		{PID: 20, PPID: 1, Command: "/usr/bin/containerd", Username: "root", Args: []string{"--config", "/etc/containerd.toml"}},
+		{PID: 21, PPID: 20, Command: "/usr/bin/nginx", Username: "www"},
+		{PID: 30, PPID: 1, Command: "/usr/bin/cron", Username: "root"},
+	}
+	printed := func(options DisplayOptions) []int32 {
+		options.MaxDepth = 999
+		processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+		processTree.MarkProcesses()
+		return printedPIDs(processTree)
+	}
+
+	// Subtrees are pruned by command pattern, including arguments, and by owner
+	assert.Equal(t, []int32{1, 10, 11, 12, 30}, printed(DisplayOptions{ExcludePatterns: []string{"containerd\\.toml"}}))
+	assert.Equal(t, []int32{1, 20, 21, 30}, printed(DisplayOptions{ExcludePatterns: []string{"^sshd$"}}))
+	assert.Equal(t, []int32{1, 10, 20, 21, 30}, printed(DisplayOptions{ExcludeUsers: []string{"alice"}}))
+
+	// A descendant matching another filter survives the exclusion of its ancestor
+	assert.Equal(t, []int32{1, 20, 21}, printed(DisplayOptions{Contains: "nginx", ExcludePatterns: []string{"containerd"}}))
+	assert.Equal(t, []int32{1, 20, 21}, printed(DisplayOptions{Usernames: []string{"www"}, ExcludeUsers: []string{"root"}}))
+
+	// A process matching another filter is still hidden if it is excluded itself
+	assert.Equal(t, []int32{}, printed(DisplayOptions{Contains: "vim", ExcludeUsers: []string{"alice"}}))
+}
+
+// TestHighlightPID verifies the process given by --highlight-pid and its ancestors are highlighted
+func TestHighlightPID(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd"},
+		{PID: 11, PPID: 10, Command: "/bin/bash"},
+		{PID: 12, PPID: 11, Command: "/usr/bin/pstree"},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/cron"},
+	}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{ColorSupport: true, HighlightPID: 11, MaxDepth: 999, ScreenWidth: 132})
+	processTree.MarkProcesses()
+
+	highlighted := []bool{}
+	for pidIndex := range processTree.Nodes {
+		highlighted = append(highlighted, processTree.Nodes[pidIndex].IsCurrentOrAncestor)
+	}
+	assert.Equal(t, []bool{true, true, true, false, false}, highlighted)
+	assert.Contains(t, processTree.buildLineItem(" ", 2), "\x1b[1m\x1b[7m/bin/bash\x1b[0m")
+	assert.NotContains(t, processTree.buildLineItem(" ", 3), "\x1b[")
+	assert.NotContains(t, processTree.buildLineItem(" ", 4), "\x1b[")
+}
+
+// TestHighlight verifies --highlight marks matching command lines without pruning the tree
+func TestHighlight(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd", Args: []string{"-D"}},
+		{PID: 11, PPID: 10, Command: "/usr/sbin/sshd", Args: []string{"-R"}},
+		{PID: 20, PPID: 1, Command: "/usr/bin/python3", Args: []string{"/opt/sshd-exporter"}},
+		{PID: 30, PPID: 1, Command: "/usr/sbin/cron"},
+	}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{ColorSupport: true, Highlight: "sshd", MaxDepth: 999, ScreenWidth: 132})
+	processTree.MarkProcesses()
+
+	// Matches are highlighted, including in their arguments, without pruning the other processes
+	highlighted := []bool{}
+	printed := []bool{}
+	for pidIndex := range processTree.Nodes {
+		highlighted = append(highlighted, processTree.Nodes[pidIndex].Highlighted)
+		printed = append(printed, processTree.Nodes[pidIndex].Print)
+	}
+	assert.Equal(t, []bool{false, true, true, true, false}, highlighted)
+	assert.Equal(t, []bool{true, true, true, true, true}, printed)
+	assert.Contains(t, processTree.buildLineItem(" ", 1), "\x1b[1m\x1b[7m/usr/sbin/sshd\x1b[0m")
+	assert.NotContains(t, processTree.buildLineItem(" ", 4), "\x1b[")
+}
+
+// TestMatchedNone verifies filters matching no process are reported
+func TestMatchedNone(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Username: "root"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd", Username: "root"},
+	}
+	for _, test := range []struct {
+		name    string
+		options DisplayOptions
+		want    bool
+	}{
+		{"no filters", DisplayOptions{}, false},
+		{"match", DisplayOptions{Contains: "sshd"}, false},
+		{"no match", DisplayOptions{Contains: "nginx"}, true},
+		{"no user", DisplayOptions{Usernames: []string{"alice"}}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.options.MaxDepth = 999
+			processTree := NewProcessTree(0, setupTestLogger(), processes, test.options)
+			processTree.MarkProcesses()
+			assert.Equal(t, test.want, processTree.MatchedNone())
+		})
+	}
+}
+
+// TestSelectedPIDs verifies --pids-from shows only the selected processes and their ancestors
+func TestSelectedPIDs(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd"},
+		{PID: 11, PPID: 10, Command: "/usr/sbin/sshd-session"},
+		{PID: 12, PPID: 11, Command: "/bin/bash"},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/cron"},
+		{PID: 30, PPID: 1, Command: "/usr/bin/app"},
+	}
+	// Only the selected processes and their ancestors are shown, without their descendants
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, SelectedPIDs: []int32{11, 30, 99}}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], "/sbin/init")
+	assert.Contains(t, lines[1], "/usr/sbin/sshd")
+	assert.Contains(t, lines[2], "/usr/sbin/sshd-session")
+	assert.Contains(t, lines[3], "/usr/bin/app")
+}
+
+// TestSelectorsWithFilters verifies attribute filters narrow the subtrees selected by --user,
+// --pid, --contains, and --exclude-root instead of replacing them
+func TestSelectorsWithFilters(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Username: "root", Priority: &Priority{Nice: 0}},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd", Username: "root", Unit: "ssh.service", Priority: &Priority{Nice: 0}},
+		{PID: 11, PPID: 10, Command: "/bin/bash", Username: "alice", Locks: []string{"POSIX WRITE"}, Tags: []string{"dev"}, Threads: []Thread{{TID: 13, Name: "worker"}}, Elevation: "admin", PortConflicts: []string{"tcp/8080"}, Namespaces: map[string]uint64{"net": 4026532204}, Unit: "session-3.scope", GIDs: []uint32{1000}, SupplementaryGIDs: []uint32{27}, Priority: &Priority{Nice: 10}, TTY: "pts/0", SecurityContext: "unconfined_u:unconfined_r:unconfined_t:s0"},
+		{PID: 12, PPID: 11, Command: "/usr/bin/vim", Username: "alice", ProcessDetails: &ProcessDetails{Environment: []string{"LANG=C"}}, Status: []string{"zombie"}, Runtime: "python", Namespaces: map[string]uint64{"net": 4026532204}, Unit: "session-3.scope", Priority: &Priority{Nice: 0}, TTY: "pts/0", SecurityContext: "unconfined_u:unconfined_r:unconfined_t:s0"},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/cron", Username: "root", Locks: []string{"FLOCK WRITE"}, Threads: []Thread{{TID: 23, Name: "timer"}}, Unit: "cron.service", Priority: &Priority{Nice: 0}},
+		{PID: 21, PPID: 20, Command: "/usr/bin/backup", Username: "nobody", Locks: []string{"POSIX READ"}, Tags: []string{"dev"}, Threads: []Thread{{TID: 22, Name: "worker"}}, Elevation: "admin", ProcessDetails: &ProcessDetails{Environment: []string{"LANG=C.UTF-8"}}, Status: []string{"zombie"}, PortConflicts: []string{"tcp/8080"}, Runtime: "python", Namespaces: map[string]uint64{"net": 4026532204}, Unit: "cron.service", GIDs: []uint32{65534}, SupplementaryGIDs: []uint32{27}, Priority: &Priority{Nice: 10}, TTY: "pts/1", SecurityContext: "unconfined_u:unconfined_r:unconfined_t:s0"},
+	}
+	minusHundred, zero := int32(-100), int32(0)
+	for _, test := range []struct {
+		name    string
+		options DisplayOptions
+		want    []int32
+	}{
+		{"who-locks", DisplayOptions{WhoLocks: "/var/lock"}, []int32{1, 10, 11, 20, 21}},
+		{"who-locks and pid", DisplayOptions{RootPID: 10, WhoLocks: "/var/lock"}, []int32{1, 10, 11}},
+		{"who-locks and user", DisplayOptions{Usernames: []string{"nobody"}, WhoLocks: "/var/lock"}, []int32{1, 20, 21}},
+		{"who-locks and contains", DisplayOptions{Contains: "backup", WhoLocks: "/var/lock"}, []int32{1, 20, 21}},
+		{"who-locks and exclude-root", DisplayOptions{ExcludeRoot: true, WhoLocks: "/var/lock"}, []int32{1, 10, 11, 20, 21}},
+		{"tag and pid", DisplayOptions{RootPID: 10, Tags: []string{"dev"}}, []int32{1, 10, 11, 12}},
+		{"tag and user", DisplayOptions{Tags: []string{"dev"}, Usernames: []string{"nobody"}}, []int32{1, 20, 21}},
+		{"thread-contains and pid", DisplayOptions{RootPID: 20, ThreadContains: "work"}, []int32{1, 20, 21}},
+		{"elevated and user", DisplayOptions{ElevatedOnly: true, Usernames: []string{"alice"}}, []int32{1, 10, 11}},
+		{"require-env and contains", DisplayOptions{Contains: "vim", RequireEnv: []string{"LANG"}}, []int32{1, 10, 11, 12}},
+		{"env-contains and pid", DisplayOptions{EnvContains: []string{"LANG=UTF"}, RootPID: 10}, []int32{}},
+		{"zombie-parents and pid", DisplayOptions{RootPID: 10, ZombieParents: true}, []int32{1, 10, 11, 12}},
+		{"port-conflicts and contains", DisplayOptions{Contains: "cron", PortConflicts: true}, []int32{1, 20, 21}},
+		{"runtime and pid", DisplayOptions{RootPID: 20, Runtimes: []string{"python"}}, []int32{1, 20, 21}},
+		{"zombies-only and user", DisplayOptions{Usernames: []string{"nobody"}, ZombiesOnly: true}, []int32{1, 20, 21}},
+		{"pids-from and user", DisplayOptions{SelectedPIDs: []int32{12, 21}, Usernames: []string{"alice"}}, []int32{1, 10, 11, 12}},
+		{"ns and contains", DisplayOptions{Contains: "cron", NamespaceFilters: map[string]uint64{"net": 4026532204}}, []int32{1, 20, 21}},
+		{"unit and user", DisplayOptions{Units: []string{"session-3.scope"}, Usernames: []string{"alice"}}, []int32{1, 10, 11, 12}},
+		{"unit and pid", DisplayOptions{RootPID: 11, Units: []string{"ssh.service"}}, []int32{}},
+		{"in-group and pid", DisplayOptions{InGroups: []string{"27"}, RootPID: 20}, []int32{1, 20, 21}},
+		{"ancestors and user", DisplayOptions{Ancestors: "bash|backup", Usernames: []string{"alice"}}, []int32{1, 10, 11}},
+		{"nice-above and pid", DisplayOptions{NiceAbove: &minusHundred, RootPID: 10}, []int32{1, 10, 11, 12}},
+		{"nice-above and user", DisplayOptions{NiceAbove: &minusHundred, Usernames: []string{"nobody"}}, []int32{1, 20, 21}},
+		{"tty and contains", DisplayOptions{Contains: "vim", TTYs: []string{"pts/0"}}, []int32{1, 10, 11, 12}},
+		{"tty and nice-above", DisplayOptions{NiceAbove: &zero, TTYs: []string{"pts/0", "pts/1"}}, []int32{1, 10, 11, 20, 21}},
+		{"context-contains and user", DisplayOptions{ContextContains: "unconfined", Usernames: []string{"nobody"}}, []int32{1, 20, 21}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.options.MaxDepth = 999
+			processTree := NewProcessTree(0, setupTestLogger(), processes, test.options)
+			processTree.MarkProcesses()
+			assert.Equal(t, test.want, printedPIDs(processTree))
+		})
+	}
+}
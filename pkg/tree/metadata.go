@@ -0,0 +1,135 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+//------------------------------------------------------------------------------
+// ON-DEMAND METADATA
+//------------------------------------------------------------------------------
+// Functions in this section fetch expensive process attributes only when they are
+// asked for, so that interactive front ends can enrich just the visible nodes
+// instead of paying the full collection cost up front.
+
+// ErrUnavailable is returned by the Node accessors when an attribute could not be collected.
+var ErrUnavailable = errors.New("attribute unavailable")
+
+// MetadataSource fetches expensive per-process attributes on demand.
+type MetadataSource interface {
+	// CPUPercent returns the CPU usage percentage of a process
+	CPUPercent(pid int32) (float64, error)
+	// RSS returns the resident memory of a process in bytes
+	RSS(pid int32) (uint64, error)
+	// Cgroup returns the control group path of a process
+	Cgroup(pid int32) (string, error)
+	// Unit returns the systemd unit of a process
+	Unit(pid int32) (string, error)
+}
+
+// CPU returns the CPU usage percentage of the process, fetching it on first use.
+//
+// Returns:
+//   - float64: The CPU usage percentage
+//   - error: ErrUnavailable if the value could not be collected
+func (node Node) CPU() (float64, error) {
+	err := node.load(FieldCPUPercent, func(source MetadataSource) error {
+		cpuPercent, err := source.CPUPercent(node.Process.PID)
+		node.Process.CPUPercent = cpuPercent
+		return err
+	})
+	return node.Process.CPUPercent, err
+}
+
+// RSS returns the resident memory of the process in bytes, fetching it on first use.
+//
+// Returns:
+//   - uint64: The resident memory in bytes
+//   - error: ErrUnavailable if the value could not be collected
+func (node Node) RSS() (uint64, error) {
+	err := node.load(FieldMemory, func(source MetadataSource) error {
+		rss, err := source.RSS(node.Process.PID)
+		if err == nil {
+			if node.Process.MemoryInfo == nil {
+				node.Process.MemoryInfo = &process.MemoryInfoStat{}
+			}
+			node.Process.MemoryInfo.RSS = rss
+		}
+		return err
+	})
+	if err != nil || node.Process.MemoryInfo == nil {
+		return 0, ErrUnavailable
+	}
+	return node.Process.MemoryInfo.RSS, nil
+}
+
+// Cgroup returns the control group path of the process, fetching it on first use.
+//
+// Returns:
+//   - string: The control group path, e.g., /system.slice/nginx.service
+//   - error: ErrUnavailable if the value could not be collected
+func (node Node) Cgroup() (string, error) {
+	err := node.load(FieldCgroup, func(source MetadataSource) error {
+		cgroup, err := source.Cgroup(node.Process.PID)
+		node.Process.Cgroup = cgroup
+		return err
+	})
+	return node.Process.Cgroup, err
+}
+
+// Unit returns the systemd unit of the process, fetching it on first use.
+//
+// Returns:
+//   - string: The systemd unit, e.g., nginx.service
+//   - error: ErrUnavailable if the value could not be collected
+func (node Node) Unit() (string, error) {
+	err := node.load(FieldUnit, func(source MetadataSource) error {
+		unit, err := source.Unit(node.Process.PID)
+		node.Process.Unit = unit
+		return err
+	})
+	return node.Process.Unit, err
+}
+
+// load fetches an attribute through the tree's MetadataSource unless it was fetched before.
+//
+// Without a MetadataSource, the values collected up front are used. A failed fetch is
+// remembered by marking the field unavailable, so it is not retried on every call.
+//
+// Parameters:
+//   - field: The attribute to load
+//   - fetch: Function that fetches the attribute and stores it in the process
+//
+// Returns:
+//   - error: ErrUnavailable if the attribute could not be collected
+func (node Node) load(field Field, fetch func(source MetadataSource) error) error {
+	if node.tree == nil || node.Process == nil {
+		return fmt.Errorf("node is not part of a tree: %w", ErrUnavailable)
+	}
+	processTree := node.tree
+	processTree.metadataMutex.Lock()
+	defer processTree.metadataMutex.Unlock()
+
+	if processTree.Metadata != nil && processTree.metadataLoaded[node.Index]&field == 0 {
+		if processTree.metadataLoaded == nil {
+			processTree.metadataLoaded = make(map[int]Field)
+		}
+		processTree.metadataLoaded[node.Index] |= field
+		if err := fetch(processTree.Metadata); err != nil {
+			processTree.Logger.Debug(fmt.Sprintf("Failed to fetch metadata for PID %d: %v", node.Process.PID, err))
+			node.Process.Unavailable |= field
+		} else {
+			node.Process.Unavailable &^= field
+		}
+	} else if processTree.Metadata == nil && field&(FieldCgroup|FieldUnit) != 0 && node.Process.Cgroup == "" {
+		// These attributes are fetched on demand unless they were collected with --show-cgroup
+		return ErrUnavailable
+	}
+
+	if !node.Process.Available(field) {
+		return ErrUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package tree
+
+import (
+	"io"
+)
+
+//------------------------------------------------------------------------------
+// TREE MODELS
+//------------------------------------------------------------------------------
+// Functions in this section define the steps every tree model goes through, so
+// that ProcessTree and the experimental ProcessMap can be driven the same way
+// and share the formatting, coloring, and truncation of their lines.
+
+// TreeModel is a structure holding the processes to display as a tree.
+type TreeModel interface {
+	// Build replaces the processes of the tree and connects each one to its parent
+	Build(processes []Process)
+	// Mark marks the processes to display, based on the filters of the display options
+	Mark()
+	// Drop removes the processes that are not marked for display
+	Drop()
+	// Render writes the marked processes to output as a text tree
+	Render(output io.Writer) error
+}
+
+// Mark marks the processes to display with MarkProcesses.
+func (processTree *ProcessTree) Mark() {
+	processTree.MarkProcesses()
+}
+
+// Drop removes the processes that are not marked for display with DropUnmarked.
+func (processTree *ProcessTree) Drop() {
+	processTree.DropUnmarked()
+}
+
+// Render writes the tree as text with the TextRenderer.
+//
+// Parameters:
+//   - output: Writer to write the tree to
+//
+// Returns:
+//   - error: The first error returned by output, if any
+func (processTree *ProcessTree) Render(output io.Writer) error {
+	return (&TextRenderer{}).Render(output, processTree)
+}
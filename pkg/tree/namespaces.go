@@ -0,0 +1,61 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// NAMESPACES
+//------------------------------------------------------------------------------
+// Functions in this section show where processes enter namespaces of their own,
+// and select the processes of the namespaces given to --ns.
+
+// NamespaceTypes are the namespace types collected for each process, sorted by name.
+var NamespaceTypes = []string{"mnt", "net", "pid", "uts"}
+
+// formatNamespaces returns the namespaces a process does not share with its parent,
+// e.g., [ns:net=4026532204,pid=4026532201], marking the boundaries of containers and
+// unshare'd workloads. Namespaces that could not be read are not compared.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted namespaces, or an empty string if the process shares all of them
+func (processTree *ProcessTree) formatNamespaces(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	if process.Parent == -1 || len(process.Namespaces) == 0 {
+		return ""
+	}
+	parent := &processTree.Nodes[process.Parent]
+	entered := []string{}
+	for _, nsType := range NamespaceTypes {
+		inode, known := process.Namespaces[nsType]
+		parentInode, parentKnown := parent.Namespaces[nsType]
+		if known && parentKnown && inode != parentInode {
+			entered = append(entered, fmt.Sprintf("%s=%d", nsType, inode))
+		}
+	}
+	if len(entered) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[ns:%s]", strings.Join(entered, ","))
+}
+
+// inNamespaces returns true if a process runs in all the namespaces given to --ns.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the process is in every namespace filtered by
+func (processTree *ProcessTree) inNamespaces(pidIndex int) bool {
+	process := &processTree.Nodes[pidIndex]
+	for nsType, inode := range processTree.DisplayOptions.NamespaceFilters {
+		if actual, known := process.Namespaces[nsType]; !known || actual != inode {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShowNamespaces verifies namespaces are shown where they change and --ns filters processes
+func TestShowNamespaces(t *testing.T) {
+	host := map[string]uint64{"mnt": 4026531841, "net": 4026531840, "pid": 4026531836, "uts": 4026531838}
+	container := map[string]uint64{"mnt": 4026532200, "net": 4026532204, "pid": 4026532201, "uts": 4026531838}
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Namespaces: host},
+		{PID: 10, PPID: 1, Command: "/usr/bin/containerd-shim", Namespaces: host},
+		{PID: 11, PPID: 10, Command: "/usr/sbin/nginx", Namespaces: container},
+		{PID: 12, PPID: 11, Command: "/usr/sbin/nginx-worker", Namespaces: container},
+		{PID: 20, PPID: 1, Command: "/usr/bin/unshare", Namespaces: map[string]uint64{"net": 4026532300}},
+		{PID: 30, PPID: 1, Command: "/usr/bin/app", Unavailable: FieldNamespaces},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowNamespaces: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/sbin/nginx [ns:mnt=4026532200,net=4026532204,pid=4026532201]")
+	assert.Contains(t, output, "/usr/bin/unshare [ns:net=4026532300]")
+	assert.NotContains(t, output, "/sbin/init [ns:")
+	assert.NotContains(t, output, "/usr/bin/containerd-shim [ns:")
+	assert.NotContains(t, output, "/usr/sbin/nginx-worker [ns:")
+	assert.NotContains(t, output, "/usr/bin/app [ns:")
+
+	// Only the processes in all the given namespaces and their ancestors are shown
+	options = DisplayOptions{MaxDepth: 999, ScreenWidth: 132, NamespaceFilters: map[string]uint64{"net": 4026532204, "pid": 4026532201}}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/bin/containerd-shim")
+	assert.Contains(t, output, "/usr/sbin/nginx-worker")
+	assert.NotContains(t, output, "/usr/bin/unshare")
+	assert.NotContains(t, output, "/usr/bin/app")
+}
@@ -0,0 +1,101 @@
+package tree
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+//------------------------------------------------------------------------------
+// PROCESS OWNERS
+//------------------------------------------------------------------------------
+// Functions in this section present process owners consistently across
+// platforms, including Windows domain accounts such as CORP\alice.
+
+// OwnerMaxWidth is the widest owner shown before it is truncated, unless wide output is enabled.
+const OwnerMaxWidth = 20
+
+// Owner is a process owner split into its domain (Windows-only) and account name.
+type Owner struct {
+	Domain string // Domain or computer name, e.g., "CORP" or "NT AUTHORITY"; empty on Unix
+	Name   string // Account name, e.g., "alice"
+}
+
+// ParseOwner splits a username into its domain and account name.
+// Both the DOMAIN\user and user@domain forms are recognized.
+//
+// Parameters:
+//   - username: The username as reported by the operating system
+//
+// Returns:
+//   - The parsed Owner
+func ParseOwner(username string) Owner {
+	if domain, name, found := strings.Cut(username, `\`); found {
+		return Owner{Domain: domain, Name: name}
+	}
+	if name, domain, found := strings.Cut(username, "@"); found && name != "" && domain != "" {
+		return Owner{Domain: domain, Name: name}
+	}
+	return Owner{Name: username}
+}
+
+// Short returns the account name without the domain.
+//
+// Returns:
+//   - The account name
+func (owner Owner) Short() string {
+	return owner.Name
+}
+
+// Long returns the account name qualified with its domain, if any.
+//
+// Returns:
+//   - DOMAIN\name, or the account name when there is no domain
+func (owner Owner) Long() string {
+	if owner.Domain == "" {
+		return owner.Name
+	}
+	return owner.Domain + `\` + owner.Name
+}
+
+// Matches reports whether the owner matches a username given on the command line.
+// A bare account name matches regardless of domain, so --user alice matches CORP\alice.
+//
+// Parameters:
+//   - username: The username to compare against
+//
+// Returns:
+//   - true if the username refers to this owner
+func (owner Owner) Matches(username string) bool {
+	other := ParseOwner(username)
+	if other.Domain == "" {
+		if owner.Domain == "" {
+			return owner.Name == other.Name
+		}
+		// Windows account names are case-insensitive
+		return strings.EqualFold(owner.Name, other.Name)
+	}
+	return strings.EqualFold(owner.Long(), other.Long())
+}
+
+// formatOwner returns the owner string shown for a username according to the display options.
+//
+// The domain is omitted unless --show-domain is set, and owners wider than OwnerMaxWidth
+// are truncated with a trailing '+', as ps(1) does, unless wide output is enabled.
+//
+// Parameters:
+//   - username: The username as reported by the operating system
+//
+// Returns:
+//   - The formatted owner
+func (processTree *ProcessTree) formatOwner(username string) string {
+	owner := ParseOwner(username)
+	formatted := owner.Short()
+	if processTree.DisplayOptions.ShowDomain {
+		formatted = owner.Long()
+	}
+	if !processTree.DisplayOptions.WideDisplay && runewidth.StringWidth(formatted) > OwnerMaxWidth {
+		formatted = runewidth.Truncate(formatted, OwnerMaxWidth, "+")
+	}
+	return formatted
+}
@@ -0,0 +1,32 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOwner verifies parsing, matching, and formatting of process owners
+func TestOwner(t *testing.T) {
+	owner := ParseOwner(`CORP\alice`)
+	assert.Equal(t, Owner{Domain: "CORP", Name: "alice"}, owner)
+	assert.Equal(t, "alice", owner.Short())
+	assert.Equal(t, `CORP\alice`, owner.Long())
+	assert.Equal(t, Owner{Domain: "corp.example.com", Name: "bob"}, ParseOwner("bob@corp.example.com"))
+	assert.Equal(t, Owner{Name: "root"}, ParseOwner("root"))
+
+	// Bare names match any domain, and Windows names are case-insensitive
+	assert.True(t, owner.Matches("alice"))
+	assert.True(t, owner.Matches("Alice"))
+	assert.True(t, owner.Matches(`corp\ALICE`))
+	assert.False(t, owner.Matches(`OTHER\alice`))
+	assert.True(t, ParseOwner("root").Matches("root"))
+	assert.False(t, ParseOwner("root").Matches("Root"))
+
+	processTree := NewProcessTree(0, setupTestLogger(), []Process{}, DisplayOptions{})
+	assert.Equal(t, "alice", processTree.formatOwner(`CORP\alice`))
+	assert.Equal(t, "averyveryverylongac+", processTree.formatOwner(`CORP\averyveryverylongaccountname`))
+
+	processTree = NewProcessTree(0, setupTestLogger(), []Process{}, DisplayOptions{ShowDomain: true, WideDisplay: true})
+	assert.Equal(t, `NT AUTHORITY\NETWORK SERVICE`, processTree.formatOwner(`NT AUTHORITY\NETWORK SERVICE`))
+}
@@ -0,0 +1,186 @@
+package tree
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/giancarlosio/gorainbow"
+	"golang.org/x/term"
+)
+
+//------------------------------------------------------------------------------
+// OUTPUT PIPELINE
+//------------------------------------------------------------------------------
+// Functions in this section pass each rendered line of the text tree through an
+// ordered pipeline of post-processors, e.g., rainbow coloring and truncation, so
+// that decorations are composable instead of being applied in place by the printers.
+
+// Line is a rendered line of the text tree passing through the post-processors.
+type Line struct {
+	// The rendered text, which may contain ANSI escape sequences
+	Text string
+	// Index of the process the line describes, or of the owning process for a thread
+	PIDIndex int
+	// The thread the line describes, or nil for a process line
+	Thread *Thread
+}
+
+// PostProcessor transforms a rendered line before it is written.
+type PostProcessor interface {
+	// Process returns the text of the line after applying the post-processor
+	Process(processTree *ProcessTree, line Line) string
+}
+
+// PostProcessorFunc adapts an ordinary function to the PostProcessor interface.
+type PostProcessorFunc func(processTree *ProcessTree, line Line) string
+
+// Process calls the function.
+//
+// Parameters:
+//   - processTree: The process tree being rendered
+//   - line: The line to transform
+//
+// Returns:
+//   - The transformed text
+func (fn PostProcessorFunc) Process(processTree *ProcessTree, line Line) string {
+	return fn(processTree, line)
+}
+
+// namedPostProcessor is a post-processor registered in the pipeline under a name.
+type namedPostProcessor struct {
+	Name          string
+	PostProcessor PostProcessor
+}
+
+// defaultPostProcessors returns the post-processors every text tree starts with.
+//
+// Rainbow coloring is applied first so that truncation accounts for its escape sequences,
+// followed by the diff markers, which keep their own colors.
+// Colors are stripped when the output is not a terminal, and lines are then truncated to the
+// screen width unless wide output is enabled.
+//
+// Returns:
+//   - The default pipeline, in order
+func defaultPostProcessors() []namedPostProcessor {
+	return []namedPostProcessor{
+		{Name: "rainbow", PostProcessor: PostProcessorFunc(rainbowPostProcessor)},
+		{Name: "diff", PostProcessor: PostProcessorFunc(diffPostProcessor)},
+		{Name: "strip-ansi", PostProcessor: PostProcessorFunc(stripANSIPostProcessor)},
+		{Name: "truncate", PostProcessor: PostProcessorFunc(truncatePostProcessor)},
+	}
+}
+
+// AddPostProcessor appends a post-processor to the end of the pipeline, or replaces the
+// post-processor already registered under the same name, keeping its position.
+//
+// Parameters:
+//   - name: Name identifying the post-processor, e.g., "hyperlinks"
+//   - postProcessor: The post-processor to register
+func (processTree *ProcessTree) AddPostProcessor(name string, postProcessor PostProcessor) {
+	processTree.initPostProcessors()
+	for i := range processTree.postProcessors {
+		if processTree.postProcessors[i].Name == name {
+			processTree.postProcessors[i].PostProcessor = postProcessor
+			return
+		}
+	}
+	processTree.postProcessors = append(processTree.postProcessors, namedPostProcessor{Name: name, PostProcessor: postProcessor})
+}
+
+// RemovePostProcessor removes a post-processor from the pipeline.
+//
+// Parameters:
+//   - name: Name of the post-processor to remove
+//
+// Returns:
+//   - true if a post-processor with the name was registered
+func (processTree *ProcessTree) RemovePostProcessor(name string) bool {
+	processTree.initPostProcessors()
+	for i := range processTree.postProcessors {
+		if processTree.postProcessors[i].Name == name {
+			processTree.postProcessors = append(processTree.postProcessors[:i], processTree.postProcessors[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PostProcessorNames returns the names of the registered post-processors in pipeline order.
+//
+// Returns:
+//   - The post-processor names
+func (processTree *ProcessTree) PostProcessorNames() []string {
+	processTree.initPostProcessors()
+	names := make([]string, 0, len(processTree.postProcessors))
+	for _, postProcessor := range processTree.postProcessors {
+		names = append(names, postProcessor.Name)
+	}
+	return names
+}
+
+// initPostProcessors registers the default pipeline the first time it is needed.
+func (processTree *ProcessTree) initPostProcessors() {
+	if processTree.postProcessors == nil {
+		processTree.postProcessors = defaultPostProcessors()
+	}
+}
+
+// writeLine passes a line through the pipeline and writes it to the output.
+//
+// Parameters:
+//   - line: The rendered line
+func (processTree *ProcessTree) writeLine(line Line) {
+	processTree.initPostProcessors()
+	for _, postProcessor := range processTree.postProcessors {
+		line.Text = postProcessor.PostProcessor.Process(processTree, line)
+	}
+	if _, err := fmt.Fprintln(processTree.output(), line.Text); err != nil {
+		processTree.setWriteErr(err)
+	}
+}
+
+// setWriteErr records the first error met while writing the text tree.
+//
+// Parameters:
+//   - err: The error
+func (processTree *ProcessTree) setWriteErr(err error) {
+	if processTree.writeErr == nil {
+		processTree.writeErr = err
+	}
+}
+
+// outputIsTerminal reports whether the tree is written to a terminal.
+//
+// Returns:
+//   - true if the output is a file descriptor attached to a terminal
+func (processTree *ProcessTree) outputIsTerminal() bool {
+	file, ok := processTree.output().(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// rainbowPostProcessor colors the line with a rainbow when enabled and writing to a terminal.
+func rainbowPostProcessor(processTree *ProcessTree, line Line) string {
+	if !processTree.DisplayOptions.RainbowOutput || !processTree.outputIsTerminal() {
+		return line.Text
+	}
+	return gorainbow.Rainbow(line.Text)
+}
+
+// stripANSIPostProcessor removes colors when the output is not a terminal.
+func stripANSIPostProcessor(processTree *ProcessTree, line Line) string {
+	if processTree.outputIsTerminal() {
+		return line.Text
+	}
+	return processTree.stripANSI(line.Text)
+}
+
+// truncatePostProcessor truncates the line to the screen width unless wide output is enabled.
+func truncatePostProcessor(processTree *ProcessTree, line Line) string {
+	if processTree.DisplayOptions.WideDisplay || len(line.Text) <= processTree.DisplayOptions.ScreenWidth {
+		return line.Text
+	}
+	if processTree.outputIsTerminal() {
+		return processTree.truncateANSI(line.Text)
+	}
+	return processTree.truncatePlain(line.Text)
+}
@@ -0,0 +1,52 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostProcessors verifies line post-processors run in order and can be replaced and removed
+func TestPostProcessors(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/bin/" + strings.Repeat("x", 60)},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 40}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	assert.Equal(t, []string{"rainbow", "diff", "strip-ansi", "truncate"}, processTree.PostProcessorNames())
+
+	// Decorations run in registration order, after the defaults
+	processTree.AddPostProcessor("icon", PostProcessorFunc(func(processTree *ProcessTree, line Line) string {
+		return "* " + line.Text
+	}))
+	processTree.AddPostProcessor("pid", PostProcessorFunc(func(processTree *ProcessTree, line Line) string {
+		return fmt.Sprintf("%s [%d]", line.Text, processTree.Nodes[line.PIDIndex].PID)
+	}))
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "* -+- /sbin/init  [1]", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "* "))
+	assert.True(t, strings.HasSuffix(lines[1], "... [10]"))
+
+	// Replacing keeps the position, and removing truncate leaves long lines intact
+	processTree.AddPostProcessor("icon", PostProcessorFunc(func(processTree *ProcessTree, line Line) string {
+		return "> " + line.Text
+	}))
+	assert.True(t, processTree.RemovePostProcessor("truncate"))
+	assert.False(t, processTree.RemovePostProcessor("truncate"))
+	assert.Equal(t, []string{"rainbow", "diff", "strip-ansi", "icon", "pid"}, processTree.PostProcessorNames())
+	processTree.AtDepth = 0
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "> ")
+	assert.Contains(t, output, strings.Repeat("x", 60)+"  [10]")
+}
@@ -0,0 +1,88 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/gdanko/pstree/pkg/color"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+//------------------------------------------------------------------------------
+// COLOR SCHEME PREVIEWS
+//------------------------------------------------------------------------------
+// Functions in this section render a fixed sample tree in a color scheme, so that
+// `pstree colors preview` shows how each scheme looks in the current terminal
+// before it is selected with --color-scheme.
+
+// previewProcesses returns the sample processes of a color scheme preview.
+//
+// Returns:
+//   - []Process: A small tree with a daemon, a login shell, and a worker pool
+func previewProcesses() []Process {
+	return []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init", Username: "root", CPUPercent: 0.1, NumThreads: 1, MemoryInfo: &process.MemoryInfoStat{RSS: 12 << 20}},
+		{PID: 812, PPID: 1, PGID: 812, Command: "/usr/sbin/sshd", Args: []string{"-D"}, Username: "root", NumThreads: 1, MemoryInfo: &process.MemoryInfoStat{RSS: 8 << 20}},
+		{PID: 4107, PPID: 812, PGID: 4107, Command: "/bin/bash", Args: []string{"--login"}, Username: "alice", NumThreads: 1, MemoryInfo: &process.MemoryInfoStat{RSS: 5 << 20}},
+		{PID: 950, PPID: 1, PGID: 950, Command: "/usr/sbin/nginx", Args: []string{"-g", "daemon off;"}, Username: "root", CPUPercent: 1.5, NumThreads: 1, MemoryInfo: &process.MemoryInfoStat{RSS: 16 << 20}},
+		{PID: 951, PPID: 950, PGID: 950, Command: "/usr/sbin/nginx", Username: "www-data", CPUPercent: 42.0, NumThreads: 4, MemoryInfo: &process.MemoryInfoStat{RSS: 96 << 20}},
+		{PID: 952, PPID: 950, PGID: 950, Command: "/usr/sbin/nginx", Username: "www-data", CPUPercent: 87.5, NumThreads: 4, MemoryInfo: &process.MemoryInfoStat{RSS: 512 << 20}},
+	}
+}
+
+// PreviewColorScheme writes a sample tree colored with a color scheme, followed by its
+// semantic colors, as rendered on a terminal with 256 colors.
+//
+// Parameters:
+//   - output: Writer to write the preview to
+//   - logger: Logger for the sample process tree
+//   - name: Name of the color scheme
+//   - displayOptions: Options of the sample tree, e.g., the tree characters
+//
+// Returns:
+//   - error: Error if the color scheme does not exist or the preview could not be written
+func PreviewColorScheme(output io.Writer, logger *slog.Logger, name string, displayOptions DisplayOptions) error {
+	scheme, ok := color.ColorSchemes[name]
+	if !ok || name == "ansi8" {
+		return fmt.Errorf("unknown color scheme '%s'", name)
+	}
+	displayOptions.ColorCount = 256
+	displayOptions.ColorScheme = name
+	displayOptions.ColorSupport = true
+	displayOptions.ColorizeOutput = true
+	displayOptions.MaxDepth = 999
+	displayOptions.ShowArguments = true
+	displayOptions.ShowCpuPercent = true
+	displayOptions.ShowMemoryUsage = true
+	displayOptions.ShowNumThreads = true
+	displayOptions.ShowOwner = true
+	displayOptions.ShowPIDs = true
+	displayOptions.WideDisplay = true
+
+	processTree := NewProcessTree(0, logger, previewProcesses(), displayOptions)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	fmt.Fprintf(output, "%s:\n", name)
+	if err := (&TextRenderer{}).Render(output, processTree); err != nil {
+		return err
+	}
+	semantic := []struct {
+		Label    string
+		Colorize color.ColorFunc
+	}{
+		{"ok", color.Color256OK},
+		{"info", color.Color256Info},
+		{"warn", color.Color256Warn},
+		{"crit", color.Color256Crit},
+	}
+	fmt.Fprint(output, "semantic colors:")
+	for _, slot := range semantic {
+		label := slot.Label
+		slot.Colorize(scheme, &label)
+		fmt.Fprintf(output, " %s", label)
+	}
+	_, err := fmt.Fprintln(output)
+	return err
+}
@@ -0,0 +1,31 @@
+package tree
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gdanko/pstree/pkg/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreviewColorScheme verifies that the sample tree is rendered in the colors of the scheme
+func TestPreviewColorScheme(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, PreviewColorScheme(&buf, setupTestLogger(), "windows10", DisplayOptions{}))
+	output := buf.String()
+	assert.True(t, strings.HasPrefix(output, "windows10:\n"))
+	assert.Contains(t, output, "/usr/sbin/nginx")
+
+	// Colors of the tree are kept only on a terminal, semantic colors are always shown
+	scheme := color.ColorSchemes["windows10"]
+	crit := "crit"
+	color.Color256Crit(scheme, &crit)
+	assert.Contains(t, output, "semantic colors:")
+	assert.Contains(t, output, crit)
+
+	assert.Error(t, PreviewColorScheme(io.Discard, setupTestLogger(), "ansi8", DisplayOptions{}))
+	assert.Error(t, PreviewColorScheme(io.Discard, setupTestLogger(), "nope", DisplayOptions{}))
+}
@@ -0,0 +1,109 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// PRIORITY
+//------------------------------------------------------------------------------
+// Functions in this section format how the kernel schedules each process, its
+// nice value and CPU and I/O scheduling classes, and find processes whose
+// priority was raised or lowered.
+
+// Priority is how the kernel schedules a process on the CPUs and disks.
+type Priority struct {
+	Nice       int32  // Nice value, from -20 (highest priority) to 19
+	Policy     string // CPU scheduling class, e.g., other, batch, idle, fifo, rr, or deadline, or "" if unknown (Linux-only)
+	RTPriority int32  // Real-time priority of the fifo and rr classes, from 1 to 99
+	IOClass    string // I/O scheduling class set by ionice: none, rt, be, or idle, or "" if unknown (Linux-only)
+	IOLevel    int32  // I/O priority within the rt and be classes, from 0 (highest) to 7
+}
+
+// HasPriority reports whether the nice value of the process was collected.
+//
+// Returns:
+//   - true if the priority is meaningful
+func (process *Process) HasPriority() bool {
+	return process.Priority != nil && process.Available(FieldPriority)
+}
+
+// RealTime reports whether the process runs in a real-time CPU scheduling class, whose
+// real-time priority applies.
+//
+// Returns:
+//   - true for the fifo and rr classes
+func (priority *Priority) RealTime() bool {
+	return priority.Policy == "fifo" || priority.Policy == "rr"
+}
+
+// IOLeveled reports whether the I/O scheduling class of the process has priority levels.
+//
+// Returns:
+//   - true for the rt and be classes
+func (priority *Priority) IOLeveled() bool {
+	return priority.IOClass == "rt" || priority.IOClass == "be"
+}
+
+// jsonPriority converts the priority into its JSON representation.
+//
+// Returns:
+//   - The JSON representation of the priority
+func (priority *Priority) jsonPriority() *JSONPriority {
+	node := &JSONPriority{Nice: priority.Nice, Policy: priority.Policy, IOClass: priority.IOClass}
+	if priority.RealTime() {
+		node.RTPriority = &priority.RTPriority
+	}
+	if priority.IOLeveled() {
+		node.IOLevel = &priority.IOLevel
+	}
+	return node
+}
+
+// formatPriority formats the nice value of a process, followed by its CPU scheduling class
+// unless it is the default one, and its I/O scheduling class if set with ionice, e.g.,
+// (ni:10) or (ni:-5 sched:fifo/50 ionice:idle).
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted priority
+func (processTree *ProcessTree) formatPriority(pidIndex int) string {
+	priority := processTree.Nodes[pidIndex].Priority
+	parts := []string{fmt.Sprintf("ni:%d", priority.Nice)}
+	if priority.RealTime() {
+		parts = append(parts, fmt.Sprintf("sched:%s/%d", priority.Policy, priority.RTPriority))
+	} else if priority.Policy != "" && priority.Policy != "other" {
+		parts = append(parts, "sched:"+priority.Policy)
+	}
+	if priority.IOLeveled() {
+		parts = append(parts, fmt.Sprintf("ionice:%s/%d", priority.IOClass, priority.IOLevel))
+	} else if priority.IOClass != "" && priority.IOClass != "none" {
+		parts = append(parts, "ionice:"+priority.IOClass)
+	}
+	return fmt.Sprintf("(%s)", strings.Join(parts, " "))
+}
+
+// niceMatches reports whether the nice value of a process is within the limits of
+// NiceBelow and NiceAbove.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the nice value was collected and is below NiceBelow and above NiceAbove, where set
+func (processTree *ProcessTree) niceMatches(pidIndex int) bool {
+	process := &processTree.Nodes[pidIndex]
+	if !process.HasPriority() {
+		return false
+	}
+	if below := processTree.DisplayOptions.NiceBelow; below != nil && process.Priority.Nice >= *below {
+		return false
+	}
+	if above := processTree.DisplayOptions.NiceAbove; above != nil && process.Priority.Nice <= *above {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowPriority verifies nice values and scheduling classes are shown and --nice-below and --nice-above filter processes
+func TestShowPriority(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Priority: &Priority{Policy: "other", IOClass: "none"}},
+		{PID: 10, PPID: 1, Command: "/usr/bin/audio", Priority: &Priority{Nice: -5, Policy: "fifo", RTPriority: 50, IOClass: "rt", IOLevel: 0}},
+		{PID: 20, PPID: 1, Command: "/usr/bin/backup", Priority: &Priority{Nice: 10, Policy: "idle", IOClass: "idle"}},
+		{PID: 30, PPID: 1, Command: "/usr/bin/hidden", Priority: &Priority{}, Unavailable: FieldPriority},
+		{PID: 40, PPID: 1, Command: "/usr/bin/old-snapshot"},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowPriority: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(ni:0) /sbin/init")
+	assert.Contains(t, output, "(ni:-5 sched:fifo/50 ionice:rt/0) /usr/bin/audio")
+	assert.Contains(t, output, "(ni:10 sched:idle ionice:idle) /usr/bin/backup")
+	assert.Contains(t, output, "(ni:–) /usr/bin/hidden")
+	assert.Contains(t, output, "(ni:–) /usr/bin/old-snapshot")
+
+	root := processTree.BuildJSONTree()
+	require.NotNil(t, root)
+	rtPriority, ioLevel := int32(50), int32(0)
+	assert.Equal(t, &JSONPriority{Nice: -5, Policy: "fifo", RTPriority: &rtPriority, IOClass: "rt", IOLevel: &ioLevel}, root.Children[0].Priority)
+	assert.Equal(t, &JSONPriority{Nice: 10, Policy: "idle", IOClass: "idle"}, root.Children[1].Priority)
+	assert.Nil(t, root.Children[2].Priority)
+
+	// Only processes within the nice limits and their ancestors are shown
+	zero, minusTen, five := int32(0), int32(-10), int32(5)
+	for _, test := range []struct {
+		name      string
+		above     *int32
+		below     *int32
+		printPIDs []int32
+	}{
+		{"below", nil, &zero, []int32{1, 10}},
+		{"above", &zero, nil, []int32{1, 20}},
+		{"range", &minusTen, &five, []int32{1, 10}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			options := DisplayOptions{MaxDepth: 999, NiceAbove: test.above, NiceBelow: test.below}
+			processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+			processTree.MarkProcesses()
+			assert.Equal(t, test.printPIDs, printedPIDs(processTree))
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// PROMETHEUS OUTPUT
+//------------------------------------------------------------------------------
+// Functions in this section render the metrics of the displayed processes in
+// the Prometheus text exposition format, so that pstree serve can be scraped
+// and process trees can be graphed and alerted on.
+
+// PrometheusRenderer writes per-process and per-subtree gauges in the Prometheus text format.
+type PrometheusRenderer struct{}
+
+// prometheusMetric is a gauge family written by PrometheusRenderer.
+type prometheusMetric struct {
+	name  string
+	help  string
+	value func(process *Process, children int) (float64, bool) // The value of a process with the given number of children, and whether it is available
+}
+
+// prometheusMetrics are the gauge families written by PrometheusRenderer, in output order.
+// Subtree families are written only when subtree totals were aggregated; see DisplayOptions.Cumulative.
+var prometheusMetrics = []prometheusMetric{
+	{"pstree_process_cpu_percent", "CPU usage of the process in percent.", func(process *Process, children int) (float64, bool) {
+		return process.CPUPercent, process.Available(FieldCPUPercent)
+	}},
+	{"pstree_process_resident_memory_bytes", "Resident memory of the process in bytes.", func(process *Process, children int) (float64, bool) {
+		if process.MemoryInfo == nil {
+			return 0, false
+		}
+		return float64(process.MemoryInfo.RSS), process.Available(FieldMemory)
+	}},
+	{"pstree_process_threads", "Number of threads of the process.", func(process *Process, children int) (float64, bool) {
+		return float64(process.NumThreads), process.Available(FieldNumThreads)
+	}},
+	{"pstree_process_open_fds", "Number of open file descriptors of the process.", func(process *Process, children int) (float64, bool) {
+		return float64(process.NumFDs), process.Available(FieldNumFDs)
+	}},
+	{"pstree_process_children", "Number of child processes of the process, including those not exported.", func(process *Process, children int) (float64, bool) {
+		return float64(children), true
+	}},
+	{"pstree_subtree_cpu_percent", "CPU usage of the process and all of its descendants in percent.", func(process *Process, children int) (float64, bool) {
+		return process.Subtree.CPUPercent, true
+	}},
+	{"pstree_subtree_resident_memory_bytes", "Resident memory of the process and all of its descendants in bytes.", func(process *Process, children int) (float64, bool) {
+		return float64(process.Subtree.RSS), true
+	}},
+	{"pstree_subtree_threads", "Number of threads of the process and all of its descendants.", func(process *Process, children int) (float64, bool) {
+		return float64(process.Subtree.Threads), true
+	}},
+}
+
+// Render writes a gauge for every metric of every printable process, labeled with the
+// PID, PPID, command name, and owner of the process.
+//
+// Metrics that could not be collected for a process are omitted rather than exported as zero.
+// Children are counted in the full tree, so that filtering does not change the count.
+//
+// Parameters:
+//   - output: Writer to write the metrics to
+//   - processTree: The marked and pruned process tree
+//
+// Returns:
+//   - error: Error if the output could not be written
+func (renderer *PrometheusRenderer) Render(output io.Writer, processTree *ProcessTree) error {
+	// DropUnmarked unlinks the children that are not printed, but not their parents
+	children := make(map[int]int, len(processTree.Nodes))
+	for pidIndex := range processTree.Nodes {
+		if parent := processTree.Nodes[pidIndex].Parent; parent != -1 {
+			children[parent]++
+		}
+	}
+	indices := []int{}
+	labels := map[int]string{}
+	processTree.Walk(func(node Node) WalkDecision {
+		indices = append(indices, node.Index)
+		labels[node.Index] = prometheusLabels(node.Process)
+		return WalkContinue
+	})
+
+	writer := bufio.NewWriter(output)
+	for _, metric := range prometheusMetrics {
+		if strings.HasPrefix(metric.name, "pstree_subtree_") && !processTree.DisplayOptions.Cumulative {
+			continue
+		}
+		fmt.Fprintf(writer, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(writer, "# TYPE %s gauge\n", metric.name)
+		for _, pidIndex := range indices {
+			if value, available := metric.value(&processTree.Nodes[pidIndex], children[pidIndex]); available {
+				fmt.Fprintf(writer, "%s{%s} %s\n", metric.name, labels[pidIndex], strconv.FormatFloat(value, 'f', -1, 64))
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// prometheusLabels returns the labels identifying a process, e.g., pid="1",ppid="0",command="init",user="root".
+//
+// Parameters:
+//   - process: The process
+//
+// Returns:
+//   - The comma-separated labels, with their values escaped
+func prometheusLabels(process *Process) string {
+	return fmt.Sprintf(`pid="%d",ppid="%d",command="%s",user="%s"`,
+		process.PID, process.PPID, prometheusEscape(filepath.Base(process.Command)), prometheusEscape(process.Username))
+}
+
+// prometheusEscape escapes a string for use as a label value.
+//
+// Parameters:
+//   - value: The raw value
+//
+// Returns:
+//   - The value with backslashes, quotes, and newlines escaped
+func prometheusEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
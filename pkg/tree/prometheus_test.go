@@ -0,0 +1,53 @@
+package tree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPrometheusRenderer verifies the metrics of filtered processes, their escaped labels, and their subtree totals
+func TestPrometheusRenderer(t *testing.T) {
+	processes := goldenProcesses()
+	processes[3].CPUPercent = 1.5
+	processes[3].NumThreads = 2
+	processes[3].MemoryInfo = &process.MemoryInfoStat{RSS: 4096}
+	processes[4].NumThreads = 1
+	processes[4].MemoryInfo = &process.MemoryInfoStat{RSS: 2048}
+	processes[5].Command = `/usr/sbin/"nginx"`
+	processes[5].Unavailable = FieldCPUPercent | FieldNumFDs
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{Contains: "nginx", Cumulative: true, MaxDepth: 999})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	renderer, err := NewRenderer("prometheus")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, renderer.Render(&buf, processTree))
+	output := buf.String()
+
+	assert.True(t, strings.HasPrefix(output, "# HELP pstree_process_cpu_percent "))
+	assert.Contains(t, output, "# TYPE pstree_process_cpu_percent gauge\n")
+	assert.Contains(t, output, `pstree_process_cpu_percent{pid="20",ppid="1",command="nginx",user="root"} 1.5`)
+	assert.Contains(t, output, `pstree_process_resident_memory_bytes{pid="20",ppid="1",command="nginx",user="root"} 4096`)
+	assert.Contains(t, output, `pstree_process_children{pid="1",ppid="0",command="init",user="root"} 2`)
+	assert.Contains(t, output, `pstree_process_children{pid="20",ppid="1",command="nginx",user="root"} 2`)
+	assert.Contains(t, output, `pstree_subtree_resident_memory_bytes{pid="20",ppid="1",command="nginx",user="root"} 6144`)
+	assert.Contains(t, output, `pstree_subtree_threads{pid="20",ppid="1",command="nginx",user="root"} 3`)
+	assert.Contains(t, output, `pstree_process_threads{pid="22",ppid="20",command="\"nginx\"",user="www-data"} 0`)
+	assert.NotContains(t, output, `pstree_process_cpu_percent{pid="22"`)
+	assert.NotContains(t, output, `pstree_process_open_fds{pid="22"`)
+	assert.NotContains(t, output, `pid="10"`)
+
+	// Subtree families are only exported when the totals were aggregated
+	processTree = NewProcessTree(0, setupTestLogger(), goldenProcesses(), DisplayOptions{MaxDepth: 999})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	buf.Reset()
+	require.NoError(t, renderer.Render(&buf, processTree))
+	assert.NotContains(t, buf.String(), "pstree_subtree_")
+}
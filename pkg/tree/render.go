@@ -0,0 +1,100 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// OUTPUT RENDERERS
+//------------------------------------------------------------------------------
+// Functions in this section select how a marked process tree is written out,
+// e.g., as the traditional text tree or as machine-readable JSON.
+
+// Renderer writes a process tree that has already been marked and pruned.
+//
+// Renderers write to any io.Writer, so that programs embedding pstree can capture the
+// output in their own logs or buffers instead of printing it.
+type Renderer interface {
+	// Render writes the tree starting at its root processes to output
+	Render(output io.Writer, processTree *ProcessTree) error
+}
+
+// rendererFactories maps each --output format to a constructor for its renderer.
+var rendererFactories = map[string]func() Renderer{
+	"dot":        func() Renderer { return &DOTRenderer{} },
+	"html":       func() Renderer { return &HTMLRenderer{} },
+	"json":       func() Renderer { return &JSONRenderer{} },
+	"prometheus": func() Renderer { return &PrometheusRenderer{} },
+	"text":       func() Renderer { return &TextRenderer{} },
+	"yaml":       func() Renderer { return &YAMLRenderer{} },
+}
+
+// OutputFormats returns the names of all supported output formats in sorted order.
+//
+// Returns:
+//   - A sorted slice of format names, e.g., ["json", "text"]
+func OutputFormats() []string {
+	formats := make([]string, 0, len(rendererFactories))
+	for format := range rendererFactories {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// NewRenderer returns the renderer for an output format.
+//
+// Parameters:
+//   - format: Name of the output format; an empty string selects "text"
+//
+// Returns:
+//   - Renderer: The renderer for the format
+//   - error: Error if the format is not supported
+func NewRenderer(format string) (Renderer, error) {
+	if format == "" {
+		format = "text"
+	}
+	factory, ok := rendererFactories[format]
+	if !ok {
+		return nil, fmt.Errorf("valid options for --output are: %s", strings.Join(OutputFormats(), ", "))
+	}
+	return factory(), nil
+}
+
+// TextRenderer writes the traditional pstree-style text tree.
+type TextRenderer struct{}
+
+// Render prints the tree using PrintTree, one top-level tree for each root process.
+//
+// Colors are kept only when output is a terminal, as when printing to stdout.
+//
+// Parameters:
+//   - output: Writer to write the tree to
+//   - processTree: The marked and pruned process tree
+//
+// Returns:
+//   - error: The first error returned by output, if any
+func (renderer *TextRenderer) Render(output io.Writer, processTree *ProcessTree) error {
+	processTree.Output = output
+	processTree.writeErr = nil
+	processTree.writeTitle()
+	for _, rootIndex := range processTree.Roots() {
+		processTree.PrintTree(rootIndex, "")
+	}
+	return processTree.writeErr
+}
+
+// output returns the writer the text tree is written to.
+//
+// Returns:
+//   - io.Writer: The Output writer, or os.Stdout if none is set
+func (processTree *ProcessTree) output() io.Writer {
+	if processTree.Output == nil {
+		return os.Stdout
+	}
+	return processTree.Output
+}
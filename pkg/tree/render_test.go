@@ -0,0 +1,40 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingWriter is an io.Writer that always fails
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("disk full")
+}
+
+// TestRendererWriter verifies every renderer writes to the given writer and returns its errors
+func TestRendererWriter(t *testing.T) {
+	for _, format := range []string{"dot", "json", "text"} {
+		t.Run(format, func(t *testing.T) {
+			processTree := NewProcessTree(0, setupTestLogger(), goldenProcesses(), DisplayOptions{ColorSupport: true, MaxDepth: 999, ScreenWidth: 132})
+			processTree.MarkProcesses()
+			processTree.DropUnmarked()
+			renderer, err := NewRenderer(format)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			stdout := captureStdout(t, func() {
+				require.NoError(t, renderer.Render(&buf, processTree))
+			})
+			assert.Empty(t, stdout)
+			assert.Contains(t, buf.String(), "nginx")
+			assert.NotContains(t, buf.String(), "\x1b[")
+
+			assert.EqualError(t, renderer.Render(failingWriter{}, processTree), "disk full")
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// WORKER SATURATION
+//------------------------------------------------------------------------------
+// Functions in this section show how many of the workers a pre-fork server is
+// configured for are running, so that a server about to refuse work stands out.
+
+// saturationBarWidth is the number of cells in the saturation bar.
+const saturationBarWidth = 10
+
+// saturationAlert is the saturation at or above which a master is shown in red.
+const saturationAlert = 90
+
+// Saturation returns the running workers of a pre-fork server master as a percentage of
+// its configured maximum.
+//
+// Returns:
+//   - int: The saturation percentage, which exceeds 100 while workers are being replaced
+//   - bool: true if the process is a recognized master whose maximum is known
+func (process *Process) Saturation() (int, bool) {
+	if process.PreforkServer == "" || process.MaxWorkers <= 0 {
+		return 0, false
+	}
+	return process.Workers * 100 / process.MaxWorkers, true
+}
+
+// saturated returns true if a master has reached the saturation alert threshold.
+func (process *Process) saturated() bool {
+	percent, known := process.Saturation()
+	return known && percent >= saturationAlert
+}
+
+// formatSaturation returns the running and configured workers of a pre-fork server master
+// with a bar, e.g., [workers 8/10 ████████░░ 80%], or only the running workers, e.g.,
+// [workers 8], when the configuration could not be read.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted saturation, or an empty string if the process is not a recognized master
+func (processTree *ProcessTree) formatSaturation(pidIndex int) string {
+	process := &processTree.Nodes[pidIndex]
+	if process.PreforkServer == "" {
+		return ""
+	}
+	percent, known := process.Saturation()
+	if !known {
+		return fmt.Sprintf("[workers %d]", process.Workers)
+	}
+
+	full, empty := "#", "."
+	if processTree.DisplayOptions.UTF8Graphics {
+		full, empty = "█", "░"
+	}
+	filled := min((percent*saturationBarWidth+50)/100, saturationBarWidth)
+	bar := strings.Repeat(full, filled) + strings.Repeat(empty, saturationBarWidth-filled)
+	return fmt.Sprintf("[workers %d/%d %s %d%%]", process.Workers, process.MaxWorkers, bar, percent)
+}
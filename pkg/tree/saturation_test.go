@@ -0,0 +1,32 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShowSaturation verifies the worker saturation of prefork servers
+func TestShowSaturation(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", PreforkServer: "nginx", Workers: 8, MaxWorkers: 10},
+		{PID: 20, PPID: 1, Command: "/usr/sbin/php-fpm", PreforkServer: "php-fpm", Workers: 5, MaxWorkers: 5},
+		{PID: 30, PPID: 1, Command: "/usr/sbin/apache2", PreforkServer: "apache", Workers: 3},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowSaturation: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/sbin/nginx [workers 8/10 ########.. 80%]")
+	assert.Contains(t, output, "/usr/sbin/php-fpm [workers 5/5 ########## 100%]")
+	assert.Contains(t, output, "/usr/sbin/apache2 [workers 3]")
+	assert.NotContains(t, output, "/sbin/init [workers")
+	assert.False(t, processTree.Nodes[1].saturated())
+	assert.True(t, processTree.Nodes[2].saturated())
+
+	processTree.DisplayOptions.UTF8Graphics = true
+	assert.Equal(t, "[workers 8/10 ████████░░ 80%]", processTree.formatSaturation(1))
+}
@@ -0,0 +1,40 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// SECURITY CONTEXTS
+//------------------------------------------------------------------------------
+// Functions in this section format and match the SELinux or AppArmor label set by
+// --show-security-context and --context-contains.
+
+// formatSecurityContext formats the security label of a process, e.g.,
+// [ctx:system_u:system_r:sshd_t:s0] or [ctx:unconfined].
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The formatted label, or "" if the process has none
+func (processTree *ProcessTree) formatSecurityContext(pidIndex int) string {
+	if processTree.Nodes[pidIndex].SecurityContext == "" {
+		return ""
+	}
+	return fmt.Sprintf("[ctx:%s]", processTree.Nodes[pidIndex].SecurityContext)
+}
+
+// contextMatches returns true if the security label of a process contains the text given to
+// --context-contains, e.g., unconfined.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the process has a label containing the text
+func (processTree *ProcessTree) contextMatches(pidIndex int) bool {
+	context := processTree.Nodes[pidIndex].SecurityContext
+	return context != "" && strings.Contains(context, processTree.DisplayOptions.ContextContains)
+}
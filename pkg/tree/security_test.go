@@ -0,0 +1,37 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowSecurityContext verifies security labels are shown inline and --context-contains filters processes
+func TestShowSecurityContext(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", SecurityContext: "system_u:system_r:init_t:s0"},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/sshd", SecurityContext: "system_u:system_r:sshd_t:s0-s0:c0.c1023"},
+		{PID: 20, PPID: 10, Command: "/bin/bash", SecurityContext: "unconfined_u:unconfined_r:unconfined_t:s0"},
+		{PID: 30, PPID: 1, Command: "/usr/bin/old-kernel"},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowSecurityContext: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/sbin/sshd [ctx:system_u:system_r:sshd_t:s0-s0:c0.c1023]")
+	assert.Contains(t, output, "/bin/bash [ctx:unconfined_u:unconfined_r:unconfined_t:s0]")
+	assert.NotContains(t, output, "[ctx:]")
+
+	root := processTree.BuildJSONTree()
+	require.NotNil(t, root)
+	assert.Equal(t, "system_u:system_r:init_t:s0", root.Context)
+
+	// Only processes whose label contains the text and their ancestors are shown
+	options = DisplayOptions{MaxDepth: 999, ContextContains: "unconfined"}
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	assert.Equal(t, []int32{1, 10, 20}, printedPIDs(processTree))
+}
@@ -0,0 +1,55 @@
+package tree
+
+import "slices"
+
+//------------------------------------------------------------------------------
+// PROCESS STATE
+//------------------------------------------------------------------------------
+// Functions in this section show the state of each process as the one-letter
+// code used by ps, e.g., R for running or Z for zombie.
+
+// stateCodes maps the process states reported by gopsutil to their ps codes.
+var stateCodes = map[string]string{
+	"blocked": "D",
+	"idle":    "I",
+	"lock":    "L",
+	"running": "R",
+	"sleep":   "S",
+	"stop":    "T",
+	"wait":    "W",
+	"zombie":  "Z",
+}
+
+// StateCode returns the one-letter ps code of the process state.
+//
+// Returns:
+//   - The state code, e.g., R or Z, or ? if the state is unknown
+func (process *Process) StateCode() string {
+	for _, status := range process.Status {
+		if code, exists := stateCodes[status]; exists {
+			return code
+		}
+	}
+	return "?"
+}
+
+// IsUninterruptible returns true if the process is in uninterruptible sleep, usually waiting on I/O.
+//
+// Returns:
+//   - true if the process status is blocked (D)
+func (process *Process) IsUninterruptible() bool {
+	return slices.Contains(process.Status, "blocked")
+}
+
+// stateAlert returns true if --show-state flags the process, i.e., it is a zombie or is
+// in uninterruptible sleep.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - true if the process should be shown in red
+func (processTree *ProcessTree) stateAlert(pidIndex int) bool {
+	process := &processTree.Nodes[pidIndex]
+	return processTree.DisplayOptions.ShowState && (process.IsZombie() || process.IsUninterruptible())
+}
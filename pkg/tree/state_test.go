@@ -0,0 +1,49 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShowState verifies process states are shown, zombies are colored, and --zombies-only filters processes
+func TestShowState(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Status: []string{"sleep"}},
+		{PID: 10, PPID: 1, Command: "/usr/bin/app", Status: []string{"running"}},
+		{PID: 11, PPID: 10, Command: "/usr/bin/worker", Status: []string{"zombie"}},
+		{PID: 20, PPID: 1, Command: "/usr/bin/backup", Status: []string{"blocked"}},
+		{PID: 30, PPID: 1, Command: "/usr/bin/unknown", Unavailable: FieldState},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowState: true}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(S) /sbin/init")
+	assert.Contains(t, output, "(R) (z:1) /usr/bin/app")
+	assert.Contains(t, output, "(Z) /usr/bin/worker")
+	assert.Contains(t, output, "(D) /usr/bin/backup")
+	assert.Contains(t, output, "(–) /usr/bin/unknown")
+
+	// Zombies and uninterruptible sleeps are shown in red
+	processTree.DisplayOptions.ColorSupport = true
+	processTree.DisplayOptions.ColorCount = 256
+	processTree = NewProcessTree(0, setupTestLogger(), processes, processTree.DisplayOptions)
+	processTree.MarkProcesses()
+	assert.Contains(t, processTree.buildLineItem(" ", 2), "\x1b[")
+	assert.Contains(t, processTree.buildLineItem(" ", 3), "\x1b[")
+	assert.NotContains(t, processTree.buildLineItem(" ", 4), "\x1b[")
+
+	options.ZombiesOnly = true
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "/usr/bin/worker")
+	assert.Contains(t, output, "/usr/bin/app")
+	assert.NotContains(t, output, "/usr/bin/backup")
+}
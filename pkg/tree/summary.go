@@ -0,0 +1,99 @@
+package tree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// SUMMARY
+//------------------------------------------------------------------------------
+// Functions in this section total the displayed processes, so that --summary can
+// print how much of the system the tree shows, and how many threads, CPU, and
+// resident memory it accounts for, after the tree.
+
+// UserCount is the number of displayed processes of one user.
+type UserCount struct {
+	Username  string // Owner of the processes
+	Processes int    // Number of displayed processes
+}
+
+// Summary holds the totals of the displayed processes.
+type Summary struct {
+	Displayed  int         // Number of displayed processes
+	Total      int         // Number of collected processes
+	Threads    int         // Sum of the thread counts of the displayed processes
+	CPUPercent float64     // Sum of the CPU usage percentages of the displayed processes
+	RSS        uint64      // Sum of the resident memory of the displayed processes in bytes
+	Users      []UserCount // Number of displayed processes per user, by most processes, then by username
+}
+
+// Summarize totals the displayed processes. Metrics that could not be collected count as zero,
+// and the nodes standing for control groups are not counted.
+//
+// Returns:
+//   - Summary: The totals
+func (processTree *ProcessTree) Summarize() Summary {
+	summary := Summary{}
+	byUser := map[string]int{}
+	for pidIndex := range processTree.Nodes {
+		process := &processTree.Nodes[pidIndex]
+		if process.CgroupNode {
+			continue
+		}
+		summary.Total++
+		if !process.Print {
+			continue
+		}
+		summary.Displayed++
+		byUser[process.Username]++
+		if process.Available(FieldNumThreads) {
+			summary.Threads += int(process.NumThreads)
+		}
+		if process.Available(FieldCPUPercent) {
+			summary.CPUPercent += process.CPUPercent
+		}
+		if process.Available(FieldMemory) && process.MemoryInfo != nil {
+			summary.RSS += process.MemoryInfo.RSS
+		}
+	}
+
+	summary.Users = make([]UserCount, 0, len(byUser))
+	for username, processes := range byUser {
+		summary.Users = append(summary.Users, UserCount{Username: username, Processes: processes})
+	}
+	sort.Slice(summary.Users, func(i, j int) bool {
+		if summary.Users[i].Processes != summary.Users[j].Processes {
+			return summary.Users[i].Processes > summary.Users[j].Processes
+		}
+		return summary.Users[i].Username < summary.Users[j].Username
+	})
+	return summary
+}
+
+// PrintSummary prints the totals of the displayed processes after the tree, e.g.,
+//
+//	Processes: 42 of 318 displayed
+//	Threads:   512
+//	CPU:       12.5%
+//	Memory:    1.20 GiB
+//	Users:     root 30, alice 10, www-data 2
+func (processTree *ProcessTree) PrintSummary() {
+	summary := processTree.Summarize()
+	users := make([]string, 0, len(summary.Users))
+	for _, userCount := range summary.Users {
+		users = append(users, fmt.Sprintf("%s %d", userCount.Username, userCount.Processes))
+	}
+
+	fmt.Fprintln(processTree.output())
+	fmt.Fprintf(processTree.output(), "Processes: %d of %d displayed\n", summary.Displayed, summary.Total)
+	fmt.Fprintf(processTree.output(), "Threads:   %d\n", summary.Threads)
+	fmt.Fprintf(processTree.output(), "CPU:       %.1f%%\n", summary.CPUPercent)
+	fmt.Fprintf(processTree.output(), "Memory:    %s\n", util.ByteConverter(summary.RSS))
+	if len(users) > 0 {
+		fmt.Fprintf(processTree.output(), "Users:     %s\n", strings.Join(users, ", "))
+	}
+}
@@ -0,0 +1,37 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSummary verifies the summary of the displayed processes
+func TestSummary(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", Username: "root", NumThreads: 1, CPUPercent: 1, MemoryInfo: &process.MemoryInfoStat{RSS: 2 * 1024 * 1024}},
+		{PID: 10, PPID: 1, Command: "/usr/bin/make", Username: "alice", NumThreads: 2, CPUPercent: 60, MemoryInfo: &process.MemoryInfoStat{RSS: 8 * 1024 * 1024}},
+		{PID: 11, PPID: 10, Command: "/usr/bin/cc", Username: "alice", NumThreads: 4, Unavailable: FieldCPUPercent | FieldMemory},
+		{PID: 20, PPID: 1, Command: "/usr/bin/vim", Username: "bob", NumThreads: 1, CPUPercent: 5, MemoryInfo: &process.MemoryInfoStat{RSS: 4 * 1024 * 1024}},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, Usernames: []string{"alice"}}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	// The ancestors of the matches are displayed too
+	assert.Equal(t, Summary{
+		Displayed:  3,
+		Total:      4,
+		Threads:    7,
+		CPUPercent: 61,
+		RSS:        10 * 1024 * 1024,
+		Users:      []UserCount{{Username: "alice", Processes: 2}, {Username: "root", Processes: 1}},
+	}, processTree.Summarize())
+
+	output := captureStdout(t, func() {
+		processTree.PrintSummary()
+	})
+	assert.Equal(t, "\nProcesses: 3 of 4 displayed\nThreads:   7\nCPU:       61.0%\nMemory:    10.00 MiB\nUsers:     alice 2, root 1\n", output)
+}
@@ -0,0 +1,261 @@
+package tree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdanko/pstree/util"
+)
+
+//------------------------------------------------------------------------------
+// THREADS
+//------------------------------------------------------------------------------
+// Functions in this section display the threads of a process as children of it,
+// like Linux pstree does. Threads are listed before the child processes and go
+// through the same steps: --thread-contains filters them, --level limits them
+// to one level below their process, --order-by sorts them, and compact mode
+// collapses threads sharing a name into "N*[{name}]".
+
+// ThreadGroup is a thread as displayed in the tree. In compact mode, the threads
+// of a process that share a name are collapsed into a single group.
+type ThreadGroup struct {
+	// Number of threads in the group
+	Count int
+	// Name shown for the threads of the group
+	Name string
+	// First thread of the group, which represents it
+	Thread *Thread
+	// Thread IDs of the members, in display order
+	TIDs []int32
+}
+
+// PrintThreads displays the threads of a process in a tree-like structure.
+// It formats each thread with its thread ID and PGID. This only works on
+// Linux because macOS does not provide thread IDs.
+//
+// Parameters:
+//   - pidIndex: Index of the parent process whose threads to display
+//   - head: The accumulated prefix string from parent levels
+func (processTree *ProcessTree) PrintThreads(pidIndex int, head string) {
+	groups := processTree.groupThreads(processTree.threadsWithinDepth(pidIndex), processTree.DisplayOptions.CompactMode)
+	if len(groups) == 0 {
+		return
+	}
+
+	var threadHead string
+
+	processTree.Logger.Debug(fmt.Sprintf("Printing %d thread groups for process %d", len(groups), processTree.Nodes[pidIndex].PID))
+
+	// Get the thread head with proper spacing
+	if processTree.Nodes[pidIndex].PID == 1 {
+		// Special case for PID 1 (init/systemd)
+		threadHead = " "
+	} else {
+		threadHead = processTree.buildThreadHead(head)
+	}
+
+	processTree.Logger.Debug(fmt.Sprintf("Thread head for PID %d: \"%s\"", processTree.Nodes[pidIndex].PID, threadHead))
+
+	hasChildProcess := processTree.childrenWithinDepth(pidIndex)
+	for i, group := range groups {
+		var (
+			connector  string
+			threadLine strings.Builder
+		)
+
+		// The last thread uses the L-connector (└) unless child processes follow it
+		if i == len(groups)-1 && !hasChildProcess {
+			connector = processTree.TreeChars.BarL
+		} else {
+			connector = processTree.TreeChars.BarC
+		}
+		threadLine.WriteString(threadHead + connector + processTree.TreeChars.EG + processTree.TreeChars.S2 + processTree.TreeChars.NPGL)
+		threadLine.WriteString(" ")
+
+		threadName := processTree.formatThreadName(group)
+		processTree.colorizeField("command", &threadName, pidIndex)
+		threadLine.WriteString(threadName)
+		threadLine.WriteString(" ")
+
+		if tidPgidString := processTree.formatThreadIDs(group); tidPgidString != "" {
+			processTree.colorizeField("pidPgid", &tidPgidString, pidIndex)
+			threadLine.WriteString(tidPgidString)
+			threadLine.WriteString(" ")
+		}
+
+		processTree.writeLine(Line{Text: threadLine.String(), PIDIndex: pidIndex, Thread: group.Thread})
+	}
+}
+
+// formatThreadName formats the name of a thread group like Linux pstree does,
+// e.g., "{worker}" for a single thread and "4*[{worker}]" for a group.
+//
+// Parameters:
+//   - group: The thread group to format
+//
+// Returns:
+//   - The formatted name
+func (processTree *ProcessTree) formatThreadName(group ThreadGroup) string {
+	if group.Count > 1 {
+		return fmt.Sprintf("%d*[{%s}]", group.Count, group.Name)
+	}
+	return fmt.Sprintf("{%s}", group.Name)
+}
+
+// formatThreadIDs formats the thread IDs and PGID of a thread group, e.g., "(12,10)".
+//
+// A group lists the IDs of all its members, truncated after CompactPIDsMax entries
+// when CompactShowPIDs is enabled, as for compact groups of processes.
+//
+// Parameters:
+//   - group: The thread group to format
+//
+// Returns:
+//   - The formatted IDs, or an empty string if neither PIDs nor PGIDs are shown
+func (processTree *ProcessTree) formatThreadIDs(group ThreadGroup) string {
+	if group.Count > 1 {
+		tidStrings := processTree.PIDsToString(group.TIDs)
+		if processTree.DisplayOptions.CompactShowPIDs {
+			if len(tidStrings) > CompactPIDsMax {
+				tidStrings = append(tidStrings[:CompactPIDsMax], "…")
+			}
+			return fmt.Sprintf("(tids %s)", strings.Join(tidStrings, ","))
+		} else if processTree.DisplayOptions.ShowPIDs {
+			return fmt.Sprintf("(%s)", strings.Join(tidStrings, ","))
+		}
+		return ""
+	}
+
+	tidPgidSlice := []string{}
+	if processTree.DisplayOptions.ShowPIDs && group.Thread.TID >= 0 {
+		tidPgidSlice = append(tidPgidSlice, util.Int32toStr(group.Thread.TID))
+	}
+	if processTree.DisplayOptions.ShowPGIDs && group.Thread.PGID >= 0 {
+		tidPgidSlice = append(tidPgidSlice, util.Int32toStr(group.Thread.PGID))
+	}
+	if len(tidPgidSlice) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(%s)", strings.Join(tidPgidSlice, ","))
+}
+
+// groupThreads sorts threads for display and, when compact, collapses the
+// threads that share a name into a single group.
+//
+// Threads are sorted by name with --order-by cmd, by CPU time with --order-by cpu,
+// and by thread ID otherwise, matching the order of processes. A group takes the
+// position of its first member.
+//
+// Parameters:
+//   - threads: The threads to display
+//   - compact: Whether to collapse the threads that share a name
+//
+// Returns:
+//   - The thread groups, in display order
+func (processTree *ProcessTree) groupThreads(threads []Thread, compact bool) []ThreadGroup {
+	if len(threads) == 0 {
+		return nil
+	}
+
+	sorted := make([]*Thread, len(threads))
+	for i := range threads {
+		sorted[i] = &threads[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		left, right := sorted[i], sorted[j]
+		switch processTree.DisplayOptions.OrderBy {
+		case "cmd":
+			if left.DisplayName() != right.DisplayName() {
+				return left.DisplayName() < right.DisplayName()
+			}
+		case "cpu":
+			if threadCPUTime(left) != threadCPUTime(right) {
+				return threadCPUTime(left) < threadCPUTime(right)
+			}
+		}
+		return left.TID < right.TID
+	})
+
+	groups := []ThreadGroup{}
+	positions := map[string]int{}
+	for _, thread := range sorted {
+		name := thread.DisplayName()
+		if position, exists := positions[name]; exists && compact {
+			groups[position].Count++
+			groups[position].TIDs = append(groups[position].TIDs, thread.TID)
+			continue
+		}
+		positions[name] = len(groups)
+		groups = append(groups, ThreadGroup{Count: 1, Name: name, Thread: thread, TIDs: []int32{thread.TID}})
+	}
+	return groups
+}
+
+// threadCPUTime returns the user and system CPU time consumed by a thread.
+//
+// Parameters:
+//   - thread: The thread
+//
+// Returns:
+//   - The CPU time in seconds, or 0 if it is unknown
+func threadCPUTime(thread *Thread) float64 {
+	if thread.CPUTimes == nil {
+		return 0
+	}
+	return thread.CPUTimes.User + thread.CPUTimes.System
+}
+
+// visibleThreads returns the threads of a process that should be displayed.
+//
+// All threads are shown unless threads are hidden or only ancestors are shown, or
+// --thread-contains is set, in which case only the threads marked by MarkProcesses
+// are shown. When only the changed branches of a snapshot diff are shown, unchanged
+// processes hide their threads.
+//
+// Parameters:
+//   - pidIndex: Index of the process whose threads to return
+//
+// Returns:
+//   - The threads to display, in their original order
+func (processTree *ProcessTree) visibleThreads(pidIndex int) []Thread {
+	// Threads are children like any other, which --ancestors omits
+	if processTree.DisplayOptions.HideThreads || processTree.DisplayOptions.Ancestors != "" {
+		return nil
+	}
+	if processTree.DisplayOptions.ShowDiff && !processTree.DisplayOptions.ShowUnchanged && processTree.Nodes[pidIndex].Diff == "" {
+		return nil
+	}
+	if processTree.DisplayOptions.ThreadContains == "" {
+		return processTree.Nodes[pidIndex].Threads
+	}
+	threads := []Thread{}
+	for _, thread := range processTree.Nodes[pidIndex].Threads {
+		if thread.Print {
+			threads = append(threads, thread)
+		}
+	}
+	return threads
+}
+
+// buildThreadHead constructs a head string specifically for thread display.
+// It ensures the correct spacing and vertical bars for thread hierarchy.
+//
+// Parameters:
+//   - head: The accumulated prefix string from parent levels
+//
+// Returns:
+//   - A string to be used as the head for thread display
+func (processTree *ProcessTree) buildThreadHead(head string) string {
+	// Remove the trailing space from the head if it exists
+	head = strings.TrimSuffix(head, " ")
+
+	// For thread display, we need to ensure the correct spacing
+	// The format should be "│ " (vertical bar followed by space)
+	if len(head) > 0 {
+		// Replace the last space with a vertical bar + space
+		return head + " "
+	}
+
+	return head
+}
@@ -0,0 +1,73 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestThreads verifies threads are sorted, compacted, and connected like child processes
+func TestThreads(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, PGID: 1, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, PGID: 10, Command: "/usr/bin/app", Threads: []Thread{
+			{TID: 14, PGID: 10, Name: "worker"},
+			{TID: 12, PGID: 10, Name: "worker"},
+			{TID: 11, PGID: 10, Name: "gc"},
+			{TID: 13, PGID: 10, Name: "worker"},
+		}},
+		{PID: 20, PPID: 1, PGID: 20, Command: "/usr/bin/daemon", Threads: []Thread{{TID: 21, PGID: 20, Name: "timer"}}},
+		{PID: 22, PPID: 20, PGID: 20, Command: "/usr/bin/helper"},
+	}
+	render := func(options DisplayOptions) []string {
+		processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+		processTree.MarkProcesses()
+		processTree.DropUnmarked()
+		output := captureStdout(t, func() {
+			processTree.PrintTree(0, "")
+		})
+		return strings.Split(strings.TrimRight(output, "\n"), "\n")
+	}
+
+	// Threads sharing a name are collapsed, and the last thread of a process without children ends its branch
+	lines := render(DisplayOptions{CompactMode: true, MaxDepth: 999, ScreenWidth: 132, ShowPIDs: true})
+	require.Len(t, lines, 7)
+	assert.Contains(t, lines[2], "|--- {gc} (11)")
+	assert.Contains(t, lines[3], "\\--- 3*[{worker}] (12,13,14)")
+	assert.Contains(t, lines[5], "|--- {timer} (21)")
+	assert.Contains(t, lines[6], "\\--- (22) /usr/bin/helper")
+
+	// Without compact mode, threads are listed individually in TID order
+	lines = render(DisplayOptions{MaxDepth: 999, ScreenWidth: 132, ShowPIDs: true})
+	require.Len(t, lines, 9)
+	assert.Contains(t, lines[2], "{gc} (11)")
+	assert.Contains(t, lines[3], "{worker} (12)")
+	assert.Contains(t, lines[5], "{worker} (14)")
+
+	// --ancestors omits threads, which are children like any other
+	lines = render(DisplayOptions{Ancestors: "20", CompactMode: true, MaxDepth: 999, ScreenWidth: 132, ShowPIDs: true})
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "(20) /usr/bin/daemon")
+
+	// --order-by cmd sorts threads by name
+	lines = render(DisplayOptions{MaxDepth: 999, OrderBy: "cmd", ScreenWidth: 132, ShowPIDs: true})
+	assert.Contains(t, lines[2], "{gc} (11)")
+	assert.Contains(t, lines[3], "{worker} (12)")
+
+	// --thread-contains filters threads before they are collapsed
+	lines = render(DisplayOptions{CompactMode: true, MaxDepth: 999, ScreenWidth: 132, ShowPIDs: true, ThreadContains: "work"})
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], "3*[{worker}] (12,13,14)")
+
+	// The JSON output lists the threads, collapsed only when the members can be listed
+	options := DisplayOptions{CompactMode: true, CompactShowPIDs: true, MaxDepth: 999, ScreenWidth: 132}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	root := processTree.BuildJSONTree()
+	require.NotNil(t, root)
+	require.Len(t, root.Children[0].Threads, 2)
+	assert.Equal(t, &JSONThread{TID: 12, PGID: 10, Name: "worker", GroupTIDs: []int32{12, 13, 14}}, root.Children[0].Threads[1])
+}
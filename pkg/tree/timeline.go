@@ -0,0 +1,69 @@
+package tree
+
+import (
+	"fmt"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// STARTUP TIMELINE
+//------------------------------------------------------------------------------
+// Functions in this section show when each process started relative to the root
+// of the displayed subtree, reconstructing the startup sequence of a service and
+// its workers when the tree is ordered by creation time.
+
+// timelineOrigin returns the index of the process that start offsets are measured from.
+//
+// Returns:
+//   - The index of the RootPID process if it is in the tree, otherwise the index of the first root process
+func (processTree *ProcessTree) timelineOrigin() int {
+	if processTree.DisplayOptions.RootPID > 0 {
+		if pidIndex, exists := processTree.PidToIndexMap[processTree.DisplayOptions.RootPID]; exists {
+			return pidIndex
+		}
+	}
+	if roots := processTree.Roots(); len(roots) > 0 {
+		return roots[0]
+	}
+	return 0
+}
+
+// StartOffset returns how long after the subtree root a process started.
+//
+// Ancestors of the subtree root have negative offsets.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - time.Duration: The start offset
+//   - bool: false if the creation time of the process or of the subtree root is unavailable
+func (processTree *ProcessTree) StartOffset(pidIndex int) (time.Duration, bool) {
+	origin := &processTree.Nodes[processTree.timelineOrigin()]
+	process := &processTree.Nodes[pidIndex]
+	if !origin.Available(FieldAge) || !process.Available(FieldAge) {
+		return 0, false
+	}
+	// Creation times are in milliseconds
+	return time.Duration(process.CreateTime-origin.CreateTime) * time.Millisecond, true
+}
+
+// formatStartOffset formats a start offset, e.g., +0.0s, +2.3s, or +1h2m3.4s.
+//
+// Parameters:
+//   - offset: The start offset
+//
+// Returns:
+//   - The signed offset, rounded to a tenth of a second
+func formatStartOffset(offset time.Duration) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	offset = offset.Round(100 * time.Millisecond)
+	if offset < time.Minute {
+		return fmt.Sprintf("%s%.1fs", sign, offset.Seconds())
+	}
+	return sign + offset.String()
+}
@@ -0,0 +1,29 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTimeline verifies start times are shown relative to the selected root
+func TestTimeline(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init", CreateTime: 1000},
+		{PID: 10, PPID: 1, Command: "/usr/sbin/nginx", CreateTime: 60000},
+		{PID: 11, PPID: 10, Command: "/usr/sbin/nginx-worker", CreateTime: 62300},
+		{PID: 12, PPID: 10, Command: "/usr/sbin/nginx-cache", CreateTime: 3785000},
+		{PID: 13, PPID: 10, Command: "/usr/sbin/nginx-unknown", Unavailable: FieldAge},
+	}
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, Timeline: true, RootPID: 10}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "-59.0s /sbin/init")
+	assert.Contains(t, output, "+0.0s /usr/sbin/nginx")
+	assert.Contains(t, output, "+2.3s /usr/sbin/nginx-worker")
+	assert.Contains(t, output, "+1h2m5s /usr/sbin/nginx-cache")
+	assert.Contains(t, output, "(+–) /usr/sbin/nginx-unknown")
+}
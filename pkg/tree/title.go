@@ -0,0 +1,59 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+// TITLE AND ROOT LABEL
+//------------------------------------------------------------------------------
+// Functions in this section make saved outputs self-describing, with a title
+// line above the tree and the host and time of the snapshot next to its root.
+
+// writeTitle writes the title line above the text tree, if a title is set.
+func (processTree *ProcessTree) writeTitle() {
+	if processTree.DisplayOptions.Title == "" {
+		return
+	}
+	if _, err := fmt.Fprintln(processTree.output(), processTree.DisplayOptions.Title); err != nil {
+		processTree.setWriteErr(err)
+	}
+}
+
+// rootLabel returns the label shown next to a process if it is the first printable root of
+// the tree, so that a tree with several roots is labeled once.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The label, e.g., "web01 2025-06-01T12:00:00Z", or "" if the process is not the first root or no label is set
+func (processTree *ProcessTree) rootLabel(pidIndex int) string {
+	if processTree.DisplayOptions.RootLabel == "" || processTree.Nodes[pidIndex].Parent != -1 {
+		return ""
+	}
+	for index := 0; index < pidIndex; index++ {
+		if processTree.Nodes[index].Parent == -1 && processTree.Nodes[index].Print {
+			return ""
+		}
+	}
+	return processTree.DisplayOptions.RootLabel
+}
+
+// appendRootLabel appends the root label in brackets to the text shown for a process if it
+// is the root of the tree.
+//
+// Parameters:
+//   - text: The text shown for the process
+//   - pidIndex: Index of the process
+//
+// Returns:
+//   - The text followed by the label, e.g., "systemd [web01 2025-06-01T12:00:00Z]", or the text unchanged
+func (processTree *ProcessTree) appendRootLabel(text string, pidIndex int) string {
+	label := processTree.rootLabel(pidIndex)
+	if label == "" {
+		return text
+	}
+	return fmt.Sprintf("%s [%s]", strings.TrimRight(text, " "), label)
+}
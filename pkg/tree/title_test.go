@@ -0,0 +1,51 @@
+package tree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTitleAndRootLabel verifies that the title and root label are shown in the text tree and embedded in the exports
+func TestTitleAndRootLabel(t *testing.T) {
+	options := DisplayOptions{MaxDepth: 999, ScreenWidth: 132, Title: "web01 <production>", RootLabel: "web01 2025-06-01T12:00:00Z"}
+	processTree := NewProcessTree(0, setupTestLogger(), goldenProcesses(), options)
+	processTree.MarkProcesses()
+
+	var output bytes.Buffer
+	require.NoError(t, (&TextRenderer{}).Render(&output, processTree))
+	lines := strings.Split(output.String(), "\n")
+	assert.Equal(t, "web01 <production>", lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], "/sbin/init [web01 2025-06-01T12:00:00Z]"), lines[1])
+	assert.NotContains(t, lines[2], "web01")
+
+	root := processTree.BuildJSONTree()
+	assert.Equal(t, "web01 <production>", root.Title)
+	assert.Equal(t, "web01 2025-06-01T12:00:00Z", root.Label)
+	assert.Empty(t, root.Children[0].Title)
+	assert.Empty(t, root.Children[0].Label)
+
+	output.Reset()
+	require.NoError(t, (&HTMLRenderer{}).Render(&output, processTree))
+	assert.Contains(t, output.String(), "<title>web01 &lt;production&gt;</title>")
+	assert.Contains(t, output.String(), "<h1>web01 &lt;production&gt;</h1>")
+	assert.Contains(t, output.String(), ">init (1) [web01 2025-06-01T12:00:00Z]</summary>")
+
+	output.Reset()
+	require.NoError(t, (&DOTRenderer{}).Render(&output, processTree))
+	assert.Contains(t, output.String(), "  label=\"web01 <production>\";\n  labelloc=t;\n")
+	assert.Contains(t, output.String(), `p1 [label="init\nPID 1\nweb01 2025-06-01T12:00:00Z"];`)
+
+	// Without a title, the page keeps its default title and the tree starts on the first line
+	processTree.DisplayOptions = DisplayOptions{MaxDepth: 999, ScreenWidth: 132}
+	output.Reset()
+	require.NoError(t, (&HTMLRenderer{}).Render(&output, processTree))
+	assert.Contains(t, output.String(), "<title>pstree</title>")
+	assert.NotContains(t, output.String(), "<h1>")
+	output.Reset()
+	require.NoError(t, (&TextRenderer{}).Render(&output, processTree))
+	assert.True(t, strings.HasPrefix(output.String(), "-"), output.String())
+}
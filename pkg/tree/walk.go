@@ -0,0 +1,114 @@
+package tree
+
+//------------------------------------------------------------------------------
+// TREE ITERATION
+//------------------------------------------------------------------------------
+// Functions in this section let exporters, statistics, and integrations iterate
+// over the process tree without following the Child/Sister indices themselves.
+
+// WalkOrder determines whether a process is visited before or after its descendants.
+type WalkOrder int
+
+const (
+	// PreOrder visits a process before its children
+	PreOrder WalkOrder = iota
+	// PostOrder visits a process after its children
+	PostOrder
+)
+
+// WalkDecision tells Walk how to continue after visiting a process.
+type WalkDecision int
+
+const (
+	// WalkContinue continues with the children and siblings of the process
+	WalkContinue WalkDecision = iota
+	// WalkSkipSubtree skips the descendants of the process; it has no effect in post-order walks
+	WalkSkipSubtree
+	// WalkStop ends the walk immediately
+	WalkStop
+)
+
+// Node is a process as seen during a walk. Expensive attributes can be fetched on demand
+// through its accessors; see MetadataSource.
+type Node struct {
+	Depth       int      // Depth of the process, with the starting process at depth 0
+	Index       int      // Index of the process in ProcessTree.Nodes
+	ParentIndex int      // Index of the parent process, or -1 for the starting process
+	Process     *Process // The process itself
+	tree        *ProcessTree
+}
+
+// WalkOptions controls the traversal performed by WalkWithOptions.
+type WalkOptions struct {
+	Order      WalkOrder // Visit processes in pre-order or post-order
+	Start      int       // Index of the process to start from
+	IgnoreMark bool      // Visit processes that were not marked for printing
+}
+
+// Walk visits the printable processes of the tree in pre-order, starting at each root in
+// turn, within the depth limit set by DisplayOptions.MaxDepth.
+//
+// Parameters:
+//   - fn: Function called for each process; its return value controls the walk
+func (processTree *ProcessTree) Walk(fn func(Node) WalkDecision) {
+	for _, rootIndex := range processTree.Roots() {
+		if !processTree.walk(WalkOptions{Order: PreOrder, Start: rootIndex}, fn, Node{Index: rootIndex, ParentIndex: -1}) {
+			return
+		}
+	}
+}
+
+// WalkWithOptions visits the processes of the tree in the given order, within the
+// depth limit set by DisplayOptions.MaxDepth.
+//
+// Unless IgnoreMark is set, processes not marked for printing are skipped together
+// with their descendants, so a walk sees the same processes as PrintTree.
+//
+// Parameters:
+//   - options: The traversal order, starting process, and marking behavior
+//   - fn: Function called for each process; its return value controls the walk
+func (processTree *ProcessTree) WalkWithOptions(options WalkOptions, fn func(Node) WalkDecision) {
+	if options.Start < 0 || options.Start >= len(processTree.Nodes) {
+		return
+	}
+	processTree.walk(options, fn, Node{Index: options.Start, ParentIndex: -1})
+}
+
+// walk visits a process and its descendants.
+//
+// Parameters:
+//   - options: The traversal options
+//   - fn: Function called for each process
+//   - node: The process to visit
+//
+// Returns:
+//   - false if the walk was stopped, true otherwise
+func (processTree *ProcessTree) walk(options WalkOptions, fn func(Node) WalkDecision, node Node) bool {
+	node.Process = &processTree.Nodes[node.Index]
+	node.tree = processTree
+	if !options.IgnoreMark && !node.Process.Print {
+		return true
+	}
+
+	if options.Order == PreOrder {
+		switch fn(node) {
+		case WalkStop:
+			return false
+		case WalkSkipSubtree:
+			return true
+		}
+	}
+
+	if processTree.withinDepth(processTree.level(node.Index, node.Depth) + 1) {
+		for childIndex := node.Process.Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+			if !processTree.walk(options, fn, Node{Depth: node.Depth + 1, Index: childIndex, ParentIndex: node.Index}) {
+				return false
+			}
+		}
+	}
+
+	if options.Order == PostOrder {
+		return fn(node) != WalkStop
+	}
+	return true
+}
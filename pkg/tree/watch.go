@@ -0,0 +1,35 @@
+package tree
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+//------------------------------------------------------------------------------
+// WATCH MODE
+//------------------------------------------------------------------------------
+// Functions in this section display the changes between --watch samples.
+// Processes that appeared are tagged with [new] by buildLineItem.
+
+// PrintExited prints a line for each process that was displayed in the previous
+// --watch sample but has since exited or stopped matching the filters.
+//
+// Parameters:
+//   - exited: The processes that are no longer displayed, in PID order
+func (processTree *ProcessTree) PrintExited(exited []Process) {
+	if len(exited) == 0 {
+		return
+	}
+	fmt.Fprintln(processTree.output())
+	for i := range exited {
+		line := fmt.Sprintf("[exited] %s (%d)", filepath.Base(exited[i].Command), exited[i].PID)
+		if processTree.DisplayOptions.ShowOwner && exited[i].Username != "" {
+			line = fmt.Sprintf("%s %s", line, processTree.formatOwner(exited[i].Username))
+		}
+		if !processTree.DisplayOptions.WideDisplay && len(line) > processTree.DisplayOptions.ScreenWidth {
+			line = processTree.truncatePlain(line)
+		}
+		processTree.colorizeField("exited", &line, 0)
+		fmt.Fprintln(processTree.output(), line)
+	}
+}
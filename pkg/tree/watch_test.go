@@ -0,0 +1,27 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWatchHighlight verifies appeared and exited processes are highlighted without a color mode
+func TestWatchHighlight(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/bin/app", Appeared: true},
+	}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{ColorCount: 256, ColorSupport: true, MaxDepth: 999, ScreenWidth: 132})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	appeared := processTree.buildLineItem(" ", 1)
+	assert.Contains(t, appeared, "[new]")
+	assert.Contains(t, appeared, "\x1b[")
+
+	output := captureStdout(t, func() {
+		processTree.PrintExited([]Process{{PID: 20, Command: "/usr/bin/worker"}})
+	})
+	assert.Contains(t, output, "[exited] worker (20)")
+}
@@ -0,0 +1,37 @@
+package tree
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+// YAML OUTPUT
+//------------------------------------------------------------------------------
+// Functions in this section serialize the process tree as nested YAML, with the
+// same fields as the JSON output, for reading by humans and config-driven tools.
+
+// YAMLRenderer writes the tree as nested YAML.
+type YAMLRenderer struct{}
+
+// Render writes the tree starting at its root process as a single YAML document. A tree
+// with several printable roots is written as a sequence with one mapping per root.
+//
+// Parameters:
+//   - output: Writer to write the document to
+//   - processTree: The marked and pruned process tree
+//
+// Returns:
+//   - error: Error if the tree could not be encoded or written
+func (renderer *YAMLRenderer) Render(output io.Writer, processTree *ProcessTree) error {
+	if processTree.compactJSON() {
+		processTree.InitCompactMode()
+	}
+	encoder := yaml.NewEncoder(output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(processTree.jsonDocument()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
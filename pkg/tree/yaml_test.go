@@ -0,0 +1,29 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestYAMLRenderer verifies the YAML output holds the same hierarchy as the JSON output
+func TestYAMLRenderer(t *testing.T) {
+	processes := goldenProcesses()
+	processes[2].Unavailable = FieldCPUPercent
+	processTree := NewProcessTree(0, setupTestLogger(), processes, DisplayOptions{Contains: "sshd", MaxDepth: 999})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+
+	renderer, err := NewRenderer("yaml")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, renderer.Render(&buf, processTree))
+	assert.Contains(t, buf.String(), "\n        cpu_percent: null\n")
+
+	var root JSONNode
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &root))
+	assert.Equal(t, processTree.BuildJSONTree(), &root)
+}
@@ -0,0 +1,58 @@
+package tree
+
+import (
+	"fmt"
+	"slices"
+)
+
+//------------------------------------------------------------------------------
+// ZOMBIE REAPING
+//------------------------------------------------------------------------------
+// Functions in this section aggregate zombie children per parent, so that a
+// parent failing to reap its children is shown once as (z:N) rather than as
+// scattered zombie states throughout the tree.
+
+// IsZombie returns true if the process has exited but has not been reaped by its parent.
+//
+// Returns:
+//   - true if the process status is zombie
+func (process *Process) IsZombie() bool {
+	return slices.Contains(process.Status, "zombie")
+}
+
+// CountZombies records on each process the number of its children that are zombies.
+func (processTree *ProcessTree) CountZombies() {
+	for pidIndex := range processTree.Nodes {
+		processTree.Nodes[pidIndex].Zombies = 0
+	}
+	for pidIndex := range processTree.Nodes {
+		parentIndex := processTree.Nodes[pidIndex].Parent
+		if parentIndex != -1 && processTree.Nodes[pidIndex].IsZombie() {
+			processTree.Nodes[parentIndex].Zombies++
+		}
+	}
+}
+
+// showZombies returns true if the zombie children of each process are shown as (z:N).
+//
+// The states are only collected for options that ask for them, so that the default output
+// is the same on every platform, and reading them stays optional where it is slow.
+//
+// Returns:
+//   - true if --show-state, --zombies-only, or --zombie-parents is set
+func (processTree *ProcessTree) showZombies() bool {
+	return processTree.DisplayOptions.ShowState || processTree.DisplayOptions.ZombiesOnly || processTree.DisplayOptions.ZombieParents
+}
+
+// markZombies marks the zombie children of a process failing to reap them.
+//
+// Parameters:
+//   - pidIndex: Index of the process
+func (processTree *ProcessTree) markZombies(pidIndex int) {
+	processTree.Logger.Debug(fmt.Sprintf("PID %d has %d zombie children", processTree.Nodes[pidIndex].PID, processTree.Nodes[pidIndex].Zombies))
+	for childIndex := processTree.Nodes[pidIndex].Child; childIndex != -1; childIndex = processTree.Nodes[childIndex].Sister {
+		if processTree.Nodes[childIndex].IsZombie() {
+			processTree.Nodes[childIndex].Print = true
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestZombieParents verifies zombie children are counted on their parent and --zombie-parents filters to them
+func TestZombieParents(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, Command: "/usr/bin/supervisor"},
+		{PID: 11, PPID: 10, Command: "/usr/bin/worker", Status: []string{"zombie"}},
+		{PID: 12, PPID: 10, Command: "/usr/bin/worker", Status: []string{"zombie"}},
+		{PID: 13, PPID: 10, Command: "/usr/bin/healthy", Status: []string{"sleep"}},
+		{PID: 20, PPID: 1, Command: "/usr/bin/cron", Status: []string{"sleep"}},
+	}
+	options := DisplayOptions{CompactMode: true, MaxDepth: 999, ScreenWidth: 132}
+	processTree := NewProcessTree(0, setupTestLogger(), processes, options)
+	assert.Equal(t, []int{0, 2, 0, 0, 0, 0}, []int{processTree.Nodes[0].Zombies, processTree.Nodes[1].Zombies, processTree.Nodes[2].Zombies, processTree.Nodes[3].Zombies, processTree.Nodes[4].Zombies, processTree.Nodes[5].Zombies})
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output := captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.NotContains(t, output, "(z:2)", "the count is only shown with the options showing the state")
+	assert.Contains(t, output, "/usr/bin/cron")
+
+	options.ZombiesOnly = true
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(z:2) /usr/bin/supervisor")
+	assert.NotContains(t, output, "cron")
+
+	options.ZombiesOnly, options.ZombieParents = false, true
+	processTree = NewProcessTree(0, setupTestLogger(), processes, options)
+	processTree.MarkProcesses()
+	processTree.DropUnmarked()
+	output = captureStdout(t, func() {
+		processTree.PrintTree(0, "")
+	})
+	assert.Contains(t, output, "(z:2) /usr/bin/supervisor")
+	assert.Contains(t, output, "2*[worker]")
+	assert.NotContains(t, output, "healthy")
+	assert.NotContains(t, output, "cron")
+}
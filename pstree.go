@@ -8,10 +8,10 @@ import (
 
 // main is the entry point for the pstree application.
 // It executes the root command and handles any errors that occur.
-// If an error is encountered, the program will exit with a non-zero status code.
+// The program exits with 1 if the filters matched no processes, or 2 on errors.
 func main() {
 	err := cmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -3,18 +3,18 @@ package util
 import (
 	"bytes"
 	"fmt"
-	"runtime"
 	"slices"
 
 	"math"
+	"os"
 	"os/exec"
 	"os/user"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gdanko/pstree/pkg/terminal"
 	"github.com/shirou/gopsutil/v4/mem"
-	terminal "github.com/wayneashleyberry/terminal-dimensions"
 )
 
 type Duration struct {
@@ -169,22 +169,12 @@ func Contains(elems []string, v string) bool {
 
 // GetScreenWidth determines the width of the terminal in characters.
 //
-// This function attempts to get the width of the terminal using the terminal-dimensions
-// package. If it fails, it returns a default width of 132 characters.
+// Deprecated: Use terminal.Width instead.
 //
 // Returns:
 //   - int: Width of the terminal in characters
 func GetScreenWidth() int {
-	var (
-		err   error
-		width uint
-	)
-	width, err = terminal.Width()
-	if err != nil {
-		return 132
-	}
-
-	return int(width)
+	return terminal.Width()
 }
 
 // TruncateString truncates a string to the specified maximum length.
@@ -207,33 +197,14 @@ func TruncateString(s string, length int) string {
 
 // HasColorSupport determines if the terminal supports color output and how many colors.
 //
-// This function uses the 'tput colors' command to determine the number of colors
-// supported by the terminal. It considers color support to be available if at least
-// 8 colors are supported.
+// Deprecated: Use terminal.Colors instead.
 //
 // Returns:
 //   - bool: true if the terminal supports at least 8 colors, false otherwise
 //   - int: Number of colors supported by the terminal, or 0 if color is not supported
 func HasColorSupport() (bool, int) {
-	switch runtime.GOOS {
-	case "windows":
-		return true, 256
-	case "darwin", "linux":
-		returncode, stdout, _, err := ExecutePipeline("/usr/bin/tput colors")
-		if err != nil || returncode != 0 {
-			return false, 0
-		}
-		colors, err := strconv.Atoi(stdout)
-		if err != nil {
-			return false, 0
-		}
-		if colors < 8 {
-			return false, 0
-		}
-		return true, colors
-	default:
-		return false, 0
-	}
+	colors := terminal.Colors()
+	return colors > 0, colors
 }
 
 // UserExists checks if a user with the specified username exists on the system.
@@ -289,6 +260,27 @@ func ByteConverter(num uint64) string {
 	return fmt.Sprintf("%.2f Yi%s", RoundFloat(absolute, 2), suffix)
 }
 
+// FormatNanoseconds formats a duration given in nanoseconds as a human-readable string
+// using the largest unit (ns, µs, ms, s) that keeps the value at or above 1.
+//
+// Parameters:
+//   - ns: Duration in nanoseconds
+//
+// Returns:
+//   - string: Formatted duration with two decimal places of precision, e.g., "1.25ms"
+func FormatNanoseconds(ns float64) string {
+	switch {
+	case ns < 1e3:
+		return fmt.Sprintf("%.2fns", ns)
+	case ns < 1e6:
+		return fmt.Sprintf("%.2fµs", ns/1e3)
+	case ns < 1e9:
+		return fmt.Sprintf("%.2fms", ns/1e6)
+	default:
+		return fmt.Sprintf("%.2fs", ns/1e9)
+	}
+}
+
 // BtoI converts a boolean value to an integer (1 for true, 0 for false).
 //
 // Parameters:
@@ -368,6 +360,155 @@ func FindDuration(seconds int64) (duration Duration) {
 	}
 }
 
+// Age formats accepted by FormatDuration.
+const (
+	DurationClock   = "clock"   // 03:04:05:06 (days:hours:minutes:seconds)
+	DurationHuman   = "human"   // 3d4h, 2w, 45s
+	DurationISO8601 = "iso8601" // P3DT4H5M6S
+	DurationLong    = "long"    // 3 days, 4 hours, in the language of the current locale
+	DurationSeconds = "seconds" // 273906
+)
+
+// DurationFormats returns the formats accepted by FormatDuration.
+//
+// Returns:
+//   - []string: The format names, sorted
+func DurationFormats() []string {
+	return []string{DurationClock, DurationHuman, DurationISO8601, DurationLong, DurationSeconds}
+}
+
+// Pluralizer names a quantity of a duration unit in a language, e.g., 1 day or 3 days.
+//
+// The unit is one of "week", "day", "hour", "minute", or "second".
+type Pluralizer func(unit string, count int64) string
+
+// pluralizers maps a language code to its Pluralizer.
+var pluralizers = map[string]Pluralizer{
+	"en": englishPluralizer,
+}
+
+// RegisterPluralizer adds or replaces the Pluralizer used by the long duration format
+// for a language.
+//
+// Parameters:
+//   - language: ISO 639-1 language code, e.g., de
+//   - pluralizer: Function naming a quantity of a unit in the language
+func RegisterPluralizer(language string, pluralizer Pluralizer) {
+	pluralizers[strings.ToLower(language)] = pluralizer
+}
+
+// englishPluralizer names a quantity of a duration unit in English.
+func englishPluralizer(unit string, count int64) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// localeLanguage returns the language of the current locale, e.g., de for de_DE.UTF-8.
+//
+// The locale is read from LC_ALL, LC_MESSAGES, and LANG, in that order.
+//
+// Returns:
+//   - string: The language code, or "en" if no locale is set
+func localeLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" || locale == "C" || locale == "POSIX" {
+			continue
+		}
+		language, _, _ := strings.Cut(locale, "_")
+		language, _, _ = strings.Cut(language, ".")
+		return strings.ToLower(language)
+	}
+	return "en"
+}
+
+// FormatDuration formats a duration in seconds, replacing the fixed layout of FindDuration.
+//
+// The human format shows the two most significant units, e.g., 3d4h, and the long format
+// spells them out using the Pluralizer of the current locale, falling back to English.
+//
+// Parameters:
+//   - seconds: Total duration in seconds
+//   - format: One of DurationFormats; an empty format is the same as DurationClock
+//
+// Returns:
+//   - string: The formatted duration
+func FormatDuration(seconds int64, format string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	duration := FindDuration(seconds)
+	switch format {
+	case DurationSeconds:
+		return strconv.FormatInt(seconds, 10)
+	case DurationISO8601:
+		if seconds == 0 {
+			return "PT0S"
+		}
+		var builder strings.Builder
+		builder.WriteString("P")
+		if duration.Days > 0 {
+			fmt.Fprintf(&builder, "%dD", duration.Days)
+		}
+		if duration.Hours > 0 || duration.Minutes > 0 || duration.Seconds > 0 {
+			builder.WriteString("T")
+		}
+		for _, part := range []struct {
+			value  int64
+			suffix string
+		}{{duration.Hours, "H"}, {duration.Minutes, "M"}, {duration.Seconds, "S"}} {
+			if part.value > 0 {
+				fmt.Fprintf(&builder, "%d%s", part.value, part.suffix)
+			}
+		}
+		return builder.String()
+	case DurationHuman, DurationLong:
+		units := []struct {
+			name   string
+			suffix string
+			value  int64
+		}{
+			{"week", "w", duration.Days / 7},
+			{"day", "d", duration.Days % 7},
+			{"hour", "h", duration.Hours},
+			{"minute", "m", duration.Minutes},
+			{"second", "s", duration.Seconds},
+		}
+		// Start at the most significant non-zero unit, and show the next one only if it is non-zero
+		first := len(units) - 1
+		for i, unit := range units {
+			if unit.value > 0 {
+				first = i
+				break
+			}
+		}
+		shown := units[first : first+1]
+		if first+1 < len(units) && units[first+1].value > 0 {
+			shown = units[first : first+2]
+		}
+		parts := []string{}
+		pluralizer, ok := pluralizers[localeLanguage()]
+		if !ok {
+			pluralizer = englishPluralizer
+		}
+		for _, unit := range shown {
+			if format == DurationHuman {
+				parts = append(parts, fmt.Sprintf("%d%s", unit.value, unit.suffix))
+			} else {
+				parts = append(parts, pluralizer(unit.name, unit.value))
+			}
+		}
+		if format == DurationHuman {
+			return strings.Join(parts, "")
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprintf("%02d:%02d:%02d:%02d", duration.Days, duration.Hours, duration.Minutes, duration.Seconds)
+	}
+}
+
 // DeleteSliceElement removes an element from a slice of strings at the specified index.
 //
 // Parameters:
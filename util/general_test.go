@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -126,6 +127,38 @@ func TestFindDuration(t *testing.T) {
 
 }
 
+func TestFormatDuration(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+
+	seconds := int64(3*86400 + 4*3600 + 5*60 + 6)
+	assert.Equal(t, "03:04:05:06", FormatDuration(seconds, DurationClock))
+	assert.Equal(t, "03:04:05:06", FormatDuration(seconds, ""))
+	assert.Equal(t, "3d4h", FormatDuration(seconds, DurationHuman))
+	assert.Equal(t, "P3DT4H5M6S", FormatDuration(seconds, DurationISO8601))
+	assert.Equal(t, "3 days, 4 hours", FormatDuration(seconds, DurationLong))
+	assert.Equal(t, "273906", FormatDuration(seconds, DurationSeconds))
+
+	assert.Equal(t, "2w", FormatDuration(14*86400, DurationHuman))
+	assert.Equal(t, "1w1d", FormatDuration(8*86400+60, DurationHuman))
+	assert.Equal(t, "3d", FormatDuration(3*86400+300, DurationHuman))
+	assert.Equal(t, "45s", FormatDuration(45, DurationHuman))
+	assert.Equal(t, "0s", FormatDuration(0, DurationHuman))
+	assert.Equal(t, "1 minute, 1 second", FormatDuration(61, DurationLong))
+	assert.Equal(t, "P2D", FormatDuration(2*86400, DurationISO8601))
+	assert.Equal(t, "PT0S", FormatDuration(0, DurationISO8601))
+
+	// The long format uses the pluralizer of the current locale and falls back to English
+	t.Setenv("LANG", "xx_XX.UTF-8")
+	assert.Equal(t, "2 hours", FormatDuration(7200, DurationLong))
+	RegisterPluralizer("xx", func(unit string, count int64) string {
+		return fmt.Sprintf("%s=%d", unit, count)
+	})
+	defer delete(pluralizers, "xx")
+	assert.Equal(t, "hour=2", FormatDuration(7200, DurationLong))
+}
+
 func TestDeleteSliceElement(t *testing.T) {
 	// Test deleting an element from the middle
 	slice := []string{"a", "b", "c", "d"}
@@ -152,3 +185,10 @@ func TestDeleteSliceElement(t *testing.T) {
 	result = DeleteSliceElement(slice, 0)
 	assert.Equal(t, []string{}, result)
 }
+
+func TestFormatNanoseconds(t *testing.T) {
+	assert.Equal(t, "500.00ns", FormatNanoseconds(500))
+	assert.Equal(t, "1.50µs", FormatNanoseconds(1500))
+	assert.Equal(t, "2.25ms", FormatNanoseconds(2250000))
+	assert.Equal(t, "3.00s", FormatNanoseconds(3e9))
+}
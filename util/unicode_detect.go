@@ -1,6 +1,10 @@
 package util
 
-// HasUnicodeSupport is implemented per platform.
+import "github.com/gdanko/pstree/pkg/terminal"
+
+// HasUnicodeSupport determines if the terminal can display Unicode characters.
+//
+// Deprecated: Use terminal.Unicode instead.
 func HasUnicodeSupport() bool {
-	return hasUnicodeSupport()
+	return terminal.Unicode()
 }